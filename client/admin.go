@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Clients of the admin routes are authenticated by the admin token of the
+// server in place of a session token, which is never renewed.
+
+type RestoreResult struct {
+	// Version of the archive format.
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	Workspaces int       `json:"workspaces"`
+	Sessions   int       `json:"sessions"`
+	Tasks      int       `json:"tasks"`
+}
+
+// Backup writes an archive of the whole server into w as the server writes
+// it; failing halfway leaves w with an archive restores refuse.
+func (c *Client) Backup(ctx context.Context, w io.Writer) error {
+	return c.stream(ctx, http.MethodGet, "/v1/admin/backup", http.StatusOK, w)
+}
+
+// Restore replaces the whole server by the archive read from r, which is
+// streamed rather than read whole. Archives the server refuses fail with a
+// StatusError telling why.
+func (c *Client) Restore(ctx context.Context, r io.Reader) (*RestoreResult, error) {
+	const path = "/v1/admin/restore"
+	res, err := c.send(ctx, http.MethodPost, path, c.Token(), "application/x-tar", r)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, statusError(http.MethodPost, path, res)
+	}
+
+	var result RestoreResult
+	if err := decode(http.MethodPost, path, res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
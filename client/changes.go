@@ -0,0 +1,84 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Change tells that something in the workspace was changed, and by whom. It
+// only says where to look; fetch what changed to see it.
+type Change struct {
+	// User who made the change, if made by a session.
+	Actor string `json:"actor,omitempty"`
+	// Method and path of the request that made the change, if any.
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+// WatchChanges returns changes made in the workspace of the session from now
+// on, so anything fetched after it returns is kept up to date by refetching
+// on every change. The channel is closed once ctx is done or the server ends
+// the stream.
+func (c *Client) WatchChanges(ctx context.Context) (<-chan Change, error) {
+	res, err := c.do(ctx, http.MethodGet, "/v1/changes", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, statusError(http.MethodGet, "/v1/changes", res)
+	}
+
+	events := bufio.NewReader(res.Body)
+	if name, _, err := readEvent(events); err != nil || name != "ready" {
+		res.Body.Close()
+		return nil, fmt.Errorf("GET /v1/changes: stream did not start: %v", err)
+	}
+
+	watched := make(chan Change)
+	go func() {
+		defer close(watched)
+		defer res.Body.Close()
+		for {
+			name, data, err := readEvent(events)
+			if err != nil {
+				return
+			}
+			var change Change
+			if name != "change" || json.Unmarshal(data, &change) != nil {
+				continue
+			}
+			select {
+			case watched <- change:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return watched, nil
+}
+
+// readEvent reads a server-sent event, skipping comments.
+func readEvent(r *bufio.Reader) (name string, data []byte, err error) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch {
+		case line == "" && (name != "" || data != nil):
+			return name, data, nil
+		case field == "event":
+			name = value
+		case field == "data":
+			data = append(data, value...)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+// Package client calls the /v1 API of a whostodo server over HTTP.
+//
+// Clients authenticate with a session token, either given or started by
+// Login, and renew it when the server rejects it, as sessions expire:
+//
+//	c := client.InitClient("http://localhost:8080", "")
+//	if err := c.Login(ctx, "alice", password, "team-a"); err != nil {
+//		return err
+//	}
+//	listed, err := c.ListTasks(ctx, &client.ListTasksOptions{Tags: []string{"errand"}})
+//
+// Errors of failed requests match ErrorNotFound and the like by errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Renewal gets a new token once the current one is rejected.
+type Renewal func(ctx context.Context) (string, error)
+
+// Client is safe for concurrent use.
+type Client struct {
+	url        string
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	token string
+	renew Renewal
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sends requests through the client instead of
+// http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(client *Client) {
+		client.httpClient = c
+	}
+}
+
+// WithRenewal makes the client get a new token through renew, and send the
+// request again, when the server rejects the token. Login replaces it.
+func WithRenewal(renew Renewal) Option {
+	return func(client *Client) {
+		client.renew = renew
+	}
+}
+
+// Token returns the token requests are sent with, which changes on renewal.
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// Login starts a session as the user, by the password of its account, in
+// the workspace, empty ones meaning the anonymous user and the default
+// workspace, and sends requests with its token from then on. Once the
+// session expires, the client logs in the same way again, so keeps the
+// password.
+func (c *Client) Login(ctx context.Context, user string, password string, workspace string) error {
+	login := func(ctx context.Context) (string, error) {
+		return c.authenticate(ctx, user, password, workspace)
+	}
+	token, err := login(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+	c.renew = login
+	return nil
+}
+
+// authenticate starts a session through POST /v1/auth, returning its token.
+func (c *Client) authenticate(ctx context.Context, user string, password string, workspace string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"user": user, "password": password, "workspace": workspace})
+	// Sent without the token, which would be answered by 304 if still valid.
+	res, err := c.send(ctx, http.MethodPost, "/v1/auth", "", jsonType, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return "", statusError(http.MethodPost, "/v1/auth", res)
+	}
+
+	var output struct {
+		Token string `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&output); err != nil {
+		return "", err
+	}
+	return output.Token, nil
+}
+
+// call sends the input as JSON, failing unless the response has the status,
+// and decodes the result of the response into output unless nil.
+func (c *Client) call(ctx context.Context, method string, path string, input any, status int, output any) error {
+	var body []byte
+	if input != nil {
+		var err error
+		if body, err = json.Marshal(input); err != nil {
+			return err
+		}
+	}
+
+	res, err := c.do(ctx, method, path, jsonType, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != status {
+		return statusError(method, path, res)
+	}
+	return decode(method, path, res, output)
+}
+
+// decode decodes the result of the response into output unless nil.
+func decode(method string, path string, res *http.Response, output any) error {
+	if output == nil {
+		return nil
+	}
+	result := struct {
+		Result any `json:"result"`
+	}{Result: output}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// stream sends the request, failing unless the response has the status, and
+// copies the body of the response into w.
+func (c *Client) stream(ctx context.Context, method string, path string, status int, w io.Writer) error {
+	res, err := c.do(ctx, method, path, "", nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != status {
+		return statusError(method, path, res)
+	}
+	if _, err := io.Copy(w, res.Body); err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// statusError describes the unexpected response, with the error the server
+// gave along, if any.
+func statusError(method string, path string, res *http.Response) *StatusError {
+	e := &StatusError{Method: method, Path: path, StatusCode: res.StatusCode, Rejected: rejected(res)}
+	var failed struct {
+		Result struct {
+			Error string `json:"error"`
+		} `json:"result"`
+	}
+	if json.NewDecoder(io.LimitReader(res.Body, maxErrorSize)).Decode(&failed) == nil {
+		e.Message = failed.Result.Error
+	}
+	return e
+}
+
+// maxErrorSize limits how much of an unexpected response is read.
+const maxErrorSize = 64 << 10
+
+const jsonType = "application/json"
+
+// do sends the body, if any, of the content type, renewing the token and
+// sending it again if rejected.
+func (c *Client) do(ctx context.Context, method string, path string, contentType string, body []byte) (*http.Response, error) {
+	send := func(token string) (*http.Response, error) {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		return c.send(ctx, method, path, token, contentType, reader)
+	}
+
+	token := c.Token()
+	res, err := send(token)
+	if err != nil || !rejected(res) {
+		return res, err
+	}
+
+	renewed, err := c.renewToken(ctx, token)
+	if errors.Is(err, errNoRenewal) {
+		return res, nil
+	}
+	res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("renewing token: %w", err)
+	}
+	return send(renewed)
+}
+
+var errNoRenewal = errors.New("no renewal")
+
+// rejected reports whether the response rejects the token, by 401, or by a
+// 403 challenging for another one; other 403s refuse what the session asked
+// for, which a new session is refused as well.
+func rejected(res *http.Response) bool {
+	switch res.StatusCode {
+	case http.StatusUnauthorized:
+		return true
+	case http.StatusForbidden:
+		return strings.HasPrefix(res.Header.Get("WWW-Authenticate"), "Bearer")
+	}
+	return false
+}
+
+// renewToken gets a new token in place of the rejected one, unless renewed
+// by another request meanwhile.
+func (c *Client) renewToken(ctx context.Context, rejected string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != rejected {
+		return c.token, nil
+	}
+	if c.renew == nil {
+		return "", errNoRenewal
+	}
+
+	token, err := c.renew(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token = token
+	return token, nil
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, token string, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return c.httpClient.Do(req)
+}
+
+// InitClient returns a client of the server at the URL, authenticated by the
+// session token, if any.
+func InitClient(serverURL string, token string, opts ...Option) *Client {
+	c := &Client{
+		url:        strings.TrimSuffix(serverURL, "/"),
+		httpClient: http.DefaultClient,
+		token:      token,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
@@ -0,0 +1,317 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/client"
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/rest/v1"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+// serve returns the URL of a server of the suite.
+func serve(t *testing.T, suite *util.MockTestSuite) string {
+	t.Helper()
+	server := httptest.NewServer(suite.Engine)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// clientOf returns a client of a server of the suite, as a session of alice.
+func clientOf(t *testing.T, suite *util.MockTestSuite, opts ...client.Option) *client.Client {
+	t.Helper()
+	session := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(session)
+	return client.InitClient(serve(t, suite), session.Id, opts...)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func Test_Tasks(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Priority: 3, Tags: []string{"errand"}, Creator: "alice"})
+	c := clientOf(t, suite)
+	ctx := context.Background()
+	due := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	created, err := c.CreateTask(ctx, &client.CreateTaskInput{Name: "洗碗", Priority: client.PriorityLow, DueAt: &due})
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(created, &client.Task{Id: 2, Name: "洗碗", Priority: client.PriorityLow, DueAt: &due, Creator: "alice"})
+
+	updated, err := c.UpdateTask(ctx, 2, &client.UpdateTaskInput{Name: "洗衣服", Status: client.StatusDone, Priority: ptr("")})
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(updated, &client.Task{Id: 2, Name: "洗衣服", Status: client.StatusDone, DueAt: &due, Creator: "alice"})
+	util.AssertEqual(t)(updated.IsDone(), true)
+
+	got, err := c.GetTask(ctx, 2)
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(got, updated)
+
+	undue, err := c.UpdateTask(ctx, 2, &client.UpdateTaskInput{Name: "洗衣服", ClearDueAt: true})
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(undue.DueAt, (*time.Time)(nil))
+
+	tagged, err := c.ListTasks(ctx, &client.ListTasksOptions{Tags: []string{"errand"}})
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(tagged, []client.Task{{Id: 1, Name: "買晚餐", Priority: client.PriorityHigh, Tags: []string{"errand"}, Creator: "alice"}})
+
+	util.AssertErrorEqual(t)(c.DeleteTask(ctx, 2), nil)
+	listed, err := c.ListTasks(ctx, nil)
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(len(listed), 1)
+}
+
+func Test_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(url string, c *client.Client) error
+		expected error
+		message  string
+	}{
+		{
+			name: "maps 400 to ErrorBadRequest",
+			call: func(url string, c *client.Client) error {
+				return &client.StatusError{Method: http.MethodGet, Path: "/v1/tasks", StatusCode: http.StatusBadRequest}
+			},
+			expected: client.ErrorBadRequest,
+			message:  "GET /v1/tasks: 400 Bad Request",
+		},
+		{
+			name: "maps 404 to ErrorNotFound",
+			call: func(url string, c *client.Client) error {
+				_, err := c.GetTask(context.Background(), 2)
+				return err
+			},
+			expected: client.ErrorNotFound,
+			message:  "GET /v1/task/2: 404 Not Found",
+		},
+		{
+			name: "maps 422 to ErrorUnprocessable",
+			call: func(url string, c *client.Client) error {
+				_, err := c.CreateTask(context.Background(), &client.CreateTaskInput{Name: "買晚餐", ListId: 9})
+				return err
+			},
+			expected: client.ErrorUnprocessable,
+			message:  "POST /v1/task: 422 Unprocessable Entity",
+		},
+		{
+			name: "maps a rejected token to ErrorUnauthorized without renewal",
+			call: func(url string, c *client.Client) error {
+				_, err := client.InitClient(url, "expired_token").ListTasks(context.Background(), nil)
+				return err
+			},
+			expected: client.ErrorUnauthorized,
+			message:  "GET /v1/tasks: 403 Forbidden",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			session := util.NewUserSession("alice")
+			suite.SessionRepo.PopulateData(session)
+			url := serve(t, suite)
+
+			err := tc.call(url, client.InitClient(url, session.Id))
+
+			util.AssertErrorEqual(t)(err, tc.expected)
+			util.AssertEqual(t)(err.Error(), tc.message)
+			var status *client.StatusError
+			util.AssertEqual(t)(errors.As(err, &status), true)
+		})
+	}
+}
+
+func Test_Login(t *testing.T) {
+	t.Run("starts a session of the user", func(t *testing.T) {
+		t.Parallel()
+
+		suite := util.NewTestSuite()
+		suite.AccountRepo.PopulateData(util.NewAccount("alice", "密碼", "team-a"))
+		c := client.InitClient(serve(t, suite), "")
+
+		err := c.Login(context.Background(), "alice", "密碼", "team-a")
+
+		util.AssertErrorEqual(t)(err, nil)
+		session := suite.SessionRepo.Data[c.Token()]
+		util.AssertEqual(t)(session.User, "alice")
+		util.AssertEqual(t)(session.Workspace, "team-a")
+	})
+
+	t.Run("fails on a workspace not allowed", func(t *testing.T) {
+		t.Parallel()
+
+		c := client.InitClient(serve(t, util.NewTestSuite()), "")
+
+		err := c.Login(context.Background(), "", "", "Team A")
+
+		util.AssertErrorEqual(t)(err, client.ErrorUnprocessable)
+	})
+
+	t.Run("fails on a wrong password", func(t *testing.T) {
+		t.Parallel()
+
+		suite := util.NewTestSuite()
+		suite.AccountRepo.PopulateData(util.NewAccount("alice", "密碼"))
+		c := client.InitClient(serve(t, suite), "")
+
+		err := c.Login(context.Background(), "alice", "錯的", "")
+
+		util.AssertErrorEqual(t)(err, client.ErrorUnauthorized)
+	})
+
+	t.Run("logs in again once the session expires", func(t *testing.T) {
+		t.Parallel()
+
+		suite := util.NewTestSuite()
+		suite.AccountRepo.PopulateData(util.NewAccount("alice", "密碼"))
+		c := client.InitClient(serve(t, suite), "")
+		c.Login(context.Background(), "alice", "密碼", "")
+		expired := c.Token()
+		delete(suite.SessionRepo.Data, expired)
+
+		_, err := c.ListTasks(context.Background(), nil)
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(c.Token() != expired, true)
+		util.AssertEqual(t)(suite.SessionRepo.Data[c.Token()].User, "alice")
+	})
+}
+
+func Test_Renewal(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	renewed := util.NewUserSession("bob")
+	c := clientOf(t, suite, client.WithRenewal(func(context.Context) (string, error) {
+		suite.SessionRepo.PopulateData(renewed)
+		return renewed.Id, nil
+	}))
+	delete(suite.SessionRepo.Data, c.Token())
+
+	_, err := c.ListTasks(context.Background(), nil)
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(c.Token(), renewed.Id)
+}
+
+func Test_NoRenewalOnForbidden(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "bob"})
+	suite.MemberRepo.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "alice", Role: "viewer", Accepted: true})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", ListId: 1, Creator: "bob"})
+	renewals := 0
+	c := clientOf(t, suite, client.WithRenewal(func(context.Context) (string, error) {
+		renewals++
+		return "", errors.New("not expected")
+	}))
+	token := c.Token()
+
+	_, err := c.UpdateTask(context.Background(), 1, &client.UpdateTaskInput{Name: "洗碗", Status: client.StatusDone})
+
+	util.AssertEqual(t)(errors.Is(err, client.ErrorForbidden), true)
+	util.AssertEqual(t)(renewals, 0)
+	util.AssertEqual(t)(c.Token(), token)
+}
+
+func Test_WatchChanges(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	c := clientOf(t, suite)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watched, err := c.WatchChanges(ctx)
+	util.AssertErrorEqual(t)(err, nil)
+	c.CreateTask(ctx, &client.CreateTaskInput{Name: "買晚餐"})
+
+	util.AssertEqual(t)(<-watched, client.Change{Actor: "alice", Method: http.MethodPost, Path: "/v1/task"})
+	cancel()
+	for range watched {
+	}
+}
+
+func Test_ExportImportTasks(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Creator: "alice"})
+	c := clientOf(t, suite)
+	ctx := context.Background()
+
+	var exported bytes.Buffer
+	util.AssertErrorEqual(t)(c.ExportTasks(ctx, client.FormatTodoTxt, &exported), nil)
+	util.AssertEqual(t)(exported.String(), "買晚餐 id:1\n")
+
+	checked, err := c.ImportTasks(ctx, strings.NewReader("洗碗\n"), &client.ImportTasksOptions{Format: client.FormatTodoTxt, DryRun: true})
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(checked, &client.ImportResult{DryRun: true, Created: 1, Errors: []client.ImportError{}})
+
+	invalid, err := c.ImportTasks(ctx, strings.NewReader("洗碗\n拖地 due:tomorrow\n"), &client.ImportTasksOptions{Format: client.FormatTodoTxt})
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(invalid.Errors, []client.ImportError{{Row: 2, Column: "due", Error: "Not a date"}})
+	util.AssertEqual(t)(len(suite.TaskRepo.Data), 1)
+}
+
+func Test_BackupRestore(t *testing.T) {
+	t.Parallel()
+
+	from := util.NewTestSuite()
+	from.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Creator: "alice"})
+	// Builds the default workspace, which is only backed up once used.
+	clientOf(t, from).ListTasks(context.Background(), nil)
+	to := util.NewTestSuite()
+	ctx := context.Background()
+
+	var archive bytes.Buffer
+	util.AssertErrorEqual(t)(client.InitClient(serve(t, from), util.AdminToken).Backup(ctx, &archive), nil)
+	restored, err := client.InitClient(serve(t, to), util.AdminToken).Restore(ctx, &archive)
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(restored.Tasks, 1)
+
+	_, err = client.InitClient(serve(t, to), util.AdminToken).Restore(ctx, strings.NewReader("壞掉"))
+	var status *client.StatusError
+	util.AssertEqual(t)(errors.As(err, &status), true)
+	util.AssertEqual(t)(status.StatusCode, http.StatusBadRequest)
+	util.AssertEqual(t)(status.Message != "", true)
+}
+
+// Test_TaskShape fails when tasks of the API gain fields the client drops.
+func Test_TaskShape(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2024, 1, 5, 9, 30, 0, 0, time.UTC)
+	served := routes.TaskResult{
+		Id: 1, Name: "買晚餐", Status: 1, Priority: "high", Description: "**外帶**", ListId: 2, ParentId: 3,
+		Tags: []string{"errand"}, BlockedBy: []int{4}, DueAt: &at, Creator: "alice", Assignee: "bob",
+		Recurrence: &routes.RecurrenceResult{Frequency: "weekly", Interval: 2, Weekdays: []string{"MO"}, Until: &at, Count: 3},
+		Progress:   &routes.ProgressResult{Done: 1, Total: 2},
+	}
+	content, _ := json.Marshal(served)
+
+	var task client.Task
+	json.Unmarshal(content, &task)
+	kept, _ := json.Marshal(task)
+
+	var got, expected map[string]any
+	json.Unmarshal(kept, &got)
+	json.Unmarshal(content, &expected)
+	util.AssertEqual(t)(got, expected)
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Errors that failed requests match by errors.Is, by the status of the
+// response.
+var (
+	// 400: the input is malformed, such as an unknown priority.
+	ErrorBadRequest = errors.New("Bad request")
+	// 401: the user or password logged in with is wrong. Also matched by
+	// the 403 of a token the server rejected, once it could not be renewed.
+	ErrorUnauthorized = errors.New("Unauthorized")
+	// 403: the token is not that of a session, or the session may not do
+	// this, such as writing to a list it is only invited to.
+	ErrorForbidden = errors.New("Forbidden")
+	// 404: the task, or whatever else the path names, does not exist or is
+	// not visible to the session.
+	ErrorNotFound = errors.New("Not found")
+	// 409: the change conflicts with the current state, such as completing a
+	// blocked task.
+	ErrorConflict = errors.New("Conflict")
+	// 422: the input names something that does not exist, such as a list,
+	// or a workspace sessions may not be started in.
+	ErrorUnprocessable = errors.New("Unprocessable")
+	// 5xx: the server failed.
+	ErrorServer = errors.New("Server error")
+	// Any other status not expected of the request.
+	ErrorUnexpectedStatus = errors.New("Unexpected status")
+)
+
+// StatusError is returned for responses of an unexpected status, matching
+// the error of the status by errors.Is.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Set when the server rejected the session token, rather than what the
+	// session asked for.
+	Rejected bool
+	// What the server said went wrong, for the few routes that say so.
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	message := fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	return message
+}
+
+func (e *StatusError) Unwrap() error {
+	switch {
+	case e.Rejected:
+		return ErrorUnauthorized
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrorBadRequest
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrorUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrorForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return ErrorNotFound
+	case e.StatusCode == http.StatusConflict:
+		return ErrorConflict
+	case e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrorUnprocessable
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrorServer
+	default:
+		return ErrorUnexpectedStatus
+	}
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Priorities of tasks; tasks without one have an empty priority.
+const (
+	PriorityUrgent = "urgent"
+	PriorityHigh   = "high"
+	PriorityMedium = "medium"
+	PriorityLow    = "low"
+)
+
+// Statuses of tasks. Other values are kept as they are, and mean not done.
+const (
+	StatusOpen = 0
+	StatusDone = 1
+)
+
+type Task struct {
+	Id          int         `json:"id"`
+	Name        string      `json:"name"`
+	Status      int         `json:"status"`
+	Priority    string      `json:"priority,omitempty"`
+	Description string      `json:"description,omitempty"`
+	ListId      int         `json:"list_id,omitempty"`
+	ParentId    int         `json:"parent_id,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	BlockedBy   []int       `json:"blocked_by,omitempty"`
+	DueAt       *time.Time  `json:"due_at,omitempty"`
+	Recurrence  *Recurrence `json:"recurrence,omitempty"`
+	Creator     string      `json:"creator,omitempty"`
+	Assignee    string      `json:"assignee,omitempty"`
+	Progress    *Progress   `json:"progress,omitempty"`
+}
+
+// IsDone reports whether the task is done.
+func (t *Task) IsDone() bool {
+	return t.Status == StatusDone
+}
+
+type Recurrence struct {
+	// One of daily, weekly or monthly; empty removes the recurrence when
+	// updating.
+	Frequency string `json:"frequency"`
+	// Defaults to 1.
+	Interval int `json:"interval,omitempty"`
+	// Two-letter codes such as "MO"; weekly recurrences only.
+	Weekdays []string   `json:"weekdays,omitempty"`
+	Until    *time.Time `json:"until,omitempty"`
+	// Occurrences left including this one; 0 repeats forever.
+	Count int `json:"count,omitempty"`
+}
+
+// Progress counts the subtasks of a task, and how many of them are done.
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+type ListTasksOptions struct {
+	// Only lists tasks of the list; 0 is the inbox. Nil lists every list.
+	ListId *int
+	// Only lists tasks with any of the tags, or all of them if MatchAll.
+	Tags     []string
+	MatchAll bool
+	// Sorts by priority instead of the manual order.
+	SortByPriority bool
+	// Only lists tasks assigned to the user.
+	Assignee string
+}
+
+type CreateTaskInput struct {
+	Name string `json:"name"`
+	// Markdown.
+	Description string      `json:"description,omitempty"`
+	Priority    string      `json:"priority,omitempty"`
+	ListId      int         `json:"list_id,omitempty"`
+	ParentId    int         `json:"parent_id,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	DueAt       *time.Time  `json:"due_at,omitempty"`
+	Recurrence  *Recurrence `json:"recurrence,omitempty"`
+}
+
+// UpdateTaskInput replaces the name and status of a task; other fields are
+// kept when nil.
+type UpdateTaskInput struct {
+	Name        string  `json:"name"`
+	Status      int     `json:"status"`
+	Description *string `json:"description,omitempty"`
+	Priority    *string `json:"priority,omitempty"`
+	// 0 moves the task into the inbox.
+	ListId *int `json:"list_id,omitempty"`
+	// 0 makes the task a root task.
+	ParentId   *int        `json:"parent_id,omitempty"`
+	DueAt      *time.Time  `json:"due_at,omitempty"`
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+	// Removes the due date, unless DueAt is set.
+	ClearDueAt bool `json:"-"`
+}
+
+// MarshalJSON sends a null due_at when clearing the due date, which the
+// server tells apart from leaving it out.
+func (i UpdateTaskInput) MarshalJSON() ([]byte, error) {
+	type input UpdateTaskInput
+	content, err := json.Marshal(input(i))
+	if err != nil || !i.ClearDueAt || i.DueAt != nil {
+		return content, err
+	}
+	return append(content[:len(content)-1], `,"due_at":null}`...), nil
+}
+
+// ListTasks returns the tasks visible to the session, in manual order unless
+// sorted otherwise; nil options list them all.
+func (c *Client) ListTasks(ctx context.Context, o *ListTasksOptions) ([]Task, error) {
+	query := url.Values{}
+	if o != nil {
+		if o.ListId != nil {
+			query.Set("list", strconv.Itoa(*o.ListId))
+		}
+		for _, tag := range o.Tags {
+			query.Add("tag", tag)
+		}
+		if o.MatchAll {
+			query.Set("match", "all")
+		}
+		if o.SortByPriority {
+			query.Set("sort", "priority")
+		}
+		if o.Assignee != "" {
+			query.Set("assignee", o.Assignee)
+		}
+	}
+	path := "/v1/tasks"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	listed := []Task{}
+	if err := c.call(ctx, http.MethodGet, path, nil, http.StatusOK, &listed); err != nil {
+		return nil, err
+	}
+	return listed, nil
+}
+
+func (c *Client) GetTask(ctx context.Context, id int) (*Task, error) {
+	var task Task
+	if err := c.call(ctx, http.MethodGet, taskPath(id), nil, http.StatusOK, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) CreateTask(ctx context.Context, i *CreateTaskInput) (*Task, error) {
+	var task Task
+	if err := c.call(ctx, http.MethodPost, "/v1/task", i, http.StatusCreated, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// UpdateTask replaces the name and status of the task, and whichever other
+// fields of the input are set.
+func (c *Client) UpdateTask(ctx context.Context, id int, i *UpdateTaskInput) (*Task, error) {
+	var task Task
+	if err := c.call(ctx, http.MethodPut, taskPath(id), i, http.StatusCreated, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// DeleteTask moves the task into the trash, with its subtasks moved up to its
+// parent.
+func (c *Client) DeleteTask(ctx context.Context, id int) error {
+	return c.call(ctx, http.MethodDelete, taskPath(id), nil, http.StatusOK, nil)
+}
+
+func taskPath(id int) string {
+	return "/v1/task/" + strconv.Itoa(id)
+}
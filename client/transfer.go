@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Formats tasks are exported and imported in.
+const (
+	FormatCSV     = "csv"
+	FormatTodoTxt = "todotxt"
+	FormatICal    = "ics"
+)
+
+type ImportTasksOptions struct {
+	// Defaults to FormatCSV.
+	Format string
+	// Maps columns to the header names they go by in a CSV.
+	Columns map[string]string
+	// Validates rows without importing any.
+	DryRun bool
+}
+
+type ImportError struct {
+	// Line of the import, 1 being the header of a CSV.
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
+
+type ImportResult struct {
+	DryRun  bool          `json:"dry_run"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Errors  []ImportError `json:"errors"`
+}
+
+// ExportTasks writes the tasks outside the trash visible to the session into
+// w, in the format, FormatCSV if empty.
+func (c *Client) ExportTasks(ctx context.Context, format string, w io.Writer) error {
+	return c.stream(ctx, http.MethodGet, "/v1/tasks/export?"+formatQuery(format).Encode(), http.StatusOK, w)
+}
+
+// ImportTasks creates and updates tasks by the rows read from r, which is
+// read whole first, so it can be sent again on renewal. Rows that are invalid
+// are reported in the result instead of failing, and nothing is imported
+// then.
+func (c *Client) ImportTasks(ctx context.Context, r io.Reader, o *ImportTasksOptions) (*ImportResult, error) {
+	if o == nil {
+		o = &ImportTasksOptions{}
+	}
+	query := formatQuery(o.Format)
+	for column, header := range o.Columns {
+		query.Set("columns["+column+"]", header)
+	}
+	if o.DryRun {
+		query.Set("dry_run", "true")
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	path := "/v1/tasks/import?" + query.Encode()
+	res, err := c.do(ctx, http.MethodPost, path, "text/plain; charset=utf-8", body)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusUnprocessableEntity {
+		return nil, statusError(http.MethodPost, path, res)
+	}
+
+	var result ImportResult
+	if err := decode(http.MethodPost, path, res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func formatQuery(format string) url.Values {
+	if format == "" {
+		format = FormatCSV
+	}
+	return url.Values{"format": {format}}
+}
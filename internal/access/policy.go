@@ -0,0 +1,92 @@
+// Package access decides which users may see and change which tasks, for the
+// usecases of tasks and of what is attached to them alike.
+package access
+
+import (
+	"errors"
+
+	listentity "github.com/dannyh79/whostodo/internal/lists/entities"
+	"github.com/dannyh79/whostodo/internal/repository"
+	sessionentity "github.com/dannyh79/whostodo/internal/sessions/entities"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+var (
+	// Tasks the user may not see are not found, as those not stored.
+	ErrorTaskNotFound = repository.ErrorNotFound
+	ErrorForbidden    = errors.New("Not allowed to change tasks of the list")
+)
+
+// Policy decides which users may see and change tasks of a list, other than
+// the inbox.
+type Policy interface {
+	CanRead(user string, listId int) bool
+	CanWrite(user string, listId int) bool
+}
+
+// CanReadList reports whether the user may see tasks of the list. Without a
+// policy, everyone may. Everyone has an inbox, holding only the tasks they
+// may see of it.
+func CanReadList(p Policy, user string, listId int) bool {
+	return p == nil || listId == listentity.InboxId || p.CanRead(user, listId)
+}
+
+// CanWriteList reports whether the user may change tasks of the list, and
+// add tasks to it.
+func CanWriteList(p Policy, user string, listId int) bool {
+	return p == nil || listId == listentity.InboxId || p.CanWrite(user, listId)
+}
+
+// CanReadTask reports whether the user may see the task. Tasks in the inbox
+// are only seen by their creator and assignee.
+func CanReadTask(p Policy, user string, t *entity.Task) bool {
+	if p != nil && t.ListId == listentity.InboxId {
+		return ownsInboxTask(user, t)
+	}
+	return CanReadList(p, user, t.ListId)
+}
+
+// CanWriteTask reports whether the user may change the task.
+func CanWriteTask(p Policy, user string, t *entity.Task) bool {
+	if p != nil && t.ListId == listentity.InboxId {
+		return ownsInboxTask(user, t)
+	}
+	return CanWriteList(p, user, t.ListId)
+}
+
+// ownsInboxTask reports whether the inbox task is the user's. Tasks stored
+// before creators were kept were all made by anonymous sessions.
+func ownsInboxTask(user string, t *entity.Task) bool {
+	creator := t.Creator
+	if creator == "" {
+		creator = sessionentity.AnonymousUser
+	}
+	return user == creator || user == t.Assignee
+}
+
+// FindTask returns the task outside the trash if the user may see it, and
+// change it when write is set, as if it did not exist when the user may not
+// see it.
+func FindTask(tasks repository.Repository[entity.Task], p Policy, user string, id int, write bool) (*entity.Task, error) {
+	task, err := tasks.FindBy(id)
+	if err != nil {
+		return nil, ErrorTaskNotFound
+	}
+	if err := CheckTask(p, user, task, write); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// CheckTask reports whether the user may see the task, and change it when
+// write is set, by the errors of FindTask, for tasks found elsewhere, such as
+// in the trash.
+func CheckTask(p Policy, user string, t *entity.Task, write bool) error {
+	if !CanReadTask(p, user, t) {
+		return ErrorTaskNotFound
+	}
+	if write && !CanWriteTask(p, user, t) {
+		return ErrorForbidden
+	}
+	return nil
+}
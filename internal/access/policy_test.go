@@ -0,0 +1,83 @@
+package access_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/access"
+	"github.com/dannyh79/whostodo/internal/repository"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+// policy lets alice change tasks of list 1, and bob see them.
+type policy struct{}
+
+func (policy) CanRead(user string, listId int) bool {
+	return listId == 1 && (user == "alice" || user == "bob")
+}
+
+func (policy) CanWrite(user string, listId int) bool {
+	return listId == 1 && user == "alice"
+}
+
+func Test_FindTask(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy access.Policy
+		user   string
+		id     int
+		write  bool
+		error  error
+	}{
+		{name: "returns task the user may change", policy: policy{}, user: "alice", id: 1, write: true},
+		{name: "returns task the user may see", policy: policy{}, user: "bob", id: 1},
+		{name: "returns error on task the user may only see", policy: policy{}, user: "bob", id: 1, write: true, error: access.ErrorForbidden},
+		{name: "returns error on task the user may not see", policy: policy{}, user: "carol", id: 1, error: access.ErrorTaskNotFound},
+		{name: "returns error on task not found", policy: policy{}, user: "alice", id: 9, error: access.ErrorTaskNotFound},
+		{name: "returns task to anyone without a policy", user: "carol", id: 1, write: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", ListId: 1})
+
+			got, err := access.FindTask(repo, tc.policy, tc.user, tc.id, tc.write)
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			if tc.error == nil {
+				util.AssertEqual(t)(got.Name, "買晚餐")
+			}
+		})
+	}
+}
+
+func Test_CanReadTask(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   access.Policy
+		user     string
+		task     entity.Task
+		expected bool
+	}{
+		{name: "creator sees their inbox task", policy: policy{}, user: "carol", task: entity.Task{Creator: "carol"}, expected: true},
+		{name: "assignee sees the inbox task", policy: policy{}, user: "dave", task: entity.Task{Creator: "carol", Assignee: "dave"}, expected: true},
+		{name: "others do not see the inbox task", policy: policy{}, user: "alice", task: entity.Task{Creator: "carol"}},
+		{name: "anonymous sees inbox tasks without creator", policy: policy{}, user: "anonymous", task: entity.Task{}, expected: true},
+		{name: "others do not see inbox tasks without creator", policy: policy{}, user: "alice", task: entity.Task{}},
+		{name: "members see tasks of the list", policy: policy{}, user: "bob", task: entity.Task{ListId: 1}, expected: true},
+		{name: "creator does not see tasks of a list they left", policy: policy{}, user: "carol", task: entity.Task{ListId: 1, Creator: "carol"}},
+		{name: "anyone sees inbox tasks without a policy", user: "alice", task: entity.Task{Creator: "carol"}, expected: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			util.AssertEqual(t)(access.CanReadTask(tc.policy, tc.user, &tc.task), tc.expected)
+			util.AssertEqual(t)(access.CanWriteTask(tc.policy, tc.user, &tc.task), tc.expected && tc.user != "bob")
+		})
+	}
+}
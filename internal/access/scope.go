@@ -0,0 +1,70 @@
+package access
+
+import (
+	listentity "github.com/dannyh79/whostodo/internal/lists/entities"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+// Scope collects what a change touched, to tell who may see the change:
+// whoever may see one of its tasks or lists, or is named by it. Scopes are
+// filled by a single request, so are not safe for concurrent use.
+type Scope struct {
+	policy Policy
+	// Where the tasks were and whom they were for, as they were touched.
+	tasks []entity.Task
+	lists []int
+	users map[string]bool
+}
+
+// AddTask adds the task as it is now. Tasks moved between lists are added as
+// they were before and after, so that watchers of either list are told.
+// Nil scopes collect nothing.
+func (s *Scope) AddTask(t *entity.Task) {
+	if s == nil || t == nil {
+		return
+	}
+	s.tasks = append(s.tasks, entity.Task{ListId: t.ListId, Creator: t.Creator, Assignee: t.Assignee})
+}
+
+// AddList adds the list, whose readers are looked up once the change is made.
+func (s *Scope) AddList(id int) {
+	if s == nil || id == listentity.InboxId {
+		return
+	}
+	s.lists = append(s.lists, id)
+}
+
+// AddUsers adds users the change concerns regardless of the policy, such as
+// members of a list about to be deleted.
+func (s *Scope) AddUsers(users ...string) {
+	if s == nil {
+		return
+	}
+	for _, user := range users {
+		s.users[user] = true
+	}
+}
+
+// CanRead reports whether the user may see anything the change touched.
+func (s *Scope) CanRead(user string) bool {
+	if s.users[user] {
+		return true
+	}
+	for i := range s.tasks {
+		if CanReadTask(s.policy, user, &s.tasks[i]) {
+			return true
+		}
+	}
+	for _, id := range s.lists {
+		if CanReadList(s.policy, user, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// InitScope returns a scope touching nothing yet, deciding who may see it by
+// the policy.
+func InitScope(p Policy) *Scope {
+	return &Scope{policy: p, users: make(map[string]bool)}
+}
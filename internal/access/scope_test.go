@@ -0,0 +1,38 @@
+package access_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/access"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_ScopeCanRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		touch    func(s *access.Scope)
+		user     string
+		expected bool
+	}{
+		{name: "members see tasks touched in the list", touch: func(s *access.Scope) { s.AddTask(&entity.Task{ListId: 1}) }, user: "bob", expected: true},
+		{name: "others do not see tasks touched in the list", touch: func(s *access.Scope) { s.AddTask(&entity.Task{ListId: 1}) }, user: "carol"},
+		{name: "assignee sees the inbox task touched", touch: func(s *access.Scope) { s.AddTask(&entity.Task{Creator: "alice", Assignee: "carol"}) }, user: "carol", expected: true},
+		{name: "others do not see the inbox task touched", touch: func(s *access.Scope) { s.AddTask(&entity.Task{Creator: "alice"}) }, user: "bob"},
+		{name: "members see the list touched", touch: func(s *access.Scope) { s.AddList(1) }, user: "bob", expected: true},
+		{name: "nobody sees the inbox as a list touched", touch: func(s *access.Scope) { s.AddList(0) }, user: "bob"},
+		{name: "users named see the change", touch: func(s *access.Scope) { s.AddUsers("carol") }, user: "carol", expected: true},
+		{name: "nobody sees a change touching nothing", touch: func(s *access.Scope) {}, user: "alice"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			scope := access.InitScope(policy{})
+			tc.touch(scope)
+
+			util.AssertEqual(t)(scope.CanRead(tc.user), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+type Attachment struct {
+	Id     int
+	TaskId int
+	// File name as uploaded.
+	Name        string
+	ContentType string
+	Size        int64
+	// Key of the content in the blob store.
+	BlobKey string
+	// User who uploaded the file.
+	Uploader  string
+	CreatedAt time.Time
+}
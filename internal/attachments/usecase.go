@@ -0,0 +1,280 @@
+package attachments
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/access"
+	entity "github.com/dannyh79/whostodo/internal/attachments/entities"
+	"github.com/dannyh79/whostodo/internal/blob"
+	"github.com/dannyh79/whostodo/internal/repository"
+	taskentity "github.com/dannyh79/whostodo/internal/tasks/entities"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// Files larger than this many bytes are rejected by default.
+const DefaultMaxSize = 10 * 1024 * 1024
+
+// Content types accepted by default.
+var DefaultAllowedTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+	"text/plain",
+	"text/csv",
+	"application/zip",
+}
+
+// How many leading bytes are read to detect the content type.
+const sniffSize = 3072
+
+var (
+	ErrorTaskNotFound       = errors.New("Task not found")
+	ErrorAttachmentNotFound = errors.New("Attachment not found")
+	ErrorNotUploader        = errors.New("Only the uploader can delete the attachment")
+	ErrorEmptyFile          = errors.New("File is empty")
+	ErrorTooLarge           = errors.New("File is too large")
+	ErrorUnsupportedType    = errors.New("File type is not allowed")
+	ErrorForbidden          = errors.New("Not allowed to attach files to tasks of the list")
+)
+
+type AttachmentOutput struct {
+	Id          int       `json:"id"`
+	TaskId      int       `json:"task_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Uploader    string    `json:"uploader"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AttachmentRepository interface {
+	repository.Repository[entity.Attachment]
+	repository.TaskOwned[entity.Attachment]
+}
+
+type TaskRepository repository.Repository[taskentity.Task]
+
+// AttachmentsUsecase manages files attached to tasks outside the trash. The
+// contents live in a blob store; attachments of trashed tasks are kept until
+// the task is restored or purged.
+type AttachmentsUsecase struct {
+	repo         AttachmentRepository
+	tasks        TaskRepository
+	store        blob.Store
+	access       access.Policy
+	maxSize      int64
+	allowedTypes []string
+}
+
+type Option func(*AttachmentsUsecase)
+
+// WithMaxSize sets the largest file size accepted, in bytes.
+func WithMaxSize(size int64) Option {
+	return func(u *AttachmentsUsecase) {
+		u.maxSize = size
+	}
+}
+
+// WithAllowedTypes replaces the content types accepted.
+func WithAllowedTypes(types ...string) Option {
+	return func(u *AttachmentsUsecase) {
+		u.allowedTypes = types
+	}
+}
+
+// WithAccessPolicy lets users see files of the tasks they may see, and attach
+// files to the tasks they may change.
+func WithAccessPolicy(policy access.Policy) Option {
+	return func(u *AttachmentsUsecase) {
+		u.access = policy
+	}
+}
+
+// MaxSize returns the largest file size accepted, in bytes.
+func (u *AttachmentsUsecase) MaxSize() int64 {
+	return u.maxSize
+}
+
+func (u *AttachmentsUsecase) ListAttachments(user string, taskId int) ([]*AttachmentOutput, error) {
+	if err := u.checkTask(user, taskId, false); err != nil {
+		return nil, err
+	}
+
+	var output = make([]*AttachmentOutput, 0)
+	for _, attachment := range u.repo.ListByTask(taskId) {
+		output = append(output, toAttachmentOutput(attachment))
+	}
+
+	return output, nil
+}
+
+// Upload stores the content as a file attached to the task. The content type
+// is detected from the content itself, never taken from the client.
+func (u *AttachmentsUsecase) Upload(user string, taskId int, name string, content io.Reader) (*AttachmentOutput, error) {
+	if err := u.checkTask(user, taskId, true); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrorEmptyFile
+	}
+	head = head[:n]
+
+	contentType := mimetype.Detect(head)
+	if !u.isAllowed(contentType) {
+		return nil, ErrorUnsupportedType
+	}
+
+	key := blob.NewKey()
+	limited := io.LimitReader(io.MultiReader(bytes.NewReader(head), content), u.maxSize+1)
+	size, err := u.store.Put(key, limited)
+	if err != nil {
+		u.store.Delete(key)
+		return nil, err
+	}
+	if size > u.maxSize {
+		u.store.Delete(key)
+		return nil, ErrorTooLarge
+	}
+
+	attachment := u.repo.Save(&entity.Attachment{
+		TaskId:      taskId,
+		Name:        fileName(name),
+		ContentType: contentType.String(),
+		Size:        size,
+		BlobKey:     key,
+		Uploader:    user,
+		CreatedAt:   time.Now(),
+	})
+	return toAttachmentOutput(&attachment), nil
+}
+
+// Open returns the attachment along with its content, which the caller must
+// close.
+func (u *AttachmentsUsecase) Open(user string, taskId int, id int) (*AttachmentOutput, io.ReadCloser, error) {
+	attachment, err := u.find(user, taskId, id, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := u.store.Get(attachment.BlobKey)
+	if errors.Is(err, blob.ErrorNotFound) {
+		return nil, nil, ErrorAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return toAttachmentOutput(attachment), content, nil
+}
+
+// DeleteAttachment removes an attachment and its content for good; only its
+// uploader may.
+func (u *AttachmentsUsecase) DeleteAttachment(user string, taskId int, id int) error {
+	attachment, err := u.find(user, taskId, id, true)
+	if err != nil {
+		return err
+	}
+	if attachment.Uploader != user {
+		return ErrorNotUploader
+	}
+
+	if err := u.store.Delete(attachment.BlobKey); err != nil && !errors.Is(err, blob.ErrorNotFound) {
+		return err
+	}
+	return u.repo.Delete(attachment)
+}
+
+// DeleteByTask removes all attachments of the task along with their contents,
+// so the usecase can be attached to the tasks usecase to clean up on purge.
+func (u *AttachmentsUsecase) DeleteByTask(taskId int) int {
+	for _, attachment := range u.repo.ListByTask(taskId) {
+		u.store.Delete(attachment.BlobKey)
+	}
+	return u.repo.DeleteByTask(taskId)
+}
+
+// find returns an attachment of the task.
+func (u *AttachmentsUsecase) find(user string, taskId int, id int, write bool) (*entity.Attachment, error) {
+	if err := u.checkTask(user, taskId, write); err != nil {
+		return nil, err
+	}
+
+	attachment, err := u.repo.FindBy(id)
+	if err != nil || attachment.TaskId != taskId {
+		return nil, ErrorAttachmentNotFound
+	}
+
+	return attachment, nil
+}
+
+// checkTask reports whether the task exists and the user may see it, or
+// change it when write is set, by the errors of this package.
+func (u *AttachmentsUsecase) checkTask(user string, id int, write bool) error {
+	_, err := access.FindTask(u.tasks, u.access, user, id, write)
+	switch {
+	case errors.Is(err, access.ErrorForbidden):
+		return ErrorForbidden
+	case err != nil:
+		return ErrorTaskNotFound
+	}
+	return nil
+}
+
+// isAllowed matches the detected type exactly; a type is not allowed merely
+// because a more generic one, e.g. text/plain for text/html, is.
+func (u *AttachmentsUsecase) isAllowed(contentType *mimetype.MIME) bool {
+	for _, allowed := range u.allowedTypes {
+		if contentType.Is(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileName drops any directories the client sent along with the name.
+func fileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
+
+func InitAttachmentsUsecase(repo AttachmentRepository, tasks TaskRepository, store blob.Store, opts ...Option) *AttachmentsUsecase {
+	u := &AttachmentsUsecase{
+		repo:         repo,
+		tasks:        tasks,
+		store:        store,
+		maxSize:      DefaultMaxSize,
+		allowedTypes: DefaultAllowedTypes,
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+func toAttachmentOutput(a *entity.Attachment) *AttachmentOutput {
+	return &AttachmentOutput{
+		Id:          a.Id,
+		TaskId:      a.TaskId,
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Uploader:    a.Uploader,
+		CreatedAt:   a.CreatedAt,
+	}
+}
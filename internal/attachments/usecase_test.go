@@ -0,0 +1,217 @@
+package attachments_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/attachments"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+var uploadedAt = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// Smallest valid PNG header, enough for content type detection.
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+
+func newRepos() (*util.MockAttachmentRepository, *util.MockTaskRepository, *util.MockBlobStore) {
+	tasks := util.InitMockTaskRepository()
+	tasks.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐"})
+	tasks.PopulateData(repository.TaskSchema{Id: 2, Name: "買早餐", DeletedAt: uploadedAt})
+	repo := util.InitMockAttachmentRepository()
+	repo.PopulateData(repository.AttachmentSchema{
+		Id:          1,
+		TaskId:      1,
+		Name:        "menu.txt",
+		ContentType: "text/plain; charset=utf-8",
+		Size:        9,
+		BlobKey:     "menu",
+		Uploader:    "alice",
+		CreatedAt:   uploadedAt,
+	})
+	store := util.InitMockBlobStore()
+	store.PopulateData("menu", []byte("牛肉麵"))
+	return repo, tasks, store
+}
+
+func Test_ListAttachments(t *testing.T) {
+	t.Run("returns attachments of the task", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := attachments.InitAttachmentsUsecase(newRepos())
+		got, err := usecase.ListAttachments("alice", 1)
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(got, []*attachments.AttachmentOutput{
+			{Id: 1, TaskId: 1, Name: "menu.txt", ContentType: "text/plain; charset=utf-8", Size: 9, Uploader: "alice", CreatedAt: uploadedAt},
+		})
+	})
+
+	t.Run("returns error for trashed task", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := attachments.InitAttachmentsUsecase(newRepos())
+		_, err := usecase.ListAttachments("alice", 2)
+
+		util.AssertErrorEqual(t)(err, attachments.ErrorTaskNotFound)
+	})
+}
+
+func Test_Upload(t *testing.T) {
+	tests := []struct {
+		name        string
+		param       int
+		fileName    string
+		content     []byte
+		expectError bool
+		error       error
+	}{
+		{
+			name:     "stores file with detected content type",
+			param:    1,
+			fileName: "receipt.png",
+			content:  png,
+		},
+		{
+			name:        "returns error when task not found",
+			param:       9,
+			fileName:    "receipt.png",
+			content:     png,
+			expectError: true,
+			error:       attachments.ErrorTaskNotFound,
+		},
+		{
+			name:        "returns error on empty file",
+			param:       1,
+			fileName:    "receipt.png",
+			expectError: true,
+			error:       attachments.ErrorEmptyFile,
+		},
+		{
+			name:        "returns error on type not allowed",
+			param:       1,
+			fileName:    "receipt.png",
+			content:     []byte("<html><script>alert(1)</script></html>"),
+			expectError: true,
+			error:       attachments.ErrorUnsupportedType,
+		},
+		{
+			name:        "returns error on file too large",
+			param:       1,
+			fileName:    "receipt.png",
+			content:     append(png, make([]byte, 64)...),
+			expectError: true,
+			error:       attachments.ErrorTooLarge,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, tasks, store := newRepos()
+			usecase := attachments.InitAttachmentsUsecase(repo, tasks, store, attachments.WithMaxSize(64))
+			got, err := usecase.Upload("bob", tc.param, tc.fileName, bytes.NewReader(tc.content))
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			if tc.expectError {
+				util.AssertEqual(t)(len(repo.Data), 1)
+				util.AssertEqual(t)(len(store.Data), 1)
+				return
+			}
+			util.AssertEqual(t)(got.ContentType, "image/png")
+			util.AssertEqual(t)(got.Size, int64(len(png)))
+			util.AssertEqual(t)(got.Uploader, "bob")
+			util.AssertEqual(t)(store.Data[repo.Data[got.Id].BlobKey], png)
+		})
+	}
+}
+
+func Test_UploadFileName(t *testing.T) {
+	t.Parallel()
+
+	usecase := attachments.InitAttachmentsUsecase(newRepos())
+	got, _ := usecase.Upload("bob", 1, `..\..\etc/receipt.txt`, strings.NewReader("兩份"))
+
+	util.AssertEqual(t)(got.Name, "receipt.txt")
+}
+
+func Test_Open(t *testing.T) {
+	t.Run("returns attachment with its content", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := attachments.InitAttachmentsUsecase(newRepos())
+		got, content, err := usecase.Open("alice", 1, 1)
+
+		util.AssertErrorEqual(t)(err, nil)
+		defer content.Close()
+		data, _ := io.ReadAll(content)
+		util.AssertEqual(t)(got.Name, "menu.txt")
+		util.AssertEqual(t)(string(data), "牛肉麵")
+	})
+
+	t.Run("returns error for attachment of another task", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := attachments.InitAttachmentsUsecase(newRepos())
+		_, _, err := usecase.Open("alice", 2, 1)
+
+		util.AssertErrorEqual(t)(err, attachments.ErrorTaskNotFound)
+	})
+}
+
+func Test_DeleteAttachment(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  string
+		param int
+		error error
+	}{
+		{
+			name:  "removes attachment and its content",
+			user:  "alice",
+			param: 1,
+		},
+		{
+			name:  "returns error when user is not the uploader",
+			user:  "bob",
+			param: 1,
+			error: attachments.ErrorNotUploader,
+		},
+		{
+			name:  "returns error when attachment not found",
+			user:  "alice",
+			param: 9,
+			error: attachments.ErrorAttachmentNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, tasks, store := newRepos()
+			usecase := attachments.InitAttachmentsUsecase(repo, tasks, store)
+			err := usecase.DeleteAttachment(tc.user, 1, tc.param)
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			if tc.error == nil {
+				util.AssertEqual(t)(len(repo.Data), 0)
+				util.AssertEqual(t)(len(store.Data), 0)
+			}
+		})
+	}
+}
+
+func Test_DeleteByTask(t *testing.T) {
+	t.Parallel()
+
+	repo, tasks, store := newRepos()
+	usecase := attachments.InitAttachmentsUsecase(repo, tasks, store)
+
+	util.AssertEqual(t)(usecase.DeleteByTask(1), 1)
+	util.AssertEqual(t)(len(store.Data), 0)
+}
@@ -0,0 +1,320 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/blob"
+	"github.com/dannyh79/whostodo/internal/repository"
+	sessionentity "github.com/dannyh79/whostodo/internal/sessions/entities"
+)
+
+// Version of the archive format written by Backup; Restore reads only this
+// one.
+const Version = 1
+
+// checksumPrefix names the hash of the checksum, should it ever change.
+const checksumPrefix = "sha256:"
+
+// States larger than this many bytes are not restored, as they are read into
+// memory whole; attached files are streamed and not limited.
+const MaxStateSize = 1 << 28
+
+// An archive is a tar of these entries, in order: the manifest, the state,
+// a file entry of every attached file, and the checksum. The checksum covers
+// the name, size and content of every entry before it, so entries cannot be
+// edited, dropped or reordered, and a backup cut short is never restored.
+const (
+	manifestEntry = "manifest.json"
+	stateEntry    = "state.json"
+	filesDir      = "files"
+	checksumEntry = "checksum"
+)
+
+// Manifest describes the archive, ahead of everything else.
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// State is every row of a backup as the repositories store them. Contents of
+// attached files follow it in the archive, in entries of their own.
+type State struct {
+	Sessions   []repository.SessionSchema `json:"sessions"`
+	Accounts   []repository.AccountSchema `json:"accounts"`
+	Feeds      []repository.FeedSchema    `json:"feeds"`
+	Workspaces []WorkspaceState           `json:"workspaces"`
+}
+
+type WorkspaceState struct {
+	Id          string                        `json:"id"`
+	Tasks       []repository.TaskSchema       `json:"tasks"`
+	Lists       []repository.ListSchema       `json:"lists"`
+	Members     []repository.MemberSchema     `json:"members"`
+	Comments    []repository.CommentSchema    `json:"comments"`
+	Attachments []repository.AttachmentSchema `json:"attachments"`
+	Events      []repository.EventSchema      `json:"events"`
+}
+
+// fileEntry names the entry of an attached file of the workspace by its blob
+// key.
+func fileEntry(workspace string, key string) string {
+	return path.Join(filesDir, workspace, key)
+}
+
+// parseFileEntry returns the workspace and blob key of a file entry.
+func parseFileEntry(name string) (string, string, bool) {
+	dir, key := path.Split(name)
+	parent, workspace := path.Split(path.Clean(dir))
+	if parent != filesDir+"/" || workspace == "" || key == "" {
+		return "", "", false
+	}
+	return workspace, key, true
+}
+
+// archiveWriter writes entries of an archive, summing them up as it goes.
+type archiveWriter struct {
+	tar  *tar.Writer
+	hash hash.Hash
+	time time.Time
+}
+
+func newArchiveWriter(w io.Writer, createdAt time.Time) *archiveWriter {
+	return &archiveWriter{tar: tar.NewWriter(w), hash: sha256.New(), time: createdAt}
+}
+
+// entry writes an entry of the size, failing if the content is of another.
+func (w *archiveWriter) entry(name string, size int64, content io.Reader) error {
+	if err := w.tar.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: size, Mode: 0o600, ModTime: w.time}); err != nil {
+		return err
+	}
+	fmt.Fprintf(w.hash, "%s\x00%d\x00", name, size)
+	if _, err := io.Copy(io.MultiWriter(w.tar, w.hash), content); err != nil {
+		return err
+	}
+	return w.tar.Flush()
+}
+
+func (w *archiveWriter) json(name string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.entry(name, int64(len(raw)), bytes.NewReader(raw))
+}
+
+// close writes the checksum of the entries written.
+func (w *archiveWriter) close() error {
+	sum := checksumPrefix + hex.EncodeToString(w.hash.Sum(nil))
+	if err := w.tar.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: checksumEntry, Size: int64(len(sum)), Mode: 0o600, ModTime: w.time}); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w.tar, sum); err != nil {
+		return err
+	}
+	return w.tar.Close()
+}
+
+// archiveReader reads entries of an archive, summing them up as they are
+// read.
+type archiveReader struct {
+	tar  *tar.Reader
+	hash hash.Hash
+}
+
+func newArchiveReader(r io.Reader) *archiveReader {
+	return &archiveReader{tar: tar.NewReader(r), hash: sha256.New()}
+}
+
+// next returns the name of the next entry and its content, which is summed
+// up as it is read; every entry is to be read in full.
+func (r *archiveReader) next() (string, io.Reader, error) {
+	header, err := r.tar.Next()
+	if err == io.EOF {
+		return "", nil, fmt.Errorf("%w: %s missing", ErrorInvalidArchive, checksumEntry)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrorInvalidArchive, err)
+	}
+	if header.Typeflag != tar.TypeReg {
+		return "", nil, fmt.Errorf("%w: %s: not a file", ErrorInvalidArchive, header.Name)
+	}
+	if header.Name == checksumEntry {
+		return header.Name, r.tar, nil
+	}
+	fmt.Fprintf(r.hash, "%s\x00%d\x00", header.Name, header.Size)
+	return header.Name, io.TeeReader(r.tar, r.hash), nil
+}
+
+// json decodes the next entry, which must be of the name and at most
+// MaxStateSize bytes long, into v.
+func (r *archiveReader) json(name string, v any) error {
+	got, content, err := r.next()
+	if err != nil {
+		return err
+	}
+	if got != name {
+		return fmt.Errorf("%w: %s expected, got %s", ErrorInvalidArchive, name, got)
+	}
+	raw, err := io.ReadAll(io.LimitReader(content, MaxStateSize+1))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrorInvalidArchive, err)
+	}
+	if len(raw) > MaxStateSize {
+		return fmt.Errorf("%w: %s", ErrorTooLarge, name)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrorInvalidArchive, name, err)
+	}
+	return nil
+}
+
+// verify checks the content of the checksum entry against the entries read,
+// and that nothing follows it.
+func (r *archiveReader) verify(content io.Reader) error {
+	got, err := io.ReadAll(io.LimitReader(content, 1024))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrorInvalidArchive, err)
+	}
+	if string(got) != checksumPrefix+hex.EncodeToString(r.hash.Sum(nil)) {
+		return ErrorChecksumMismatch
+	}
+	if _, err := r.tar.Next(); err != io.EOF {
+		return fmt.Errorf("%w: entries after %s", ErrorInvalidArchive, checksumEntry)
+	}
+	return nil
+}
+
+// validate checks that the state can be restored as it is: ids are unique,
+// and rows point to rows that exist.
+func validate(s *State) error {
+	sessions := map[string]bool{}
+	for _, row := range s.Sessions {
+		if row.Id == "" || sessions[row.Id] {
+			return fmt.Errorf("%w: session %q: duplicate or empty id", ErrorInvalidState, row.Id)
+		}
+		if !sessionentity.IsWorkspace(row.Workspace) {
+			return fmt.Errorf("%w: session %q: invalid workspace %q", ErrorInvalidState, row.Id, row.Workspace)
+		}
+		sessions[row.Id] = true
+	}
+
+	accounts := map[string]bool{}
+	for _, row := range s.Accounts {
+		if row.User == "" || row.User == sessionentity.AnonymousUser || accounts[row.User] {
+			return fmt.Errorf("%w: account %q: duplicate or invalid user", ErrorInvalidState, row.User)
+		}
+		for _, id := range row.Workspaces {
+			if !sessionentity.IsWorkspace(id) {
+				return fmt.Errorf("%w: account %q: invalid workspace %q", ErrorInvalidState, row.User, id)
+			}
+		}
+		accounts[row.User] = true
+	}
+
+	feeds := map[string]bool{}
+	for _, row := range s.Feeds {
+		if row.Token == "" || feeds[row.Token] {
+			return fmt.Errorf("%w: feed %q: duplicate or empty token", ErrorInvalidState, row.Token)
+		}
+		if !sessionentity.IsWorkspace(row.Workspace) {
+			return fmt.Errorf("%w: feed %q: invalid workspace %q", ErrorInvalidState, row.Token, row.Workspace)
+		}
+		feeds[row.Token] = true
+	}
+
+	workspaces := map[string]bool{}
+	for i := range s.Workspaces {
+		w := &s.Workspaces[i]
+		if !sessionentity.IsWorkspace(w.Id) || workspaces[w.Id] {
+			return fmt.Errorf("%w: workspace %q: duplicate or invalid id", ErrorInvalidState, w.Id)
+		}
+		workspaces[w.Id] = true
+		if err := validateWorkspace(w); err != nil {
+			return fmt.Errorf("%w: workspace %q: %w", ErrorInvalidState, w.Id, err)
+		}
+	}
+	return nil
+}
+
+func validateWorkspace(w *WorkspaceState) error {
+	lists, err := ids("list", w.Lists, func(row repository.ListSchema) int { return row.Id })
+	if err != nil {
+		return err
+	}
+	tasks, err := ids("task", w.Tasks, func(row repository.TaskSchema) int { return row.Id })
+	if err != nil {
+		return err
+	}
+
+	// Trashed tasks may be left in lists deleted since, and purged tasks are
+	// not removed from parents and blockers, so only lists of tasks outside
+	// the trash are checked.
+	for _, row := range w.Tasks {
+		if row.DeletedAt.IsZero() && row.ListId != 0 && !lists[row.ListId] {
+			return fmt.Errorf("task %d: list %d not found", row.Id, row.ListId)
+		}
+	}
+
+	if _, err := ids("member", w.Members, func(row repository.MemberSchema) int { return row.Id }); err != nil {
+		return err
+	}
+	for _, row := range w.Members {
+		if !lists[row.ListId] {
+			return fmt.Errorf("member %d: list %d not found", row.Id, row.ListId)
+		}
+	}
+
+	if _, err := ids("comment", w.Comments, func(row repository.CommentSchema) int { return row.Id }); err != nil {
+		return err
+	}
+	for _, row := range w.Comments {
+		if !tasks[row.TaskId] {
+			return fmt.Errorf("comment %d: task %d not found", row.Id, row.TaskId)
+		}
+	}
+
+	if _, err := ids("event", w.Events, func(row repository.EventSchema) int { return row.Id }); err != nil {
+		return err
+	}
+	for _, row := range w.Events {
+		if !tasks[row.TaskId] {
+			return fmt.Errorf("event %d: task %d not found", row.Id, row.TaskId)
+		}
+	}
+
+	if _, err := ids("attachment", w.Attachments, func(row repository.AttachmentSchema) int { return row.Id }); err != nil {
+		return err
+	}
+	for _, row := range w.Attachments {
+		if !tasks[row.TaskId] {
+			return fmt.Errorf("attachment %d: task %d not found", row.Id, row.TaskId)
+		}
+		if !blob.ValidKey(row.BlobKey) {
+			return fmt.Errorf("attachment %d: %w", row.Id, blob.ErrorInvalidKey)
+		}
+	}
+	return nil
+}
+
+// ids returns the set of ids of the rows, failing on any duplicate or
+// non-positive one.
+func ids[S any](kind string, rows []S, id func(S) int) (map[int]bool, error) {
+	set := make(map[int]bool, len(rows))
+	for _, row := range rows {
+		i := id(row)
+		if i <= 0 || set[i] {
+			return nil, fmt.Errorf("%s %d: duplicate or invalid id", kind, i)
+		}
+		set[i] = true
+	}
+	return set, nil
+}
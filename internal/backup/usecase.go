@@ -0,0 +1,297 @@
+// Package backup snapshots the whole application state into an archive, and
+// restores it from one, regardless of the repositories behind it.
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/blob"
+	"github.com/dannyh79/whostodo/internal/changes"
+	"github.com/dannyh79/whostodo/internal/repository"
+	sessionentity "github.com/dannyh79/whostodo/internal/sessions/entities"
+	"github.com/dannyh79/whostodo/internal/workspaces"
+)
+
+var (
+	ErrorInvalidArchive     = errors.New("Invalid archive")
+	ErrorInvalidState       = errors.New("Archive state is inconsistent")
+	ErrorUnsupportedVersion = errors.New("Unsupported archive version")
+	ErrorChecksumMismatch   = errors.New("Archive checksum mismatch")
+	ErrorUnsupported        = errors.New("Repositories cannot be backed up")
+	ErrorTooLarge           = errors.New("Archive state is too large")
+)
+
+type RestoreOutput struct {
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	Workspaces int       `json:"workspaces"`
+	Sessions   int       `json:"sessions"`
+	Tasks      int       `json:"tasks"`
+}
+
+// BackupUsecase backs up sessions, accounts, feeds and every workspace built
+// so far, and restores them.
+type BackupUsecase struct {
+	sessions   repository.Snapshot[repository.SessionSchema]
+	accounts   repository.Snapshot[repository.AccountSchema]
+	feeds      repository.Snapshot[repository.FeedSchema]
+	workspaces *workspaces.WorkspacesUsecase
+}
+
+// Backup writes an archive of the current state, streaming attached files
+// from their store. Rows are read before anything is written, so an error
+// without anything written leaves w untouched; one past that leaves the
+// archive without its checksum, which Restore refuses.
+func (u *BackupUsecase) Backup(w io.Writer) error {
+	state := State{
+		Sessions:   u.sessions.Dump(),
+		Accounts:   u.accounts.Dump(),
+		Feeds:      u.feeds.Dump(),
+		Workspaces: []WorkspaceState{},
+	}
+	built := u.workspaces.List()
+	for _, ws := range built {
+		ws.Lock()
+		dumped, err := dumpWorkspace(ws)
+		ws.Unlock()
+		if err != nil {
+			return fmt.Errorf("workspace %q: %w", ws.Id, err)
+		}
+		state.Workspaces = append(state.Workspaces, *dumped)
+	}
+
+	createdAt := time.Now().UTC()
+	out := newArchiveWriter(w, createdAt)
+	if err := out.json(manifestEntry, Manifest{Version: Version, CreatedAt: createdAt}); err != nil {
+		return err
+	}
+	if err := out.json(stateEntry, state); err != nil {
+		return err
+	}
+	for i, ws := range built {
+		for _, a := range state.Workspaces[i].Attachments {
+			if err := writeFile(out, ws, a); err != nil {
+				return fmt.Errorf("workspace %q: attachment %d: %w", ws.Id, a.Id, err)
+			}
+		}
+	}
+	return out.close()
+}
+
+func writeFile(out *archiveWriter, ws *workspaces.Workspace, a repository.AttachmentSchema) error {
+	file, err := ws.Rows.Files.Get(a.BlobKey)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return out.entry(fileEntry(ws.Id, a.BlobKey), a.Size, file)
+}
+
+// Restore replaces the current state with that of the archive. Workspaces
+// are built anew off to the side and filled, attached files streamed into
+// their stores under new keys, and only once the whole archive is read and
+// checked are they swapped in, all at once, for those in use. On error,
+// nothing is replaced and the files stored so far are deleted again; on
+// success, the files of the replaced workspaces are. Workspaces missing from
+// the archive are emptied, what sessions can undo is forgotten, and watchers
+// of every workspace are told of the change.
+func (u *BackupUsecase) Restore(r io.Reader) (*RestoreOutput, error) {
+	in := newArchiveReader(r)
+	var manifest Manifest
+	if err := in.json(manifestEntry, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrorUnsupportedVersion, manifest.Version)
+	}
+	var state State
+	if err := in.json(stateEntry, &state); err != nil {
+		return nil, err
+	}
+	if err := validate(&state); err != nil {
+		return nil, err
+	}
+
+	s, err := u.stage(&state)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.readFiles(in); err != nil {
+		s.discard()
+		return nil, err
+	}
+
+	output := &RestoreOutput{
+		Version:    manifest.Version,
+		CreatedAt:  manifest.CreatedAt,
+		Workspaces: len(state.Workspaces),
+		Sessions:   len(state.Sessions),
+	}
+	var built []*workspaces.Workspace
+	for _, ws := range s.built {
+		rows := s.rows[ws.Id]
+		loadWorkspace(ws, rows)
+		built = append(built, ws)
+		output.Tasks += len(rows.Tasks)
+	}
+
+	// Accounts backed up before they had workspaces were let into any.
+	for i := range state.Accounts {
+		if len(state.Accounts[i].Workspaces) == 0 {
+			state.Accounts[i].Workspaces = []string{sessionentity.DefaultWorkspace}
+		}
+	}
+	u.sessions.Load(state.Sessions)
+	u.accounts.Load(state.Accounts)
+	u.feeds.Load(state.Feeds)
+	for _, old := range u.workspaces.Replace(built) {
+		deleteFiles(old)
+	}
+	for _, ws := range built {
+		ws.Changes.Publish(changes.Change{})
+	}
+	return output, nil
+}
+
+// staging holds the workspaces a restore builds, until swapped in.
+type staging struct {
+	built map[string]*workspaces.Workspace
+	rows  map[string]*WorkspaceState
+	// New blob keys of the files stored, by workspace and key in the archive;
+	// empty for files attached but not stored yet.
+	keys map[string]map[string]string
+}
+
+// stage builds a workspace anew for every one of the state, and every one in
+// use to be emptied.
+func (u *BackupUsecase) stage(state *State) (*staging, error) {
+	s := &staging{built: map[string]*workspaces.Workspace{}, rows: map[string]*WorkspaceState{}, keys: map[string]map[string]string{}}
+	for _, ws := range u.workspaces.List() {
+		s.rows[ws.Id] = &WorkspaceState{Id: ws.Id}
+	}
+	for i := range state.Workspaces {
+		s.rows[state.Workspaces[i].Id] = &state.Workspaces[i]
+	}
+
+	for id := range s.rows {
+		ws, err := u.workspaces.Build(id)
+		if err != nil {
+			return nil, fmt.Errorf("workspace %q: %w", id, err)
+		}
+		if ws.Rows == nil {
+			return nil, fmt.Errorf("workspace %q: %w", id, ErrorUnsupported)
+		}
+		s.built[id] = ws
+		s.keys[id] = map[string]string{}
+		for _, a := range s.rows[id].Attachments {
+			s.keys[id][a.BlobKey] = ""
+		}
+	}
+	return s, nil
+}
+
+// readFiles stores the file entries up to the checksum, which it verifies,
+// and points the attachments at them.
+func (s *staging) readFiles(in *archiveReader) error {
+	for {
+		name, content, err := in.next()
+		if err != nil {
+			return err
+		}
+		if name == checksumEntry {
+			if err := in.verify(content); err != nil {
+				return err
+			}
+			break
+		}
+
+		workspace, key, ok := parseFileEntry(name)
+		if !ok {
+			return fmt.Errorf("%w: %s: unexpected entry", ErrorInvalidArchive, name)
+		}
+		stored, attached := s.keys[workspace][key]
+		if !attached {
+			return fmt.Errorf("%w: file %q: not attached in workspace %q", ErrorInvalidState, key, workspace)
+		}
+		if stored != "" {
+			return fmt.Errorf("%w: %s: duplicate entry", ErrorInvalidArchive, name)
+		}
+		s.keys[workspace][key] = blob.NewKey()
+		if _, err := s.built[workspace].Rows.Files.Put(s.keys[workspace][key], content); err != nil {
+			return fmt.Errorf("workspace %q: file %q: %w", workspace, key, err)
+		}
+	}
+
+	for id, rows := range s.rows {
+		for i := range rows.Attachments {
+			a := &rows.Attachments[i]
+			key := s.keys[id][a.BlobKey]
+			if key == "" {
+				return fmt.Errorf("%w: workspace %q: attachment %d: file %q not found", ErrorInvalidState, id, a.Id, a.BlobKey)
+			}
+			a.BlobKey = key
+		}
+	}
+	return nil
+}
+
+// discard deletes the files stored so far.
+func (s *staging) discard() {
+	for id, keys := range s.keys {
+		for _, key := range keys {
+			if key != "" {
+				s.built[id].Rows.Files.Delete(key)
+			}
+		}
+	}
+}
+
+func dumpWorkspace(ws *workspaces.Workspace) (*WorkspaceState, error) {
+	if ws.Rows == nil {
+		return nil, ErrorUnsupported
+	}
+
+	rows := ws.Rows
+	return &WorkspaceState{
+		Id:          ws.Id,
+		Tasks:       rows.Tasks.Dump(),
+		Lists:       rows.Lists.Dump(),
+		Members:     rows.Members.Dump(),
+		Comments:    rows.Comments.Dump(),
+		Attachments: rows.Attachments.Dump(),
+		Events:      rows.Events.Dump(),
+	}, nil
+}
+
+func loadWorkspace(ws *workspaces.Workspace, s *WorkspaceState) {
+	rows := ws.Rows
+	rows.Tasks.Load(s.Tasks)
+	rows.Lists.Load(s.Lists)
+	rows.Members.Load(s.Members)
+	rows.Comments.Load(s.Comments)
+	rows.Attachments.Load(s.Attachments)
+	rows.Events.Load(s.Events)
+}
+
+// deleteFiles deletes the attached files of a replaced workspace. Files that
+// fail to be deleted are left behind, unreferenced.
+func deleteFiles(ws *workspaces.Workspace) {
+	if ws.Rows == nil {
+		return
+	}
+	for _, a := range ws.Rows.Attachments.Dump() {
+		ws.Rows.Files.Delete(a.BlobKey)
+	}
+}
+
+func InitBackupUsecase(sessions repository.Snapshot[repository.SessionSchema], accounts repository.Snapshot[repository.AccountSchema], feeds repository.Snapshot[repository.FeedSchema], workspaces *workspaces.WorkspacesUsecase) *BackupUsecase {
+	return &BackupUsecase{
+		sessions:   sessions,
+		accounts:   accounts,
+		feeds:      feeds,
+		workspaces: workspaces,
+	}
+}
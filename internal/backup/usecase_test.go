@@ -0,0 +1,389 @@
+package backup_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/backup"
+	"github.com/dannyh79/whostodo/internal/comments"
+	"github.com/dannyh79/whostodo/internal/lists"
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+	"github.com/dannyh79/whostodo/internal/workspaces"
+)
+
+// app is the state a backup usecase works on, on in-memory repositories.
+type app struct {
+	sessions   *repository.InMemorySessionRepository
+	accounts   *repository.InMemoryAccountRepository
+	feeds      *repository.InMemoryFeedRepository
+	workspaces *workspaces.WorkspacesUsecase
+	usecase    *backup.BackupUsecase
+	// Every store built, in use or not.
+	stores []*util.MockBlobStore
+}
+
+func newApp() *app {
+	a := &app{
+		sessions: repository.InitInMemorySessionRepository(),
+		accounts: repository.InitInMemoryAccountRepository(),
+		feeds:    repository.InitInMemoryFeedRepository(),
+	}
+	a.workspaces = workspaces.InitWorkspacesUsecase(func(id string) (*workspaces.Workspace, error) {
+		store := util.InitMockBlobStore()
+		a.stores = append(a.stores, store)
+		return workspaces.InitInMemoryWorkspace(id, store), nil
+	}, "default", "team-a")
+	a.usecase = backup.InitBackupUsecase(a.sessions, a.accounts, a.feeds, a.workspaces)
+	return a
+}
+
+// files counts the files of every store built.
+func (a *app) files() int {
+	var n int
+	for _, store := range a.stores {
+		n += len(store.Data)
+	}
+	return n
+}
+
+// populate fills the app with a bit of everything.
+func populate(t *testing.T, a *app) {
+	t.Helper()
+	alice := tasks.Actor{User: "alice"}
+	a.sessions.Save(&repository.Session{Id: "alice_token", User: "alice", Workspace: "default", CreatedAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)})
+	a.accounts.Save(&repository.Account{User: "alice", PasswordHash: []byte("hash"), CreatedAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)})
+	a.feeds.Save(&repository.Feed{Token: "feed_token", User: "alice", Workspace: "team-a", CreatedAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)})
+
+	ws, _ := a.workspaces.Find("default")
+	list := ws.Lists.CreateList("alice", &lists.CreateListInput{Name: "家事"})
+	if _, err := ws.Lists.InviteMember("alice", list.Id, "bob", &lists.MemberInput{Role: "viewer"}); err != nil {
+		t.Fatal(err)
+	}
+	task, err := ws.Tasks.CreateTask(alice, &tasks.CreateTaskInput{Name: "洗碗", ListId: list.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.Comments.CreateComment("alice", task.Id, &comments.CommentInput{Body: "用洗碗精"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.Attachments.Upload("alice", task.Id, "清單.txt", strings.NewReader("洗碗精")); err != nil {
+		t.Fatal(err)
+	}
+	trashed, _ := ws.Tasks.CreateTask(alice, &tasks.CreateTaskInput{Name: "倒垃圾"})
+	if err := ws.Tasks.DeleteTask(alice, trashed.Id, &tasks.DeleteTaskInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	other, _ := a.workspaces.Find("team-a")
+	other.Tasks.CreateTask(alice, &tasks.CreateTaskInput{Name: "開會"})
+}
+
+type entry struct {
+	name    string
+	content string
+}
+
+// archiveOf writes an archive of the entries, followed by their checksum.
+func archiveOf(t *testing.T, entries ...entry) string {
+	t.Helper()
+	var archive bytes.Buffer
+	out := tar.NewWriter(&archive)
+	sum := sha256.New()
+	write := func(e entry) {
+		if err := out.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: e.name, Size: int64(len(e.content)), Mode: 0o600}); err != nil {
+			t.Fatal(err)
+		}
+		out.Write([]byte(e.content))
+	}
+	for _, e := range entries {
+		write(e)
+		fmt.Fprintf(sum, "%s\x00%d\x00%s", e.name, len(e.content), e.content)
+	}
+	write(entry{name: "checksum", content: "sha256:" + hex.EncodeToString(sum.Sum(nil))})
+	out.Close()
+	return archive.String()
+}
+
+// stateArchive writes an archive of the state and the files, by entry name.
+func stateArchive(t *testing.T, version int, state backup.State, files ...entry) string {
+	t.Helper()
+	manifest, _ := json.Marshal(backup.Manifest{Version: version})
+	raw, _ := json.Marshal(state)
+	return archiveOf(t, append([]entry{{name: "manifest.json", content: string(manifest)}, {name: "state.json", content: string(raw)}}, files...)...)
+}
+
+// entriesOf reads the entries of an archive.
+func entriesOf(t *testing.T, archive string) []entry {
+	t.Helper()
+	in := tar.NewReader(strings.NewReader(archive))
+	var entries []entry
+	for {
+		header, err := in.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(in)
+		entries = append(entries, entry{name: header.Name, content: string(content)})
+	}
+}
+
+// rewrite writes the entries back into an archive as they are, checksum
+// included.
+func rewrite(t *testing.T, entries []entry) string {
+	t.Helper()
+	var archive bytes.Buffer
+	out := tar.NewWriter(&archive)
+	for _, e := range entries {
+		out.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: e.name, Size: int64(len(e.content)), Mode: 0o600})
+		out.Write([]byte(e.content))
+	}
+	out.Close()
+	return archive.String()
+}
+
+// withoutBlobKeys returns the rows, with the blob keys a restore renews
+// left out.
+func withoutBlobKeys(rows []repository.AttachmentSchema) []repository.AttachmentSchema {
+	for i := range rows {
+		rows[i].BlobKey = ""
+	}
+	return rows
+}
+
+func Test_BackupRestore(t *testing.T) {
+	t.Parallel()
+
+	from := newApp()
+	populate(t, from)
+	var archive bytes.Buffer
+	if err := from.usecase.Backup(&archive); err != nil {
+		t.Fatal(err)
+	}
+
+	to := newApp()
+	got, err := to.usecase.Restore(&archive)
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(got.Version, backup.Version)
+	util.AssertEqual(t)(got.Workspaces, 2)
+	util.AssertEqual(t)(got.Sessions, 1)
+	util.AssertEqual(t)(got.Tasks, 3)
+	util.AssertEqual(t)(to.sessions.Dump(), from.sessions.Dump())
+	util.AssertEqual(t)(to.feeds.Dump(), from.feeds.Dump())
+
+	for _, id := range []string{"default", "team-a"} {
+		want, _ := from.workspaces.Find(id)
+		restored, _ := to.workspaces.Find(id)
+		util.AssertEqual(t)(restored.Rows.Tasks.Dump(), want.Rows.Tasks.Dump())
+		util.AssertEqual(t)(restored.Rows.Lists.Dump(), want.Rows.Lists.Dump())
+		util.AssertEqual(t)(restored.Rows.Members.Dump(), want.Rows.Members.Dump())
+		util.AssertEqual(t)(restored.Rows.Comments.Dump(), want.Rows.Comments.Dump())
+		util.AssertEqual(t)(withoutBlobKeys(restored.Rows.Attachments.Dump()), withoutBlobKeys(want.Rows.Attachments.Dump()))
+		util.AssertEqual(t)(restored.Rows.Events.Dump(), want.Rows.Events.Dump())
+	}
+
+	ws, _ := to.workspaces.Find("default")
+	alice := tasks.Actor{User: "alice"}
+	found, _ := ws.Tasks.SearchTasks(alice, &tasks.SearchTasksInput{Query: "洗碗"})
+	util.AssertEqual(t)(len(found), 1)
+	_, content, err := ws.Attachments.Open("alice", 1, 1)
+	util.AssertErrorEqual(t)(err, nil)
+	var file bytes.Buffer
+	file.ReadFrom(content)
+	util.AssertEqual(t)(file.String(), "洗碗精")
+	created, _ := ws.Tasks.CreateTask(alice, &tasks.CreateTaskInput{Name: "拖地"})
+	util.AssertEqual(t)(created.Id, 3)
+}
+
+func Test_RestoreSwapsWorkspaces(t *testing.T) {
+	t.Parallel()
+
+	a := newApp()
+	populate(t, a)
+	before, _ := a.workspaces.Find("default")
+	var archive bytes.Buffer
+	a.usecase.Backup(&archive)
+
+	_, err := a.usecase.Restore(&archive)
+
+	util.AssertErrorEqual(t)(err, nil)
+	after, _ := a.workspaces.Find("default")
+	util.AssertEqual(t)(after != before, true)
+	util.AssertEqual(t)(after.Changes == before.Changes, true)
+	// Files of the workspace replaced are deleted, leaving the restored one.
+	util.AssertEqual(t)(len(before.Rows.Files.(*util.MockBlobStore).Data), 0)
+	util.AssertEqual(t)(a.files(), 1)
+}
+
+func Test_RestoreEmptiesOtherWorkspaces(t *testing.T) {
+	t.Parallel()
+
+	a := newApp()
+	populate(t, a)
+	alice := tasks.Actor{SessionId: "alice_token", User: "alice"}
+	ws, _ := a.workspaces.Find("default")
+	ws.Tasks.CreateTask(alice, &tasks.CreateTaskInput{Name: "拖地"})
+
+	_, err := a.usecase.Restore(strings.NewReader(stateArchive(t, backup.Version, backup.State{
+		Workspaces: []backup.WorkspaceState{{Id: "team-a", Tasks: []repository.TaskSchema{{Id: 1, Name: "開會"}}}},
+	})))
+
+	util.AssertErrorEqual(t)(err, nil)
+	ws, _ = a.workspaces.Find("default")
+	util.AssertEqual(t)(len(ws.Tasks.ListTasks(alice, &tasks.ListTasksInput{})), 0)
+	util.AssertEqual(t)(len(ws.Lists.ListLists("alice")), 1)
+	_, err = ws.Tasks.Undo(alice)
+	util.AssertErrorEqual(t)(err, tasks.ErrorNothingToUndo)
+	_, err = a.sessions.FindBy("alice_token")
+	util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+}
+
+func Test_RestoreError(t *testing.T) {
+	attached := backup.WorkspaceState{
+		Id:          "default",
+		Tasks:       []repository.TaskSchema{{Id: 1, Name: "洗碗"}},
+		Attachments: []repository.AttachmentSchema{{Id: 1, TaskId: 1, Name: "清單.txt", Size: 9, BlobKey: "abc"}},
+	}
+
+	tests := []struct {
+		name    string
+		archive func(t *testing.T) string
+		error   error
+	}{
+		{
+			name:    "returns error on malformed archive",
+			archive: func(*testing.T) string { return `{"version":1}` },
+			error:   backup.ErrorInvalidArchive,
+		},
+		{
+			name: "returns error on another version",
+			archive: func(t *testing.T) string {
+				return stateArchive(t, backup.Version+1, backup.State{})
+			},
+			error: backup.ErrorUnsupportedVersion,
+		},
+		{
+			name: "returns error on edited state",
+			archive: func(t *testing.T) string {
+				entries := entriesOf(t, stateArchive(t, backup.Version, backup.State{Sessions: []repository.SessionSchema{{Id: "alice_token", User: "alice", Workspace: "default"}}}))
+				entries[1].content = strings.Replace(entries[1].content, "alice", "bob", 1)
+				return rewrite(t, entries)
+			},
+			error: backup.ErrorChecksumMismatch,
+		},
+		{
+			name: "returns error on edited file",
+			archive: func(t *testing.T) string {
+				entries := entriesOf(t, stateArchive(t, backup.Version, backup.State{Workspaces: []backup.WorkspaceState{attached}}, entry{name: "files/default/abc", content: "洗碗精"}))
+				entries[2].content = "洗衣精"
+				return rewrite(t, entries)
+			},
+			error: backup.ErrorChecksumMismatch,
+		},
+		{
+			name: "returns error on archive cut short",
+			archive: func(t *testing.T) string {
+				entries := entriesOf(t, stateArchive(t, backup.Version, backup.State{Workspaces: []backup.WorkspaceState{attached}}, entry{name: "files/default/abc", content: "洗碗精"}))
+				return rewrite(t, entries[:3])
+			},
+			error: backup.ErrorInvalidArchive,
+		},
+		{
+			name: "returns error on duplicate ids",
+			archive: func(t *testing.T) string {
+				return stateArchive(t, backup.Version, backup.State{Workspaces: []backup.WorkspaceState{{
+					Id:    "default",
+					Tasks: []repository.TaskSchema{{Id: 1, Name: "洗碗"}, {Id: 1, Name: "拖地"}},
+				}}})
+			},
+			error: backup.ErrorInvalidState,
+		},
+		{
+			name: "returns error on rows of missing tasks",
+			archive: func(t *testing.T) string {
+				return stateArchive(t, backup.Version, backup.State{Workspaces: []backup.WorkspaceState{{
+					Id:       "default",
+					Comments: []repository.CommentSchema{{Id: 1, TaskId: 9, Author: "alice", Body: "用洗碗精"}},
+				}}})
+			},
+			error: backup.ErrorInvalidState,
+		},
+		{
+			name: "returns error on attachments without file",
+			archive: func(t *testing.T) string {
+				return stateArchive(t, backup.Version, backup.State{Workspaces: []backup.WorkspaceState{attached}})
+			},
+			error: backup.ErrorInvalidState,
+		},
+		{
+			name: "returns error on files not attached",
+			archive: func(t *testing.T) string {
+				return stateArchive(t, backup.Version, backup.State{Workspaces: []backup.WorkspaceState{attached}},
+					entry{name: "files/default/abc", content: "洗碗精"},
+					entry{name: "files/default/def", content: "洗衣精"},
+				)
+			},
+			error: backup.ErrorInvalidState,
+		},
+		{
+			name: "returns error on invalid workspace",
+			archive: func(t *testing.T) string {
+				return stateArchive(t, backup.Version, backup.State{Workspaces: []backup.WorkspaceState{{Id: "../default"}}})
+			},
+			error: backup.ErrorInvalidState,
+		},
+		{
+			name: "returns error on workspace not set up",
+			archive: func(t *testing.T) string {
+				return stateArchive(t, backup.Version, backup.State{Workspaces: []backup.WorkspaceState{{Id: "team-b"}}})
+			},
+			error: workspaces.ErrorWorkspaceNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := newApp()
+			populate(t, a)
+			var before bytes.Buffer
+			a.usecase.Backup(&before)
+			files := a.files()
+
+			got, err := a.usecase.Restore(strings.NewReader(tc.archive(t)))
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			util.AssertEqual(t)(got, (*backup.RestoreOutput)(nil))
+			var after bytes.Buffer
+			a.usecase.Backup(&after)
+			util.AssertEqual(t)(stateOf(t, after.String()), stateOf(t, before.String()))
+			util.AssertEqual(t)(a.files(), files)
+		})
+	}
+}
+
+// stateOf returns the state entry of an archive.
+func stateOf(t *testing.T, archive string) string {
+	t.Helper()
+	for _, e := range entriesOf(t, archive) {
+		if e.name == "state.json" {
+			return e.content
+		}
+	}
+	t.Fatal("no state in the archive")
+	return ""
+}
@@ -0,0 +1,74 @@
+package blob
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LocalStore keeps each blob in a file of a directory.
+type LocalStore struct {
+	dir string
+}
+
+func (s *LocalStore) Put(key string, content io.Reader) (int64, error) {
+	if !ValidKey(key) {
+		return 0, ErrorInvalidKey
+	}
+
+	// Write aside first so readers never see a partial blob.
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return written, err
+	}
+
+	return written, os.Rename(tmp.Name(), s.path(key))
+}
+
+func (s *LocalStore) Get(key string) (io.ReadCloser, error) {
+	if !ValidKey(key) {
+		return nil, ErrorInvalidKey
+	}
+
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrorNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStore) Delete(key string) error {
+	if !ValidKey(key) {
+		return ErrorInvalidKey
+	}
+
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrorNotFound
+	}
+	return err
+}
+
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.dir, key)
+}
+
+// InitLocalStore returns a store keeping blobs in the directory, creating it
+// if needed.
+func InitLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &LocalStore{dir: dir}, nil
+}
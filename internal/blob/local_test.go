@@ -0,0 +1,55 @@
+package blob_test
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/blob"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_LocalStore(t *testing.T) {
+	t.Run("stores, reads and deletes blobs", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := blob.InitLocalStore(t.TempDir())
+		util.AssertErrorEqual(t)(err, nil)
+
+		written, err := store.Put("a1", strings.NewReader("買晚餐"))
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(written, int64(len("買晚餐")))
+
+		r, err := store.Get("a1")
+		util.AssertErrorEqual(t)(err, nil)
+		got, _ := io.ReadAll(r)
+		r.Close()
+		util.AssertEqual(t)(string(got), "買晚餐")
+
+		util.AssertErrorEqual(t)(store.Delete("a1"), nil)
+		_, err = store.Get("a1")
+		util.AssertErrorEqual(t)(err, blob.ErrorNotFound)
+	})
+
+	t.Run("leaves no file behind", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		store, _ := blob.InitLocalStore(dir)
+		store.Put("a1", strings.NewReader("買晚餐"))
+		store.Delete("a1")
+
+		entries, _ := os.ReadDir(dir)
+		util.AssertEqual(t)(len(entries), 0)
+	})
+
+	t.Run("rejects keys escaping the directory", func(t *testing.T) {
+		t.Parallel()
+
+		store, _ := blob.InitLocalStore(t.TempDir())
+		_, err := store.Put("../a1", strings.NewReader("買晚餐"))
+
+		util.AssertErrorEqual(t)(err, blob.ErrorInvalidKey)
+	})
+}
@@ -0,0 +1,46 @@
+// Package blob stores file contents under opaque keys.
+package blob
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+var (
+	ErrorNotFound   = errors.New("Blob not found")
+	ErrorInvalidKey = errors.New("Invalid blob key")
+)
+
+// Store is implemented by blob storage backends.
+type Store interface {
+	// Put stores the content under the key, replacing any previous one, and
+	// returns how many bytes were written.
+	Put(key string, content io.Reader) (int64, error)
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// ValidKey reports whether the key is made only of ASCII letters, digits,
+// dashes and underscores, so it is safe to use as a file name.
+func ValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewKey returns a random key.
+func NewKey() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
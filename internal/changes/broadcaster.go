@@ -0,0 +1,82 @@
+// Package changes tells whoever is watching a workspace that something in it
+// changed, so they can fetch it again.
+package changes
+
+import (
+	"sync"
+
+	"github.com/dannyh79/whostodo/internal/access"
+)
+
+// How many changes a subscriber can fall behind by. Changes published to a
+// subscriber that far behind are dropped, as it still has changes to read,
+// and fetches after reading them see the dropped ones too.
+const buffer = 16
+
+// Change tells that something was changed, and by whom. It only says where
+// to look; subscribers fetch what changed themselves.
+type Change struct {
+	// User who made the change, if made by a session.
+	Actor string `json:"actor,omitempty"`
+	// Method and path of the request that made the change, if any.
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// What the change touched, deciding which subscribers are told; nil for
+	// changes to the workspace as a whole, such as restores.
+	Scope *access.Scope `json:"-"`
+}
+
+// VisibleTo reports whether the user may be told of the change: one they
+// made, or one touching something they may see.
+func (c Change) VisibleTo(user string) bool {
+	return c.Scope == nil || c.Actor == user || c.Scope.CanRead(user)
+}
+
+// Broadcaster hands every change published to every subscriber whose user
+// may see it. Unlike the usecases, it is safe for concurrent use, as
+// subscribers wait on it from requests of their own.
+type Broadcaster struct {
+	mu sync.Mutex
+	// Users of the subscribers.
+	subscribers map[chan Change]string
+}
+
+// Subscribe returns the changes published from now on that the user may see,
+// until unsubscribe is called, which closes the channel.
+func (b *Broadcaster) Subscribe(user string) (changes <-chan Change, unsubscribe func()) {
+	ch := make(chan Change, buffer)
+	b.mu.Lock()
+	b.subscribers[ch] = user
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends the change to every subscriber who may see it, without
+// waiting on any. Who may see it is decided as of now, so the change is
+// published right after being made.
+func (b *Broadcaster) Publish(c Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch, user := range b.subscribers {
+		if !c.VisibleTo(user) {
+			continue
+		}
+		select {
+		case ch <- c:
+		default:
+		}
+	}
+}
+
+func InitBroadcaster() *Broadcaster {
+	return &Broadcaster{subscribers: make(map[chan Change]string)}
+}
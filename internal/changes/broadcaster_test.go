@@ -0,0 +1,79 @@
+package changes_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/access"
+	"github.com/dannyh79/whostodo/internal/changes"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_Broadcaster(t *testing.T) {
+	t.Run("sends changes to every subscriber", func(t *testing.T) {
+		t.Parallel()
+
+		b := changes.InitBroadcaster()
+		first, unsubscribeFirst := b.Subscribe("alice")
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := b.Subscribe("alice")
+		defer unsubscribeSecond()
+		change := changes.Change{Actor: "alice", Method: "PUT", Path: "/v1/task/1"}
+
+		b.Publish(change)
+
+		util.AssertEqual(t)(<-first, change)
+		util.AssertEqual(t)(<-second, change)
+	})
+
+	t.Run("sends changes only to subscribers who may see them", func(t *testing.T) {
+		t.Parallel()
+
+		b := changes.InitBroadcaster()
+		actor, unsubscribeActor := b.Subscribe("alice")
+		defer unsubscribeActor()
+		named, unsubscribeNamed := b.Subscribe("bob")
+		defer unsubscribeNamed()
+		other, unsubscribeOther := b.Subscribe("carol")
+		defer unsubscribeOther()
+
+		scope := access.InitScope(nil)
+		scope.AddUsers("bob")
+		b.Publish(changes.Change{Actor: "alice", Method: "DELETE", Path: "/v1/list/1/members/bob", Scope: scope})
+		b.Publish(changes.Change{})
+
+		util.AssertEqual(t)((<-actor).Actor, "alice")
+		util.AssertEqual(t)((<-named).Actor, "alice")
+		util.AssertEqual(t)(<-other, changes.Change{})
+	})
+
+	t.Run("stops sending once unsubscribed", func(t *testing.T) {
+		t.Parallel()
+
+		b := changes.InitBroadcaster()
+		ch, unsubscribe := b.Subscribe("alice")
+		unsubscribe()
+		unsubscribe()
+
+		b.Publish(changes.Change{Actor: "alice"})
+
+		_, ok := <-ch
+		util.AssertEqual(t)(ok, false)
+	})
+
+	t.Run("drops changes for subscribers far behind", func(t *testing.T) {
+		t.Parallel()
+
+		b := changes.InitBroadcaster()
+		ch, unsubscribe := b.Subscribe("alice")
+		for i := 0; i < 100; i++ {
+			b.Publish(changes.Change{Actor: "alice"})
+		}
+		unsubscribe()
+
+		var got int
+		for range ch {
+			got += 1
+		}
+		util.AssertEqual(t)(got, 16)
+	})
+}
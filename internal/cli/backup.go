@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// backupCommand writes an archive of the whole server state to stdout, or to
+// a file:
+//
+//	whostodo backup [-o whostodo.tar]
+func backupCommand(env *Env, args []string) error {
+	flags := newFlagSet(env, "backup")
+	s := adminFlags(env, flags)
+	output := flags.String("o", "", "file to write the archive to instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: backup [-o file]", ErrorUsage)
+	}
+
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+
+	if *output != "" && *output != "-" {
+		// Written next to the file and renamed over it, so a failed backup
+		// does not clobber an earlier one.
+		file, err := os.CreateTemp(filepath.Dir(*output), ".whostodo-backup-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(file.Name())
+		if err := c.Backup(context.Background(), file); err != nil {
+			file.Close()
+			return failed(err, "backup failed")
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		return os.Rename(file.Name(), *output)
+	}
+
+	if err := c.Backup(context.Background(), env.Stdout); err != nil {
+		return failed(err, "backup failed")
+	}
+	return nil
+}
+
+// restoreCommand replaces the whole server state with that of an archive
+// read from a file or stdin:
+//
+//	whostodo restore [whostodo.tar]
+func restoreCommand(env *Env, args []string) error {
+	flags := newFlagSet(env, "restore")
+	s := adminFlags(env, flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("%w: restore [file]", ErrorUsage)
+	}
+
+	in := env.Stdin
+	if name := flags.Arg(0); name != "" && name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+	result, err := c.Restore(context.Background(), in)
+	if err != nil {
+		return failed(err, "restore failed")
+	}
+	fmt.Fprintf(env.Stdout, "restored: %d workspaces, %d sessions, %d tasks\n", result.Workspaces, result.Sessions, result.Tasks)
+	return nil
+}
@@ -0,0 +1,83 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_BackupRestore(t *testing.T) {
+	t.Parallel()
+
+	from := util.NewTestSuite()
+	from.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐"})
+	file := filepath.Join(t.TempDir(), "whostodo.tar")
+	// Builds the default workspace, which is only backed up once used.
+	run(t, from, "", "todotxt", "export")
+
+	backedUp := run(t, from, "", "backup", "-o", file)
+	to := util.NewTestSuite()
+	restored := run(t, to, "", "restore", file)
+
+	util.AssertEqual(t)(backedUp, result{})
+	util.AssertEqual(t)(restored, result{Stdout: "restored: 1 workspaces, 1 sessions, 1 tasks\n"})
+	util.AssertEqual(t)(to.TaskRepo.Data[1].Name, "買晚餐")
+}
+
+func Test_BackupToStdout(t *testing.T) {
+	t.Parallel()
+
+	got := run(t, util.NewTestSuite(), "", "backup")
+
+	util.AssertEqual(t)(got.Code, 0)
+	util.AssertEqual(t)(strings.HasPrefix(got.Stdout, "manifest.json\x00"), true)
+}
+
+func Test_RestoreError(t *testing.T) {
+	tests := []struct {
+		name     string
+		stdin    string
+		args     []string
+		expected result
+	}{
+		{
+			name:     "reports why the archive was rejected",
+			stdin:    `{"version":1}`,
+			args:     []string{"restore"},
+			expected: result{Code: 1, Stderr: "restore failed: Invalid archive: unexpected EOF\n"},
+		},
+		{
+			name:     "reports usage on extra arguments",
+			args:     []string{"restore", "a.tar", "b.tar"},
+			expected: result{Code: 2, Stderr: "Invalid usage: restore [file]\n"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := run(t, util.NewTestSuite(), tc.stdin, tc.args...)
+
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+}
+
+func Test_BackupKeepsFileOnFailure(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "whostodo.tar")
+	os.WriteFile(file, []byte("earlier"), 0o644)
+	suite := util.NewTestSuite()
+
+	got := run(t, suite, "", "backup", "-o", file, "-token", "wrong_token")
+	content, _ := os.ReadFile(file)
+
+	util.AssertEqual(t)(got, result{Code: 1, Stderr: "backup failed: 403 Forbidden: access denied\n"})
+	util.AssertEqual(t)(string(content), "earlier")
+}
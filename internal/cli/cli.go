@@ -0,0 +1,215 @@
+// Package cli implements the subcommands of the whostodo binary, which talk
+// to a running server through the client package.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/dannyh79/whostodo/client"
+)
+
+const DefaultURL = "http://localhost:8080"
+
+var ErrorUsage = errors.New("Invalid usage")
+
+// command runs a subcommand with the arguments following its name.
+type command func(env *Env, args []string) error
+
+var commands = map[string]command{
+	"login":   loginCommand,
+	"ls":      lsCommand,
+	"add":     addCommand,
+	"done":    doneCommand,
+	"edit":    editCommand,
+	"rm":      rmCommand,
+	"tui":     tuiCommand,
+	"todotxt": todoTxtCommand,
+	"backup":  backupCommand,
+	"restore": restoreCommand,
+}
+
+// Env is what subcommands read from and write to.
+type Env struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Looks up environment variables; os.Getenv unless overridden.
+	Getenv func(string) string
+	Client *http.Client
+}
+
+// IsCommand reports whether the binary is to run a subcommand, rather than
+// serve.
+func IsCommand(args []string) bool {
+	_, ok := commands[firstArg(args)]
+	return ok
+}
+
+// Run runs the subcommand args name, returning the exit code.
+func Run(env *Env, args []string) int {
+	if env.Getenv == nil {
+		env.Getenv = os.Getenv
+	}
+	if env.Client == nil {
+		env.Client = http.DefaultClient
+	}
+
+	cmd, ok := commands[firstArg(args)]
+	if !ok {
+		fmt.Fprintf(env.Stderr, "unknown command %q\n", firstArg(args))
+		return 2
+	}
+	err := cmd(env, args[1:])
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, ErrorUsage):
+		fmt.Fprintln(env.Stderr, err)
+		return 2
+	case err != nil:
+		fmt.Fprintln(env.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// server holds where and as whom to call the REST API.
+type server struct {
+	env   *Env
+	url   string
+	token string
+	// Where the token comes from, for reporting it missing.
+	tokenEnv   string
+	tokenUsage string
+	// Whether the token cached by login may be used when none is given.
+	cached   bool
+	resolved bool
+	// Set when the token is the one cached by login, so it is renewed when
+	// its session expires.
+	login *config
+	// Set when the terminal is taken, as by tui, so renewing cannot prompt
+	// for the password.
+	noPrompt bool
+}
+
+// serverFlags adds the flags locating the server to the flag set, defaulting
+// to WHOSTODO_URL and WHOSTODO_TOKEN, then to what login cached.
+func serverFlags(env *Env, flags *flag.FlagSet) *server {
+	return tokenFlags(env, flags, "WHOSTODO_TOKEN", "session token", true)
+}
+
+// adminFlags is serverFlags for the admin routes, whose token defaults to
+// WHOSTODO_ADMIN_TOKEN instead, and never to that of login.
+func adminFlags(env *Env, flags *flag.FlagSet) *server {
+	return tokenFlags(env, flags, "WHOSTODO_ADMIN_TOKEN", "admin token", false)
+}
+
+func tokenFlags(env *Env, flags *flag.FlagSet, tokenEnv string, tokenUsage string, cached bool) *server {
+	s := &server{env: env, tokenEnv: tokenEnv, tokenUsage: tokenUsage, cached: cached}
+	flags.StringVar(&s.url, "url", env.Getenv("WHOSTODO_URL"), "server URL; defaults to WHOSTODO_URL, then the one logged in to, then "+DefaultURL)
+	flags.StringVar(&s.token, "token", env.Getenv(tokenEnv), tokenUsage+"; defaults to "+tokenEnv)
+	return s
+}
+
+// resolve fills in the URL, and the token if it may, from the config of
+// login when flags and environment left them unset.
+func (s *server) resolve() error {
+	if s.resolved {
+		return nil
+	}
+	s.resolved = true
+
+	if s.url == "" || (s.cached && s.token == "") {
+		c, err := loadConfig(s.env)
+		if err != nil {
+			return err
+		}
+		if s.url == "" {
+			s.url = c.URL
+		}
+		if s.cached && s.token == "" && c.Token != "" && sameURL(c.URL, s.url) {
+			s.token = c.Token
+			s.login = c
+		}
+	}
+	if s.url == "" {
+		s.url = DefaultURL
+	}
+	return nil
+}
+
+// authenticated resolves the server, failing without a token.
+func (s *server) authenticated() error {
+	if err := s.resolve(); err != nil {
+		return err
+	}
+	if s.token == "" && s.cached {
+		return fmt.Errorf("%w: no %s; run login, or set -token or %s", ErrorUsage, s.tokenUsage, s.tokenEnv)
+	}
+	if s.token == "" {
+		return fmt.Errorf("%w: no %s; set -token or %s", ErrorUsage, s.tokenUsage, s.tokenEnv)
+	}
+	return nil
+}
+
+// client returns a client of the server authenticated by the token, failing
+// without one. Clients of the token cached by login log in anew should the
+// server reject it, as it does once its session expires.
+func (s *server) client() (*client.Client, error) {
+	if err := s.authenticated(); err != nil {
+		return nil, err
+	}
+	opts := []client.Option{client.WithHTTPClient(s.env.Client)}
+	if s.login != nil {
+		opts = append(opts, client.WithRenewal(s.relogin))
+	}
+	return client.InitClient(s.url, s.token, opts...), nil
+}
+
+// describe tells what failed, telling a rejected token from a request the
+// session may not make.
+func describe(err error) string {
+	var status *client.StatusError
+	if !errors.As(err, &status) {
+		return err.Error()
+	}
+	text := fmt.Sprintf("%d %s", status.StatusCode, http.StatusText(status.StatusCode))
+	switch {
+	case status.Message != "":
+		return status.Message
+	case status.Rejected:
+		return text + ": session is missing or expired; run login"
+	case status.StatusCode == http.StatusForbidden:
+		return text + ": access denied"
+	}
+	return text
+}
+
+// failed prefixes the description of the error with what failed, unless it
+// is about usage, which is reported as is.
+func failed(err error, format string, args ...any) error {
+	if errors.Is(err, ErrorUsage) {
+		return err
+	}
+	return fmt.Errorf(format+": %s", append(args, describe(err))...)
+}
+
+// newFlagSet returns a flag set reporting errors instead of exiting, with
+// usage written to stderr.
+func newFlagSet(env *Env, name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	return flags
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dannyh79/whostodo/client"
+	sessionentity "github.com/dannyh79/whostodo/internal/sessions/entities"
+)
+
+// ErrorLoginAgain is returned once the session of a named user expires and
+// there is no password to log in again with.
+var ErrorLoginAgain = errors.New("session expired; run login again, or set WHOSTODO_PASSWORD")
+
+// config is what login keeps between runs: the server logged in to, the
+// session token, and whom to log in as again once the session expires. The
+// password is never kept.
+type config struct {
+	URL       string `json:"url"`
+	Token     string `json:"token"`
+	User      string `json:"user"`
+	Workspace string `json:"workspace"`
+}
+
+// configPath is WHOSTODO_CONFIG, defaulting to whostodo/config.json under
+// the user's config directory.
+func configPath(env *Env) (string, error) {
+	if path := env.Getenv("WHOSTODO_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "whostodo", "config.json"), nil
+}
+
+// loadConfig reads the config, which is empty before the first login.
+func loadConfig(env *Env) (*config, error) {
+	path, err := configPath(env)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var c config
+	if err := json.Unmarshal(content, &c); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// save writes the config readable by the user only, as it holds the token.
+func (c *config) save(env *Env) error {
+	path, err := configPath(env)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".config-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(append(content, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// loginCommand starts a session and caches its token for other commands.
+// Named users give their password by WHOSTODO_PASSWORD, or else on stdin:
+//
+//	whostodo login [-user alice] [-workspace team-a]
+func loginCommand(env *Env, args []string) error {
+	flags := newFlagSet(env, "login")
+	url := flags.String("url", env.Getenv("WHOSTODO_URL"), "server URL; defaults to WHOSTODO_URL, then the one logged in to, then "+DefaultURL)
+	user := flags.String("user", "", "user to log in as; defaults to "+sessionentity.AnonymousUser)
+	workspace := flags.String("workspace", "", "workspace to log in to; defaults to "+sessionentity.DefaultWorkspace)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: login [-user name] [-workspace id]", ErrorUsage)
+	}
+
+	c, err := loadConfig(env)
+	if err != nil {
+		return err
+	}
+	if *url == "" {
+		*url = c.URL
+	}
+	if *url == "" {
+		*url = DefaultURL
+	}
+
+	var password string
+	if *user != "" && *user != sessionentity.AnonymousUser {
+		if password, err = readPassword(env); err != nil {
+			return err
+		}
+	}
+
+	token, err := login(context.Background(), env, *url, *user, password, *workspace)
+	if err != nil {
+		return err
+	}
+	c = &config{URL: *url, Token: token, User: *user, Workspace: *workspace}
+	if err := c.save(env); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.Stdout, "logged in as %s in %s\n", orDefault(*user, sessionentity.AnonymousUser), orDefault(*workspace, sessionentity.DefaultWorkspace))
+	return nil
+}
+
+// relogin starts a new session as the user and in the workspace of the
+// login, the cached one having expired, and caches its token instead. Named
+// users give their password again by WHOSTODO_PASSWORD, or else when
+// prompted on a terminal; without either, it fails with ErrorLoginAgain.
+func (s *server) relogin(ctx context.Context) (string, error) {
+	var password string
+	if user := s.login.User; user != "" && user != sessionentity.AnonymousUser {
+		password = s.env.Getenv("WHOSTODO_PASSWORD")
+		if password == "" {
+			if s.noPrompt || !isTerminal(s.env.Stdin) {
+				return "", ErrorLoginAgain
+			}
+			fmt.Fprintf(s.env.Stderr, "session expired; logging in again as %s\n", user)
+			var err error
+			if password, err = readPassword(s.env); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	token, err := login(ctx, s.env, s.url, s.login.User, password, s.login.Workspace)
+	if err != nil {
+		return "", fmt.Errorf("logging in again: %w", err)
+	}
+	s.token = token
+	s.login.Token = token
+	return token, s.login.save(s.env)
+}
+
+// readPassword returns WHOSTODO_PASSWORD, or else the first line of stdin,
+// prompting for it on stderr.
+func readPassword(env *Env) (string, error) {
+	if password := env.Getenv("WHOSTODO_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	fmt.Fprint(env.Stderr, "password: ")
+	line, err := bufio.NewReader(env.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// isTerminal reports whether the reader is a terminal, which may be prompted
+// without taking input meant for the command.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// login starts a session, returning its token.
+func login(ctx context.Context, env *Env, url string, user string, password string, workspace string) (string, error) {
+	c := client.InitClient(url, "", client.WithHTTPClient(env.Client))
+	err := c.Login(ctx, user, password, workspace)
+	switch {
+	case errors.Is(err, client.ErrorUnauthorized):
+		return "", fmt.Errorf("login failed: wrong user or password")
+	case errors.Is(err, client.ErrorUnprocessable):
+		return "", fmt.Errorf("login failed: workspace %q is not allowed", workspace)
+	case err != nil:
+		return "", fmt.Errorf("login failed: %s", describe(err))
+	}
+	return c.Token(), nil
+}
+
+func sameURL(a string, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
+
+func orDefault(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dannyh79/whostodo/client"
+)
+
+// The task commands take their flags before any other argument, as the flag
+// package stops at the first one that is not a flag.
+
+// lsCommand lists the tasks of the session:
+//
+//	whostodo ls [-list 1] [-tag errand]... [-sort priority] [-json]
+func lsCommand(env *Env, args []string) error {
+	flags := newFlagSet(env, "ls")
+	s := serverFlags(env, flags)
+	asJSON := jsonFlag(flags)
+	list := flags.String("list", "", "only list tasks of the list id; 0 lists the inbox")
+	var tags stringsFlag
+	flags.Var(&tags, "tag", "only list tasks tagged so; repeatable")
+	sort := flags.String("sort", "", "priority sorts by priority instead of the manual order")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: ls [-list id] [-tag tag]... [-sort priority] [-json]", ErrorUsage)
+	}
+
+	o := &client.ListTasksOptions{Tags: tags}
+	if *list != "" {
+		id, err := strconv.Atoi(*list)
+		if err != nil {
+			return fmt.Errorf("%w: list id %q is not a number", ErrorUsage, *list)
+		}
+		o.ListId = &id
+	}
+	switch *sort {
+	case "":
+	case "priority":
+		o.SortByPriority = true
+	default:
+		return fmt.Errorf("%w: sort %q is not priority", ErrorUsage, *sort)
+	}
+
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+	listed, err := c.ListTasks(context.Background(), o)
+	if err != nil {
+		return failed(err, "ls failed")
+	}
+	return printTasks(env, *asJSON, listed, listed)
+}
+
+// addCommand creates a task named by the arguments:
+//
+//	whostodo add [-priority high] [-list 1] [-due 2024-01-05] [-tag errand]... 買晚餐
+func addCommand(env *Env, args []string) error {
+	flags := newFlagSet(env, "add")
+	s := serverFlags(env, flags)
+	asJSON := jsonFlag(flags)
+	description := flags.String("description", "", "description, in markdown")
+	priority := flags.String("priority", "", "urgent, high, medium or low")
+	list := flags.Int("list", 0, "list id; defaults to the inbox")
+	parent := flags.Int("parent", 0, "id of the parent task")
+	due := flags.String("due", "", "due date, as 2006-01-02 or RFC 3339")
+	var tags stringsFlag
+	flags.Var(&tags, "tag", "tag; repeatable")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("%w: add [flags] name", ErrorUsage)
+	}
+
+	i := &client.CreateTaskInput{
+		Name:        strings.Join(flags.Args(), " "),
+		Description: *description,
+		Priority:    *priority,
+		ListId:      *list,
+		ParentId:    *parent,
+		Tags:        tags,
+	}
+	if *due != "" {
+		at, err := parseDue(*due)
+		if err != nil {
+			return err
+		}
+		i.DueAt = &at
+	}
+
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+	created, err := c.CreateTask(context.Background(), i)
+	if err != nil {
+		return failed(err, "add failed")
+	}
+	return printTasks(env, *asJSON, created, []client.Task{*created})
+}
+
+// doneCommand marks the tasks done, or open again:
+//
+//	whostodo done [-undo] [-json] 1 2
+func doneCommand(env *Env, args []string) error {
+	flags := newFlagSet(env, "done")
+	s := serverFlags(env, flags)
+	asJSON := jsonFlag(flags)
+	undo := flags.Bool("undo", false, "mark the tasks open again")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	ids, err := taskIds(flags.Args())
+	if err != nil || len(ids) == 0 {
+		return fmt.Errorf("%w: done [-undo] [-json] id...", ErrorUsage)
+	}
+
+	status := client.StatusDone
+	if *undo {
+		status = client.StatusOpen
+	}
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+	var updated []client.Task
+	for _, id := range ids {
+		task, err := updateTask(c, id, func(i *client.UpdateTaskInput) { i.Status = status })
+		if err != nil {
+			return failed(err, "done failed: task %d", id)
+		}
+		updated = append(updated, *task)
+	}
+	return printTasks(env, *asJSON, updated, updated)
+}
+
+// editCommand changes the fields given by flags, leaving others as they are:
+//
+//	whostodo edit [-name 買午餐] [-priority low] [-due 2024-01-05] 1
+func editCommand(env *Env, args []string) error {
+	flags := newFlagSet(env, "edit")
+	s := serverFlags(env, flags)
+	asJSON := jsonFlag(flags)
+	flags.String("name", "", "name")
+	flags.String("description", "", "description, in markdown")
+	flags.String("priority", "", "urgent, high, medium, low, or empty for none")
+	flags.Int("status", 0, "status; 1 means done")
+	flags.Int("list", 0, "list id; 0 moves the task into the inbox")
+	flags.Int("parent", 0, "id of the parent task; 0 makes it a root task")
+	flags.String("due", "", "due date, as 2006-01-02 or RFC 3339, or empty for none")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	ids, err := taskIds(flags.Args())
+	if err != nil || len(ids) != 1 {
+		return fmt.Errorf("%w: edit [flags] id", ErrorUsage)
+	}
+
+	var changes []func(*client.UpdateTaskInput)
+	var visitErr error
+	flags.Visit(func(f *flag.Flag) {
+		switch value := f.Value.(flag.Getter).Get().(type) {
+		case string:
+			switch f.Name {
+			case "name":
+				changes = append(changes, func(i *client.UpdateTaskInput) { i.Name = value })
+			case "description":
+				changes = append(changes, func(i *client.UpdateTaskInput) { i.Description = &value })
+			case "priority":
+				changes = append(changes, func(i *client.UpdateTaskInput) { i.Priority = &value })
+			case "due":
+				if value == "" {
+					changes = append(changes, func(i *client.UpdateTaskInput) { i.ClearDueAt = true })
+					return
+				}
+				at, err := parseDue(value)
+				if err != nil {
+					visitErr = err
+				}
+				changes = append(changes, func(i *client.UpdateTaskInput) { i.DueAt = &at })
+			}
+		case int:
+			switch f.Name {
+			case "status":
+				changes = append(changes, func(i *client.UpdateTaskInput) { i.Status = value })
+			case "list":
+				changes = append(changes, func(i *client.UpdateTaskInput) { i.ListId = &value })
+			case "parent":
+				changes = append(changes, func(i *client.UpdateTaskInput) { i.ParentId = &value })
+			}
+		}
+	})
+	if visitErr != nil {
+		return visitErr
+	}
+
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+	updated, err := updateTask(c, ids[0], changes...)
+	if err != nil {
+		return failed(err, "edit failed")
+	}
+	return printTasks(env, *asJSON, updated, []client.Task{*updated})
+}
+
+// rmCommand moves the tasks into the trash:
+//
+//	whostodo rm 1 2
+func rmCommand(env *Env, args []string) error {
+	flags := newFlagSet(env, "rm")
+	s := serverFlags(env, flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	ids, err := taskIds(flags.Args())
+	if err != nil || len(ids) == 0 {
+		return fmt.Errorf("%w: rm id...", ErrorUsage)
+	}
+
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := c.DeleteTask(context.Background(), id); err != nil {
+			return failed(err, "rm failed: task %d", id)
+		}
+		fmt.Fprintf(env.Stdout, "deleted: %d\n", id)
+	}
+	return nil
+}
+
+// updateTask applies the changes to the task. Updates replace the name and
+// status, so those are read from the task first.
+func updateTask(c *client.Client, id int, changes ...func(*client.UpdateTaskInput)) (*client.Task, error) {
+	ctx := context.Background()
+	current, err := c.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &client.UpdateTaskInput{Name: current.Name, Status: current.Status}
+	for _, change := range changes {
+		change(i)
+	}
+	return c.UpdateTask(ctx, id, i)
+}
+
+// printTasks writes the result as JSON, or the items as a table.
+func printTasks(env *Env, asJSON bool, result any, items []client.Task) error {
+	if asJSON {
+		encoder := json.NewEncoder(env.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tPRIORITY\tDUE\tTAGS\tNAME")
+	for _, t := range items {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", t.Id, formatStatus(t.Status), t.Priority, formatDue(t.DueAt), strings.Join(t.Tags, ","), t.Name)
+	}
+	return w.Flush()
+}
+
+func formatStatus(status int) string {
+	if status == client.StatusDone {
+		return "done"
+	}
+	return "open"
+}
+
+// formatDue writes dates at midnight UTC as dates only.
+func formatDue(at *time.Time) string {
+	if at == nil {
+		return ""
+	}
+	utc := at.UTC()
+	if utc.Equal(utc.Truncate(24 * time.Hour)) {
+		return utc.Format(time.DateOnly)
+	}
+	return utc.Format(time.RFC3339)
+}
+
+// parseDue reads a date as midnight UTC, or a time in RFC 3339.
+func parseDue(value string) (time.Time, error) {
+	if at, err := time.Parse(time.DateOnly, value); err == nil {
+		return at, nil
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: due date %q is neither 2006-01-02 nor RFC 3339", ErrorUsage, value)
+	}
+	return at, nil
+}
+
+func taskIds(args []string) ([]int, error) {
+	ids := make([]int, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func jsonFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool("json", false, "write the result as JSON instead of a table")
+}
+
+// stringsFlag collects every value of a repeated flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
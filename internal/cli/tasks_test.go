@@ -0,0 +1,236 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+// loggedIn returns the environment of commands run after logging in as alice
+// to a server of the suite, with nothing else set.
+func loggedIn(t *testing.T, suite *util.MockTestSuite) map[string]string {
+	t.Helper()
+	server := httptest.NewServer(suite.Engine)
+	t.Cleanup(server.Close)
+	suite.AccountRepo.PopulateData(util.NewAccount("alice", "密碼"))
+	vars := map[string]string{"WHOSTODO_CONFIG": filepath.Join(t.TempDir(), "whostodo", "config.json")}
+
+	got := runWithEnv(t, vars, "密碼\n", "login", "-url", server.URL, "-user", "alice")
+	util.AssertEqual(t)(got, result{Stdout: "logged in as alice in default\n", Stderr: "password: "})
+	return vars
+}
+
+func readConfig(t *testing.T, vars map[string]string) map[string]string {
+	t.Helper()
+	content, err := os.ReadFile(vars["WHOSTODO_CONFIG"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c map[string]string
+	json.Unmarshal(content, &c)
+	return c
+}
+
+func Test_Login(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	vars := loggedIn(t, suite)
+
+	c := readConfig(t, vars)
+	util.AssertEqual(t)(c["user"], "alice")
+	_, saved := c["password"]
+	util.AssertEqual(t)(saved, false)
+	util.AssertEqual(t)(suite.SessionRepo.Data[c["token"]].User, "alice")
+	info, _ := os.Stat(vars["WHOSTODO_CONFIG"])
+	util.AssertEqual(t)(info.Mode().Perm(), os.FileMode(0o600))
+}
+
+func Test_LoginRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		vars     map[string]string
+		args     []string
+		expected result
+	}{
+		{
+			name:     "reports a workspace not allowed",
+			vars:     map[string]string{},
+			args:     []string{"-workspace", "不存在"},
+			expected: result{Code: 1, Stderr: "login failed: workspace \"不存在\" is not allowed\n"},
+		},
+		{
+			name:     "reports a wrong password",
+			vars:     map[string]string{"WHOSTODO_PASSWORD": "錯的"},
+			args:     []string{"-user", "alice"},
+			expected: result{Code: 1, Stderr: "login failed: wrong user or password\n"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.AccountRepo.PopulateData(util.NewAccount("alice", "密碼"))
+			server := httptest.NewServer(suite.Engine)
+			t.Cleanup(server.Close)
+
+			got := runWithEnv(t, tc.vars, "", append([]string{"login", "-url", server.URL}, tc.args...)...)
+
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+}
+
+func Test_LoggedInCommands(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	vars := loggedIn(t, suite)
+
+	added := runWithEnv(t, vars, "", "add", "-priority", "high", "-due", "2024-01-05", "-tag", "errand", "買晚餐")
+	util.AssertEqual(t)(added, result{Stdout: "ID  STATUS  PRIORITY  DUE         TAGS    NAME\n1   open    high      2024-01-05  errand  買晚餐\n"})
+
+	done := runWithEnv(t, vars, "", "done", "1")
+	util.AssertEqual(t)(done, result{Stdout: "ID  STATUS  PRIORITY  DUE         TAGS    NAME\n1   done    high      2024-01-05  errand  買晚餐\n"})
+
+	edited := runWithEnv(t, vars, "", "edit", "-name", "買午餐", "-priority", "", "1")
+	util.AssertEqual(t)(edited, result{Stdout: "ID  STATUS  PRIORITY  DUE         TAGS    NAME\n1   done              2024-01-05  errand  買午餐\n"})
+
+	undue := runWithEnv(t, vars, "", "edit", "-due", "", "1")
+	util.AssertEqual(t)(undue, result{Stdout: "ID  STATUS  PRIORITY  DUE  TAGS    NAME\n1   done                   errand  買午餐\n"})
+
+	listed := runWithEnv(t, vars, "", "ls", "-json")
+	util.AssertEqual(t)(listed, result{Stdout: `[
+  {
+    "id": 1,
+    "name": "買午餐",
+    "status": 1,
+    "tags": [
+      "errand"
+    ],
+    "creator": "alice"
+  }
+]
+`})
+
+	removed := runWithEnv(t, vars, "", "rm", "1")
+	util.AssertEqual(t)(removed, result{Stdout: "deleted: 1\n"})
+	util.AssertEqual(t)(suite.TaskRepo.Data[1].DeletedAt.IsZero(), false)
+}
+
+func Test_LoginAgainOnExpiredSession(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Creator: "alice", DueAt: time.Date(2024, 1, 5, 9, 30, 0, 0, time.UTC)})
+	vars := loggedIn(t, suite)
+	expired := readConfig(t, vars)["token"]
+	delete(suite.SessionRepo.Data, expired)
+	vars["WHOSTODO_PASSWORD"] = "密碼"
+
+	got := runWithEnv(t, vars, "", "ls")
+
+	util.AssertEqual(t)(got, result{Stdout: "ID  STATUS  PRIORITY  DUE                   TAGS  NAME\n1   open              2024-01-05T09:30:00Z        買晚餐\n"})
+	renewed := readConfig(t, vars)["token"]
+	util.AssertEqual(t)(renewed != expired, true)
+	util.AssertEqual(t)(suite.SessionRepo.Data[renewed].User, "alice")
+}
+
+func Test_LoginAgainWithoutPassword(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	vars := loggedIn(t, suite)
+	expired := readConfig(t, vars)["token"]
+	delete(suite.SessionRepo.Data, expired)
+
+	got := runWithEnv(t, vars, "", "ls")
+
+	util.AssertEqual(t)(got, result{Code: 1, Stderr: "ls failed: renewing token: session expired; run login again, or set WHOSTODO_PASSWORD\n"})
+	util.AssertEqual(t)(readConfig(t, vars)["token"], expired)
+}
+
+func Test_AccessDenied(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "bob"})
+	suite.MemberRepo.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "alice", Role: "viewer", Accepted: true})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", ListId: 1, Creator: "bob"})
+	vars := loggedIn(t, suite)
+	token := readConfig(t, vars)["token"]
+
+	got := runWithEnv(t, vars, "", "done", "1")
+
+	util.AssertEqual(t)(got, result{Code: 1, Stderr: "done failed: task 1: 403 Forbidden: access denied\n"})
+	util.AssertEqual(t)(readConfig(t, vars)["token"], token)
+}
+
+func Test_ExpiredToken(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	server := httptest.NewServer(suite.Engine)
+	t.Cleanup(server.Close)
+	expired := util.NewExpiredSession()
+	suite.SessionRepo.PopulateData(expired)
+
+	got := runWithEnv(t, map[string]string{"WHOSTODO_URL": server.URL, "WHOSTODO_TOKEN": expired.Id}, "", "ls")
+
+	util.AssertEqual(t)(got, result{Code: 1, Stderr: "ls failed: 403 Forbidden: session is missing or expired; run login\n"})
+}
+
+func Test_TaskCommandErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected result
+	}{
+		{
+			name:     "reports usage without a name",
+			args:     []string{"add"},
+			expected: result{Code: 2, Stderr: "Invalid usage: add [flags] name\n"},
+		},
+		{
+			name:     "reports usage on a malformed id",
+			args:     []string{"done", "一"},
+			expected: result{Code: 2, Stderr: "Invalid usage: done [-undo] [-json] id...\n"},
+		},
+		{
+			name:     "reports usage on a malformed due date",
+			args:     []string{"edit", "-due", "明天", "1"},
+			expected: result{Code: 2, Stderr: "Invalid usage: due date \"明天\" is neither 2006-01-02 nor RFC 3339\n"},
+		},
+		{
+			name:     "reports an unknown task",
+			args:     []string{"rm", "2"},
+			expected: result{Code: 1, Stderr: "rm failed: task 2: 404 Not Found\n"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := run(t, util.NewTestSuite(), "", tc.args...)
+
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+}
+
+func Test_NotLoggedIn(t *testing.T) {
+	t.Parallel()
+
+	got := runWithEnv(t, map[string]string{}, "", "ls")
+
+	util.AssertEqual(t)(got, result{Code: 2, Stderr: "Invalid usage: no session token; run login, or set -token or WHOSTODO_TOKEN\n"})
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/dannyh79/whostodo/client"
+)
+
+// todoTxtCommand exports tasks to stdout in todo.txt format, or imports them
+// from a file or stdin:
+//
+//	whostodo todotxt export > todo.txt
+//	whostodo todotxt import [-dry-run] [todo.txt]
+func todoTxtCommand(env *Env, args []string) error {
+	switch firstArg(args) {
+	case "export":
+		return todoTxtExport(env, args[1:])
+	case "import":
+		return todoTxtImport(env, args[1:])
+	default:
+		return fmt.Errorf("%w: todotxt export|import", ErrorUsage)
+	}
+}
+
+func todoTxtExport(env *Env, args []string) error {
+	flags := newFlagSet(env, "todotxt export")
+	s := serverFlags(env, flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+	if err := c.ExportTasks(context.Background(), client.FormatTodoTxt, env.Stdout); err != nil {
+		return failed(err, "export failed")
+	}
+	return nil
+}
+
+func todoTxtImport(env *Env, args []string) error {
+	flags := newFlagSet(env, "todotxt import")
+	s := serverFlags(env, flags)
+	dryRun := flags.Bool("dry-run", false, "validate lines without importing any")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("%w: todotxt import [-dry-run] [file]", ErrorUsage)
+	}
+
+	in := env.Stdin
+	if name := flags.Arg(0); name != "" && name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+	result, err := c.ImportTasks(context.Background(), in, &client.ImportTasksOptions{Format: client.FormatTodoTxt, DryRun: *dryRun})
+	if err != nil {
+		return failed(err, "import failed")
+	}
+	for _, e := range result.Errors {
+		fmt.Fprintf(env.Stderr, "line %d: %s: %s\n", e.Row, e.Column, e.Error)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("nothing imported: %d invalid lines", len(result.Errors))
+	}
+
+	verb := "imported"
+	if result.DryRun {
+		verb = "would import"
+	}
+	fmt.Fprintf(env.Stdout, "%s: %d created, %d updated\n", verb, result.Created, result.Updated)
+	return nil
+}
@@ -0,0 +1,137 @@
+package cli_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/cli"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+type result struct {
+	Code   int
+	Stdout string
+	Stderr string
+}
+
+// run runs the command against a server of the suite, as a session of alice,
+// or as the admin for admin commands.
+func run(t *testing.T, suite *util.MockTestSuite, stdin string, args ...string) result {
+	t.Helper()
+	server := httptest.NewServer(suite.Engine)
+	t.Cleanup(server.Close)
+	session := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(session)
+
+	return runWithEnv(t, map[string]string{
+		"WHOSTODO_URL":         server.URL,
+		"WHOSTODO_TOKEN":       session.Id,
+		"WHOSTODO_ADMIN_TOKEN": util.AdminToken,
+	}, stdin, args...)
+}
+
+// runWithEnv runs the command with the environment variables, keeping its
+// config in a temporary directory unless WHOSTODO_CONFIG is among them.
+func runWithEnv(t *testing.T, vars map[string]string, stdin string, args ...string) result {
+	t.Helper()
+	if _, ok := vars["WHOSTODO_CONFIG"]; !ok {
+		vars["WHOSTODO_CONFIG"] = filepath.Join(t.TempDir(), "config.json")
+	}
+
+	var stdout, stderr bytes.Buffer
+	env := &cli.Env{
+		Stdin:  strings.NewReader(stdin),
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Getenv: func(key string) string {
+			return vars[key]
+		},
+	}
+	code := cli.Run(env, args)
+	return result{Code: code, Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+func Test_TodoTxtExport(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Priority: 3, Tags: []string{"errand"}, Creator: "alice"})
+
+	got := run(t, suite, "", "todotxt", "export")
+
+	util.AssertEqual(t)(got, result{Stdout: "(B) 買晚餐 @errand id:1\n"})
+}
+
+func Test_TodoTxtImport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "todo.txt")
+	if err := os.WriteFile(file, []byte("洗碗 +家事\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		stdin    string
+		args     []string
+		expected result
+		tasks    int
+	}{
+		{
+			name:     "imports lines from stdin",
+			stdin:    "x 買晚餐 id:1\n洗碗 @home\n",
+			args:     []string{"todotxt", "import"},
+			expected: result{Stdout: "imported: 1 created, 1 updated\n"},
+			tasks:    2,
+		},
+		{
+			name:     "imports lines from a file",
+			args:     []string{"todotxt", "import", file},
+			expected: result{Stdout: "imported: 1 created, 0 updated\n"},
+			tasks:    2,
+		},
+		{
+			name:     "validates lines on dry run",
+			stdin:    "洗碗\n",
+			args:     []string{"todotxt", "import", "-dry-run"},
+			expected: result{Stdout: "would import: 1 created, 0 updated\n"},
+			tasks:    1,
+		},
+		{
+			name:     "reports invalid lines",
+			stdin:    "洗碗\n拖地 due:tomorrow\n",
+			args:     []string{"todotxt", "import"},
+			expected: result{Code: 1, Stderr: "line 2: due: Not a date\nnothing imported: 1 invalid lines\n"},
+			tasks:    1,
+		},
+		{
+			name:     "reports usage on unknown subcommand",
+			args:     []string{"todotxt", "sync"},
+			expected: result{Code: 2, Stderr: "Invalid usage: todotxt export|import\n"},
+			tasks:    1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Creator: "alice"})
+
+			got := run(t, suite, tc.stdin, tc.args...)
+
+			util.AssertEqual(t)(got, tc.expected)
+			util.AssertEqual(t)(len(suite.TaskRepo.Data), tc.tasks)
+		})
+	}
+}
+
+func Test_IsCommand(t *testing.T) {
+	util.AssertEqual(t)(cli.IsCommand([]string{"todotxt", "export"}), true)
+	util.AssertEqual(t)(cli.IsCommand([]string{}), false)
+	util.AssertEqual(t)(cli.IsCommand([]string{"serve"}), false)
+}
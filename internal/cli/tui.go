@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/dannyh79/whostodo/internal/tui"
+)
+
+// tuiCommand shows the tasks in a full-screen UI on the terminal:
+//
+//	whostodo tui
+func tuiCommand(env *Env, args []string) error {
+	flags := newFlagSet(env, "tui")
+	s := serverFlags(env, flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: tui", ErrorUsage)
+	}
+	s.noPrompt = true
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+
+	in, inOk := env.Stdin.(*os.File)
+	out, outOk := env.Stdout.(*os.File)
+	if !inOk || !outOk {
+		return tui.ErrorNotTerminal
+	}
+	term, restore, err := tui.OpenTerminal(in, out)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	return tui.InitApp(c).Run(context.Background(), term)
+}
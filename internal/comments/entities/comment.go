@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+type Comment struct {
+	Id     int
+	TaskId int
+	// User who wrote the comment.
+	Author    string
+	Body      string
+	CreatedAt time.Time
+	// Zero until the comment is edited.
+	EditedAt time.Time
+}
+
+func NewComment(id int, taskId int, author string, body string) *Comment {
+	return &Comment{
+		Id:        id,
+		TaskId:    taskId,
+		Author:    author,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (c *Comment) IsEdited() bool {
+	return !c.EditedAt.IsZero()
+}
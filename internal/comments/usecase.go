@@ -0,0 +1,179 @@
+package comments
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/access"
+	entity "github.com/dannyh79/whostodo/internal/comments/entities"
+	"github.com/dannyh79/whostodo/internal/repository"
+	taskentity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+// Comments longer than this many bytes are rejected.
+const MaxCommentSize = 4 * 1024
+
+var (
+	ErrorTaskNotFound    = errors.New("Task not found")
+	ErrorCommentNotFound = errors.New("Comment not found")
+	ErrorNotAuthor       = errors.New("Only the author can change the comment")
+	ErrorInvalidComment  = errors.New("Comment is empty or too long")
+	ErrorForbidden       = errors.New("Not allowed to comment on tasks of the list")
+)
+
+type CommentOutput struct {
+	Id        int        `json:"id"`
+	TaskId    int        `json:"task_id"`
+	Author    string     `json:"author"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+}
+
+type CommentInput struct {
+	Body string `json:"body"`
+}
+
+type CommentRepository interface {
+	repository.Repository[entity.Comment]
+	repository.TaskOwned[entity.Comment]
+}
+
+type TaskRepository repository.Repository[taskentity.Task]
+
+// CommentsUsecase manages comments of tasks outside the trash. Comments of
+// trashed tasks are kept, hidden, until the task is restored or purged.
+type CommentsUsecase struct {
+	repo   CommentRepository
+	tasks  TaskRepository
+	access access.Policy
+}
+
+type Option func(*CommentsUsecase)
+
+// WithAccessPolicy lets users see comments of the tasks they may see, and
+// comment on the tasks they may change.
+func WithAccessPolicy(policy access.Policy) Option {
+	return func(u *CommentsUsecase) {
+		u.access = policy
+	}
+}
+
+func (u *CommentsUsecase) ListComments(user string, taskId int) ([]*CommentOutput, error) {
+	if err := u.checkTask(user, taskId, false); err != nil {
+		return nil, err
+	}
+
+	var output = make([]*CommentOutput, 0)
+	for _, comment := range u.repo.ListByTask(taskId) {
+		output = append(output, toCommentOutput(comment))
+	}
+
+	return output, nil
+}
+
+func (u *CommentsUsecase) CreateComment(user string, taskId int, i *CommentInput) (*CommentOutput, error) {
+	if err := u.checkTask(user, taskId, true); err != nil {
+		return nil, err
+	}
+	if err := checkBody(i.Body); err != nil {
+		return nil, err
+	}
+
+	comment := u.repo.Save(entity.NewComment(0, taskId, user, i.Body))
+	return toCommentOutput(&comment), nil
+}
+
+// UpdateComment edits the body of a comment; only its author may.
+func (u *CommentsUsecase) UpdateComment(user string, taskId int, id int, i *CommentInput) (*CommentOutput, error) {
+	comment, err := u.find(user, taskId, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBody(i.Body); err != nil {
+		return nil, err
+	}
+
+	comment.Body = i.Body
+	comment.EditedAt = time.Now()
+	updated, err := u.repo.Update(comment)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCommentOutput(updated), nil
+}
+
+// DeleteComment removes a comment for good; only its author may.
+func (u *CommentsUsecase) DeleteComment(user string, taskId int, id int) error {
+	comment, err := u.find(user, taskId, id)
+	if err != nil {
+		return err
+	}
+
+	return u.repo.Delete(comment)
+}
+
+// find returns a comment of the task written by the user.
+func (u *CommentsUsecase) find(user string, taskId int, id int) (*entity.Comment, error) {
+	if err := u.checkTask(user, taskId, true); err != nil {
+		return nil, err
+	}
+
+	comment, err := u.repo.FindBy(id)
+	if err != nil || comment.TaskId != taskId {
+		return nil, ErrorCommentNotFound
+	}
+	if comment.Author != user {
+		return nil, ErrorNotAuthor
+	}
+
+	return comment, nil
+}
+
+// checkTask reports whether the task exists and the user may see it, or
+// change it when write is set, by the errors of this package.
+func (u *CommentsUsecase) checkTask(user string, id int, write bool) error {
+	_, err := access.FindTask(u.tasks, u.access, user, id, write)
+	switch {
+	case errors.Is(err, access.ErrorForbidden):
+		return ErrorForbidden
+	case err != nil:
+		return ErrorTaskNotFound
+	}
+	return nil
+}
+
+func checkBody(body string) error {
+	if strings.TrimSpace(body) == "" || len(body) > MaxCommentSize {
+		return ErrorInvalidComment
+	}
+	return nil
+}
+
+func InitCommentsUsecase(repo CommentRepository, tasks TaskRepository, opts ...Option) *CommentsUsecase {
+	u := &CommentsUsecase{
+		repo:  repo,
+		tasks: tasks,
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+func toCommentOutput(c *entity.Comment) *CommentOutput {
+	output := &CommentOutput{
+		Id:        c.Id,
+		TaskId:    c.TaskId,
+		Author:    c.Author,
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt,
+	}
+	if c.IsEdited() {
+		editedAt := c.EditedAt
+		output.EditedAt = &editedAt
+	}
+	return output
+}
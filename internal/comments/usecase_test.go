@@ -0,0 +1,167 @@
+package comments_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/comments"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+var commentedAt = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+func newRepos() (*util.MockCommentRepository, *util.MockTaskRepository) {
+	tasks := util.InitMockTaskRepository()
+	tasks.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐"})
+	tasks.PopulateData(repository.TaskSchema{Id: 2, Name: "買早餐", DeletedAt: commentedAt})
+	repo := util.InitMockCommentRepository()
+	repo.PopulateData(repository.CommentSchema{Id: 1, TaskId: 1, Author: "alice", Body: "要買幾份？", CreatedAt: commentedAt})
+	repo.PopulateData(repository.CommentSchema{Id: 2, TaskId: 2, Author: "alice", Body: "買了", CreatedAt: commentedAt})
+	return repo, tasks
+}
+
+func Test_ListComments(t *testing.T) {
+	t.Run("returns comments of the task", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := comments.InitCommentsUsecase(newRepos())
+		got, err := usecase.ListComments("alice", 1)
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(got, []*comments.CommentOutput{
+			{Id: 1, TaskId: 1, Author: "alice", Body: "要買幾份？", CreatedAt: commentedAt},
+		})
+	})
+
+	t.Run("returns error for trashed task", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := comments.InitCommentsUsecase(newRepos())
+		_, err := usecase.ListComments("alice", 2)
+
+		util.AssertErrorEqual(t)(err, comments.ErrorTaskNotFound)
+	})
+}
+
+func Test_CreateComment(t *testing.T) {
+	tests := []struct {
+		name        string
+		param       int
+		payload     comments.CommentInput
+		expectError bool
+		error       error
+	}{
+		{
+			name:    "returns created comment",
+			param:   1,
+			payload: comments.CommentInput{Body: "兩份"},
+		},
+		{
+			name:        "returns error on empty body",
+			param:       1,
+			payload:     comments.CommentInput{Body: "  "},
+			expectError: true,
+			error:       comments.ErrorInvalidComment,
+		},
+		{
+			name:        "returns error when task not found",
+			param:       9,
+			payload:     comments.CommentInput{Body: "兩份"},
+			expectError: true,
+			error:       comments.ErrorTaskNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase := comments.InitCommentsUsecase(newRepos())
+			got, err := usecase.CreateComment("bob", tc.param, &tc.payload)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				util.AssertErrorEqual(t)(err, nil)
+				util.AssertEqual(t)(got.Author, "bob")
+				util.AssertEqual(t)(got.Body, tc.payload.Body)
+				util.AssertEqual(t)(got.EditedAt, (*time.Time)(nil))
+			}
+		})
+	}
+}
+
+func Test_UpdateComment(t *testing.T) {
+	tests := []struct {
+		name        string
+		user        string
+		taskId      int
+		param       int
+		expectError bool
+		error       error
+	}{
+		{
+			name:   "returns edited comment",
+			user:   "alice",
+			taskId: 1,
+			param:  1,
+		},
+		{
+			name:        "returns error for other users",
+			user:        "bob",
+			taskId:      1,
+			param:       1,
+			expectError: true,
+			error:       comments.ErrorNotAuthor,
+		},
+		{
+			name:        "returns error for comment of another task",
+			user:        "alice",
+			taskId:      1,
+			param:       2,
+			expectError: true,
+			error:       comments.ErrorCommentNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase := comments.InitCommentsUsecase(newRepos())
+			got, err := usecase.UpdateComment(tc.user, tc.taskId, tc.param, &comments.CommentInput{Body: "三份"})
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				util.AssertErrorEqual(t)(err, nil)
+				util.AssertEqual(t)(got.Body, "三份")
+				util.AssertEqual(t)(got.CreatedAt, commentedAt)
+				util.AssertEqual(t)(got.EditedAt != nil, true)
+			}
+		})
+	}
+}
+
+func Test_DeleteComment(t *testing.T) {
+	t.Run("deletes comment", func(t *testing.T) {
+		t.Parallel()
+
+		repo, tasks := newRepos()
+		usecase := comments.InitCommentsUsecase(repo, tasks)
+		err := usecase.DeleteComment("alice", 1, 1)
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(len(repo.ListByTask(1)), 0)
+	})
+
+	t.Run("returns error for other users", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := comments.InitCommentsUsecase(newRepos())
+		err := usecase.DeleteComment("bob", 1, 1)
+
+		util.AssertErrorEqual(t)(err, comments.ErrorNotAuthor)
+	})
+}
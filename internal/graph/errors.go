@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"errors"
+
+	"github.com/dannyh79/whostodo/internal/sessions"
+	"github.com/dannyh79/whostodo/internal/tasks"
+)
+
+// Codes in the extensions of errors, telling apart what REST tells by status.
+const (
+	// No valid session was sent along; authenticate and send its token. Also
+	// given for authenticating with a wrong password, as with 401.
+	CodeUnauthenticated = "UNAUTHENTICATED"
+	// The session may not change what it asked to, as with 403.
+	CodeForbidden = "FORBIDDEN"
+	// What was asked for does not exist or is not visible, as with 404.
+	CodeNotFound = "NOT_FOUND"
+	// The task is blocked by open tasks, as with 409.
+	CodeConflict = "CONFLICT"
+	// The input is invalid, as with 400 and 422.
+	CodeInvalidInput = "INVALID_INPUT"
+)
+
+var ErrorUnauthenticated = errors.New("Session is missing or expired")
+
+var errorUnauthenticated = codedError{ErrorUnauthenticated, CodeUnauthenticated}
+
+// ErrorListNotVisible fails subscriptions watching a list the viewer may no
+// longer see, such as once removed from its members.
+var ErrorListNotVisible = errors.New("List is not visible")
+
+var errorListNotVisible = codedError{ErrorListNotVisible, CodeForbidden}
+
+// codedError carries its code in the extensions of the error in responses.
+type codedError struct {
+	error
+	code string
+}
+
+func (e codedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+func (e codedError) Unwrap() error {
+	return e.error
+}
+
+// toError codes an error of the usecases. Errors not coded otherwise are
+// taken as not found, as the REST routes do, since repositories have errors
+// of their own for rows not found.
+func toError(err error) error {
+	switch {
+	case errors.Is(err, sessions.ErrorInvalidCredentials):
+		return codedError{err, CodeUnauthenticated}
+	case errors.Is(err, tasks.ErrorForbidden), errors.Is(err, sessions.ErrorNotMember):
+		return codedError{err, CodeForbidden}
+	case errors.Is(err, tasks.ErrorBlocked):
+		return codedError{err, CodeConflict}
+	case isInvalidInput(err):
+		return codedError{err, CodeInvalidInput}
+	default:
+		return codedError{err, CodeNotFound}
+	}
+}
+
+func isInvalidInput(err error) bool {
+	return errors.Is(err, sessions.ErrorInvalidWorkspace) ||
+		errors.Is(err, tasks.ErrorListNotFound) ||
+		errors.Is(err, tasks.ErrorParentNotFound) ||
+		errors.Is(err, tasks.ErrorCycle) ||
+		errors.Is(err, tasks.ErrorBlockerNotFound) ||
+		errors.Is(err, tasks.ErrorDependencyCycle) ||
+		errors.Is(err, tasks.ErrorInvalidRecurrence) ||
+		errors.Is(err, tasks.ErrorInvalidPriority) ||
+		errors.Is(err, tasks.ErrorDescriptionTooLong) ||
+		errors.Is(err, tasks.ErrorInvalidTag) ||
+		errors.Is(err, tasks.ErrorInvalidAssignee) ||
+		errors.Is(err, tasks.ErrorUnknownDeleteMode)
+}
@@ -0,0 +1,620 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/access"
+	"github.com/dannyh79/whostodo/internal/changes"
+	"github.com/dannyh79/whostodo/internal/lists"
+	"github.com/dannyh79/whostodo/internal/markdown"
+	"github.com/dannyh79/whostodo/internal/sessions"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/dannyh79/whostodo/internal/workspaces"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// resolver resolves the root fields of the schema, each for the viewer in
+// its context.
+type resolver struct {
+	sessions   *sessions.SessionsUsecase
+	workspaces *workspaces.WorkspacesUsecase
+}
+
+// viewer is whom a request is served for, in the workspace of their session.
+type viewer struct {
+	actor     tasks.Actor
+	workspace *workspaces.Workspace
+}
+
+type viewerKey struct{}
+
+// viewerOf returns the viewer of the request, failing fields that need a
+// session when the request has none.
+func viewerOf(ctx context.Context) (*viewer, error) {
+	v, ok := ctx.Value(viewerKey{}).(*viewer)
+	if !ok {
+		return nil, errorUnauthenticated
+	}
+	return v, nil
+}
+
+// lock holds the workspace of the viewer until the returned func is called.
+// Fields are resolved concurrently, so each holds it around its calls into
+// the usecases.
+func (v *viewer) lock() (unlock func()) {
+	v.workspace.Lock()
+	return v.workspace.Unlock
+}
+
+// mutating returns the viewer of a mutation, whose actor collects the tasks
+// it changes into the scope.
+func (v *viewer) mutating() (*viewer, *access.Scope) {
+	scope := access.InitScope(v.workspace.Lists)
+	mutating := *v
+	mutating.actor.Scope = scope
+	return &mutating, scope
+}
+
+// changed tells watchers of the workspace who may see what the mutation
+// touched about it, once it succeeded.
+func (v *viewer) changed(scope *access.Scope) {
+	v.workspace.Changes.Publish(changes.Change{
+		Actor:  v.actor.User,
+		Method: http.MethodPost,
+		Path:   Path,
+		Scope:  scope,
+	})
+}
+
+func (v *viewer) task(t *tasks.TaskOutput) *taskResolver {
+	return &taskResolver{task: t, viewer: v}
+}
+
+func (v *viewer) tasks(ts []*tasks.TaskOutput) []*taskResolver {
+	result := make([]*taskResolver, 0, len(ts))
+	for _, t := range ts {
+		result = append(result, v.task(t))
+	}
+	return result
+}
+
+func (v *viewer) canReadList(id int) bool {
+	defer v.lock()()
+	return access.CanReadList(v.workspace.Lists, v.actor.User, id)
+}
+
+func (v *viewer) listTasks(f *taskFilter) []*taskResolver {
+	var query tasks.ListTasksInput
+	if f != nil {
+		query = tasks.ListTasksInput{
+			ListId:   toInt(f.List),
+			Tags:     deref(f.Tags),
+			Match:    deref(f.Match),
+			Sort:     deref(f.Sort),
+			Assignee: deref(f.Assignee),
+		}
+	}
+	defer v.lock()()
+	return v.tasks(v.workspace.Tasks.ListTasks(v.actor, &query))
+}
+
+type taskFilter struct {
+	List     *int32
+	Tags     *[]string
+	Match    *string
+	Sort     *string
+	Assignee *string
+}
+
+type sessionResolver struct {
+	user      string
+	workspace string
+}
+
+func (r *sessionResolver) User() string {
+	return r.user
+}
+
+func (r *sessionResolver) Workspace() string {
+	return r.workspace
+}
+
+func (r *resolver) Me(ctx context.Context) (*sessionResolver, error) {
+	v, err := viewerOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionResolver{user: v.actor.User, workspace: v.workspace.Id}, nil
+}
+
+func (r *resolver) Tasks(ctx context.Context, args struct{ Filter *taskFilter }) ([]*taskResolver, error) {
+	v, err := viewerOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.listTasks(args.Filter), nil
+}
+
+func (r *resolver) Task(ctx context.Context, args struct{ Id int32 }) (*taskResolver, error) {
+	v, err := viewerOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer v.lock()()
+	task, err := v.workspace.Tasks.GetTask(v.actor, int(args.Id))
+	if err != nil {
+		return nil, nil
+	}
+	return v.task(task), nil
+}
+
+func (r *resolver) Lists(ctx context.Context) ([]*listResolver, error) {
+	v, err := viewerOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer v.lock()()
+	var result []*listResolver
+	for _, l := range v.workspace.Lists.ListLists(v.actor.User) {
+		result = append(result, &listResolver{list: l, viewer: v})
+	}
+	return result, nil
+}
+
+func (r *resolver) Tags(ctx context.Context) ([]*tagResolver, error) {
+	v, err := viewerOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer v.lock()()
+	var result []*tagResolver
+	for _, t := range v.workspace.Tasks.ListTags(v.actor) {
+		result = append(result, &tagResolver{tag: t, viewer: v})
+	}
+	return result, nil
+}
+
+func (r *resolver) Authenticate(args struct{ User, Password, Workspace *string }) (string, error) {
+	token, err := r.sessions.Authenticate(&sessions.AuthenticateInput{
+		User:      deref(args.User),
+		Password:  deref(args.Password),
+		Workspace: deref(args.Workspace),
+	})
+	if err != nil {
+		return "", toError(err)
+	}
+	return token, nil
+}
+
+type createTaskInput struct {
+	Name        string
+	Description *string
+	Priority    *string
+	ListId      *int32
+	ParentId    *int32
+	Tags        *[]string
+	DueAt       *graphql.Time
+	Recurrence  *recurrenceInput
+}
+
+type updateTaskInput struct {
+	Name        string
+	Status      int32
+	Description *string
+	Priority    *string
+	ListId      *int32
+	ParentId    *int32
+	DueAt       *graphql.Time
+	Recurrence  *recurrenceInput
+}
+
+type recurrenceInput struct {
+	Frequency string
+	Interval  *int32
+	Weekdays  *[]string
+	Until     *graphql.Time
+	Count     *int32
+}
+
+func (r *resolver) CreateTask(ctx context.Context, args struct{ Input createTaskInput }) (*taskResolver, error) {
+	return r.mutateTask(ctx, func(v *viewer) (*tasks.TaskOutput, error) {
+		i := args.Input
+		return v.workspace.Tasks.CreateTask(v.actor, &tasks.CreateTaskInput{
+			Name:        i.Name,
+			Description: deref(i.Description),
+			Priority:    deref(i.Priority),
+			ListId:      deref(toInt(i.ListId)),
+			ParentId:    deref(toInt(i.ParentId)),
+			Tags:        deref(i.Tags),
+			DueAt:       toTime(i.DueAt),
+			Recurrence:  toRecurrenceInput(i.Recurrence),
+		})
+	})
+}
+
+func (r *resolver) UpdateTask(ctx context.Context, args struct {
+	Id    int32
+	Input updateTaskInput
+}) (*taskResolver, error) {
+	return r.mutateTask(ctx, func(v *viewer) (*tasks.TaskOutput, error) {
+		i := args.Input
+		return v.workspace.Tasks.UpdateTask(v.actor, int(args.Id), &tasks.UpdateTaskInput{
+			Name:        i.Name,
+			Status:      int(i.Status),
+			Description: i.Description,
+			Priority:    i.Priority,
+			ListId:      toInt(i.ListId),
+			ParentId:    toInt(i.ParentId),
+			DueAt:       toTime(i.DueAt),
+			Recurrence:  toRecurrenceInput(i.Recurrence),
+		})
+	})
+}
+
+func (r *resolver) DeleteTask(ctx context.Context, args struct {
+	Id       int32
+	Children *string
+}) (bool, error) {
+	v, err := viewerOf(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer v.lock()()
+	v, scope := v.mutating()
+	err = v.workspace.Tasks.DeleteTask(v.actor, int(args.Id), &tasks.DeleteTaskInput{Children: deref(args.Children)})
+	if err != nil {
+		return false, toError(err)
+	}
+	v.changed(scope)
+	return true, nil
+}
+
+func (r *resolver) AddTags(ctx context.Context, args struct {
+	Id   int32
+	Tags []string
+}) (*taskResolver, error) {
+	return r.mutateTask(ctx, func(v *viewer) (*tasks.TaskOutput, error) {
+		return v.workspace.Tasks.AddTags(v.actor, int(args.Id), &tasks.TagsInput{Tags: args.Tags})
+	})
+}
+
+func (r *resolver) RemoveTag(ctx context.Context, args struct {
+	Id  int32
+	Tag string
+}) (*taskResolver, error) {
+	return r.mutateTask(ctx, func(v *viewer) (*tasks.TaskOutput, error) {
+		return v.workspace.Tasks.RemoveTag(v.actor, int(args.Id), args.Tag)
+	})
+}
+
+func (r *resolver) AssignTask(ctx context.Context, args struct {
+	Id       int32
+	Assignee string
+}) (*taskResolver, error) {
+	return r.mutateTask(ctx, func(v *viewer) (*tasks.TaskOutput, error) {
+		return v.workspace.Tasks.AssignTask(v.actor, int(args.Id), &tasks.AssignTaskInput{Assignee: args.Assignee})
+	})
+}
+
+func (r *resolver) UnassignTask(ctx context.Context, args struct{ Id int32 }) (*taskResolver, error) {
+	return r.mutateTask(ctx, func(v *viewer) (*tasks.TaskOutput, error) {
+		return v.workspace.Tasks.UnassignTask(v.actor, int(args.Id))
+	})
+}
+
+// mutateTask applies the mutation for the viewer, telling watchers about it
+// once it succeeded.
+func (r *resolver) mutateTask(ctx context.Context, mutate func(*viewer) (*tasks.TaskOutput, error)) (*taskResolver, error) {
+	v, err := viewerOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer v.lock()()
+	v, scope := v.mutating()
+	task, err := mutate(v)
+	if err != nil {
+		return nil, toError(err)
+	}
+	v.changed(scope)
+	return v.task(task), nil
+}
+
+// Changes streams changes the viewer may see until the subscription ends. Like watchers of the
+// REST stream, subscribers falling too far behind miss changes.
+func (r *resolver) Changes(ctx context.Context) (<-chan *changeResolver, error) {
+	v, err := viewerOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	watched, unsubscribe := v.workspace.Changes.Subscribe(v.actor.User)
+	result := make(chan *changeResolver)
+	go func() {
+		defer close(result)
+		defer unsubscribe()
+		for {
+			select {
+			case change := <-watched:
+				select {
+				case result <- &changeResolver{change: change, viewer: v}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return result, nil
+}
+
+type taskResolver struct {
+	task   *tasks.TaskOutput
+	viewer *viewer
+}
+
+func (r *taskResolver) Id() int32 {
+	return int32(r.task.Id)
+}
+
+func (r *taskResolver) Name() string {
+	return r.task.Name
+}
+
+func (r *taskResolver) Description(args struct{ Format string }) string {
+	if args.Format == "HTML" {
+		return markdown.Render(r.task.Description)
+	}
+	return r.task.Description
+}
+
+func (r *taskResolver) Status() int32 {
+	return int32(r.task.Status)
+}
+
+func (r *taskResolver) Priority() *string {
+	return optional(r.task.Priority)
+}
+
+func (r *taskResolver) List() *listResolver {
+	v := r.viewer
+	defer v.lock()()
+	for _, l := range v.workspace.Lists.ListLists(v.actor.User) {
+		if l.Id == r.task.ListId {
+			return &listResolver{list: l, viewer: v}
+		}
+	}
+	return nil
+}
+
+func (r *taskResolver) Parent() *taskResolver {
+	if r.task.ParentId == 0 {
+		return nil
+	}
+	v := r.viewer
+	defer v.lock()()
+	parent, err := v.workspace.Tasks.GetTask(v.actor, r.task.ParentId)
+	if err != nil {
+		return nil
+	}
+	return v.task(parent)
+}
+
+func (r *taskResolver) Subtasks() []*taskResolver {
+	v := r.viewer
+	defer v.lock()()
+	var subtasks []*tasks.TaskOutput
+	for _, t := range v.workspace.Tasks.ListTasks(v.actor, &tasks.ListTasksInput{}) {
+		if t.ParentId == r.task.Id {
+			subtasks = append(subtasks, t)
+		}
+	}
+	return v.tasks(subtasks)
+}
+
+func (r *taskResolver) Tags() []string {
+	if r.task.Tags == nil {
+		return []string{}
+	}
+	return r.task.Tags
+}
+
+func (r *taskResolver) BlockedBy() []*taskResolver {
+	v := r.viewer
+	defer v.lock()()
+	var blockers []*tasks.TaskOutput
+	for _, id := range r.task.BlockedBy {
+		if blocker, err := v.workspace.Tasks.GetTask(v.actor, id); err == nil {
+			blockers = append(blockers, blocker)
+		}
+	}
+	return v.tasks(blockers)
+}
+
+func (r *taskResolver) DueAt() *graphql.Time {
+	if r.task.DueAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.task.DueAt}
+}
+
+func (r *taskResolver) Recurrence() *recurrenceResolver {
+	if r.task.Recurrence == nil {
+		return nil
+	}
+	return &recurrenceResolver{recurrence: r.task.Recurrence}
+}
+
+func (r *taskResolver) Creator() *string {
+	return optional(r.task.Creator)
+}
+
+func (r *taskResolver) Assignee() *string {
+	return optional(r.task.Assignee)
+}
+
+func (r *taskResolver) Progress() *progressResolver {
+	if r.task.Progress == nil {
+		return nil
+	}
+	return &progressResolver{progress: r.task.Progress}
+}
+
+type recurrenceResolver struct {
+	recurrence *tasks.RecurrenceOutput
+}
+
+func (r *recurrenceResolver) Frequency() string {
+	return r.recurrence.Frequency
+}
+
+func (r *recurrenceResolver) Interval() int32 {
+	return int32(r.recurrence.Interval)
+}
+
+func (r *recurrenceResolver) Weekdays() []string {
+	if r.recurrence.Weekdays == nil {
+		return []string{}
+	}
+	return r.recurrence.Weekdays
+}
+
+func (r *recurrenceResolver) Until() *graphql.Time {
+	if r.recurrence.Until == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.recurrence.Until}
+}
+
+func (r *recurrenceResolver) Count() int32 {
+	return int32(r.recurrence.Count)
+}
+
+type progressResolver struct {
+	progress *tasks.ProgressOutput
+}
+
+func (r *progressResolver) Done() int32 {
+	return int32(r.progress.Done)
+}
+
+func (r *progressResolver) Total() int32 {
+	return int32(r.progress.Total)
+}
+
+type listResolver struct {
+	list   *lists.ListOutput
+	viewer *viewer
+}
+
+func (r *listResolver) Id() int32 {
+	return int32(r.list.Id)
+}
+
+func (r *listResolver) Name() string {
+	return r.list.Name
+}
+
+func (r *listResolver) Owner() *string {
+	return optional(r.list.Owner)
+}
+
+func (r *listResolver) Tasks() []*taskResolver {
+	v := r.viewer
+	defer v.lock()()
+	return v.tasks(v.workspace.Tasks.ListTasks(v.actor, &tasks.ListTasksInput{ListId: &r.list.Id}))
+}
+
+type tagResolver struct {
+	tag    *tasks.TagOutput
+	viewer *viewer
+}
+
+func (r *tagResolver) Name() string {
+	return r.tag.Name
+}
+
+func (r *tagResolver) Count() int32 {
+	return int32(r.tag.Count)
+}
+
+func (r *tagResolver) Tasks() []*taskResolver {
+	v := r.viewer
+	defer v.lock()()
+	return v.tasks(v.workspace.Tasks.ListTasks(v.actor, &tasks.ListTasksInput{Tags: []string{r.tag.Name}}))
+}
+
+type changeResolver struct {
+	change changes.Change
+	viewer *viewer
+}
+
+func (r *changeResolver) Actor() *string {
+	return optional(r.change.Actor)
+}
+
+func (r *changeResolver) Method() *string {
+	return optional(r.change.Method)
+}
+
+func (r *changeResolver) Path() *string {
+	return optional(r.change.Path)
+}
+
+// Tasks fails once the viewer may no longer see the list filtered by, which
+// closes the WebSocket of the subscription.
+func (r *changeResolver) Tasks(args struct{ Filter *taskFilter }) ([]*taskResolver, error) {
+	v := r.viewer
+	if f := args.Filter; f != nil && f.List != nil && !v.canReadList(int(*f.List)) {
+		return nil, errorListNotVisible
+	}
+	return v.listTasks(args.Filter), nil
+}
+
+func toRecurrenceInput(i *recurrenceInput) *tasks.RecurrenceInput {
+	if i == nil {
+		return nil
+	}
+	return &tasks.RecurrenceInput{
+		Frequency: i.Frequency,
+		Interval:  deref(toInt(i.Interval)),
+		Weekdays:  deref(i.Weekdays),
+		Until:     toTime(i.Until),
+		Count:     deref(toInt(i.Count)),
+	}
+}
+
+func toInt(i *int32) *int {
+	if i == nil {
+		return nil
+	}
+	n := int(*i)
+	return &n
+}
+
+func toTime(t *graphql.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.Time
+}
+
+// deref returns the zero value for nil, which the usecases take as unset.
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
+// optional returns nil for the empty string, which the usecases leave fields
+// unset with.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
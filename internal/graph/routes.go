@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/dannyh79/whostodo/internal/sessions"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/dannyh79/whostodo/internal/workspaces"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// Path serves queries and mutations by POST, and subscriptions over
+// WebSocket by GET.
+const Path = "/graphql"
+
+// Request is the body of queries and mutations, and the payload of
+// subscribe messages.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type server struct {
+	schema     *graphql.Schema
+	sessions   *sessions.SessionsUsecase
+	workspaces *workspaces.WorkspacesUsecase
+	upgrader   websocket.Upgrader
+}
+
+func AddRoutes(r *gin.Engine, sessionsU *sessions.SessionsUsecase, workspacesU *workspaces.WorkspacesUsecase) {
+	s := &server{
+		schema:     graphql.MustParseSchema(schema, &resolver{sessions: sessionsU, workspaces: workspacesU}),
+		sessions:   sessionsU,
+		workspaces: workspacesU,
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{subprotocol},
+			// Sessions are sent by token rather than cookie, so pages of other
+			// origins have nothing to ride on.
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+	}
+
+	r.POST(Path, s.queryHandler)
+	r.GET(Path, s.subscriptionHandler)
+}
+
+// queryHandler executes the query or mutation in the body, for the session of
+// the Authorization header if any. Errors are in the response rather than
+// its status, which is 200 as long as the body could be read.
+func (s *server) queryHandler(c *gin.Context) {
+	var request Request
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{"message": "Body is not a GraphQL request"}}})
+		return
+	}
+
+	v, err := s.viewerOf(tokenFromHeader(c.Request))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{})
+		return
+	}
+	ctx := c.Request.Context()
+	if v != nil {
+		ctx = withViewer(ctx, v)
+	}
+	c.JSON(http.StatusOK, s.schema.Exec(ctx, request.Query, request.OperationName, request.Variables))
+}
+
+// viewerOf returns the viewer of the session of the token, or nil when the
+// session is missing or expired.
+func (s *server) viewerOf(token string) (*viewer, error) {
+	session, ok := s.sessions.Session(token)
+	if !ok {
+		return nil, nil
+	}
+	w, err := s.workspaces.Find(session.Workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &viewer{
+		actor:     tasks.Actor{SessionId: token, User: session.User},
+		workspace: w,
+	}, nil
+}
+
+func withViewer(ctx context.Context, v *viewer) context.Context {
+	return context.WithValue(ctx, viewerKey{}, v)
+}
+
+func tokenFromHeader(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return token
+}
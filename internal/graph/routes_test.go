@@ -0,0 +1,199 @@
+package graph_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/graph"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+// populate stores tasks in a list of alice, one under and blocked by another.
+func populate(suite *util.MockTestSuite) {
+	suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "alice"})
+	suite.AccountRepo.PopulateData(util.NewAccount("alice", "密碼"))
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Description: "**記得**帶袋子", ListId: 1, Tags: []string{"errand"}, Assignee: "bob"})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "洗碗", ListId: 1, ParentId: 1, BlockedBy: []int{1}})
+}
+
+func Test_POSTGraphQL(t *testing.T) {
+	tests := []struct {
+		name       string
+		authorized bool
+		query      string
+		variables  map[string]any
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns tasks along with their lists, tags and assignees",
+			authorized: true,
+			query:      `{ tasks { id name list { name } tags assignee parent { id } subtasks { name } blockedBy { name } progress { done total } } }`,
+			statusCode: http.StatusOK,
+			expected: `{"data":{"tasks":[` +
+				`{"id":1,"name":"買晚餐","list":{"name":"家事"},"tags":["errand"],"assignee":"bob","parent":null,"subtasks":[{"name":"洗碗"}],"blockedBy":[],"progress":{"done":0,"total":1}},` +
+				`{"id":2,"name":"洗碗","list":{"name":"家事"},"tags":[],"assignee":null,"parent":{"id":1},"subtasks":[],"blockedBy":[{"name":"買晚餐"}],"progress":null}` +
+				`]}}`,
+		},
+		{
+			name:       "returns tasks filtered as REST does",
+			authorized: true,
+			query:      `query($tag: String!) { tasks(filter: {tags: [$tag]}) { name } }`,
+			variables:  map[string]any{"tag": "errand"},
+			statusCode: http.StatusOK,
+			expected:   `{"data":{"tasks":[{"name":"買晚餐"}]}}`,
+		},
+		{
+			name:       "returns lists and tags along with their tasks",
+			authorized: true,
+			query:      `{ me { user workspace } lists { id name owner tasks { name } } tags { name count tasks { name } } }`,
+			statusCode: http.StatusOK,
+			expected: `{"data":{"me":{"user":"alice","workspace":"default"},` +
+				`"lists":[{"id":0,"name":"Inbox","owner":null,"tasks":[]},{"id":1,"name":"家事","owner":"alice","tasks":[{"name":"買晚餐"},{"name":"洗碗"}]}],` +
+				`"tags":[{"name":"errand","count":1,"tasks":[{"name":"買晚餐"}]}]}}`,
+		},
+		{
+			name:       "returns descriptions rendered as asked",
+			authorized: true,
+			query:      `{ task(id: 1) { source: description html: description(format: HTML) } }`,
+			statusCode: http.StatusOK,
+			expected:   `{"data":{"task":{"source":"**記得**帶袋子","html":"\u003cp\u003e\u003cstrong\u003e記得\u003c/strong\u003e帶袋子\u003c/p\u003e"}}}`,
+		},
+		{
+			name:       "returns null for a task not found",
+			authorized: true,
+			query:      `{ task(id: 3) { name } }`,
+			statusCode: http.StatusOK,
+			expected:   `{"data":{"task":null}}`,
+		},
+		{
+			name:       "creates a task",
+			authorized: true,
+			query:      `mutation { createTask(input: {name: "倒垃圾", listId: 1, tags: ["Chore"]}) { id name list { name } tags creator } }`,
+			statusCode: http.StatusOK,
+			expected:   `{"data":{"createTask":{"id":3,"name":"倒垃圾","list":{"name":"家事"},"tags":["chore"],"creator":"alice"}}}`,
+		},
+		{
+			name:       "updates a task",
+			authorized: true,
+			query:      `mutation { updateTask(id: 1, input: {name: "買午餐", status: 1, priority: "high"}) { name status priority } }`,
+			statusCode: http.StatusOK,
+			expected:   `{"data":{"updateTask":{"name":"買午餐","status":1,"priority":"high"}}}`,
+		},
+		{
+			name:       "assigns and tags a task",
+			authorized: true,
+			query:      `mutation { assignTask(id: 2, assignee: "alice") { assignee } addTags(id: 2, tags: ["kitchen"]) { tags } }`,
+			statusCode: http.StatusOK,
+			expected:   `{"data":{"assignTask":{"assignee":"alice"},"addTags":{"tags":["kitchen"]}}}`,
+		},
+		{
+			name:       "deletes a task",
+			authorized: true,
+			query:      `mutation { deleteTask(id: 1, children: "cascade") }`,
+			statusCode: http.StatusOK,
+			expected:   `{"data":{"deleteTask":true}}`,
+		},
+		{
+			name:       "returns an error coded as the REST status",
+			authorized: true,
+			query:      `mutation { updateTask(id: 9, input: {name: "買午餐", status: 1}) { name } }`,
+			statusCode: http.StatusOK,
+			expected:   `{"errors":[{"message":"Task not found","path":["updateTask"],"extensions":{"code":"NOT_FOUND"}}],"data":null}`,
+		},
+		{
+			name:       "returns an error coded as invalid input",
+			authorized: true,
+			query:      `mutation { updateTask(id: 1, input: {name: "買午餐", status: 0, priority: "最高"}) { name } }`,
+			statusCode: http.StatusOK,
+			expected:   `{"errors":[{"message":"Invalid priority","path":["updateTask"],"extensions":{"code":"INVALID_INPUT"}}],"data":null}`,
+		},
+		{
+			name:       "returns the error of an invalid workspace",
+			query:      `mutation { authenticate(workspace: "不存在的 工作區") }`,
+			statusCode: http.StatusOK,
+			expected:   `{"errors":[{"message":"Invalid workspace","path":["authenticate"],"extensions":{"code":"INVALID_INPUT"}}],"data":null}`,
+		},
+		{
+			name:       "without session token returns an unauthenticated error",
+			query:      `{ tasks { name } }`,
+			statusCode: http.StatusOK,
+			expected:   `{"errors":[{"message":"Session is missing or expired","path":["tasks"],"extensions":{"code":"UNAUTHENTICATED"}}],"data":null}`,
+		},
+		{
+			name:       "returns validation errors",
+			authorized: true,
+			query:      `{ tasks { title } }`,
+			statusCode: http.StatusOK,
+			expected:   `{"errors":[{"message":"Cannot query field \"title\" on type \"Task\".","locations":[{"line":1,"column":11}]}]}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			populate(suite)
+			body, _ := json.Marshal(graph.Request{Query: tc.query, Variables: tc.variables})
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, graph.Path, bytes.NewBuffer(body))
+			req.Header.Add("Content-Type", "application/json")
+			if tc.authorized {
+				alice := util.NewUserSession("alice")
+				suite.SessionRepo.PopulateData(alice)
+				req.Header.Set("Authorization", "Bearer "+alice.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_POSTGraphQLAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.AccountRepo.PopulateData(util.NewAccount("alice", "密碼", "team-a"))
+	query := func(token string, q string) map[string]any {
+		body, _ := json.Marshal(graph.Request{Query: q})
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, graph.Path, bytes.NewBuffer(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		suite.Engine.ServeHTTP(rr, req)
+		var response struct {
+			Data map[string]any `json:"data"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return response.Data
+	}
+
+	token := query("", `mutation { authenticate(user: "alice", password: "密碼", workspace: "team-a") }`)["authenticate"].(string)
+
+	util.AssertEqual(t)(query(token, `{ me { user workspace } }`), map[string]any{
+		"me": map[string]any{"user": "alice", "workspace": "team-a"},
+	})
+}
+
+func Test_POSTGraphQLBadRequest(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, graph.Path, bytes.NewBufferString(`query { tasks { name } }`))
+
+	suite.Engine.ServeHTTP(rr, req)
+
+	util.AssertHttpStatus(t)(rr, http.StatusBadRequest)
+	util.AssertEqual(t)(rr.Body.String(), `{"errors":[{"message":"Body is not a GraphQL request"}]}`)
+}
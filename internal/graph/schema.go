@@ -0,0 +1,156 @@
+// Package graph serves the usecases over GraphQL, next to the REST routes,
+// for clients wanting tasks along with their lists, tags and assignees in a
+// single round trip.
+package graph
+
+// schema is served at /graphql. Fields are named after those of the REST
+// routes, in camel case.
+const schema = `
+scalar Time
+
+schema {
+	query: Query
+	mutation: Mutation
+	subscription: Subscription
+}
+
+type Query {
+	# The session the request is made in.
+	me: Session!
+	tasks(filter: TaskFilter): [Task!]!
+	# Null if there is no such task, or the session may not see it.
+	task(id: Int!): Task
+	# The inbox followed by lists the session may see.
+	lists: [List!]!
+	tags: [Tag!]!
+}
+
+type Mutation {
+	# Starts a session, returning its token. Works without a session. Named
+	# users need the password of their account.
+	authenticate(user: String, password: String, workspace: String): String!
+	createTask(input: CreateTaskInput!): Task!
+	updateTask(id: Int!, input: UpdateTaskInput!): Task!
+	# Either reparent, the default, or cascade.
+	deleteTask(id: Int!, children: String): Boolean!
+	addTags(id: Int!, tags: [String!]!): Task!
+	removeTag(id: Int!, tag: String!): Task!
+	assignTask(id: Int!, assignee: String!): Task!
+	unassignTask(id: Int!): Task!
+}
+
+type Subscription {
+	# Changes made in the workspace of the session from now on, by REST
+	# requests and mutations alike.
+	changes: Change!
+}
+
+type Session {
+	user: String!
+	workspace: String!
+}
+
+type Task {
+	id: Int!
+	name: String!
+	# Markdown source unless asked for html.
+	description(format: DescriptionFormat = MARKDOWN): String!
+	status: Int!
+	priority: String
+	# The inbox for tasks in no list.
+	list: List
+	parent: Task
+	subtasks: [Task!]!
+	tags: [String!]!
+	blockedBy: [Task!]!
+	dueAt: Time
+	recurrence: Recurrence
+	creator: String
+	assignee: String
+	# Null for tasks without subtasks.
+	progress: Progress
+}
+
+enum DescriptionFormat {
+	MARKDOWN
+	HTML
+}
+
+type Recurrence {
+	frequency: String!
+	interval: Int!
+	weekdays: [String!]!
+	until: Time
+	count: Int!
+}
+
+type Progress {
+	done: Int!
+	total: Int!
+}
+
+type List {
+	id: Int!
+	name: String!
+	owner: String
+	tasks: [Task!]!
+}
+
+type Tag {
+	name: String!
+	count: Int!
+	tasks: [Task!]!
+}
+
+# Tells that something in the workspace was changed, and by whom. Select the
+# tasks along with it to have them fetched again on every change.
+type Change {
+	actor: String
+	method: String
+	path: String
+	tasks(filter: TaskFilter): [Task!]!
+}
+
+input TaskFilter {
+	list: Int
+	tags: [String!]
+	# Either any, the default, or all.
+	match: String
+	# Either priority or empty for the manual order.
+	sort: String
+	assignee: String
+}
+
+input CreateTaskInput {
+	name: String!
+	description: String
+	priority: String
+	listId: Int
+	parentId: Int
+	tags: [String!]
+	dueAt: Time
+	recurrence: RecurrenceInput
+}
+
+# Fields left out keep their current value, except name and status.
+input UpdateTaskInput {
+	name: String!
+	status: Int!
+	description: String
+	priority: String
+	listId: Int
+	# 0 makes the task a root task.
+	parentId: Int
+	dueAt: Time
+	# An empty frequency removes the recurrence.
+	recurrence: RecurrenceInput
+}
+
+input RecurrenceInput {
+	frequency: String!
+	interval: Int
+	weekdays: [String!]
+	until: Time
+	count: Int
+}
+`
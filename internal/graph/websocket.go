@@ -0,0 +1,307 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// subprotocol is the GraphQL over WebSocket protocol of the graphql-ws
+// library, which subscriptions are served by.
+const subprotocol = "graphql-transport-ws"
+
+const (
+	// How long a connection may take to send connection_init.
+	initTimeout = 10 * time.Second
+	// How often idle connections are pinged, so proxies do not close them.
+	keepAlive = 30 * time.Second
+)
+
+// Close codes of the protocol.
+const (
+	closeBadRequest          = 4400
+	closeUnauthorized        = 4401
+	closeForbidden           = 4403
+	closeSubprotocol         = 4406
+	closeInitTimeout         = 4408
+	closeSubscriberExists    = 4409
+	closeTooManyInitRequests = 4429
+)
+
+// Message types of the protocol.
+const (
+	messageConnectionInit = "connection_init"
+	messageConnectionAck  = "connection_ack"
+	messagePing           = "ping"
+	messagePong           = "pong"
+	messageSubscribe      = "subscribe"
+	messageNext           = "next"
+	messageError          = "error"
+	messageComplete       = "complete"
+)
+
+// Message is what either side sends over the WebSocket.
+type Message struct {
+	Id      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// InitPayload is the payload of connection_init. Browsers cannot set headers
+// on WebSockets, so the token is sent in it instead.
+type InitPayload struct {
+	Authorization string `json:"Authorization"`
+}
+
+// subscriptionHandler serves operations over WebSocket. The connection is
+// authenticated by its connection_init, then checked again before every
+// result, so it is closed once the session expires or is removed.
+func (s *server) subscriptionHandler(c *gin.Context) {
+	if !websocket.IsWebSocketUpgrade(c.Request) {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{"message": "Subscriptions are served over WebSocket; send queries and mutations by POST"}}})
+		return
+	}
+	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	sc := &connection{
+		server:        s,
+		conn:          conn,
+		ctx:           ctx,
+		header:        c.Request.Header,
+		subscriptions: make(map[string]context.CancelFunc),
+	}
+	if conn.Subprotocol() != subprotocol {
+		sc.close(closeSubprotocol, "Subprotocol not acceptable")
+		return
+	}
+	sc.serve()
+}
+
+// connection serves the operations of a single WebSocket, one goroutine
+// reading messages and another sending the results of each subscription.
+type connection struct {
+	server *server
+	conn   *websocket.Conn
+	// Ends once the connection is closed, and with it every subscription.
+	ctx    context.Context
+	header http.Header
+	// Nil until connection_init is acknowledged.
+	viewer context.Context
+	// Token of the session of the viewer, checked before every result.
+	token string
+
+	writeMu       sync.Mutex
+	mu            sync.Mutex
+	subscriptions map[string]context.CancelFunc
+}
+
+func (c *connection) serve() {
+	c.conn.SetReadDeadline(time.Now().Add(initTimeout))
+	for {
+		var m Message
+		if err := c.conn.ReadJSON(&m); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			switch {
+			case c.viewer == nil && isTimeout(err):
+				c.close(closeInitTimeout, "Connection initialisation timeout")
+			case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+				c.close(closeBadRequest, "Invalid message received")
+			}
+			return
+		}
+		if !c.handle(m) {
+			return
+		}
+	}
+}
+
+// handle acts on the message, returning false once the connection is closed.
+func (c *connection) handle(m Message) bool {
+	switch m.Type {
+	case messageConnectionInit:
+		if c.viewer != nil {
+			c.close(closeTooManyInitRequests, "Too many initialisation requests")
+			return false
+		}
+		return c.init(m.Payload)
+	case messagePing:
+		c.send(Message{Type: messagePong})
+	case messagePong:
+	case messageSubscribe:
+		if c.viewer == nil {
+			c.close(closeUnauthorized, "Unauthorized")
+			return false
+		}
+		var request Request
+		if m.Id == "" || json.Unmarshal(m.Payload, &request) != nil {
+			c.close(closeBadRequest, "Invalid message received")
+			return false
+		}
+		if !c.subscribe(m.Id, &request) {
+			c.close(closeSubscriberExists, "Subscriber for "+m.Id+" already exists")
+			return false
+		}
+	case messageComplete:
+		c.unsubscribe(m.Id)
+	default:
+		c.close(closeBadRequest, "Invalid message received")
+		return false
+	}
+	return true
+}
+
+// init authenticates the connection by the token in the payload, or else in
+// the Authorization header of the upgrade request.
+func (c *connection) init(payload json.RawMessage) bool {
+	var p InitPayload
+	if len(payload) > 0 && json.Unmarshal(payload, &p) != nil {
+		c.close(closeBadRequest, "Invalid message received")
+		return false
+	}
+	token, ok := strings.CutPrefix(p.Authorization, "Bearer ")
+	if !ok {
+		token = tokenFromHeader(&http.Request{Header: c.header})
+	}
+
+	v, err := c.server.viewerOf(token)
+	if err != nil || v == nil {
+		c.close(closeForbidden, "Forbidden")
+		return false
+	}
+	c.viewer = withViewer(c.ctx, v)
+	c.token = token
+	c.conn.SetReadDeadline(time.Time{})
+	c.send(Message{Type: messageConnectionAck})
+	go c.keepAlive()
+	return true
+}
+
+// subscribe starts the operation under the id, returning false if one is
+// already running under it. Subscriptions are subscribed to by the time it
+// returns, so clients miss no change made after a later ping is answered.
+func (c *connection) subscribe(id string, r *Request) bool {
+	c.mu.Lock()
+	if _, ok := c.subscriptions[id]; ok {
+		c.mu.Unlock()
+		return false
+	}
+	ctx, cancel := context.WithCancel(c.viewer)
+	c.subscriptions[id] = cancel
+	c.mu.Unlock()
+
+	results, err := c.server.schema.Subscribe(ctx, r.Query, r.OperationName, r.Variables)
+	if err != nil {
+		c.finish(id, Message{Id: id, Type: messageError, Payload: marshal([]gin.H{{"message": err.Error()}})})
+		return true
+	}
+	go func() {
+		for result := range results {
+			response := result.(*graphql.Response)
+			if !c.allowed(response) {
+				c.revoke()
+				return
+			}
+			if response.Data == nil && len(response.Errors) > 0 {
+				c.finish(id, Message{Id: id, Type: messageError, Payload: marshal(response.Errors)})
+				return
+			}
+			c.send(Message{Id: id, Type: messageNext, Payload: marshal(response)})
+		}
+		c.finish(id, Message{Id: id, Type: messageComplete})
+	}()
+	return true
+}
+
+// finish ends the subscription by the message, unless the client ended it
+// first.
+func (c *connection) finish(id string, m Message) {
+	c.mu.Lock()
+	cancel, ok := c.subscriptions[id]
+	delete(c.subscriptions, id)
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	cancel()
+	c.send(m)
+}
+
+func (c *connection) unsubscribe(id string) {
+	c.mu.Lock()
+	cancel, ok := c.subscriptions[id]
+	delete(c.subscriptions, id)
+	c.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// allowed reports whether the result may be sent: the session is still
+// valid, and the viewer may still see the lists subscribed to.
+func (c *connection) allowed(r *graphql.Response) bool {
+	if _, ok := c.server.sessions.Session(c.token); !ok {
+		return false
+	}
+	for _, e := range r.Errors {
+		if errors.Is(e.ResolverError, ErrorListNotVisible) {
+			return false
+		}
+	}
+	return true
+}
+
+// revoke closes the connection from a subscription, which ends the reading
+// goroutine and every other subscription with it.
+func (c *connection) revoke() {
+	c.close(closeForbidden, "Forbidden")
+	c.conn.Close()
+}
+
+func (c *connection) keepAlive() {
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.send(Message{Type: messagePing})
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *connection) send(m Message) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.WriteMessage(websocket.TextMessage, marshal(m))
+}
+
+func (c *connection) close(code int, reason string) {
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func marshal(v any) json.RawMessage {
+	b, _ := json.Marshal(v)
+	return b
+}
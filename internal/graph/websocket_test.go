@@ -0,0 +1,286 @@
+package graph_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/graph"
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/sessions"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+	"github.com/gorilla/websocket"
+)
+
+// dial connects to the GraphQL WebSocket of the server, speaking the
+// subprotocol if given.
+func dial(t *testing.T, server *httptest.Server, subprotocol string) *websocket.Conn {
+	t.Helper()
+	dialer := websocket.Dialer{HandshakeTimeout: 5 * time.Second}
+	if subprotocol != "" {
+		dialer.Subprotocols = []string{subprotocol}
+	}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+graph.Path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, message string) {
+	t.Helper()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// receive returns the next message, or the close code and reason once the
+// connection is closed.
+func receive(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+	_, message, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return closeErr.Error()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(message)
+}
+
+func Test_GraphQLSubscription(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	populate(suite)
+	alice := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(alice)
+	server := httptest.NewServer(suite.Engine)
+	t.Cleanup(server.Close)
+	request := func(method string, path string, body string) {
+		req, _ := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+alice.Id)
+		req.Header.Set("Content-Type", "application/json")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	conn := dial(t, server, "graphql-transport-ws")
+
+	send(t, conn, `{"type":"connection_init","payload":{"Authorization":"Bearer `+alice.Id+`"}}`)
+	util.AssertEqual(t)(receive(t, conn), `{"type":"connection_ack"}`)
+
+	send(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription { changes { actor method path tasks(filter: {list: 1}) { name } } }"}}`)
+	// Answered once subscribed, as messages are handled in order.
+	send(t, conn, `{"type":"ping"}`)
+	util.AssertEqual(t)(receive(t, conn), `{"type":"pong"}`)
+
+	request(http.MethodPut, "/v1/task/2", `{"name":"洗好碗","status":0}`)
+	util.AssertEqual(t)(receive(t, conn), `{"id":"1","type":"next","payload":{"data":{"changes":{"actor":"alice","method":"PUT","path":"/v1/task/2","tasks":[{"name":"買晚餐"},{"name":"洗好碗"}]}}}}`)
+
+	body, _ := json.Marshal(graph.Request{Query: `mutation { deleteTask(id: 2) }`})
+	request(http.MethodPost, graph.Path, string(body))
+	util.AssertEqual(t)(receive(t, conn), `{"id":"1","type":"next","payload":{"data":{"changes":{"actor":"alice","method":"POST","path":"/graphql","tasks":[{"name":"買晚餐"}]}}}}`)
+
+	// Ended by the client, the subscription is not completed by the server.
+	send(t, conn, `{"id":"1","type":"complete"}`)
+	send(t, conn, `{"type":"ping"}`)
+	util.AssertEqual(t)(receive(t, conn), `{"type":"pong"}`)
+	request(http.MethodPut, "/v1/task/1", `{"name":"買午餐","status":1}`)
+	send(t, conn, `{"type":"ping"}`)
+	util.AssertEqual(t)(receive(t, conn), `{"type":"pong"}`)
+}
+
+func Test_GraphQLSubscriptionRevoked(t *testing.T) {
+	type request struct {
+		token  string
+		method string
+		path   string
+		body   string
+	}
+	expiring := util.NewUserSession("alice")
+	// Expires after subscribing, before the change.
+	expiring.CreatedAt = time.Now().Add(-sessions.Timeout + 500*time.Millisecond)
+
+	tests := []struct {
+		name     string
+		session  util.Session
+		wait     time.Duration
+		requests []request
+	}{
+		{
+			name:    "closes with 4403 once the session expires",
+			session: expiring,
+			wait:    600 * time.Millisecond,
+			requests: []request{
+				{token: "bob_token", method: http.MethodPut, path: "/v1/task/1", body: `{"name":"洗好碗","status":0}`},
+			},
+		},
+		{
+			name:    "closes with 4403 once removed from the list subscribed to",
+			session: util.NewUserSession("alice"),
+			requests: []request{
+				{token: "bob_token", method: http.MethodDelete, path: "/v1/list/1/members/alice"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "bob"})
+			suite.MemberRepo.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "alice", Role: "editor", Accepted: true})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", ListId: 1, Creator: "bob"})
+			suite.SessionRepo.PopulateData(tc.session)
+			suite.SessionRepo.PopulateData(util.NewUserSession("bob"))
+			server := httptest.NewServer(suite.Engine)
+			t.Cleanup(server.Close)
+			conn := dial(t, server, "graphql-transport-ws")
+
+			send(t, conn, `{"type":"connection_init","payload":{"Authorization":"Bearer alice_token"}}`)
+			send(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription { changes { tasks(filter: {list: 1}) { name } } }"}}`)
+			send(t, conn, `{"type":"ping"}`)
+			util.AssertEqual(t)(receive(t, conn), `{"type":"connection_ack"}`)
+			util.AssertEqual(t)(receive(t, conn), `{"type":"pong"}`)
+			time.Sleep(tc.wait)
+
+			for _, r := range tc.requests {
+				req, _ := http.NewRequest(r.method, server.URL+r.path, bytes.NewBufferString(r.body))
+				req.Header.Set("Authorization", "Bearer "+r.token)
+				req.Header.Set("Content-Type", "application/json")
+				res, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				res.Body.Close()
+			}
+
+			util.AssertEqual(t)(receive(t, conn), "websocket: close 4403: Forbidden")
+		})
+	}
+}
+
+func Test_GraphQLWebSocket(t *testing.T) {
+	tests := []struct {
+		name        string
+		subprotocol string
+		messages    []string
+		expected    []string
+	}{
+		{
+			name:        "returns queries as a single result",
+			subprotocol: "graphql-transport-ws",
+			messages: []string{
+				`{"type":"connection_init","payload":{"Authorization":"Bearer alice_token"}}`,
+				`{"id":"1","type":"subscribe","payload":{"query":"{ task(id: 1) { name assignee } }"}}`,
+			},
+			expected: []string{
+				`{"type":"connection_ack"}`,
+				`{"id":"1","type":"next","payload":{"data":{"task":{"name":"買晚餐","assignee":"bob"}}}}`,
+				`{"id":"1","type":"complete"}`,
+			},
+		},
+		{
+			name:        "returns invalid operations as an error",
+			subprotocol: "graphql-transport-ws",
+			messages: []string{
+				`{"type":"connection_init","payload":{"Authorization":"Bearer alice_token"}}`,
+				`{"id":"1","type":"subscribe","payload":{"query":"subscription { tasks { name } }"}}`,
+			},
+			expected: []string{
+				`{"type":"connection_ack"}`,
+				`{"id":"1","type":"error","payload":[{"message":"Cannot query field \"tasks\" on type \"Subscription\".","locations":[{"line":1,"column":16}]}]}`,
+			},
+		},
+		{
+			name:        "with an expired session token closes with 4403",
+			subprotocol: "graphql-transport-ws",
+			messages:    []string{`{"type":"connection_init","payload":{"Authorization":"Bearer stubbed_token"}}`},
+			expected:    []string{"websocket: close 4403: Forbidden"},
+		},
+		{
+			name:        "subscribing before connection_init closes with 4401",
+			subprotocol: "graphql-transport-ws",
+			messages:    []string{`{"id":"1","type":"subscribe","payload":{"query":"subscription { changes { path } }"}}`},
+			expected:    []string{"websocket: close 4401: Unauthorized"},
+		},
+		{
+			name:        "initialising twice closes with 4429",
+			subprotocol: "graphql-transport-ws",
+			messages: []string{
+				`{"type":"connection_init","payload":{"Authorization":"Bearer alice_token"}}`,
+				`{"type":"connection_init","payload":{"Authorization":"Bearer alice_token"}}`,
+			},
+			expected: []string{`{"type":"connection_ack"}`, "websocket: close 4429: Too many initialisation requests"},
+		},
+		{
+			name:        "subscribing twice under an id closes with 4409",
+			subprotocol: "graphql-transport-ws",
+			messages: []string{
+				`{"type":"connection_init","payload":{"Authorization":"Bearer alice_token"}}`,
+				`{"id":"1","type":"subscribe","payload":{"query":"subscription { changes { path } }"}}`,
+				`{"id":"1","type":"subscribe","payload":{"query":"subscription { changes { path } }"}}`,
+			},
+			expected: []string{`{"type":"connection_ack"}`, "websocket: close 4409: Subscriber for 1 already exists"},
+		},
+		{
+			name:        "an invalid message closes with 4400",
+			subprotocol: "graphql-transport-ws",
+			messages:    []string{`{"type":"start"}`},
+			expected:    []string{"websocket: close 4400: Invalid message received"},
+		},
+		{
+			name:     "without the subprotocol closes with 4406",
+			expected: []string{"websocket: close 4406: Subprotocol not acceptable"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Creator: "alice", Assignee: "bob"})
+			suite.SessionRepo.PopulateData(util.NewUserSession("alice"))
+			suite.SessionRepo.PopulateData(util.NewExpiredSession())
+			server := httptest.NewServer(suite.Engine)
+			t.Cleanup(server.Close)
+			conn := dial(t, server, tc.subprotocol)
+
+			for _, message := range tc.messages {
+				send(t, conn, message)
+			}
+
+			var got []string
+			for range tc.expected {
+				got = append(got, receive(t, conn))
+			}
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+}
+
+func Test_GETGraphQLWithoutWebSocket(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, graph.Path, nil)
+
+	suite.Engine.ServeHTTP(rr, req)
+
+	util.AssertHttpStatus(t)(rr, http.StatusBadRequest)
+	util.AssertEqual(t)(rr.Body.String(), `{"errors":[{"message":"Subscriptions are served over WebSocket; send queries and mutations by POST"}]}`)
+}
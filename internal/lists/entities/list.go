@@ -0,0 +1,25 @@
+package entity
+
+// Tasks not assigned to any list belong to the inbox, which is not stored.
+const InboxId = 0
+
+const InboxName = "Inbox"
+
+type List struct {
+	Id   int
+	Name string
+	// User who created the list; lists stored without one are closed to
+	// everyone.
+	Owner string
+}
+
+func NewList(id int, name string) *List {
+	return &List{
+		Id:   id,
+		Name: name,
+	}
+}
+
+func (l *List) IsOwnedBy(user string) bool {
+	return l.Owner != "" && l.Owner == user
+}
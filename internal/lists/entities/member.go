@@ -0,0 +1,42 @@
+package entity
+
+import "time"
+
+const (
+	// Viewers see tasks of the list.
+	RoleViewer = "viewer"
+	// Editors also change tasks of the list.
+	RoleEditor = "editor"
+)
+
+// Member is a user a list is shared with. Members are invited first and get
+// access once they accept.
+type Member struct {
+	Id        int
+	ListId    int
+	User      string
+	Role      string
+	Accepted  bool
+	CreatedAt time.Time
+}
+
+func NewMember(listId int, user string, role string) *Member {
+	return &Member{
+		ListId:    listId,
+		User:      user,
+		Role:      role,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (m *Member) CanRead() bool {
+	return m.Accepted
+}
+
+func (m *Member) CanWrite() bool {
+	return m.Accepted && m.Role == RoleEditor
+}
+
+func IsRole(role string) bool {
+	return role == RoleViewer || role == RoleEditor
+}
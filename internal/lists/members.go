@@ -0,0 +1,206 @@
+package lists
+
+import (
+	"errors"
+	"strings"
+
+	entity "github.com/dannyh79/whostodo/internal/lists/entities"
+	"github.com/dannyh79/whostodo/internal/repository"
+)
+
+var (
+	ErrorInvalidRole        = errors.New("Role must be viewer or editor")
+	ErrorInvalidMember      = errors.New("Invalid member")
+	ErrorMemberNotFound     = errors.New("Member not found")
+	ErrorInvitationNotFound = errors.New("Invitation not found")
+)
+
+type MemberOutput struct {
+	User     string `json:"user"`
+	Role     string `json:"role"`
+	Accepted bool   `json:"accepted"`
+}
+
+type InvitationOutput struct {
+	ListId   int    `json:"list_id"`
+	ListName string `json:"list_name"`
+	Owner    string `json:"owner"`
+	Role     string `json:"role"`
+}
+
+type MemberInput struct {
+	// Either entity.RoleViewer or entity.RoleEditor.
+	Role string `json:"role"`
+}
+
+// ListMembers returns whom the list is shared with, invitations not yet
+// accepted included.
+func (u *ListsUsecase) ListMembers(user string, listId int) ([]*MemberOutput, error) {
+	if _, err := u.find(user, listId); err != nil {
+		return nil, err
+	}
+
+	var output = make([]*MemberOutput, 0)
+	for _, member := range u.members.ListByList(listId) {
+		output = append(output, toMemberOutput(member))
+	}
+
+	return output, nil
+}
+
+// InviteMember shares the list with the member, who gets access once they
+// accept. Inviting an existing member changes their role instead. Only the
+// owner may invite.
+func (u *ListsUsecase) InviteMember(user string, listId int, member string, i *MemberInput) (*MemberOutput, error) {
+	if !entity.IsRole(i.Role) {
+		return nil, ErrorInvalidRole
+	}
+	member = strings.TrimSpace(member)
+	list, err := u.findOwned(user, listId)
+	if err != nil {
+		return nil, err
+	}
+	if member == "" || member == list.Owner {
+		return nil, ErrorInvalidMember
+	}
+
+	existing, err := u.members.FindMember(listId, member)
+	if err != nil {
+		saved := u.members.Save(entity.NewMember(listId, member, i.Role))
+		return toMemberOutput(&saved), nil
+	}
+
+	existing.Role = i.Role
+	updated, err := u.members.Update(existing)
+	if err != nil {
+		return nil, err
+	}
+	return toMemberOutput(updated), nil
+}
+
+// RemoveMember revokes the member's access or invitation, effective right
+// away. The owner may remove anyone; members may remove themselves.
+func (u *ListsUsecase) RemoveMember(user string, listId int, member string) error {
+	list, err := u.repo.FindBy(listId)
+	if err != nil {
+		return err
+	}
+	if user != member && !list.IsOwnedBy(user) {
+		if !u.CanRead(user, listId) {
+			return repository.ErrorNotFound
+		}
+		return ErrorNotOwner
+	}
+
+	found, err := u.members.FindMember(listId, member)
+	if err != nil {
+		return ErrorMemberNotFound
+	}
+	return u.members.Delete(found)
+}
+
+// ListInvitations returns invitations sent to the user and not yet accepted.
+func (u *ListsUsecase) ListInvitations(user string) []*InvitationOutput {
+	var output = make([]*InvitationOutput, 0)
+
+	for _, member := range u.members.ListByUser(user) {
+		if member.Accepted {
+			continue
+		}
+		list, err := u.repo.FindBy(member.ListId)
+		if err != nil {
+			continue
+		}
+		output = append(output, &InvitationOutput{
+			ListId:   list.Id,
+			ListName: list.Name,
+			Owner:    list.Owner,
+			Role:     member.Role,
+		})
+	}
+
+	return output
+}
+
+// AcceptInvitation gives the user access to the list they were invited to.
+func (u *ListsUsecase) AcceptInvitation(user string, listId int) (*MemberOutput, error) {
+	member, err := u.members.FindMember(listId, user)
+	if err != nil || member.Accepted {
+		return nil, ErrorInvitationNotFound
+	}
+
+	member.Accepted = true
+	updated, err := u.members.Update(member)
+	if err != nil {
+		return nil, err
+	}
+	return toMemberOutput(updated), nil
+}
+
+// CanRead reports whether the user may see tasks of the list. Only the owner
+// and members are allowed; nobody is for the inbox, whose tasks belong to
+// their own users, nor for lists that do not exist or have no owner.
+func (u *ListsUsecase) CanRead(user string, listId int) bool {
+	return u.can(user, listId, (*entity.Member).CanRead)
+}
+
+// Readers returns the users who may see tasks of the list: its owner, and
+// the members who accepted.
+func (u *ListsUsecase) Readers(listId int) []string {
+	list, err := u.repo.FindBy(listId)
+	if err != nil {
+		return nil
+	}
+
+	var readers []string
+	if list.Owner != "" {
+		readers = append(readers, list.Owner)
+	}
+	for _, member := range u.members.ListByList(listId) {
+		if member.CanRead() {
+			readers = append(readers, member.User)
+		}
+	}
+	return readers
+}
+
+// CanWrite reports whether the user may change tasks of the list.
+func (u *ListsUsecase) CanWrite(user string, listId int) bool {
+	return u.can(user, listId, (*entity.Member).CanWrite)
+}
+
+func (u *ListsUsecase) can(user string, listId int, allowed func(*entity.Member) bool) bool {
+	if listId == entity.InboxId {
+		return false
+	}
+	list, err := u.repo.FindBy(listId)
+	if err != nil {
+		return false
+	}
+	if list.IsOwnedBy(user) {
+		return true
+	}
+
+	member, err := u.members.FindMember(listId, user)
+	return err == nil && allowed(member)
+}
+
+// find returns the list, as if it did not exist when the user may not see it.
+func (u *ListsUsecase) find(user string, id int) (*entity.List, error) {
+	list, err := u.repo.FindBy(id)
+	if err != nil {
+		return nil, err
+	}
+	if !u.CanRead(user, id) {
+		return nil, repository.ErrorNotFound
+	}
+	return list, nil
+}
+
+func toMemberOutput(m *entity.Member) *MemberOutput {
+	return &MemberOutput{
+		User:     m.User,
+		Role:     m.Role,
+		Accepted: m.Accepted,
+	}
+}
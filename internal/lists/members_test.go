@@ -0,0 +1,210 @@
+package lists_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/lists"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func initMembersUsecase() (*lists.ListsUsecase, *util.MockMemberRepository) {
+	repo := util.InitMockListRepository()
+	repo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "alice"})
+	repo.PopulateData(repository.ListSchema{Id: 2, Name: "工作"})
+	members := util.InitMockMemberRepository()
+	return lists.InitListsUsecase(repo, members), members
+}
+
+func Test_InviteMember(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []repository.MemberSchema
+		user     string
+		listId   int
+		member   string
+		payload  lists.MemberInput
+		expected *lists.MemberOutput
+		error    error
+	}{
+		{
+			name:     "returns pending member",
+			user:     "alice",
+			listId:   1,
+			member:   "bob",
+			payload:  lists.MemberInput{Role: "viewer"},
+			expected: &lists.MemberOutput{User: "bob", Role: "viewer"},
+		},
+		{
+			name:     "changes role of an existing member",
+			data:     []repository.MemberSchema{{Id: 1, ListId: 1, User: "bob", Role: "viewer", Accepted: true}},
+			user:     "alice",
+			listId:   1,
+			member:   "bob",
+			payload:  lists.MemberInput{Role: "editor"},
+			expected: &lists.MemberOutput{User: "bob", Role: "editor", Accepted: true},
+		},
+		{
+			name:    "returns error on unknown role",
+			user:    "alice",
+			listId:  1,
+			member:  "bob",
+			payload: lists.MemberInput{Role: "owner"},
+			error:   lists.ErrorInvalidRole,
+		},
+		{
+			name:    "returns error when inviting the owner",
+			user:    "alice",
+			listId:  1,
+			member:  "alice",
+			payload: lists.MemberInput{Role: "editor"},
+			error:   lists.ErrorInvalidMember,
+		},
+		{
+			name:    "returns error when not the owner",
+			data:    []repository.MemberSchema{{Id: 1, ListId: 1, User: "bob", Role: "editor", Accepted: true}},
+			user:    "bob",
+			listId:  1,
+			member:  "carol",
+			payload: lists.MemberInput{Role: "viewer"},
+			error:   lists.ErrorNotOwner,
+		},
+		{
+			name:    "returns error when the list is hidden",
+			user:    "bob",
+			listId:  1,
+			member:  "carol",
+			payload: lists.MemberInput{Role: "viewer"},
+			error:   repository.ErrorNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase, members := initMembersUsecase()
+			for _, row := range tc.data {
+				members.PopulateData(row)
+			}
+			got, err := usecase.InviteMember(tc.user, tc.listId, tc.member, &tc.payload)
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+}
+
+func Test_AcceptInvitation(t *testing.T) {
+	t.Parallel()
+
+	usecase, _ := initMembersUsecase()
+	if _, err := usecase.InviteMember("alice", 1, "bob", &lists.MemberInput{Role: "editor"}); err != nil {
+		t.Fatal(err)
+	}
+
+	util.AssertEqual(t)(usecase.CanRead("bob", 1), false)
+	util.AssertEqual(t)(usecase.ListInvitations("bob"), []*lists.InvitationOutput{
+		{ListId: 1, ListName: "家事", Owner: "alice", Role: "editor"},
+	})
+
+	got, err := usecase.AcceptInvitation("bob", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	util.AssertEqual(t)(*got, lists.MemberOutput{User: "bob", Role: "editor", Accepted: true})
+	util.AssertEqual(t)(usecase.CanWrite("bob", 1), true)
+	util.AssertEqual(t)(usecase.ListInvitations("bob"), []*lists.InvitationOutput{})
+
+	_, err = usecase.AcceptInvitation("bob", 1)
+	util.AssertErrorEqual(t)(err, lists.ErrorInvitationNotFound)
+}
+
+func Test_RemoveMember(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   string
+		member string
+		error  error
+	}{
+		{name: "owner removes a member", user: "alice", member: "bob"},
+		{name: "member removes themself", user: "bob", member: "bob"},
+		{name: "returns error when another member removes", user: "carol", member: "bob", error: lists.ErrorNotOwner},
+		{name: "returns error when a stranger removes", user: "dave", member: "bob", error: repository.ErrorNotFound},
+		{name: "returns error on unknown member", user: "alice", member: "dave", error: lists.ErrorMemberNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase, members := initMembersUsecase()
+			members.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "bob", Role: "editor", Accepted: true})
+			members.PopulateData(repository.MemberSchema{Id: 2, ListId: 1, User: "carol", Role: "viewer", Accepted: true})
+
+			err := usecase.RemoveMember(tc.user, 1, tc.member)
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			if tc.error == nil {
+				util.AssertEqual(t)(usecase.CanRead(tc.member, 1), false)
+			}
+		})
+	}
+}
+
+func Test_CanReadAndWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		listId   int
+		canRead  bool
+		canWrite bool
+	}{
+		{name: "owner", user: "alice", listId: 1, canRead: true, canWrite: true},
+		{name: "editor", user: "bob", listId: 1, canRead: true, canWrite: true},
+		{name: "viewer", user: "carol", listId: 1, canRead: true, canWrite: false},
+		{name: "pending invitee", user: "dave", listId: 1, canRead: false, canWrite: false},
+		{name: "stranger", user: "erin", listId: 1, canRead: false, canWrite: false},
+		{name: "inbox", user: "erin", listId: 0, canRead: false, canWrite: false},
+		{name: "list without owner", user: "erin", listId: 2, canRead: false, canWrite: false},
+		{name: "list not found", user: "erin", listId: 3, canRead: false, canWrite: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase, members := initMembersUsecase()
+			members.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "bob", Role: "editor", Accepted: true})
+			members.PopulateData(repository.MemberSchema{Id: 2, ListId: 1, User: "carol", Role: "viewer", Accepted: true})
+			members.PopulateData(repository.MemberSchema{Id: 3, ListId: 1, User: "dave", Role: "editor"})
+
+			util.AssertEqual(t)(usecase.CanRead(tc.user, tc.listId), tc.canRead)
+			util.AssertEqual(t)(usecase.CanWrite(tc.user, tc.listId), tc.canWrite)
+		})
+	}
+}
+
+func Test_Readers(t *testing.T) {
+	tests := []struct {
+		name     string
+		listId   int
+		expected []string
+	}{
+		{name: "owner and accepted members", listId: 1, expected: []string{"alice", "bob"}},
+		{name: "list without owner", listId: 2, expected: []string(nil)},
+		{name: "list not found", listId: 3, expected: []string(nil)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase, members := initMembersUsecase()
+			members.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "bob", Role: "viewer", Accepted: true})
+			members.PopulateData(repository.MemberSchema{Id: 2, ListId: 1, User: "dave", Role: "editor"})
+
+			util.AssertEqual(t)(usecase.Readers(tc.listId), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package lists
+
+import (
+	"errors"
+
+	entity "github.com/dannyh79/whostodo/internal/lists/entities"
+	"github.com/dannyh79/whostodo/internal/repository"
+)
+
+const (
+	// Deleting a list moves its tasks into the inbox.
+	MoveToInbox = "inbox"
+	// Deleting a list moves its tasks into the trash.
+	Cascade = "cascade"
+)
+
+var (
+	ErrorUnknownDeleteMode = errors.New("Unknown delete mode")
+	ErrorNotOwner          = errors.New("Only the owner can change the list")
+)
+
+type ListOutput struct {
+	Id    int    `json:"id"`
+	Name  string `json:"name"`
+	Owner string `json:"owner,omitempty"`
+}
+
+type CreateListInput struct {
+	Name string `json:"name"`
+}
+
+type UpdateListInput struct {
+	Name string `json:"name"`
+}
+
+type DeleteListInput struct {
+	// Either MoveToInbox or Cascade; empty defaults to MoveToInbox.
+	Tasks string `form:"tasks"`
+}
+
+type ListRepository repository.Repository[entity.List]
+
+type MemberRepository interface {
+	repository.Repository[entity.Member]
+	FindMember(listId int, user string) (*entity.Member, error)
+	ListByList(listId int) []*entity.Member
+	ListByUser(user string) []*entity.Member
+	DeleteByList(listId int) int
+}
+
+// ListsUsecase manages lists and whom they are shared with. Lists are only
+// visible to their owner and the members who accepted an invitation.
+type ListsUsecase struct {
+	repo    ListRepository
+	members MemberRepository
+}
+
+// ListLists returns the inbox followed by stored lists the user may see.
+func (u *ListsUsecase) ListLists(user string) []*ListOutput {
+	var output = []*ListOutput{{Id: entity.InboxId, Name: entity.InboxName}}
+
+	lists := u.repo.ListAll()
+	for _, list := range lists {
+		if u.CanRead(user, list.Id) {
+			output = append(output, toListOutput(list))
+		}
+	}
+
+	return output
+}
+
+func (u *ListsUsecase) CreateList(user string, i *CreateListInput) *ListOutput {
+	list := u.repo.Save(&entity.List{Name: i.Name, Owner: user})
+	return toListOutput(&list)
+}
+
+// UpdateList renames the list; only its owner may.
+func (u *ListsUsecase) UpdateList(user string, id int, i *UpdateListInput) (*ListOutput, error) {
+	list, err := u.findOwned(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	list.Name = i.Name
+
+	updated, err := u.repo.Update(list)
+	if err != nil {
+		return nil, err
+	}
+
+	return toListOutput(updated), nil
+}
+
+// DeleteList removes the list along with its members; only its owner may.
+// Its tasks are to be moved out beforehand, by the tasks usecase's ClearList,
+// once CheckDeleteList passed.
+func (u *ListsUsecase) DeleteList(user string, id int, i *DeleteListInput) error {
+	list, err := u.findDeletable(user, id, i)
+	if err != nil {
+		return err
+	}
+
+	u.members.DeleteByList(list.Id)
+	return u.repo.Delete(list)
+}
+
+// CheckDeleteList reports whether the user may delete the list as asked.
+func (u *ListsUsecase) CheckDeleteList(user string, id int, i *DeleteListInput) error {
+	_, err := u.findDeletable(user, id, i)
+	return err
+}
+
+// findDeletable returns the list if the user owns it and the delete mode is
+// known.
+func (u *ListsUsecase) findDeletable(user string, id int, i *DeleteListInput) (*entity.List, error) {
+	if i.Tasks != "" && i.Tasks != MoveToInbox && i.Tasks != Cascade {
+		return nil, ErrorUnknownDeleteMode
+	}
+	return u.findOwned(user, id)
+}
+
+// findOwned returns the list if the user owns it.
+func (u *ListsUsecase) findOwned(user string, id int) (*entity.List, error) {
+	list, err := u.find(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if !list.IsOwnedBy(user) {
+		return nil, ErrorNotOwner
+	}
+	return list, nil
+}
+
+func InitListsUsecase(repo ListRepository, members MemberRepository) *ListsUsecase {
+	return &ListsUsecase{repo, members}
+}
+
+func toListOutput(l *entity.List) *ListOutput {
+	return &ListOutput{
+		Id:    l.Id,
+		Name:  l.Name,
+		Owner: l.Owner,
+	}
+}
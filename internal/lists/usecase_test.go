@@ -0,0 +1,196 @@
+package lists_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/lists"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_ListLists(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []repository.ListSchema
+		expected []*lists.ListOutput
+	}{
+		{
+			name: "returns inbox followed by lists",
+			data: []repository.ListSchema{{Id: 1, Name: "家事", Owner: "alice"}},
+			expected: []*lists.ListOutput{
+				{Id: 0, Name: "Inbox"},
+				{Id: 1, Name: "家事", Owner: "alice"},
+			},
+		},
+		{
+			name:     "leaves out lists without owner",
+			data:     []repository.ListSchema{{Id: 1, Name: "家事"}},
+			expected: []*lists.ListOutput{{Id: 0, Name: "Inbox"}},
+		},
+		{
+			name:     "returns inbox only",
+			expected: []*lists.ListOutput{{Id: 0, Name: "Inbox"}},
+		},
+		{
+			name: "returns lists owned by the user",
+			data: []repository.ListSchema{{Id: 1, Name: "家事", Owner: "alice"}, {Id: 2, Name: "工作", Owner: "bob"}},
+			expected: []*lists.ListOutput{
+				{Id: 0, Name: "Inbox"},
+				{Id: 1, Name: "家事", Owner: "alice"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockListRepository()
+			for _, row := range tc.data {
+				repo.PopulateData(row)
+			}
+			usecase := lists.InitListsUsecase(repo, util.InitMockMemberRepository())
+			got := usecase.ListLists("alice")
+
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+}
+
+func Test_CreateList(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockListRepository()
+	usecase := lists.InitListsUsecase(repo, util.InitMockMemberRepository())
+	got := usecase.CreateList("alice", &lists.CreateListInput{Name: "家事"})
+
+	util.AssertEqual(t)(*got, lists.ListOutput{Id: 1, Name: "家事", Owner: "alice"})
+}
+
+func Test_UpdateList(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        repository.ListSchema
+		param       int
+		payload     lists.UpdateListInput
+		expected    lists.ListOutput
+		expectError bool
+		error       error
+	}{
+		{
+			name:     "returns updated list",
+			data:     repository.ListSchema{Id: 1, Name: "家事", Owner: "alice"},
+			param:    1,
+			payload:  lists.UpdateListInput{Name: "工作"},
+			expected: lists.ListOutput{Id: 1, Name: "工作", Owner: "alice"},
+		},
+		{
+			name:        "returns error when not the owner",
+			data:        repository.ListSchema{Id: 1, Name: "家事", Owner: "bob"},
+			param:       1,
+			payload:     lists.UpdateListInput{Name: "工作"},
+			expectError: true,
+			error:       repository.ErrorNotFound,
+		},
+		{
+			name:        "returns error",
+			data:        repository.ListSchema{Id: 1, Name: "家事"},
+			param:       2,
+			payload:     lists.UpdateListInput{Name: "工作"},
+			expectError: true,
+			error:       util.MockNotFoundError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockListRepository()
+			repo.PopulateData(tc.data)
+			usecase := lists.InitListsUsecase(repo, util.InitMockMemberRepository())
+			got, err := usecase.UpdateList("alice", tc.param, &tc.payload)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				if err != nil {
+					t.Error(err)
+				}
+				util.AssertEqual(t)(*got, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_DeleteList(t *testing.T) {
+	tests := []struct {
+		name        string
+		param       int
+		payload     lists.DeleteListInput
+		expectError bool
+		error       error
+	}{
+		{
+			name:    "deletes list moving tasks into the inbox",
+			param:   1,
+			payload: lists.DeleteListInput{},
+		},
+		{
+			name:    "deletes list moving tasks into the trash",
+			param:   1,
+			payload: lists.DeleteListInput{Tasks: lists.Cascade},
+		},
+		{
+			name:        "returns error on unknown mode",
+			param:       1,
+			payload:     lists.DeleteListInput{Tasks: "elsewhere"},
+			expectError: true,
+			error:       lists.ErrorUnknownDeleteMode,
+		},
+		{
+			name:        "returns error when not owner",
+			param:       2,
+			payload:     lists.DeleteListInput{},
+			expectError: true,
+			error:       lists.ErrorNotOwner,
+		},
+		{
+			name:        "returns error when not found",
+			param:       3,
+			payload:     lists.DeleteListInput{},
+			expectError: true,
+			error:       util.MockNotFoundError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockListRepository()
+			repo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "alice"})
+			repo.PopulateData(repository.ListSchema{Id: 2, Name: "工作", Owner: "bob"})
+			members := util.InitMockMemberRepository()
+			members.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "bob", Role: "editor", Accepted: true})
+			members.PopulateData(repository.MemberSchema{Id: 2, ListId: 2, User: "alice", Role: "editor", Accepted: true})
+			usecase := lists.InitListsUsecase(repo, members)
+
+			checked := usecase.CheckDeleteList("alice", tc.param, &tc.payload)
+			err := usecase.DeleteList("alice", tc.param, &tc.payload)
+
+			util.AssertErrorEqual(t)(checked, tc.error)
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+				util.AssertEqual(t)(len(repo.Data), 2)
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			util.AssertEqual(t)(len(repo.Data), 1)
+			util.AssertEqual(t)(len(members.ListByList(1)), 0)
+			util.AssertEqual(t)(len(members.ListByList(2)), 1)
+		})
+	}
+}
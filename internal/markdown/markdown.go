@@ -0,0 +1,172 @@
+// Package markdown renders a subset of Markdown into HTML that is safe to
+// embed in a page.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingPattern     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletPattern      = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedPattern     = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	quotePattern       = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	allowedURLPrefixes = []string{"http://", "https://", "mailto:"}
+)
+
+// Render converts Markdown into HTML. It supports ATX headings, paragraphs,
+// blockquotes, fenced code blocks, flat bullet and ordered lists, emphasis,
+// strong emphasis, inline code and links. Raw HTML in the source is escaped
+// rather than passed through, and links keep only http, https and mailto
+// URLs.
+func Render(src string) string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	return strings.Join(renderBlocks(lines), "\n")
+}
+
+func renderBlocks(lines []string) []string {
+	var out []string
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i += 1
+		case isFence(line):
+			var code []string
+			for i += 1; i < len(lines) && !isFence(lines[i]); i++ {
+				code = append(code, lines[i])
+			}
+			i += 1
+			out = append(out, "<pre><code>"+html.EscapeString(strings.Join(code, "\n"))+"</code></pre>")
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			tag := "h" + string(rune('0'+len(m[1])))
+			out = append(out, "<"+tag+">"+renderInline(m[2])+"</"+tag+">")
+			i += 1
+		case quotePattern.MatchString(line):
+			var quoted []string
+			for ; i < len(lines) && quotePattern.MatchString(lines[i]); i++ {
+				quoted = append(quoted, quotePattern.FindStringSubmatch(lines[i])[1])
+			}
+			out = append(out, "<blockquote>")
+			out = append(out, renderBlocks(quoted)...)
+			out = append(out, "</blockquote>")
+		case bulletPattern.MatchString(line):
+			var items []string
+			items, i = collectItems(lines, i, bulletPattern)
+			out = append(out, renderList("ul", items)...)
+		case orderedPattern.MatchString(line):
+			var items []string
+			items, i = collectItems(lines, i, orderedPattern)
+			out = append(out, renderList("ol", items)...)
+		default:
+			var paragraph []string
+			for ; i < len(lines) && !startsBlock(lines[i]); i++ {
+				paragraph = append(paragraph, strings.TrimSpace(lines[i]))
+			}
+			out = append(out, "<p>"+renderInline(strings.Join(paragraph, "\n"))+"</p>")
+		}
+	}
+	return out
+}
+
+func startsBlock(line string) bool {
+	return strings.TrimSpace(line) == "" ||
+		isFence(line) ||
+		headingPattern.MatchString(line) ||
+		quotePattern.MatchString(line) ||
+		bulletPattern.MatchString(line) ||
+		orderedPattern.MatchString(line)
+}
+
+func isFence(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+func collectItems(lines []string, i int, pattern *regexp.Regexp) ([]string, int) {
+	var items []string
+	for ; i < len(lines) && pattern.MatchString(lines[i]); i++ {
+		items = append(items, pattern.FindStringSubmatch(lines[i])[1])
+	}
+	return items, i
+}
+
+func renderList(tag string, items []string) []string {
+	out := []string{"<" + tag + ">"}
+	for _, item := range items {
+		out = append(out, "<li>"+renderInline(item)+"</li>")
+	}
+	return append(out, "</"+tag+">")
+}
+
+// renderInline renders spans within a block, escaping everything else.
+func renderInline(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_[]()#+-.!>", rune(rest[1])):
+			b.WriteString(html.EscapeString(rest[1:2]))
+			i += 2
+			continue
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end >= 0 {
+				b.WriteString("<code>" + html.EscapeString(rest[1:1+end]) + "</code>")
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if end := strings.Index(rest[2:], rest[:2]); end > 0 {
+				b.WriteString("<strong>" + renderInline(rest[2:2+end]) + "</strong>")
+				i += end + 4
+				continue
+			}
+		case rest[0] == '*' || rest[0] == '_':
+			if end := strings.IndexByte(rest[1:], rest[0]); end > 0 {
+				b.WriteString("<em>" + renderInline(rest[1:1+end]) + "</em>")
+				i += end + 2
+				continue
+			}
+		case rest[0] == '[':
+			if label, url, n, ok := parseLink(rest); ok {
+				if isAllowedURL(url) {
+					b.WriteString(`<a href="` + html.EscapeString(url) + `">` + renderInline(label) + "</a>")
+				} else {
+					b.WriteString(renderInline(label))
+				}
+				i += n
+				continue
+			}
+		}
+		b.WriteString(html.EscapeString(rest[:1]))
+		i += 1
+	}
+	return b.String()
+}
+
+// parseLink parses "[label](url)" at the start of text, returning how many
+// bytes it spans.
+func parseLink(text string) (string, string, int, bool) {
+	closing := strings.Index(text, "](")
+	if closing < 0 {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(text[closing+2:], ')')
+	if end < 0 {
+		return "", "", 0, false
+	}
+	url := strings.TrimSpace(text[closing+2 : closing+2+end])
+	return text[1:closing], url, closing + 3 + end, true
+}
+
+func isAllowedURL(url string) bool {
+	lower := strings.ToLower(url)
+	for _, prefix := range allowedURLPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
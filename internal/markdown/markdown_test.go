@@ -0,0 +1,75 @@
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/markdown"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_Render(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name:     "renders paragraphs",
+			src:      "買晚餐\n記得帶袋子\n\n順便買水果",
+			expected: "<p>買晚餐\n記得帶袋子</p>\n<p>順便買水果</p>",
+		},
+		{
+			name:     "renders headings",
+			src:      "## 購物清單 ##",
+			expected: "<h2>購物清單</h2>",
+		},
+		{
+			name:     "renders inline spans",
+			src:      "**must** buy *fresh* `milk_2`",
+			expected: "<p><strong>must</strong> buy <em>fresh</em> <code>milk_2</code></p>",
+		},
+		{
+			name:     "renders lists",
+			src:      "- 牛奶\n- 雞蛋\n\n1. 洗菜\n2. 煮飯",
+			expected: "<ul>\n<li>牛奶</li>\n<li>雞蛋</li>\n</ul>\n<ol>\n<li>洗菜</li>\n<li>煮飯</li>\n</ol>",
+		},
+		{
+			name:     "renders blockquotes",
+			src:      "> **注意**\n> 別忘了",
+			expected: "<blockquote>\n<p><strong>注意</strong>\n別忘了</p>\n</blockquote>",
+		},
+		{
+			name:     "renders fenced code escaped",
+			src:      "```go\nif a < b {}\n```",
+			expected: "<pre><code>if a &lt; b {}</code></pre>",
+		},
+		{
+			name:     "renders links",
+			src:      "see [the menu](https://example.com/?a=1&b=2)",
+			expected: `<p>see <a href="https://example.com/?a=1&amp;b=2">the menu</a></p>`,
+		},
+		{
+			name:     "drops unsafe link targets",
+			src:      "[click](javascript:alert(1))",
+			expected: "<p>click)</p>",
+		},
+		{
+			name:     "escapes raw HTML",
+			src:      `<script>alert("x")</script> & <b onclick=x>`,
+			expected: "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; &lt;b onclick=x&gt;</p>",
+		},
+		{
+			name:     "keeps escaped markers literal",
+			src:      `\*not emphasis\*`,
+			expected: "<p>*not emphasis*</p>",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			util.AssertEqual(t)(markdown.Render(tc.src), tc.expected)
+		})
+	}
+}
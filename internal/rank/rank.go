@@ -0,0 +1,106 @@
+// Package rank generates strings that sort between two others, so an item can
+// be reordered by rewriting only its own rank.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+// Digits are in ascending byte order so ranks compare as plain strings.
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Width is the length of ranks generated by After. Consecutive ones differ
+// in the digit before the last two, leaving room for about a dozen ranks in
+// between before any grows longer.
+const Width = 6
+
+const step = len(digits)
+
+// MaxLength is how long a rank may grow by inserting in between before the
+// items around it should get fresh ranks from After.
+const MaxLength = 12
+
+var ErrorOutOfOrder = errors.New("Ranks out of order")
+
+// Between returns a rank sorting after prev and before next; an empty prev
+// stands for the start and an empty next for the end. Generated ranks never
+// end in the lowest digit, so there is always room before them.
+func Between(prev string, next string) (string, error) {
+	if next != "" && prev >= next {
+		return "", ErrorOutOfOrder
+	}
+	return midpoint(prev, next), nil
+}
+
+// After returns a rank sorting after prev, Width long, so appending never
+// makes ranks longer. An empty prev starts in the middle, leaving as much
+// room before the first rank as after it. Only past the last rank of Width
+// digits do ranks grow longer.
+func After(prev string) string {
+	middle := string(digits[len(digits)/2])
+	if prev == "" {
+		return middle + strings.Repeat(string(digits[0]), Width-2) + middle
+	}
+
+	// The last digit stays in the middle, so generated ranks keep not ending
+	// in the lowest digit.
+	values := make([]int, Width-1)
+	for i := range values {
+		values[i] = strings.IndexByte(digits, digitAt(prev, i))
+	}
+	carry := step
+	for i := len(values) - 1; i >= 0 && carry > 0; i-- {
+		sum := values[i] + carry
+		values[i] = sum % len(digits)
+		carry = sum / len(digits)
+	}
+	if carry > 0 {
+		return midpoint(prev, "")
+	}
+
+	next := make([]byte, 0, Width)
+	for _, v := range values {
+		next = append(next, digits[v])
+	}
+	return string(next) + middle
+}
+
+// midpoint treats both ranks as base-62 fractions, next being 1 when empty.
+func midpoint(prev string, next string) string {
+	var n int
+	for n < len(next) && digitAt(prev, n) == next[n] {
+		n += 1
+	}
+	if n > 0 {
+		return next[:n] + midpoint(tail(prev, n), next[n:])
+	}
+
+	low := strings.IndexByte(digits, digitAt(prev, 0))
+	high := len(digits)
+	if next != "" {
+		high = strings.IndexByte(digits, next[0])
+	}
+	if high-low > 1 {
+		return string(digits[(low+high)/2])
+	}
+	if len(next) > 1 {
+		return next[:1]
+	}
+	return string(digits[low]) + midpoint(tail(prev, 1), "")
+}
+
+// digitAt reads prev as if padded with the lowest digit.
+func digitAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return digits[0]
+}
+
+func tail(s string, i int) string {
+	if i < len(s) {
+		return s[i:]
+	}
+	return ""
+}
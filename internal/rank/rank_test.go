@@ -0,0 +1,95 @@
+package rank_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/rank"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_Between(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     string
+		next     string
+		expected string
+	}{
+		{name: "returns middle digit when unbounded", prev: "", next: "", expected: "V"},
+		{name: "returns rank after prev", prev: "V", next: "", expected: "k"},
+		{name: "returns rank before next", prev: "", next: "V", expected: "F"},
+		{name: "returns longer rank between adjacent digits", prev: "V", next: "W", expected: "VV"},
+		{name: "returns rank under common prefix", prev: "V1", next: "V3", expected: "V2"},
+		{name: "returns prefix of longer next", prev: "V", next: "WV", expected: "W"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := rank.Between(tc.prev, tc.next)
+
+			util.AssertErrorEqual(t)(err, nil)
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+
+	t.Run("returns error when out of order", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := rank.Between("W", "V")
+
+		util.AssertErrorEqual(t)(err, rank.ErrorOutOfOrder)
+	})
+
+	t.Run("keeps ranks in order when inserting repeatedly", func(t *testing.T) {
+		t.Parallel()
+
+		prev, next := rank.After(""), rank.After(rank.After(""))
+		for i := 0; i < 200; i++ {
+			mid, err := rank.Between(prev, next)
+			if err != nil || mid <= prev || mid >= next {
+				t.Fatalf("%q is not between %q and %q", mid, prev, next)
+			}
+			if i%2 == 0 {
+				prev = mid
+			} else {
+				next = mid
+			}
+		}
+	})
+}
+
+func Test_After(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     string
+		expected string
+	}{
+		{name: "returns middle rank when unbounded", prev: "", expected: "V0000V"},
+		{name: "returns rank a step after prev", prev: "V0000V", expected: "V0010V"},
+		{name: "carries into higher digits", prev: "V0zz0V", expected: "V1000V"},
+		{name: "returns rank of the same width after a longer prev", prev: "V0010VV1", expected: "V0020V"},
+		{name: "returns longer rank past the last of the same width", prev: "zzzz0V", expected: "zzzzV"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			util.AssertEqual(t)(rank.After(tc.prev), tc.expected)
+		})
+	}
+
+	t.Run("keeps ranks short when appending repeatedly", func(t *testing.T) {
+		t.Parallel()
+
+		prev := ""
+		for i := 0; i < 1000; i++ {
+			next := rank.After(prev)
+			if next <= prev || len(next) != rank.Width {
+				t.Fatalf("%q is not a rank of width %d after %q", next, rank.Width, prev)
+			}
+			prev = next
+		}
+	})
+}
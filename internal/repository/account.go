@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/sessions/entities"
+)
+
+type Account = entity.Account
+
+type AccountSchema struct {
+	User         string
+	PasswordHash []byte
+	Workspaces   []string
+	CreatedAt    time.Time
+}
+
+type InMemoryAccountRepository struct {
+	data map[string]AccountSchema
+}
+
+// ListAll returns the accounts by user.
+func (r *InMemoryAccountRepository) ListAll() []*Account {
+	var accounts []*Account
+	for _, row := range r.data {
+		accounts = append(accounts, toAccount(row))
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].User < accounts[j].User })
+	return accounts
+}
+
+func (r *InMemoryAccountRepository) Save(a *Account) Account {
+	r.data[a.User] = *toAccountSchema(a)
+	return *a
+}
+
+func (r *InMemoryAccountRepository) FindBy(user any) (*Account, error) {
+	row, ok := r.data[user.(string)]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	return toAccount(row), nil
+}
+
+func (r *InMemoryAccountRepository) Update(a *Account) (*Account, error) {
+	if _, ok := r.data[a.User]; !ok {
+		return nil, ErrorNotFound
+	}
+
+	r.data[a.User] = *toAccountSchema(a)
+	return toAccount(r.data[a.User]), nil
+}
+
+func (r *InMemoryAccountRepository) Delete(a *Account) error {
+	if _, ok := r.data[a.User]; !ok {
+		return ErrorNotFound
+	}
+
+	delete(r.data, a.User)
+	return nil
+}
+
+// Dump returns the accounts by user.
+func (r *InMemoryAccountRepository) Dump() []AccountSchema {
+	rows := make([]AccountSchema, 0, len(r.data))
+	for _, row := range r.data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].User < rows[j].User })
+	return rows
+}
+
+func (r *InMemoryAccountRepository) Load(rows []AccountSchema) {
+	r.data = make(map[string]AccountSchema, len(rows))
+	for _, row := range rows {
+		r.data[row.User] = row
+	}
+}
+
+func InitInMemoryAccountRepository() *InMemoryAccountRepository {
+	return &InMemoryAccountRepository{
+		data: map[string]AccountSchema{},
+	}
+}
+
+func toAccount(s AccountSchema) *Account {
+	return &Account{
+		User:         s.User,
+		PasswordHash: append([]byte(nil), s.PasswordHash...),
+		Workspaces:   append([]string(nil), s.Workspaces...),
+		CreatedAt:    s.CreatedAt,
+	}
+}
+
+func toAccountSchema(a *Account) *AccountSchema {
+	return &AccountSchema{
+		User:         a.User,
+		PasswordHash: append([]byte(nil), a.PasswordHash...),
+		Workspaces:   append([]string(nil), a.Workspaces...),
+		CreatedAt:    a.CreatedAt,
+	}
+}
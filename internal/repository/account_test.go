@@ -0,0 +1,64 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/sessions/entities"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_InMemoryAccountRepository(t *testing.T) {
+	t.Run("finds a saved account", func(t *testing.T) {
+		t.Parallel()
+
+		repo := repository.InitInMemoryAccountRepository()
+		account := &entity.Account{User: "alice", PasswordHash: []byte("hash")}
+		repo.Save(account)
+
+		got, err := repo.FindBy("alice")
+
+		util.AssertEqual(t)(got, account)
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(repo.ListAll(), []*entity.Account{account})
+	})
+
+	t.Run("returns error when not found", func(t *testing.T) {
+		t.Parallel()
+
+		repo := repository.InitInMemoryAccountRepository()
+
+		_, err := repo.FindBy("alice")
+
+		util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+	})
+
+	t.Run("updates an account", func(t *testing.T) {
+		t.Parallel()
+
+		repo := repository.InitInMemoryAccountRepository()
+		repo.Save(&entity.Account{User: "alice", PasswordHash: []byte("hash")})
+
+		got, err := repo.Update(&entity.Account{User: "alice", PasswordHash: []byte("new_hash")})
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(got.PasswordHash, []byte("new_hash"))
+		_, err = repo.Update(&entity.Account{User: "bob"})
+		util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+	})
+
+	t.Run("deletes an account", func(t *testing.T) {
+		t.Parallel()
+
+		repo := repository.InitInMemoryAccountRepository()
+		account := &entity.Account{User: "alice", PasswordHash: []byte("hash")}
+		repo.Save(account)
+
+		err := repo.Delete(account)
+		_, findErr := repo.FindBy("alice")
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertErrorEqual(t)(findErr, repository.ErrorNotFound)
+		util.AssertErrorEqual(t)(repo.Delete(account), repository.ErrorNotFound)
+	})
+}
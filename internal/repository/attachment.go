@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/attachments/entities"
+)
+
+type AttachmentSchema struct {
+	Id          int
+	TaskId      int
+	Name        string
+	ContentType string
+	Size        int64
+	BlobKey     string
+	Uploader    string
+	CreatedAt   time.Time
+}
+
+type InMemoryAttachmentRepository struct {
+	position int
+	data     map[int]AttachmentSchema
+}
+
+func (r *InMemoryAttachmentRepository) ListAll() []*entity.Attachment {
+	var attachments []*entity.Attachment
+	for _, row := range r.data {
+		attachments = append(attachments, toAttachment(row))
+	}
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].Id < attachments[j].Id })
+	return attachments
+}
+
+func (r *InMemoryAttachmentRepository) NextId() int {
+	r.position += 1
+	return r.position
+}
+
+func (r *InMemoryAttachmentRepository) Save(a *entity.Attachment) entity.Attachment {
+	a.Id = r.NextId()
+	row := *toAttachmentSchema(a)
+	r.data[row.Id] = row
+	return *toAttachment(row)
+}
+
+func (r *InMemoryAttachmentRepository) FindBy(id any) (*entity.Attachment, error) {
+	row, ok := r.data[id.(int)]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	return toAttachment(row), nil
+}
+
+func (r *InMemoryAttachmentRepository) Update(a *entity.Attachment) (*entity.Attachment, error) {
+	_, ok := r.data[a.Id]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	r.data[a.Id] = *toAttachmentSchema(a)
+	return toAttachment(r.data[a.Id]), nil
+}
+
+func (r *InMemoryAttachmentRepository) Delete(a *entity.Attachment) error {
+	_, ok := r.data[a.Id]
+	if !ok {
+		return ErrorNotFound
+	}
+
+	delete(r.data, a.Id)
+	return nil
+}
+
+func (r *InMemoryAttachmentRepository) ListByTask(taskId int) []*entity.Attachment {
+	var attachments []*entity.Attachment
+	for _, a := range r.ListAll() {
+		if a.TaskId == taskId {
+			attachments = append(attachments, a)
+		}
+	}
+	return attachments
+}
+
+func (r *InMemoryAttachmentRepository) DeleteByTask(taskId int) int {
+	var deleted int
+	for id, row := range r.data {
+		if row.TaskId == taskId {
+			delete(r.data, id)
+			deleted += 1
+		}
+	}
+	return deleted
+}
+
+func (r *InMemoryAttachmentRepository) Dump() []AttachmentSchema {
+	rows := make([]AttachmentSchema, 0, len(r.data))
+	for _, row := range r.data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+func (r *InMemoryAttachmentRepository) Load(rows []AttachmentSchema) {
+	r.data = make(map[int]AttachmentSchema, len(rows))
+	r.position = 0
+	for _, row := range rows {
+		r.data[row.Id] = row
+		r.position = max(r.position, row.Id)
+	}
+}
+
+func InitInMemoryAttachmentRepository() *InMemoryAttachmentRepository {
+	return &InMemoryAttachmentRepository{
+		data: map[int]AttachmentSchema{},
+	}
+}
+
+func toAttachment(row AttachmentSchema) *entity.Attachment {
+	return &entity.Attachment{
+		Id:          row.Id,
+		TaskId:      row.TaskId,
+		Name:        row.Name,
+		ContentType: row.ContentType,
+		Size:        row.Size,
+		BlobKey:     row.BlobKey,
+		Uploader:    row.Uploader,
+		CreatedAt:   row.CreatedAt,
+	}
+}
+
+func toAttachmentSchema(a *entity.Attachment) *AttachmentSchema {
+	return &AttachmentSchema{
+		Id:          a.Id,
+		TaskId:      a.TaskId,
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		BlobKey:     a.BlobKey,
+		Uploader:    a.Uploader,
+		CreatedAt:   a.CreatedAt,
+	}
+}
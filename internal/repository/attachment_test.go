@@ -0,0 +1,27 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/attachments/entities"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_InMemoryAttachmentRepository(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.InitInMemoryAttachmentRepository()
+	first := repo.Save(&entity.Attachment{TaskId: 1, Name: "receipt.png", BlobKey: "a"})
+	repo.Save(&entity.Attachment{TaskId: 2, Name: "menu.pdf", BlobKey: "b"})
+	repo.Save(&entity.Attachment{TaskId: 1, Name: "list.txt", BlobKey: "c"})
+
+	util.AssertEqual(t)(first.Id, 1)
+	util.AssertEqual(t)(len(repo.ListByTask(1)), 2)
+
+	util.AssertEqual(t)(repo.DeleteByTask(1), 2)
+	util.AssertEqual(t)(len(repo.ListAll()), 1)
+
+	_, err := repo.FindBy(first.Id)
+	util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+}
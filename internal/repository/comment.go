@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/comments/entities"
+)
+
+type CommentSchema struct {
+	Id        int
+	TaskId    int
+	Author    string
+	Body      string
+	CreatedAt time.Time
+	EditedAt  time.Time
+}
+
+type InMemoryCommentRepository struct {
+	position int
+	data     map[int]CommentSchema
+}
+
+func (r *InMemoryCommentRepository) ListAll() []*entity.Comment {
+	var comments []*entity.Comment
+	for _, row := range r.data {
+		comments = append(comments, toComment(row))
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].Id < comments[j].Id })
+	return comments
+}
+
+func (r *InMemoryCommentRepository) NextId() int {
+	r.position += 1
+	return r.position
+}
+
+func (r *InMemoryCommentRepository) Save(c *entity.Comment) entity.Comment {
+	c.Id = r.NextId()
+	row := *toCommentSchema(c)
+	r.data[row.Id] = row
+	return *toComment(row)
+}
+
+func (r *InMemoryCommentRepository) FindBy(id any) (*entity.Comment, error) {
+	row, ok := r.data[id.(int)]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	return toComment(row), nil
+}
+
+func (r *InMemoryCommentRepository) Update(c *entity.Comment) (*entity.Comment, error) {
+	_, ok := r.data[c.Id]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	r.data[c.Id] = *toCommentSchema(c)
+	return toComment(r.data[c.Id]), nil
+}
+
+func (r *InMemoryCommentRepository) Delete(c *entity.Comment) error {
+	_, ok := r.data[c.Id]
+	if !ok {
+		return ErrorNotFound
+	}
+
+	delete(r.data, c.Id)
+	return nil
+}
+
+func (r *InMemoryCommentRepository) ListByTask(taskId int) []*entity.Comment {
+	var comments []*entity.Comment
+	for _, c := range r.ListAll() {
+		if c.TaskId == taskId {
+			comments = append(comments, c)
+		}
+	}
+	return comments
+}
+
+func (r *InMemoryCommentRepository) DeleteByTask(taskId int) int {
+	var deleted int
+	for id, row := range r.data {
+		if row.TaskId == taskId {
+			delete(r.data, id)
+			deleted += 1
+		}
+	}
+	return deleted
+}
+
+func (r *InMemoryCommentRepository) Dump() []CommentSchema {
+	rows := make([]CommentSchema, 0, len(r.data))
+	for _, row := range r.data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+func (r *InMemoryCommentRepository) Load(rows []CommentSchema) {
+	r.data = make(map[int]CommentSchema, len(rows))
+	r.position = 0
+	for _, row := range rows {
+		r.data[row.Id] = row
+		r.position = max(r.position, row.Id)
+	}
+}
+
+func InitInMemoryCommentRepository() *InMemoryCommentRepository {
+	return &InMemoryCommentRepository{
+		data: map[int]CommentSchema{},
+	}
+}
+
+func toComment(row CommentSchema) *entity.Comment {
+	comment := entity.NewComment(row.Id, row.TaskId, row.Author, row.Body)
+	comment.CreatedAt = row.CreatedAt
+	comment.EditedAt = row.EditedAt
+	return comment
+}
+
+func toCommentSchema(c *entity.Comment) *CommentSchema {
+	return &CommentSchema{
+		Id:        c.Id,
+		TaskId:    c.TaskId,
+		Author:    c.Author,
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt,
+		EditedAt:  c.EditedAt,
+	}
+}
@@ -0,0 +1,32 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/comments/entities"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_InMemoryCommentRepository(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.InitInMemoryCommentRepository()
+	first := repo.Save(entity.NewComment(0, 1, "alice", "要買幾份？"))
+	repo.Save(entity.NewComment(0, 2, "bob", "買了"))
+	repo.Save(entity.NewComment(0, 1, "bob", "兩份"))
+
+	util.AssertEqual(t)(first.Id, 1)
+	util.AssertEqual(t)(len(repo.ListByTask(1)), 2)
+
+	first.Body = "要買幾份呢？"
+	updated, err := repo.Update(&first)
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(updated.Body, "要買幾份呢？")
+
+	util.AssertEqual(t)(repo.DeleteByTask(1), 2)
+	util.AssertEqual(t)(len(repo.ListAll()), 1)
+
+	_, err = repo.FindBy(first.Id)
+	util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+}
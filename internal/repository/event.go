@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+type EventSchema struct {
+	Id        int
+	TaskId    int
+	Kind      string
+	Actor     string
+	From      string
+	To        string
+	CreatedAt time.Time
+}
+
+type InMemoryEventRepository struct {
+	position int
+	data     map[int]EventSchema
+}
+
+func (r *InMemoryEventRepository) ListAll() []*entity.Event {
+	var events []*entity.Event
+	for _, row := range r.data {
+		events = append(events, toEvent(row))
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Id < events[j].Id })
+	return events
+}
+
+func (r *InMemoryEventRepository) NextId() int {
+	r.position += 1
+	return r.position
+}
+
+func (r *InMemoryEventRepository) Save(e *entity.Event) entity.Event {
+	e.Id = r.NextId()
+	row := *toEventSchema(e)
+	r.data[row.Id] = row
+	return *toEvent(row)
+}
+
+func (r *InMemoryEventRepository) FindBy(id any) (*entity.Event, error) {
+	row, ok := r.data[id.(int)]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	return toEvent(row), nil
+}
+
+func (r *InMemoryEventRepository) Update(e *entity.Event) (*entity.Event, error) {
+	_, ok := r.data[e.Id]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	r.data[e.Id] = *toEventSchema(e)
+	return toEvent(r.data[e.Id]), nil
+}
+
+func (r *InMemoryEventRepository) Delete(e *entity.Event) error {
+	_, ok := r.data[e.Id]
+	if !ok {
+		return ErrorNotFound
+	}
+
+	delete(r.data, e.Id)
+	return nil
+}
+
+func (r *InMemoryEventRepository) ListByTask(taskId int) []*entity.Event {
+	var events []*entity.Event
+	for _, e := range r.ListAll() {
+		if e.TaskId == taskId {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func (r *InMemoryEventRepository) DeleteByTask(taskId int) int {
+	var deleted int
+	for id, row := range r.data {
+		if row.TaskId == taskId {
+			delete(r.data, id)
+			deleted += 1
+		}
+	}
+	return deleted
+}
+
+func (r *InMemoryEventRepository) Dump() []EventSchema {
+	rows := make([]EventSchema, 0, len(r.data))
+	for _, row := range r.data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+func (r *InMemoryEventRepository) Load(rows []EventSchema) {
+	r.data = make(map[int]EventSchema, len(rows))
+	r.position = 0
+	for _, row := range rows {
+		r.data[row.Id] = row
+		r.position = max(r.position, row.Id)
+	}
+}
+
+func InitInMemoryEventRepository() *InMemoryEventRepository {
+	return &InMemoryEventRepository{
+		data: map[int]EventSchema{},
+	}
+}
+
+func toEvent(row EventSchema) *entity.Event {
+	return &entity.Event{
+		Id:        row.Id,
+		TaskId:    row.TaskId,
+		Kind:      row.Kind,
+		Actor:     row.Actor,
+		From:      row.From,
+		To:        row.To,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+func toEventSchema(e *entity.Event) *EventSchema {
+	return &EventSchema{
+		Id:        e.Id,
+		TaskId:    e.TaskId,
+		Kind:      e.Kind,
+		Actor:     e.Actor,
+		From:      e.From,
+		To:        e.To,
+		CreatedAt: e.CreatedAt,
+	}
+}
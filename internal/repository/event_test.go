@@ -0,0 +1,27 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks/entities"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_InMemoryEventRepository(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.InitInMemoryEventRepository()
+	first := repo.Save(entity.NewEvent(1, entity.EventAssigned, "alice", "", "bob"))
+	repo.Save(entity.NewEvent(2, entity.EventAssigned, "alice", "", "alice"))
+	repo.Save(entity.NewEvent(1, entity.EventUnassigned, "bob", "bob", ""))
+
+	util.AssertEqual(t)(first.Id, 1)
+	util.AssertEqual(t)(len(repo.ListByTask(1)), 2)
+
+	util.AssertEqual(t)(repo.DeleteByTask(1), 2)
+	util.AssertEqual(t)(len(repo.ListAll()), 1)
+
+	_, err := repo.FindBy(first.Id)
+	util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/sessions/entities"
+)
+
+type Feed = entity.Feed
+
+type FeedSchema struct {
+	Token     string
+	User      string
+	Workspace string
+	CreatedAt time.Time
+}
+
+type InMemoryFeedRepository struct {
+	data map[string]FeedSchema
+}
+
+// ListAll returns the feeds, oldest first.
+func (r *InMemoryFeedRepository) ListAll() []*Feed {
+	var feeds []*Feed
+	for _, row := range r.data {
+		feeds = append(feeds, toFeed(row))
+	}
+	sort.Slice(feeds, func(i, j int) bool { return feeds[i].CreatedAt.Before(feeds[j].CreatedAt) })
+	return feeds
+}
+
+func (r *InMemoryFeedRepository) Save(f *Feed) Feed {
+	r.data[f.Token] = *toFeedSchema(f)
+	return *f
+}
+
+func (r *InMemoryFeedRepository) FindBy(token any) (*Feed, error) {
+	row, ok := r.data[token.(string)]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	return toFeed(row), nil
+}
+
+func (r *InMemoryFeedRepository) Update(*Feed) (*Feed, error) {
+	panic("not implemented")
+}
+
+func (r *InMemoryFeedRepository) Delete(f *Feed) error {
+	if _, ok := r.data[f.Token]; !ok {
+		return ErrorNotFound
+	}
+
+	delete(r.data, f.Token)
+	return nil
+}
+
+// Dump returns the feeds, oldest first.
+func (r *InMemoryFeedRepository) Dump() []FeedSchema {
+	rows := make([]FeedSchema, 0, len(r.data))
+	for _, row := range r.data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].CreatedAt.Before(rows[j].CreatedAt) })
+	return rows
+}
+
+func (r *InMemoryFeedRepository) Load(rows []FeedSchema) {
+	r.data = make(map[string]FeedSchema, len(rows))
+	for _, row := range rows {
+		r.data[row.Token] = row
+	}
+}
+
+func InitInMemoryFeedRepository() *InMemoryFeedRepository {
+	return &InMemoryFeedRepository{
+		data: map[string]FeedSchema{},
+	}
+}
+
+func toFeed(s FeedSchema) *Feed {
+	return &Feed{
+		Token:     s.Token,
+		User:      s.User,
+		Workspace: s.Workspace,
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+func toFeedSchema(f *Feed) *FeedSchema {
+	return &FeedSchema{
+		Token:     f.Token,
+		User:      f.User,
+		Workspace: f.Workspace,
+		CreatedAt: f.CreatedAt,
+	}
+}
@@ -0,0 +1,50 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/sessions/entities"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_InMemoryFeedRepository(t *testing.T) {
+	t.Run("finds a saved feed", func(t *testing.T) {
+		t.Parallel()
+
+		repo := repository.InitInMemoryFeedRepository()
+		feed := entity.NewFeed("default", "alice")
+		repo.Save(feed)
+
+		got, err := repo.FindBy(feed.Token)
+
+		util.AssertEqual(t)(got, feed)
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(repo.ListAll(), []*entity.Feed{feed})
+	})
+
+	t.Run("returns error when not found", func(t *testing.T) {
+		t.Parallel()
+
+		repo := repository.InitInMemoryFeedRepository()
+
+		_, err := repo.FindBy("nonexistent_token")
+
+		util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+	})
+
+	t.Run("deletes a feed", func(t *testing.T) {
+		t.Parallel()
+
+		repo := repository.InitInMemoryFeedRepository()
+		feed := entity.NewFeed("default", "alice")
+		repo.Save(feed)
+
+		err := repo.Delete(feed)
+		_, findErr := repo.FindBy(feed.Token)
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertErrorEqual(t)(findErr, repository.ErrorNotFound)
+		util.AssertErrorEqual(t)(repo.Delete(feed), repository.ErrorNotFound)
+	})
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com/dannyh79/whostodo/internal/lists/entities"
+)
+
+type ListSchema struct {
+	Id    int
+	Name  string
+	Owner string
+}
+
+type InMemoryListRepository struct {
+	position int
+	data     map[int]ListSchema
+}
+
+func (r *InMemoryListRepository) ListAll() []*entity.List {
+	var lists []*entity.List
+	for _, row := range r.data {
+		lists = append(lists, toList(row))
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Id < lists[j].Id })
+	return lists
+}
+
+func (r *InMemoryListRepository) NextId() int {
+	r.position += 1
+	return r.position
+}
+
+func (r *InMemoryListRepository) Save(l *entity.List) entity.List {
+	l.Id = r.NextId()
+	row := *toListSchema(l)
+	r.data[row.Id] = row
+	return *toList(row)
+}
+
+func (r *InMemoryListRepository) FindBy(id any) (*entity.List, error) {
+	row, ok := r.data[id.(int)]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	return toList(row), nil
+}
+
+func (r *InMemoryListRepository) Update(l *entity.List) (*entity.List, error) {
+	_, ok := r.data[l.Id]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	r.data[l.Id] = *toListSchema(l)
+	return toList(r.data[l.Id]), nil
+}
+
+func (r *InMemoryListRepository) Delete(l *entity.List) error {
+	_, ok := r.data[l.Id]
+	if !ok {
+		return ErrorNotFound
+	}
+
+	delete(r.data, l.Id)
+	return nil
+}
+
+func (r *InMemoryListRepository) Dump() []ListSchema {
+	rows := make([]ListSchema, 0, len(r.data))
+	for _, row := range r.data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+func (r *InMemoryListRepository) Load(rows []ListSchema) {
+	r.data = make(map[int]ListSchema, len(rows))
+	r.position = 0
+	for _, row := range rows {
+		r.data[row.Id] = row
+		r.position = max(r.position, row.Id)
+	}
+}
+
+func InitInMemoryListRepository() *InMemoryListRepository {
+	return &InMemoryListRepository{
+		data: map[int]ListSchema{},
+	}
+}
+
+func toList(row ListSchema) *entity.List {
+	list := entity.NewList(row.Id, row.Name)
+	list.Owner = row.Owner
+	return list
+}
+
+func toListSchema(l *entity.List) *ListSchema {
+	return &ListSchema{
+		Id:    l.Id,
+		Name:  l.Name,
+		Owner: l.Owner,
+	}
+}
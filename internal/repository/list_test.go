@@ -0,0 +1,137 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/lists/entities"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_InMemoryListRepositorySave(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.InitInMemoryListRepository()
+	got := repo.Save(&entity.List{Name: "家事"})
+
+	util.AssertEqual(t)(got, entity.List{Id: 1, Name: "家事"})
+	util.AssertEqual(t)(len(repo.ListAll()), 1)
+}
+
+func Test_InMemoryListRepositoryFindBy(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        entity.List
+		param       int
+		expectError bool
+		error       error
+	}{
+		{
+			name:  "returns a list",
+			data:  entity.List{Id: 1, Name: "家事"},
+			param: 1,
+		},
+		{
+			name:        "returns error when not found",
+			data:        entity.List{Id: 1, Name: "家事"},
+			param:       2,
+			expectError: true,
+			error:       repository.ErrorNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := repository.InitInMemoryListRepository()
+			repo.Save(&tc.data)
+
+			got, err := repo.FindBy(tc.param)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				util.AssertEqual(t)(*got, tc.data)
+			}
+		})
+	}
+}
+
+func Test_InMemoryListRepositoryUpdate(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        entity.List
+		param       entity.List
+		expectError bool
+		error       error
+	}{
+		{
+			name:  "returns updated list",
+			data:  entity.List{Id: 1, Name: "家事"},
+			param: entity.List{Id: 1, Name: "工作"},
+		},
+		{
+			name:        "returns error when not found",
+			data:        entity.List{Id: 1, Name: "家事"},
+			param:       entity.List{Id: 2, Name: "工作"},
+			expectError: true,
+			error:       repository.ErrorNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := repository.InitInMemoryListRepository()
+			repo.Save(&tc.data)
+
+			got, err := repo.Update(&tc.param)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				util.AssertEqual(t)(*got, tc.param)
+			}
+		})
+	}
+}
+
+func Test_InMemoryListRepositoryDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   entity.List
+		param  entity.List
+		error  error
+		length int
+	}{
+		{
+			name:   "deletes the list",
+			data:   entity.List{Id: 1, Name: "家事"},
+			param:  entity.List{Id: 1, Name: "家事"},
+			length: 0,
+		},
+		{
+			name:   "returns error",
+			data:   entity.List{Id: 1, Name: "家事"},
+			param:  entity.List{Id: 2, Name: "家事"},
+			error:  repository.ErrorNotFound,
+			length: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := repository.InitInMemoryListRepository()
+			repo.Save(&tc.data)
+
+			err := repo.Delete(&tc.param)
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			util.AssertEqual(t)(len(repo.ListAll()), tc.length)
+		})
+	}
+}
@@ -10,4 +10,49 @@ type Repository[T any] interface {
 	Delete(*T) error
 }
 
+// Trash is implemented by repositories whose Delete is a soft delete; trashed
+// rows are hidden from Repository until restored or purged.
+type Trash[T any] interface {
+	ListTrashed() []*T
+	FindTrashedBy(id any) (*T, error)
+	Restore(*T) (*T, error)
+	Purge(*T) error
+}
+
+// Tags is implemented by repositories storing tags on their rows; only rows
+// visible through Repository count.
+type Tags[T any] interface {
+	ListTags() []TagCount
+	FindByTags(tags []string, matchAll bool) []*T
+	// RenameTag renames the tag on the rows of the ids only.
+	RenameTag(from string, to string, ids []int) int
+}
+
+// Search is implemented by repositories with full-text search over their
+// rows, ranked best first; only rows visible through Repository are found.
+type Search[T any] interface {
+	Search(query string) []*T
+}
+
+// TaskOwned is implemented by repositories of rows belonging to a task.
+type TaskOwned[T any] interface {
+	ListByTask(taskId int) []*T
+	// DeleteByTask removes every row of the task and returns how many.
+	DeleteByTask(taskId int) int
+}
+
+type TagCount struct {
+	Name  string
+	Count int
+}
+
 var ErrorNotFound = errors.New("Task not found")
+
+// Snapshot is implemented by repositories that can be backed up. Dump returns
+// every row, trashed ones included, ordered by id; Load replaces every row
+// with the given ones, keeping their ids, so rows saved afterwards get ids
+// past them.
+type Snapshot[S any] interface {
+	Dump() []S
+	Load(rows []S)
+}
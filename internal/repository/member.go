@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/lists/entities"
+)
+
+type MemberSchema struct {
+	Id        int
+	ListId    int
+	User      string
+	Role      string
+	Accepted  bool
+	CreatedAt time.Time
+}
+
+type InMemoryMemberRepository struct {
+	position int
+	data     map[int]MemberSchema
+}
+
+func (r *InMemoryMemberRepository) ListAll() []*entity.Member {
+	var members []*entity.Member
+	for _, row := range r.data {
+		members = append(members, toMember(row))
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Id < members[j].Id })
+	return members
+}
+
+func (r *InMemoryMemberRepository) NextId() int {
+	r.position += 1
+	return r.position
+}
+
+func (r *InMemoryMemberRepository) Save(m *entity.Member) entity.Member {
+	m.Id = r.NextId()
+	row := *toMemberSchema(m)
+	r.data[row.Id] = row
+	return *toMember(row)
+}
+
+func (r *InMemoryMemberRepository) FindBy(id any) (*entity.Member, error) {
+	row, ok := r.data[id.(int)]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	return toMember(row), nil
+}
+
+func (r *InMemoryMemberRepository) Update(m *entity.Member) (*entity.Member, error) {
+	_, ok := r.data[m.Id]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	r.data[m.Id] = *toMemberSchema(m)
+	return toMember(r.data[m.Id]), nil
+}
+
+func (r *InMemoryMemberRepository) Delete(m *entity.Member) error {
+	_, ok := r.data[m.Id]
+	if !ok {
+		return ErrorNotFound
+	}
+
+	delete(r.data, m.Id)
+	return nil
+}
+
+// FindMember returns the membership of the user in the list, accepted or not.
+func (r *InMemoryMemberRepository) FindMember(listId int, user string) (*entity.Member, error) {
+	for _, row := range r.data {
+		if row.ListId == listId && row.User == user {
+			return toMember(row), nil
+		}
+	}
+	return nil, ErrorNotFound
+}
+
+func (r *InMemoryMemberRepository) ListByList(listId int) []*entity.Member {
+	var members []*entity.Member
+	for _, m := range r.ListAll() {
+		if m.ListId == listId {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+func (r *InMemoryMemberRepository) ListByUser(user string) []*entity.Member {
+	var members []*entity.Member
+	for _, m := range r.ListAll() {
+		if m.User == user {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+func (r *InMemoryMemberRepository) DeleteByList(listId int) int {
+	var deleted int
+	for id, row := range r.data {
+		if row.ListId == listId {
+			delete(r.data, id)
+			deleted += 1
+		}
+	}
+	return deleted
+}
+
+func (r *InMemoryMemberRepository) Dump() []MemberSchema {
+	rows := make([]MemberSchema, 0, len(r.data))
+	for _, row := range r.data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+func (r *InMemoryMemberRepository) Load(rows []MemberSchema) {
+	r.data = make(map[int]MemberSchema, len(rows))
+	r.position = 0
+	for _, row := range rows {
+		r.data[row.Id] = row
+		r.position = max(r.position, row.Id)
+	}
+}
+
+func InitInMemoryMemberRepository() *InMemoryMemberRepository {
+	return &InMemoryMemberRepository{
+		data: map[int]MemberSchema{},
+	}
+}
+
+func toMember(row MemberSchema) *entity.Member {
+	return &entity.Member{
+		Id:        row.Id,
+		ListId:    row.ListId,
+		User:      row.User,
+		Role:      row.Role,
+		Accepted:  row.Accepted,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+func toMemberSchema(m *entity.Member) *MemberSchema {
+	return &MemberSchema{
+		Id:        m.Id,
+		ListId:    m.ListId,
+		User:      m.User,
+		Role:      m.Role,
+		Accepted:  m.Accepted,
+		CreatedAt: m.CreatedAt,
+	}
+}
@@ -0,0 +1,30 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/lists/entities"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_InMemoryMemberRepository(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.InitInMemoryMemberRepository()
+	first := repo.Save(entity.NewMember(1, "bob", entity.RoleViewer))
+	repo.Save(entity.NewMember(2, "bob", entity.RoleEditor))
+	repo.Save(entity.NewMember(1, "carol", entity.RoleEditor))
+
+	util.AssertEqual(t)(first.Id, 1)
+	util.AssertEqual(t)(len(repo.ListByList(1)), 2)
+	util.AssertEqual(t)(len(repo.ListByUser("bob")), 2)
+
+	found, err := repo.FindMember(1, "carol")
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(found.Id, 3)
+
+	util.AssertEqual(t)(repo.DeleteByList(1), 2)
+	_, err = repo.FindMember(1, "carol")
+	util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"sort"
 	"time"
 
 	"github.com/dannyh79/whostodo/internal/sessions/entities"
@@ -10,6 +11,8 @@ type Session = entity.Session
 
 type SessionSchema struct {
 	Id        string
+	User      string
+	Workspace string
 	CreatedAt time.Time
 }
 
@@ -47,6 +50,23 @@ func (r *InMemorySessionRepository) Update(*Session) (*Session, error) {
 	panic("not implemented")
 }
 
+// Dump returns the sessions, oldest first.
+func (r *InMemorySessionRepository) Dump() []SessionSchema {
+	rows := make([]SessionSchema, 0, len(r.data))
+	for _, row := range r.data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].CreatedAt.Before(rows[j].CreatedAt) })
+	return rows
+}
+
+func (r *InMemorySessionRepository) Load(rows []SessionSchema) {
+	r.data = make(map[string]SessionSchema, len(rows))
+	for _, row := range rows {
+		r.data[row.Id] = row
+	}
+}
+
 func InitInMemorySessionRepository() *InMemorySessionRepository {
 	return &InMemorySessionRepository{
 		data: map[string]SessionSchema{},
@@ -56,6 +76,8 @@ func InitInMemorySessionRepository() *InMemorySessionRepository {
 func toSession(s SessionSchema) *Session {
 	return &Session{
 		Id:        s.Id,
+		User:      s.User,
+		Workspace: s.Workspace,
 		CreatedAt: s.CreatedAt,
 	}
 }
@@ -63,6 +85,8 @@ func toSession(s SessionSchema) *Session {
 func toSessionSchema(s *Session) *SessionSchema {
 	return &SessionSchema{
 		Id:        s.Id,
+		User:      s.User,
+		Workspace: s.Workspace,
 		CreatedAt: s.CreatedAt,
 	}
 }
@@ -45,3 +45,16 @@ func Test_InMemorySessionRepositoryFindBy(t *testing.T) {
 		util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
 	})
 }
+
+func Test_InMemorySessionRepositorySnapshot(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.InitInMemorySessionRepository()
+	session := entity.NewSession()
+	repo.Save(session)
+	repo.Load([]repository.SessionSchema{{Id: "restored_token", User: "alice", Workspace: "default"}})
+
+	_, err := repo.FindBy(session.Id)
+	util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+	util.AssertEqual(t)(repo.Dump(), []repository.SessionSchema{{Id: "restored_token", User: "alice", Workspace: "default"}})
+}
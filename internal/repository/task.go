@@ -1,24 +1,46 @@
 package repository
 
 import (
+	"sort"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/search"
 	"github.com/dannyh79/whostodo/internal/tasks/entities"
 )
 
 type TaskSchema struct {
-	Id     int
-	Name   string
-	Status int
+	Id          int
+	Name        string
+	Description string
+	Status      int
+	Priority    int
+	Position    string
+	ListId      int
+	ParentId    int
+	Tags        []string
+	BlockedBy   []int
+	DueAt       time.Time
+	Recurrence  *entity.Recurrence
+	Creator     string
+	Assignee    string
+	CreatedAt   time.Time
+	CompletedAt time.Time
+	DeletedAt   time.Time
 }
 
 type InMemoryTaskRepository struct {
 	position int
 	data     map[int]TaskSchema
+	// Indexes tasks outside the trash.
+	index *search.Index
 }
 
 func (r *InMemoryTaskRepository) ListAll() []*entity.Task {
 	var tasks []*entity.Task
-	for _, row := range r.data {
-		tasks = append(tasks, toTask(row))
+	for _, row := range r.sortedRows() {
+		if row.DeletedAt.IsZero() {
+			tasks = append(tasks, toTask(row))
+		}
 	}
 	return tasks
 }
@@ -32,12 +54,13 @@ func (r *InMemoryTaskRepository) Save(t *entity.Task) entity.Task {
 	t.Id = r.NextId()
 	row := *toTaskSchema(t)
 	r.data[row.Id] = row
+	r.index.Add(row.Id, indexedText(row))
 	return *toTask(row)
 }
 
 func (r *InMemoryTaskRepository) FindBy(id any) (*entity.Task, error) {
 	row, ok := r.data[id.(int)]
-	if !ok {
+	if !ok || !row.DeletedAt.IsZero() {
 		return nil, ErrorNotFound
 	}
 
@@ -45,18 +68,63 @@ func (r *InMemoryTaskRepository) FindBy(id any) (*entity.Task, error) {
 }
 
 func (r *InMemoryTaskRepository) Update(t *entity.Task) (*entity.Task, error) {
-	_, ok := r.data[t.Id]
-	if !ok {
+	row, ok := r.data[t.Id]
+	if !ok || !row.DeletedAt.IsZero() {
 		return nil, ErrorNotFound
 	}
 
 	r.data[t.Id] = *toTaskSchema(t)
+	r.index.Add(t.Id, indexedText(r.data[t.Id]))
 	return toTask(r.data[t.Id]), nil
 }
 
+// Delete moves the task into the trash; see Purge for removing it for good.
 func (r *InMemoryTaskRepository) Delete(t *entity.Task) error {
-	_, ok := r.data[t.Id]
-	if !ok {
+	row, ok := r.data[t.Id]
+	if !ok || !row.DeletedAt.IsZero() {
+		return ErrorNotFound
+	}
+
+	row.DeletedAt = time.Now()
+	r.data[t.Id] = row
+	r.index.Remove(t.Id)
+	return nil
+}
+
+func (r *InMemoryTaskRepository) ListTrashed() []*entity.Task {
+	var tasks []*entity.Task
+	for _, row := range r.sortedRows() {
+		if !row.DeletedAt.IsZero() {
+			tasks = append(tasks, toTask(row))
+		}
+	}
+	return tasks
+}
+
+func (r *InMemoryTaskRepository) FindTrashedBy(id any) (*entity.Task, error) {
+	row, ok := r.data[id.(int)]
+	if !ok || row.DeletedAt.IsZero() {
+		return nil, ErrorNotFound
+	}
+
+	return toTask(row), nil
+}
+
+func (r *InMemoryTaskRepository) Restore(t *entity.Task) (*entity.Task, error) {
+	row, ok := r.data[t.Id]
+	if !ok || row.DeletedAt.IsZero() {
+		return nil, ErrorNotFound
+	}
+
+	row.DeletedAt = time.Time{}
+	r.data[t.Id] = row
+	r.index.Add(t.Id, indexedText(row))
+	return toTask(row), nil
+}
+
+func (r *InMemoryTaskRepository) Purge(t *entity.Task) error {
+	row, ok := r.data[t.Id]
+	if !ok || row.DeletedAt.IsZero() {
 		return ErrorNotFound
 	}
 
@@ -64,20 +132,167 @@ func (r *InMemoryTaskRepository) Delete(t *entity.Task) error {
 	return nil
 }
 
+func (r *InMemoryTaskRepository) ListTags() []TagCount {
+	counts := map[string]int{}
+	for _, row := range r.data {
+		if !row.DeletedAt.IsZero() {
+			continue
+		}
+		for _, tag := range row.Tags {
+			counts[tag] += 1
+		}
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, TagCount{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags
+}
+
+// FindByTags returns tasks having any of the tags, or all of them when
+// matchAll is set.
+func (r *InMemoryTaskRepository) FindByTags(tags []string, matchAll bool) []*entity.Task {
+	tags = entity.NormalizeTags(tags)
+
+	var tasks []*entity.Task
+	for _, row := range r.sortedRows() {
+		if !row.DeletedAt.IsZero() {
+			continue
+		}
+		task := toTask(row)
+		matched := 0
+		for _, tag := range tags {
+			if task.HasTag(tag) {
+				matched += 1
+			}
+		}
+		if (matchAll && matched == len(tags)) || (!matchAll && matched > 0) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// RenameTag renames a tag on the tasks of the ids, trashed ones included,
+// merging it into an existing tag of the new name. It returns how many tasks
+// changed.
+func (r *InMemoryTaskRepository) RenameTag(from string, to string, ids []int) int {
+	from, to = entity.NormalizeTag(from), entity.NormalizeTag(to)
+
+	var renamed int
+	for _, id := range ids {
+		row, ok := r.data[id]
+		if !ok {
+			continue
+		}
+		task := toTask(row)
+		if !task.HasTag(from) {
+			continue
+		}
+		task.RemoveTag(from)
+		task.AddTags(to)
+		r.data[id] = *toTaskSchema(task)
+		renamed += 1
+	}
+	return renamed
+}
+
+func (r *InMemoryTaskRepository) Search(query string) []*entity.Task {
+	var tasks []*entity.Task
+	for _, match := range r.index.Search(query) {
+		tasks = append(tasks, toTask(r.data[match.Id]))
+	}
+	return tasks
+}
+
+// sortedRows orders rows by position, then by id for rows sharing one.
+func (r *InMemoryTaskRepository) sortedRows() []TaskSchema {
+	rows := make([]TaskSchema, 0, len(r.data))
+	for _, row := range r.data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Position != rows[j].Position {
+			return rows[i].Position < rows[j].Position
+		}
+		return rows[i].Id < rows[j].Id
+	})
+	return rows
+}
+
+func (r *InMemoryTaskRepository) Dump() []TaskSchema {
+	rows := make([]TaskSchema, 0, len(r.data))
+	for _, row := range r.data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+// Load also rebuilds the search index from the rows outside the trash.
+func (r *InMemoryTaskRepository) Load(rows []TaskSchema) {
+	r.data = make(map[int]TaskSchema, len(rows))
+	r.index = search.NewIndex()
+	r.position = 0
+	for _, row := range rows {
+		r.data[row.Id] = row
+		r.position = max(r.position, row.Id)
+		if row.DeletedAt.IsZero() {
+			r.index.Add(row.Id, indexedText(row))
+		}
+	}
+}
+
 func InitInMemoryTaskRepository() *InMemoryTaskRepository {
 	return &InMemoryTaskRepository{
-		data: map[int]TaskSchema{},
+		data:  map[int]TaskSchema{},
+		index: search.NewIndex(),
 	}
 }
 
+func indexedText(row TaskSchema) string {
+	return row.Name + "\n" + row.Description
+}
+
 func toTask(row TaskSchema) *entity.Task {
-	return entity.NewTask(row.Id, row.Name, row.Status)
+	task := entity.NewTask(row.Id, row.Name, row.Status)
+	task.Description = row.Description
+	task.Priority = row.Priority
+	task.Position = row.Position
+	task.ListId = row.ListId
+	task.ParentId = row.ParentId
+	task.Tags = append([]string(nil), row.Tags...)
+	task.BlockedBy = append([]int(nil), row.BlockedBy...)
+	task.DueAt = row.DueAt
+	task.Recurrence = row.Recurrence.Clone()
+	task.Creator = row.Creator
+	task.Assignee = row.Assignee
+	task.CreatedAt = row.CreatedAt
+	task.CompletedAt = row.CompletedAt
+	task.DeletedAt = row.DeletedAt
+	return task
 }
 
 func toTaskSchema(t *entity.Task) *TaskSchema {
 	return &TaskSchema{
-		Id:     t.Id,
-		Name:   t.Name,
-		Status: t.Status,
+		Id:          t.Id,
+		Name:        t.Name,
+		Description: t.Description,
+		Status:      t.Status,
+		Priority:    t.Priority,
+		Position:    t.Position,
+		ListId:      t.ListId,
+		ParentId:    t.ParentId,
+		Tags:        append([]string(nil), t.Tags...),
+		BlockedBy:   append([]int(nil), t.BlockedBy...),
+		DueAt:       t.DueAt,
+		Recurrence:  t.Recurrence.Clone(),
+		Creator:     t.Creator,
+		Assignee:    t.Assignee,
+		CreatedAt:   t.CreatedAt,
+		CompletedAt: t.CompletedAt,
+		DeletedAt:   t.DeletedAt,
 	}
 }
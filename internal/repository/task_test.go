@@ -2,6 +2,7 @@ package repository_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/dannyh79/whostodo/internal/repository"
 	"github.com/dannyh79/whostodo/internal/tasks/entities"
@@ -18,6 +19,22 @@ func Test_InMemoryTaskRepositoryListAll(t *testing.T) {
 	util.AssertEqual(t)(len(tasks), 0)
 }
 
+func Test_InMemoryTaskRepositoryListAllOrder(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.InitInMemoryTaskRepository()
+	repo.Save(&entity.Task{Name: "買晚餐", Position: "k"})
+	repo.Save(&entity.Task{Name: "買早餐", Position: "V"})
+	repo.Save(&entity.Task{Name: "買午餐", Position: "V"})
+
+	var got []int
+	for _, task := range repo.ListAll() {
+		got = append(got, task.Id)
+	}
+
+	util.AssertEqual(t)(got, []int{2, 3, 1})
+}
+
 func Test_InMemoryTaskRepositorySave(t *testing.T) {
 	t.Parallel()
 
@@ -157,3 +174,159 @@ func Test_InMemoryTaskRepositoryDelete(t *testing.T) {
 		})
 	}
 }
+
+func Test_InMemoryTaskRepositoryTrash(t *testing.T) {
+	t.Run("moves deleted task into the trash", func(t *testing.T) {
+		t.Parallel()
+
+		repo := repository.InitInMemoryTaskRepository()
+		saved := repo.Save(&entity.Task{Name: "買早餐", Status: 0})
+		repo.Delete(&saved)
+
+		trashed := repo.ListTrashed()
+		_, err := repo.FindBy(saved.Id)
+
+		util.AssertEqual(t)(len(repo.ListAll()), 0)
+		util.AssertEqual(t)(len(trashed), 1)
+		util.AssertEqual(t)(trashed[0].IsTrashed(), true)
+		util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+	})
+
+	t.Run("restores a trashed task", func(t *testing.T) {
+		t.Parallel()
+
+		repo := repository.InitInMemoryTaskRepository()
+		saved := repo.Save(&entity.Task{Name: "買早餐", Status: 0})
+		repo.Delete(&saved)
+
+		got, err := repo.Restore(&saved)
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(*got, saved)
+		util.AssertEqual(t)(len(repo.ListAll()), 1)
+		util.AssertEqual(t)(len(repo.ListTrashed()), 0)
+	})
+
+	t.Run("purges a trashed task", func(t *testing.T) {
+		t.Parallel()
+
+		repo := repository.InitInMemoryTaskRepository()
+		saved := repo.Save(&entity.Task{Name: "買早餐", Status: 0})
+		repo.Delete(&saved)
+
+		err := repo.Purge(&saved)
+		_, findErr := repo.FindTrashedBy(saved.Id)
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertErrorEqual(t)(findErr, repository.ErrorNotFound)
+		util.AssertEqual(t)(len(repo.ListTrashed()), 0)
+	})
+
+	t.Run("returns error when purging a task not in the trash", func(t *testing.T) {
+		t.Parallel()
+
+		repo := repository.InitInMemoryTaskRepository()
+		saved := repo.Save(&entity.Task{Name: "買早餐", Status: 0})
+
+		err := repo.Purge(&saved)
+
+		util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+		util.AssertEqual(t)(len(repo.ListAll()), 1)
+	})
+}
+
+func Test_InMemoryTaskRepositoryTags(t *testing.T) {
+	newRepo := func() *repository.InMemoryTaskRepository {
+		repo := repository.InitInMemoryTaskRepository()
+		repo.Save(&entity.Task{Name: "買早餐", Tags: []string{"errand"}})
+		repo.Save(&entity.Task{Name: "買晚餐", Tags: []string{"errand", "food"}})
+		trashed := repo.Save(&entity.Task{Name: "洗碗", Tags: []string{"chore"}})
+		repo.Delete(&trashed)
+		return repo
+	}
+
+	t.Run("lists tags of visible tasks with counts", func(t *testing.T) {
+		t.Parallel()
+
+		got := newRepo().ListTags()
+
+		util.AssertEqual(t)(got, []repository.TagCount{{Name: "errand", Count: 2}, {Name: "food", Count: 1}})
+	})
+
+	t.Run("finds tasks by any of the tags", func(t *testing.T) {
+		t.Parallel()
+
+		got := newRepo().FindByTags([]string{"food", "chore"}, false)
+
+		util.AssertEqual(t)(len(got), 1)
+		util.AssertEqual(t)(got[0].Name, "買晚餐")
+	})
+
+	t.Run("finds tasks by all of the tags", func(t *testing.T) {
+		t.Parallel()
+
+		got := newRepo().FindByTags([]string{"Errand", "food"}, true)
+
+		util.AssertEqual(t)(len(got), 1)
+		util.AssertEqual(t)(got[0].Name, "買晚餐")
+	})
+
+	t.Run("renames a tag merging into an existing one", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newRepo()
+		got := repo.RenameTag("food", "Errand", []int{1, 2})
+		task, _ := repo.FindBy(2)
+
+		util.AssertEqual(t)(got, 1)
+		util.AssertEqual(t)(task.Tags, []string{"errand"})
+		util.AssertEqual(t)(repo.ListTags(), []repository.TagCount{{Name: "errand", Count: 2}})
+	})
+}
+
+func Test_InMemoryTaskRepositorySearch(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.InitInMemoryTaskRepository()
+	breakfast := repo.Save(&entity.Task{Name: "買早餐"})
+	dinner := repo.Save(&entity.Task{Name: "買晚餐"})
+
+	util.AssertEqual(t)(len(repo.Search("買")), 2)
+
+	breakfast.Name = "煮早餐"
+	repo.Update(&breakfast)
+	util.AssertEqual(t)(len(repo.Search("買")), 1)
+
+	repo.Delete(&dinner)
+	util.AssertEqual(t)(len(repo.Search("買")), 0)
+
+	repo.Restore(&dinner)
+	util.AssertEqual(t)(repo.Search("晚餐")[0].Id, dinner.Id)
+
+	lunch := repo.Save(&entity.Task{Name: "買午餐", Description: "順便買水果"})
+	util.AssertEqual(t)(repo.Search("水果")[0].Id, lunch.Id)
+}
+
+func Test_InMemoryTaskRepositorySnapshot(t *testing.T) {
+	t.Parallel()
+
+	repo := repository.InitInMemoryTaskRepository()
+	repo.Save(&entity.Task{Name: "買晚餐"})
+	repo.Load([]repository.TaskSchema{
+		{Id: 5, Name: "洗碗", Position: "b"},
+		{Id: 2, Name: "倒垃圾", Position: "a", DeletedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	})
+	saved := repo.Save(&entity.Task{Name: "拖地", Position: "c"})
+
+	util.AssertEqual(t)(saved.Id, 6)
+	util.AssertEqual(t)(len(repo.ListAll()), 2)
+	util.AssertEqual(t)(len(repo.ListTrashed()), 1)
+	util.AssertEqual(t)(len(repo.Search("洗碗")), 1)
+	util.AssertEqual(t)(len(repo.Search("倒垃圾")), 0)
+
+	var got []int
+	for _, row := range repo.Dump() {
+		got = append(got, row.Id)
+	}
+	util.AssertEqual(t)(got, []int{2, 5, 6})
+}
@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dannyh79/whostodo/internal/sessions"
+	sessionentity "github.com/dannyh79/whostodo/internal/sessions/entities"
+	"github.com/gin-gonic/gin"
+)
+
+type AccountOutput struct {
+	Result sessions.AccountOutput `json:"result"`
+}
+
+type FailedAccountOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func setAccountHandler(u *sessions.SessionsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var payload sessions.AccountInput
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, FailedAccountOutput{})
+			return
+		}
+
+		account, err := u.SetAccount(c.Param("user"), &payload)
+		if errors.Is(err, sessionentity.ErrorInvalidUser) ||
+			errors.Is(err, sessionentity.ErrorInvalidPassword) ||
+			errors.Is(err, sessionentity.ErrorInvalidWorkspaces) ||
+			errors.Is(err, sessions.ErrorInvalidWorkspace) {
+			c.JSON(http.StatusBadRequest, FailedAccountOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, FailedAccountOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, AccountOutput{Result: *account})
+	}
+}
+
+func deleteAccountHandler(u *sessions.SessionsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := u.DeleteAccount(c.Param("user"))
+		if errors.Is(err, sessions.ErrorAccountNotFound) {
+			c.JSON(http.StatusNotFound, nil)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, nil)
+			return
+		}
+
+		c.JSON(http.StatusOK, nil)
+	}
+}
@@ -0,0 +1,150 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_POSTAuthCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    string
+		statusCode int
+		user       string
+	}{
+		{
+			name:       "returns status code 201 with the password of the account",
+			payload:    `{"user":"alice","password":"密碼"}`,
+			statusCode: http.StatusCreated,
+			user:       "alice",
+		},
+		{
+			name:       "returns status code 201 for the anonymous user without a password",
+			payload:    `{"user":"anonymous"}`,
+			statusCode: http.StatusCreated,
+			user:       "anonymous",
+		},
+		{
+			name:       "returns status code 401 on a wrong password",
+			payload:    `{"user":"alice","password":"錯的"}`,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "returns status code 401 on a user without an account",
+			payload:    `{"user":"bob","password":"密碼"}`,
+			statusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.AccountRepo.PopulateData(util.NewAccount("alice", "密碼"))
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/v1/auth", bytes.NewBufferString(tc.payload))
+			req.Header.Add("Content-Type", "application/json")
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			if tc.user == "" {
+				util.AssertEqual(t)(rr.Body.String(), `{"result":""}`)
+				util.AssertEqual(t)(len(suite.SessionRepo.Data), 0)
+				return
+			}
+			for _, session := range suite.SessionRepo.Data {
+				util.AssertEqual(t)(session.User, tc.user)
+			}
+		})
+	}
+}
+
+func Test_PUTAdminAccount(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       string
+		password   string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with a new account",
+			user:       "bob",
+			password:   "密碼",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "returns status code 200 with a new password",
+			user:       "alice",
+			password:   "新密碼",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "returns status code 400 on an empty password",
+			user:       "bob",
+			password:   "",
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "returns status code 400 on the anonymous user",
+			user:       "anonymous",
+			password:   "密碼",
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.AccountRepo.PopulateData(util.NewAccount("alice", "密碼"))
+			rr := httptest.NewRecorder()
+			payload, _ := json.Marshal(map[string]string{"password": tc.password})
+			req, _ := http.NewRequest(http.MethodPut, "/v1/admin/accounts/"+tc.user, bytes.NewBuffer(payload))
+			req.Header.Add("Content-Type", "application/json")
+			setRequestTokenHeader(t)(req, util.AdminToken)
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			if tc.expected != "" {
+				util.AssertEqual(t)(rr.Body.String(), tc.expected)
+				return
+			}
+			account := suite.AccountRepo.Data[tc.user]
+			util.AssertEqual(t)(account.Verify(tc.password), true)
+		})
+	}
+}
+
+func Test_DELETEAdminAccount(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.AccountRepo.PopulateData(util.NewAccount("alice", "密碼"))
+	suite.FeedRepo.PopulateData(repository.Feed{Token: "feed_token", User: "alice", Workspace: "default"})
+	del := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/v1/admin/accounts/alice", nil)
+		setRequestTokenHeader(t)(req, util.AdminToken)
+		suite.Engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	util.AssertHttpStatus(t)(del(), http.StatusOK)
+	util.AssertEqual(t)(len(suite.AccountRepo.Data), 0)
+	util.AssertEqual(t)(len(suite.FeedRepo.Data), 0)
+	util.AssertHttpStatus(t)(del(), http.StatusNotFound)
+}
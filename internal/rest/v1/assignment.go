@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+type AssignTaskOutput struct {
+	Result TaskResult `json:"result"`
+}
+
+type FailedAssignTaskOutput struct {
+	Result struct{} `json:"result"`
+}
+
+type EventResult struct {
+	Id        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	Actor     string    `json:"actor"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TaskHistoryOutput struct {
+	Result []EventResult `json:"result"`
+}
+
+type FailedTaskHistoryOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func assignTaskHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		var payload tasks.AssignTaskInput
+		c.ShouldBind(&payload)
+
+		updated, err := u.AssignTask(actorFromContext(c), id, &payload)
+		if errors.Is(err, tasks.ErrorInvalidAssignee) {
+			c.JSON(http.StatusUnprocessableEntity, FailedAssignTaskOutput{})
+			return
+		}
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, FailedAssignTaskOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedAssignTaskOutput{})
+			return
+		}
+
+		c.JSON(http.StatusCreated, AssignTaskOutput{Result: toTaskResult(updated)})
+	}
+}
+
+func unassignTaskHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+
+		updated, err := u.UnassignTask(actorFromContext(c), id)
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, FailedAssignTaskOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedAssignTaskOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, AssignTaskOutput{Result: toTaskResult(updated)})
+	}
+}
+
+func listAssignedTasksHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tasks := u.ListAssignedTasks(actorFromContext(c))
+		c.JSON(http.StatusOK, toListTasksOutput(tasks))
+	}
+}
+
+func taskHistoryHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		events, err := u.GetTaskHistory(actorFromContext(c), id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedTaskHistoryOutput{})
+			return
+		}
+
+		var output = TaskHistoryOutput{Result: make([]EventResult, 0)}
+		for _, e := range events {
+			output.Result = append(output.Result, EventResult{
+				Id:        e.Id,
+				Kind:      e.Kind,
+				Actor:     e.Actor,
+				From:      e.From,
+				To:        e.To,
+				CreatedAt: e.CreatedAt,
+			})
+		}
+		c.JSON(http.StatusOK, output)
+	}
+}
@@ -0,0 +1,160 @@
+package routes_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func populateAssignments(suite *util.MockTestSuite) {
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Creator: "alice", Assignee: "bob"})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "買早餐", Creator: "alice"})
+	suite.AccountRepo.PopulateData(util.NewAccount("bob", "密碼"))
+	suite.AccountRepo.PopulateData(util.NewAccount("carol", "密碼"))
+}
+
+func Test_AssignmentRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		method     string
+		path       string
+		payload    string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "PUT assignee returns status code 201 with result",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodPut,
+			path:       "/v1/task/2/assignee",
+			payload:    `{"assignee":"bob"}`,
+			statusCode: http.StatusCreated,
+			expected:   `{"result":{"name":"買早餐","status":0,"id":2,"creator":"alice","assignee":"bob"}}`,
+		},
+		{
+			name:       "PUT empty assignee returns status code 422",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodPut,
+			path:       "/v1/task/2/assignee",
+			payload:    `{"assignee":""}`,
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "PUT assignee without an account returns status code 422",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodPut,
+			path:       "/v1/task/2/assignee",
+			payload:    `{"assignee":"dave"}`,
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "PUT assignee of unknown task returns status code 404",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodPut,
+			path:       "/v1/task/9/assignee",
+			payload:    `{"assignee":"bob"}`,
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "DELETE assignee returns status code 200 with result",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodDelete,
+			path:       "/v1/task/1/assignee",
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"name":"買晚餐","status":0,"id":1,"creator":"alice"}}`,
+		},
+		{
+			name:       "GET assigned tasks returns those of the session's user",
+			authroized: true,
+			session:    util.NewUserSession("bob"),
+			method:     http.MethodGet,
+			path:       "/v1/tasks/assigned",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":1,"name":"買晚餐","status":0,"creator":"alice","assignee":"bob"}]}`,
+		},
+		{
+			name:       "GET tasks filtered by assignee returns status code 200",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodGet,
+			path:       "/v1/tasks?assignee=bob",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":1,"name":"買晚餐","status":0,"creator":"alice","assignee":"bob"}]}`,
+		},
+		{
+			name:       "GET history of unknown task returns status code 404",
+			authroized: true,
+			session:    util.NewSession(),
+			method:     http.MethodGet,
+			path:       "/v1/task/9/history",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			authroized: false,
+			method:     http.MethodGet,
+			path:       "/v1/tasks/assigned",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			populateAssignments(suite)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.payload))
+			req.Header.Add("Content-Type", "application/json")
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_GETTaskHistory(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	populateAssignments(suite)
+	session := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(session)
+	req, _ := http.NewRequest(http.MethodPut, "/v1/task/1/assignee", bytes.NewBufferString(`{"assignee":"carol"}`))
+	req.Header.Add("Content-Type", "application/json")
+	setRequestTokenHeader(t)(req, session.Id)
+	suite.Engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	rr := httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/v1/task/1/history", nil)
+	setRequestTokenHeader(t)(req, session.Id)
+	suite.Engine.ServeHTTP(rr, req)
+
+	util.AssertHttpStatus(t)(rr, http.StatusOK)
+	created := suite.EventRepo.Data[1].CreatedAt.Format("2006-01-02T15:04:05.999999999Z07:00")
+	util.AssertEqual(t)(rr.Body.String(), `{"result":[{"id":1,"kind":"assigned","actor":"alice","from":"bob","to":"carol","created_at":"`+created+`"}]}`)
+}
@@ -0,0 +1,150 @@
+package routes
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/attachments"
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentResult struct {
+	Id          int       `json:"id"`
+	TaskId      int       `json:"task_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Uploader    string    `json:"uploader"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ListAttachmentsOutput struct {
+	Result []AttachmentResult `json:"result"`
+}
+
+type AttachmentOutput struct {
+	Result AttachmentResult `json:"result"`
+}
+
+type FailedAttachmentOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func listAttachmentsHandler(u *attachments.AttachmentsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskId, _ := strconv.Atoi(c.Param("id"))
+		as, err := u.ListAttachments(userFromContext(c), taskId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedAttachmentOutput{})
+			return
+		}
+
+		var output = ListAttachmentsOutput{Result: make([]AttachmentResult, 0)}
+		for _, attachment := range as {
+			output.Result = append(output.Result, toAttachmentResult(attachment))
+		}
+		c.JSON(http.StatusOK, output)
+	}
+}
+
+// uploadOverhead is what upload bodies may carry on top of the largest file
+// accepted, for the rest of the multipart form.
+const uploadOverhead = 1 << 20
+
+// uploadAttachmentHandler limits the body before parsing the form, which
+// would otherwise spool files of any size to disk.
+func uploadAttachmentHandler(u *attachments.AttachmentsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskId, _ := strconv.Atoi(c.Param("id"))
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, u.MaxSize()+uploadOverhead)
+		header, err := c.FormFile("file")
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, FailedAttachmentOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, FailedAttachmentOutput{})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, FailedAttachmentOutput{})
+			return
+		}
+		defer file.Close()
+
+		attachment, err := u.Upload(userFromContext(c), taskId, header.Filename, file)
+		if err != nil {
+			c.JSON(attachmentErrorStatus(err), FailedAttachmentOutput{})
+			return
+		}
+
+		c.JSON(http.StatusCreated, AttachmentOutput{Result: toAttachmentResult(attachment)})
+	}
+}
+
+func downloadAttachmentHandler(u *attachments.AttachmentsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskId, _ := strconv.Atoi(c.Param("id"))
+		id, _ := strconv.Atoi(c.Param("attachment"))
+
+		attachment, content, err := u.Open(userFromContext(c), taskId, id)
+		if err != nil {
+			c.JSON(attachmentErrorStatus(err), FailedAttachmentOutput{})
+			return
+		}
+		defer content.Close()
+
+		// Always download, never render, what users uploaded.
+		c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+			"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}),
+			"X-Content-Type-Options": "nosniff",
+		})
+	}
+}
+
+func deleteAttachmentHandler(u *attachments.AttachmentsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskId, _ := strconv.Atoi(c.Param("id"))
+		id, _ := strconv.Atoi(c.Param("attachment"))
+
+		err := u.DeleteAttachment(userFromContext(c), taskId, id)
+		if err != nil {
+			c.JSON(attachmentErrorStatus(err), nil)
+			return
+		}
+
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func attachmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, attachments.ErrorTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, attachments.ErrorUnsupportedType), errors.Is(err, attachments.ErrorEmptyFile):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, attachments.ErrorNotUploader), errors.Is(err, attachments.ErrorForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, attachments.ErrorTaskNotFound), errors.Is(err, attachments.ErrorAttachmentNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func toAttachmentResult(a *attachments.AttachmentOutput) AttachmentResult {
+	return AttachmentResult{
+		Id:          a.Id,
+		TaskId:      a.TaskId,
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Uploader:    a.Uploader,
+		CreatedAt:   a.CreatedAt,
+	}
+}
@@ -0,0 +1,270 @@
+package routes_test
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/attachments"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func populateAttachments(suite *util.MockTestSuite) {
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Creator: "alice", Assignee: "bob"})
+	suite.AttachmentRepo.PopulateData(repository.AttachmentSchema{
+		Id:          1,
+		TaskId:      1,
+		Name:        "菜單.txt",
+		ContentType: "text/plain; charset=utf-8",
+		Size:        9,
+		BlobKey:     "menu",
+		Uploader:    "alice",
+		CreatedAt:   time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+	})
+	suite.BlobStore.PopulateData("menu", []byte("牛肉麵"))
+}
+
+// newUploadRequest returns a multipart request carrying the content as the
+// "file" field, or no file at all when the field is empty.
+func newUploadRequest(path string, field string, content string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if field != "" {
+		part, _ := writer.CreateFormFile(field, "receipt.txt")
+		part.Write([]byte(content))
+	}
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, path, &body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func Test_AttachmentRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		method     string
+		path       string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "GET attachments returns status code 200 with result",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodGet,
+			path:       "/v1/task/1/attachments",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":1,"task_id":1,"name":"菜單.txt","content_type":"text/plain; charset=utf-8","size":9,"uploader":"alice","created_at":"2024-01-01T09:00:00Z"}]}`,
+		},
+		{
+			name:       "GET attachments of unknown task returns status code 404",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodGet,
+			path:       "/v1/task/9/attachments",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "GET unknown attachment returns status code 404",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodGet,
+			path:       "/v1/task/1/attachments/9",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "DELETE attachment of another user returns status code 403",
+			authroized: true,
+			session:    util.NewUserSession("bob"),
+			method:     http.MethodDelete,
+			path:       "/v1/task/1/attachments/1",
+			statusCode: http.StatusForbidden,
+			expected:   `null`,
+		},
+		{
+			name:       "DELETE attachment returns status code 200",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodDelete,
+			path:       "/v1/task/1/attachments/1",
+			statusCode: http.StatusOK,
+			expected:   `null`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			authroized: false,
+			method:     http.MethodGet,
+			path:       "/v1/task/1/attachments",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			populateAttachments(suite)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_POSTAttachment(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		field      string
+		content    string
+		statusCode int
+	}{
+		{
+			name:       "returns status code 201",
+			path:       "/v1/task/1/attachments",
+			field:      "file",
+			content:    "兩份",
+			statusCode: http.StatusCreated,
+		},
+		{
+			name:       "without file returns status code 400",
+			path:       "/v1/task/1/attachments",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "with type not allowed returns status code 422",
+			path:       "/v1/task/1/attachments",
+			field:      "file",
+			content:    "<html><script>alert(1)</script></html>",
+			statusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "to unknown task returns status code 404",
+			path:       "/v1/task/9/attachments",
+			field:      "file",
+			content:    "兩份",
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			populateAttachments(suite)
+			session := util.NewUserSession("bob")
+			suite.SessionRepo.PopulateData(session)
+			rr := httptest.NewRecorder()
+			req := newUploadRequest(tc.path, tc.field, tc.content)
+			setRequestTokenHeader(t)(req, session.Id)
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			if tc.statusCode == http.StatusCreated {
+				util.AssertEqual(t)(suite.AttachmentRepo.Data[2].Name, "receipt.txt")
+				util.AssertEqual(t)(suite.AttachmentRepo.Data[2].Uploader, "bob")
+			}
+		})
+	}
+}
+
+func Test_POSTAttachmentTooLarge(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	populateAttachments(suite)
+	session := util.NewUserSession("bob")
+	suite.SessionRepo.PopulateData(session)
+	rr := httptest.NewRecorder()
+	req := newUploadRequest("/v1/task/1/attachments", "file", strings.Repeat("兩份", attachments.DefaultMaxSize/3))
+	body := &countingReader{Reader: req.Body}
+	req.Body = io.NopCloser(body)
+	setRequestTokenHeader(t)(req, session.Id)
+
+	suite.Engine.ServeHTTP(rr, req)
+
+	util.AssertJsonHeader(t)(rr)
+	util.AssertHttpStatus(t)(rr, http.StatusRequestEntityTooLarge)
+	util.AssertEqual(t)(len(suite.AttachmentRepo.Data), 1)
+	// Stops reading not far past the largest file accepted.
+	util.AssertEqual(t)(body.n < attachments.DefaultMaxSize+2<<20, true)
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+func Test_GETAttachment(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	populateAttachments(suite)
+	session := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(session)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/v1/task/1/attachments/1", nil)
+	setRequestTokenHeader(t)(req, session.Id)
+
+	suite.Engine.ServeHTTP(rr, req)
+
+	util.AssertHttpStatus(t)(rr, http.StatusOK)
+	util.AssertEqual(t)(rr.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+	util.AssertEqual(t)(rr.Header().Get("Content-Length"), "9")
+	util.AssertEqual(t)(rr.Header().Get("Content-Disposition"), "attachment; filename*=utf-8''%E8%8F%9C%E5%96%AE.txt")
+	util.AssertEqual(t)(rr.Header().Get("X-Content-Type-Options"), "nosniff")
+	util.AssertEqual(t)(rr.Body.String(), "牛肉麵")
+}
+
+func Test_PurgeTaskWithAttachments(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	populateAttachments(suite)
+	task := suite.TaskRepo.Data[1]
+	task.DeletedAt = time.Now()
+	suite.TaskRepo.PopulateData(task)
+	session := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(session)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/v1/trash/1", nil)
+	setRequestTokenHeader(t)(req, session.Id)
+
+	suite.Engine.ServeHTTP(rr, req)
+
+	util.AssertHttpStatus(t)(rr, http.StatusOK)
+	util.AssertEqual(t)(len(suite.AttachmentRepo.Data), 0)
+	util.AssertEqual(t)(len(suite.BlobStore.Data), 0)
+}
@@ -0,0 +1,96 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/backup"
+	"github.com/dannyh79/whostodo/internal/sessions"
+	"github.com/dannyh79/whostodo/internal/workspaces"
+	"github.com/gin-gonic/gin"
+)
+
+type RestoreOutput struct {
+	Result backup.RestoreOutput `json:"result"`
+}
+
+type FailedRestoreOutput struct {
+	Result RestoreError `json:"result"`
+}
+
+type RestoreError struct {
+	Error string `json:"error,omitempty"`
+}
+
+// AddAdminRoutes adds the routes administering the server as a whole. They
+// are authenticated by the admin token instead of a session, so are not
+// added at all without one.
+func AddAdminRoutes(r *gin.Engine, s *sessions.SessionsUsecase, u *backup.BackupUsecase, token string) {
+	if token == "" {
+		return
+	}
+
+	admin := r.Group("/v1/admin")
+	admin.Use(adminMiddleware(token))
+
+	admin.GET("/backup", backupHandler(u))
+	admin.POST("/restore", restoreHandler(u))
+	admin.PUT("/accounts/:user", setAccountHandler(s))
+	admin.DELETE("/accounts/:user", deleteAccountHandler(s))
+}
+
+func adminMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(getTokenFromHeader(c)), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{})
+			return
+		}
+		c.Next()
+	}
+}
+
+// backupHandler streams the archive as it is written. Once any of it is
+// sent, errors can only cut it short, which leaves it without the checksum
+// restores require.
+func backupHandler(u *backup.BackupUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filename := "whostodo-" + time.Now().UTC().Format("20060102T150405Z") + ".tar"
+		c.Header("Content-Type", "application/x-tar")
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		if err := u.Backup(c.Writer); err != nil {
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.Writer.Header().Del("Content-Disposition")
+			c.JSON(http.StatusInternalServerError, gin.H{})
+		}
+	}
+}
+
+func restoreHandler(u *backup.BackupUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		restored, err := u.Restore(c.Request.Body)
+		if err != nil {
+			c.JSON(restoreErrorStatus(err), FailedRestoreOutput{Result: RestoreError{Error: err.Error()}})
+			return
+		}
+
+		c.JSON(http.StatusOK, RestoreOutput{Result: *restored})
+	}
+}
+
+func restoreErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, backup.ErrorTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, backup.ErrorInvalidArchive), errors.Is(err, backup.ErrorUnsupportedVersion):
+		return http.StatusBadRequest
+	case errors.Is(err, backup.ErrorChecksumMismatch), errors.Is(err, backup.ErrorInvalidState), errors.Is(err, workspaces.ErrorWorkspaceNotFound):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,192 @@
+package routes_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/backup"
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/rest/v1"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+// backupOf returns an archive of the suite, after building its default
+// workspace with a request of the session.
+func backupOf(t *testing.T, suite *util.MockTestSuite, session Session) *httptest.ResponseRecorder {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, "/v1/tasks", nil)
+	setRequestTokenHeader(t)(req, session.Id)
+	suite.Engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	rr := httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/v1/admin/backup", nil)
+	setRequestTokenHeader(t)(req, util.AdminToken)
+	suite.Engine.ServeHTTP(rr, req)
+	return rr
+}
+
+// entriesOf reads the contents of the entries of an archive by name.
+func entriesOf(t *testing.T, archive []byte) ([]string, map[string]string) {
+	t.Helper()
+	in := tar.NewReader(bytes.NewReader(archive))
+	var names []string
+	contents := map[string]string{}
+	for {
+		header, err := in.Next()
+		if err == io.EOF {
+			return names, contents
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(in)
+		names = append(names, header.Name)
+		contents[header.Name] = string(content)
+	}
+}
+
+func Test_GETAdminBackup(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐"})
+	session := util.NewSession()
+	suite.SessionRepo.PopulateData(session)
+
+	rr := backupOf(t, suite, session)
+
+	util.AssertHttpStatus(t)(rr, http.StatusOK)
+	util.AssertEqual(t)(rr.Header().Get("Content-Type"), "application/x-tar")
+	util.AssertEqual(t)(strings.HasPrefix(rr.Header().Get("Content-Disposition"), `attachment; filename="whostodo-`), true)
+	names, contents := entriesOf(t, rr.Body.Bytes())
+	util.AssertEqual(t)(names, []string{"manifest.json", "state.json", "checksum"})
+	var manifest backup.Manifest
+	json.Unmarshal([]byte(contents["manifest.json"]), &manifest)
+	util.AssertEqual(t)(manifest.Version, backup.Version)
+	util.AssertEqual(t)(strings.Contains(contents["state.json"], "買晚餐"), true)
+}
+
+func Test_AdminRoutesForbidden(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+	}{
+		{
+			name:   "GET backup without token returns status code 403",
+			method: http.MethodGet,
+			path:   "/v1/admin/backup",
+		},
+		{
+			name:   "GET backup with a session token returns status code 403",
+			method: http.MethodGet,
+			path:   "/v1/admin/backup",
+			token:  util.NewSession().Id,
+		},
+		{
+			name:   "POST restore with a session token returns status code 403",
+			method: http.MethodPost,
+			path:   "/v1/admin/restore",
+			token:  util.NewSession().Id,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.SessionRepo.PopulateData(util.NewSession())
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			if tc.token != "" {
+				setRequestTokenHeader(t)(req, tc.token)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, http.StatusForbidden)
+			util.AssertEqual(t)(rr.Body.String(), `{}`)
+		})
+	}
+}
+
+func Test_POSTAdminRestore(t *testing.T) {
+	from := util.NewTestSuite()
+	from.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐"})
+	session := util.NewSession()
+	from.SessionRepo.PopulateData(session)
+	archive := backupOf(t, from, session).Body.String()
+	var unsupported bytes.Buffer
+	out := tar.NewWriter(&unsupported)
+	out.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "manifest.json", Size: int64(len(`{"version":2}`)), Mode: 0o600})
+	out.Write([]byte(`{"version":2}`))
+	out.Close()
+
+	tests := []struct {
+		name       string
+		payload    string
+		statusCode int
+		expected   string
+		tasks      int
+	}{
+		{
+			name:       "returns status code 200 with counts",
+			payload:    archive,
+			statusCode: http.StatusOK,
+			tasks:      1,
+		},
+		{
+			name:       "returns status code 400 on malformed archive",
+			payload:    `{"version":`,
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{"error":"Invalid archive: unexpected EOF"}}`,
+		},
+		{
+			name:       "returns status code 400 on another version",
+			payload:    unsupported.String(),
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{"error":"Unsupported archive version: 2"}}`,
+		},
+		{
+			name:       "returns status code 422 on edited archive",
+			payload:    strings.Replace(archive, "買晚餐", "買早餐", 1),
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{"error":"Archive checksum mismatch"}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/v1/admin/restore", bytes.NewBufferString(tc.payload))
+			setRequestTokenHeader(t)(req, util.AdminToken)
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			if tc.expected != "" {
+				util.AssertEqual(t)(rr.Body.String(), tc.expected)
+			} else {
+				var got routes.RestoreOutput
+				json.Unmarshal(rr.Body.Bytes(), &got)
+				util.AssertEqual(t)(got.Result.Sessions, 1)
+				util.AssertEqual(t)(got.Result.Tasks, 1)
+				util.AssertEqual(t)(suite.SessionRepo.Data[session.Id].User, session.User)
+			}
+			util.AssertEqual(t)(len(suite.TaskRepo.Data), tc.tasks)
+		})
+	}
+}
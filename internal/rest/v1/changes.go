@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/access"
+	"github.com/dannyh79/whostodo/internal/changes"
+	taskentity "github.com/dannyh79/whostodo/internal/tasks/entities"
+	"github.com/dannyh79/whostodo/internal/workspaces"
+	"github.com/gin-gonic/gin"
+)
+
+// How often a comment is sent down idle change streams, so proxies do not
+// close them.
+const changesKeepAlive = 30 * time.Second
+
+// scopeKey holds the *access.Scope of a request that may change something in
+// the gin context, for actorFromContext to hand to the tasks usecase.
+const scopeKey = "changes_scope"
+
+// changesMiddleware tells watchers of the session's workspace about requests
+// that may have changed something in it, once they succeeded, if they may see
+// what the request touched.
+func changesMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w, ok := c.Get(workspaceKey)
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			ok = false
+		}
+		if !ok {
+			c.Next()
+			return
+		}
+
+		workspace := w.(*workspaces.Workspace)
+		scope := access.InitScope(workspace.Lists)
+		c.Set(scopeKey, scope)
+		touchRoute(c, workspace, scope)
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		workspace.Changes.Publish(changes.Change{
+			Actor:  userFromContext(c),
+			Method: c.Request.Method,
+			Path:   c.Request.URL.Path,
+			Scope:  scope,
+		})
+	}
+}
+
+// touchRoute adds what the route names to the scope, other than tasks, which
+// the tasks usecase adds as it changes them. Readers of a list are taken
+// before the request, so that those it is deleted or unshared from are told.
+func touchRoute(c *gin.Context, w *workspaces.Workspace, scope *access.Scope) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	path := c.FullPath()
+	switch {
+	case strings.HasPrefix(path, "/v1/task/:id/comments"), strings.HasPrefix(path, "/v1/task/:id/attachments"):
+		if t, err := w.Tasks.GetTask(actorFromContext(c), id); err == nil {
+			scope.AddTask(&taskentity.Task{ListId: t.ListId, Creator: t.Creator, Assignee: t.Assignee})
+		}
+	case strings.HasPrefix(path, "/v1/list/:id"), path == "/v1/invitations/:id/accept":
+		scope.AddList(id)
+		scope.AddUsers(w.Lists.Readers(id)...)
+		if member := c.Param("user"); member != "" {
+			scope.AddUsers(member)
+		}
+	}
+}
+
+// watchChangesHandler streams changes made in the session's workspace that
+// its user may see as server-sent events. A ready event comes first, once subscribed, so clients
+// fetching after it miss no change.
+func watchChangesHandler(c *gin.Context) {
+	w := c.MustGet(workspaceKey).(*workspaces.Workspace)
+	watched, unsubscribe := w.Changes.Subscribe(userFromContext(c))
+	defer unsubscribe()
+	keepAlive := time.NewTicker(changesKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.SSEvent("ready", gin.H{})
+	c.Writer.Flush()
+	c.Stream(func(out io.Writer) bool {
+		select {
+		case change := <-watched:
+			c.SSEvent("change", change)
+		case <-keepAlive.C:
+			io.WriteString(out, ": keep-alive\n\n")
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
@@ -0,0 +1,144 @@
+package routes_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+// readEvents returns the lines of the next count server-sent events.
+func readEvents(t *testing.T, r *bufio.Reader, count int) []string {
+	t.Helper()
+	var lines []string
+	for count > 0 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			count -= 1
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func Test_GETChanges(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Creator: "alice"})
+	alice := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(alice)
+	server := httptest.NewServer(suite.Engine)
+	t.Cleanup(server.Close)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v1/changes", nil)
+	setRequestTokenHeader(t)(req, alice.Id)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	events := bufio.NewReader(res.Body)
+
+	util.AssertEqual(t)(res.StatusCode, http.StatusOK)
+	util.AssertEqual(t)(res.Header.Get("Content-Type"), "text/event-stream")
+	util.AssertEqual(t)(readEvents(t, events, 1), []string{"event:ready", "data:{}"})
+
+	for _, id := range []string{"2", "1"} {
+		req, _ := http.NewRequest(http.MethodPut, server.URL+"/v1/task/"+id, bytes.NewBufferString(`{"name":"買午餐","status":1}`))
+		setRequestTokenHeader(t)(req, alice.Id)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	// Only the update that succeeded is told of.
+	util.AssertEqual(t)(readEvents(t, events, 1), []string{"event:change", `data:{"actor":"alice","method":"PUT","path":"/v1/task/1"}`})
+}
+
+func Test_GETChangesVisibility(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "alice"})
+	suite.MemberRepo.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "carol", Role: "viewer", Accepted: true})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Creator: "alice"})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "洗碗", ListId: 1, Creator: "alice"})
+	alice := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(alice)
+	server := httptest.NewServer(suite.Engine)
+	t.Cleanup(server.Close)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watch := func(user string) *bufio.Reader {
+		session := util.NewUserSession(user)
+		suite.SessionRepo.PopulateData(session)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v1/changes", nil)
+		setRequestTokenHeader(t)(req, session.Id)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { res.Body.Close() })
+		events := bufio.NewReader(res.Body)
+		readEvents(t, events, 1)
+		return events
+	}
+	bob := watch("bob")
+	carol := watch("carol")
+
+	for _, r := range []struct{ method, path, payload string }{
+		{http.MethodPut, "/v1/task/1", `{"name":"買午餐","status":1}`},
+		{http.MethodPut, "/v1/task/2", `{"name":"洗碗","status":1}`},
+		{http.MethodPost, "/v1/task/2/comments", `{"body":"洗好了"}`},
+		{http.MethodPut, "/v1/list/1/members/bob", `{"role":"viewer"}`},
+	} {
+		req, _ := http.NewRequest(r.method, server.URL+r.path, bytes.NewBufferString(r.payload))
+		req.Header.Add("Content-Type", "application/json")
+		setRequestTokenHeader(t)(req, alice.Id)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	// Changes to the inbox of alice reach no one else, those to the list its
+	// members, and invitations the invitee.
+	util.AssertEqual(t)(readEvents(t, bob, 1), []string{"event:change", `data:{"actor":"alice","method":"PUT","path":"/v1/list/1/members/bob"}`})
+	util.AssertEqual(t)(readEvents(t, carol, 3), []string{
+		"event:change", `data:{"actor":"alice","method":"PUT","path":"/v1/task/2"}`,
+		"event:change", `data:{"actor":"alice","method":"POST","path":"/v1/task/2/comments"}`,
+		"event:change", `data:{"actor":"alice","method":"PUT","path":"/v1/list/1/members/bob"}`,
+	})
+}
+
+func Test_GETChangesForbidden(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/v1/changes", nil)
+
+	suite.Engine.ServeHTTP(rr, req)
+
+	util.AssertHttpStatus(t)(rr, http.StatusForbidden)
+	util.AssertEqual(t)(rr.Body.String(), `{}`)
+}
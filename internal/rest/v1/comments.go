@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/comments"
+	"github.com/gin-gonic/gin"
+)
+
+type CommentResult struct {
+	Id        int        `json:"id"`
+	TaskId    int        `json:"task_id"`
+	Author    string     `json:"author"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+}
+
+type ListCommentsOutput struct {
+	Result []CommentResult `json:"result"`
+}
+
+type CommentOutput struct {
+	Result CommentResult `json:"result"`
+}
+
+type FailedCommentOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func listCommentsHandler(u *comments.CommentsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskId, _ := strconv.Atoi(c.Param("id"))
+		cs, err := u.ListComments(userFromContext(c), taskId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedCommentOutput{})
+			return
+		}
+
+		var output = ListCommentsOutput{Result: make([]CommentResult, 0)}
+		for _, comment := range cs {
+			output.Result = append(output.Result, toCommentResult(comment))
+		}
+		c.JSON(http.StatusOK, output)
+	}
+}
+
+func createCommentHandler(u *comments.CommentsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskId, _ := strconv.Atoi(c.Param("id"))
+		var payload comments.CommentInput
+		c.ShouldBind(&payload)
+
+		comment, err := u.CreateComment(userFromContext(c), taskId, &payload)
+		if err != nil {
+			c.JSON(commentErrorStatus(err), FailedCommentOutput{})
+			return
+		}
+
+		c.JSON(http.StatusCreated, CommentOutput{Result: toCommentResult(comment)})
+	}
+}
+
+func updateCommentHandler(u *comments.CommentsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskId, _ := strconv.Atoi(c.Param("id"))
+		id, _ := strconv.Atoi(c.Param("comment"))
+		var payload comments.CommentInput
+		c.ShouldBind(&payload)
+
+		comment, err := u.UpdateComment(userFromContext(c), taskId, id, &payload)
+		if err != nil {
+			c.JSON(commentErrorStatus(err), FailedCommentOutput{})
+			return
+		}
+
+		c.JSON(http.StatusCreated, CommentOutput{Result: toCommentResult(comment)})
+	}
+}
+
+func deleteCommentHandler(u *comments.CommentsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskId, _ := strconv.Atoi(c.Param("id"))
+		id, _ := strconv.Atoi(c.Param("comment"))
+
+		err := u.DeleteComment(userFromContext(c), taskId, id)
+		if err != nil {
+			c.JSON(commentErrorStatus(err), nil)
+			return
+		}
+
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func commentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, comments.ErrorInvalidComment):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, comments.ErrorNotAuthor), errors.Is(err, comments.ErrorForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusNotFound
+	}
+}
+
+func toCommentResult(c *comments.CommentOutput) CommentResult {
+	return CommentResult{
+		Id:        c.Id,
+		TaskId:    c.TaskId,
+		Author:    c.Author,
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt,
+		EditedAt:  c.EditedAt,
+	}
+}
@@ -0,0 +1,142 @@
+package routes_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func populateComments(suite *util.MockTestSuite) {
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Creator: "alice", Assignee: "bob"})
+	suite.CommentRepo.PopulateData(repository.CommentSchema{
+		Id:        1,
+		TaskId:    1,
+		Author:    "alice",
+		Body:      "要買幾份？",
+		CreatedAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+	})
+}
+
+func Test_CommentRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		method     string
+		path       string
+		payload    string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "GET comments returns status code 200 with result",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodGet,
+			path:       "/v1/task/1/comments",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":1,"task_id":1,"author":"alice","body":"要買幾份？","created_at":"2024-01-01T09:00:00Z"}]}`,
+		},
+		{
+			name:       "GET comments of unknown task returns status code 404",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodGet,
+			path:       "/v1/task/9/comments",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "POST comments with empty body returns status code 422",
+			authroized: true,
+			session:    util.NewUserSession("bob"),
+			method:     http.MethodPost,
+			path:       "/v1/task/1/comments",
+			payload:    `{"body":""}`,
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "PUT comment of another user returns status code 403",
+			authroized: true,
+			session:    util.NewUserSession("bob"),
+			method:     http.MethodPut,
+			path:       "/v1/task/1/comments/1",
+			payload:    `{"body":"兩份"}`,
+			statusCode: http.StatusForbidden,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "DELETE comment returns status code 200",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodDelete,
+			path:       "/v1/task/1/comments/1",
+			statusCode: http.StatusOK,
+			expected:   `null`,
+		},
+		{
+			name:       "DELETE unknown comment returns status code 404",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodDelete,
+			path:       "/v1/task/1/comments/9",
+			statusCode: http.StatusNotFound,
+			expected:   `null`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			authroized: false,
+			method:     http.MethodGet,
+			path:       "/v1/task/1/comments",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			populateComments(suite)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.payload))
+			req.Header.Add("Content-Type", "application/json")
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_POSTComment(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	populateComments(suite)
+	session := util.NewUserSession("bob")
+	suite.SessionRepo.PopulateData(session)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/v1/task/1/comments", bytes.NewBufferString(`{"body":"兩份"}`))
+	req.Header.Add("Content-Type", "application/json")
+	setRequestTokenHeader(t)(req, session.Id)
+
+	suite.Engine.ServeHTTP(rr, req)
+
+	util.AssertHttpStatus(t)(rr, http.StatusCreated)
+	util.AssertEqual(t)(suite.CommentRepo.Data[2].Author, "bob")
+	util.AssertEqual(t)(suite.CommentRepo.Data[2].Body, "兩份")
+}
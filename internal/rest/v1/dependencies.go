@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+type DependenciesOutput struct {
+	Result struct {
+		Blockers   []ListTaskItem `json:"blockers"`
+		Dependents []ListTaskItem `json:"dependents"`
+	} `json:"result"`
+}
+
+type FailedDependenciesOutput struct {
+	Result struct{} `json:"result"`
+}
+
+type BlockerTaskOutput struct {
+	Result TaskResult `json:"result"`
+}
+
+type FailedBlockerTaskOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func dependenciesHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		deps, err := u.GetDependencies(actorFromContext(c), id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedDependenciesOutput{})
+			return
+		}
+
+		var output DependenciesOutput
+		output.Result.Blockers = toListTaskItems(deps.Blockers)
+		output.Result.Dependents = toListTaskItems(deps.Dependents)
+		c.JSON(http.StatusOK, output)
+	}
+}
+
+func addBlockerHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		var payload tasks.BlockerInput
+		c.ShouldBind(&payload)
+
+		updated, err := u.AddBlocker(actorFromContext(c), id, &payload)
+		if isInvalidTaskInput(err) {
+			c.JSON(http.StatusUnprocessableEntity, FailedBlockerTaskOutput{})
+			return
+		}
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, FailedBlockerTaskOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedBlockerTaskOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, BlockerTaskOutput{Result: toTaskResult(updated)})
+	}
+}
+
+func removeBlockerHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		blockerId, _ := strconv.Atoi(c.Param("blocker"))
+
+		updated, err := u.RemoveBlocker(actorFromContext(c), id, blockerId)
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, FailedBlockerTaskOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedBlockerTaskOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, BlockerTaskOutput{Result: toTaskResult(updated)})
+	}
+}
+
+func listNextTasksHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tasks := u.ListNextTasks(actorFromContext(c))
+		c.JSON(http.StatusOK, toListTasksOutput(tasks))
+	}
+}
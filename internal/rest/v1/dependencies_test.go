@@ -0,0 +1,126 @@
+package routes_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func populateDependencies(suite *util.MockTestSuite) {
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "煮飯", BlockedBy: []int{2}})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "買菜"})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 3, Name: "倒垃圾"})
+}
+
+func Test_DependencyRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		method     string
+		path       string
+		payload    string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "GET dependencies returns status code 200 with result",
+			authroized: true,
+			session:    util.NewSession(),
+			method:     http.MethodGet,
+			path:       "/v1/task/2/dependencies",
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"blockers":[],"dependents":[{"id":1,"name":"煮飯","status":0,"blocked_by":[2]}]}}`,
+		},
+		{
+			name:       "GET dependencies returns status code 404",
+			authroized: true,
+			session:    util.NewSession(),
+			method:     http.MethodGet,
+			path:       "/v1/task/9/dependencies",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "POST blockers returns status code 200 with blocked task",
+			authroized: true,
+			session:    util.NewSession(),
+			method:     http.MethodPost,
+			path:       "/v1/task/3/blockers",
+			payload:    `{"id":1}`,
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"name":"倒垃圾","status":0,"id":3,"blocked_by":[1]}}`,
+		},
+		{
+			name:       "POST blockers with a cycle returns status code 422",
+			authroized: true,
+			session:    util.NewSession(),
+			method:     http.MethodPost,
+			path:       "/v1/task/2/blockers",
+			payload:    `{"id":1}`,
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "DELETE blockers returns status code 200 with unblocked task",
+			authroized: true,
+			session:    util.NewSession(),
+			method:     http.MethodDelete,
+			path:       "/v1/task/1/blockers/2",
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"name":"煮飯","status":0,"id":1}}`,
+		},
+		{
+			name:       "PUT blocked task as done returns status code 409",
+			authroized: true,
+			session:    util.NewSession(),
+			method:     http.MethodPut,
+			path:       "/v1/task/1",
+			payload:    `{"name":"煮飯","status":1}`,
+			statusCode: http.StatusConflict,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "GET next tasks returns status code 200 with ordered tasks",
+			authroized: true,
+			session:    util.NewSession(),
+			method:     http.MethodGet,
+			path:       "/v1/tasks/next",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":2,"name":"買菜","status":0},{"id":1,"name":"煮飯","status":0,"blocked_by":[2]},{"id":3,"name":"倒垃圾","status":0}]}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			method:     http.MethodGet,
+			path:       "/v1/tasks/next",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			populateDependencies(suite)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.payload))
+			req.Header.Add("Content-Type", "application/json")
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dannyh79/whostodo/internal/markdown"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+// Values of the format query parameter for task descriptions.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+type GetTaskOutput struct {
+	Result TaskResult `json:"result"`
+}
+
+type FailedGetTaskOutput struct {
+	Result struct{} `json:"result"`
+}
+
+type FailedListTasksOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func getTaskHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		render, ok := descriptionRenderer(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, FailedGetTaskOutput{})
+			return
+		}
+
+		id, _ := strconv.Atoi(c.Param("id"))
+		task, err := u.GetTask(actorFromContext(c), id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedGetTaskOutput{})
+			return
+		}
+
+		renderDescriptions(render, task)
+		c.JSON(http.StatusOK, GetTaskOutput{Result: toTaskResult(task)})
+	}
+}
+
+// descriptionRenderer returns how to present descriptions in the requested
+// format, Markdown source by default, or false for an unknown format.
+func descriptionRenderer(c *gin.Context) (func(string) string, bool) {
+	switch c.DefaultQuery("format", FormatMarkdown) {
+	case FormatMarkdown:
+		return func(source string) string { return source }, true
+	case FormatHTML:
+		return markdown.Render, true
+	default:
+		return nil, false
+	}
+}
+
+func renderDescriptions(render func(string) string, ts ...*tasks.TaskOutput) {
+	for _, t := range ts {
+		if t.Description != "" {
+			t.Description = render(t.Description)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_TaskDescriptionRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		path       string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "GET task returns status code 200 with Markdown description",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/1",
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"name":"買晚餐","status":0,"id":1,"description":"**快** \u003cb\u003e"}}`,
+		},
+		{
+			name:       "GET task returns status code 200 with HTML description",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/1?format=html",
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"name":"買晚餐","status":0,"id":1,"description":"\u003cp\u003e\u003cstrong\u003e快\u003c/strong\u003e \u0026lt;b\u0026gt;\u003c/p\u003e"}}`,
+		},
+		{
+			name:       "GET task with unknown format returns status code 400",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/1?format=pdf",
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "GET task returns status code 404",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/9",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "GET tasks returns status code 200 with HTML descriptions",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/tasks?format=html",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":1,"name":"買晚餐","status":0,"description":"\u003cp\u003e\u003cstrong\u003e快\u003c/strong\u003e \u0026lt;b\u0026gt;\u003c/p\u003e"}]}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			authroized: false,
+			path:       "/v1/task/1",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Description: "**快** <b>"})
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
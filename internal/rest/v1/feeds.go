@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/sessions"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/dannyh79/whostodo/internal/workspaces"
+	"github.com/gin-gonic/gin"
+)
+
+// Feed paths end in this, as some calendar apps go by the extension.
+const feedExtension = ".ics"
+
+type FeedResult struct {
+	Token     string    `json:"token"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FeedOutput struct {
+	Result FeedResult `json:"result"`
+}
+
+type FailedFeedOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func getFeedHandler(u *sessions.SessionsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		feed, err := u.Feed(c.GetString(sessions.WorkspaceKey), userFromContext(c))
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedFeedOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, FeedOutput{Result: toFeedResult(feed)})
+	}
+}
+
+func rotateFeedHandler(u *sessions.SessionsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		feed, err := u.RotateFeed(c.GetString(sessions.WorkspaceKey), userFromContext(c))
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedFeedOutput{})
+			return
+		}
+
+		c.JSON(http.StatusCreated, FeedOutput{Result: toFeedResult(feed)})
+	}
+}
+
+func revokeFeedHandler(u *sessions.SessionsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := u.RevokeFeed(c.GetString(sessions.WorkspaceKey), userFromContext(c))
+		if err != nil {
+			c.JSON(http.StatusNotFound, nil)
+			return
+		}
+
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+// feedCalendarHandler serves the tasks the feed's user may see as an
+// iCalendar, authenticated by the token in the path rather than a session.
+func feedCalendarHandler(sessionsU *sessions.SessionsUsecase, workspacesU *workspaces.WorkspacesUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		feed, ok := sessionsU.FeedOf(strings.TrimSuffix(c.Param("token"), feedExtension))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{})
+			return
+		}
+		w, err := workspacesU.Find(feed.Workspace)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		w.Lock()
+		defer w.Unlock()
+		c.Header("Content-Type", "text/calendar; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := w.Tasks.ExportICal(tasks.Actor{User: feed.User}, c.Writer); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+func toFeedResult(f *sessions.Feed) FeedResult {
+	return FeedResult{
+		Token:     f.Token,
+		Path:      "/v1/feeds/" + f.Token + feedExtension,
+		CreatedAt: f.CreatedAt,
+	}
+}
@@ -0,0 +1,154 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/rest/v1"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_FeedRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		feed       *repository.Feed
+		method     string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "GET feed returns status code 200 with result",
+			feed:       &repository.Feed{Token: "feed_token", User: "alice", Workspace: "default", CreatedAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)},
+			method:     http.MethodGet,
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"token":"feed_token","path":"/v1/feeds/feed_token.ics","created_at":"2024-01-01T09:00:00Z"}}`,
+		},
+		{
+			name:       "GET feed of another user returns status code 404",
+			feed:       &repository.Feed{Token: "feed_token", User: "bob", Workspace: "default"},
+			method:     http.MethodGet,
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "GET feed of another workspace returns status code 404",
+			feed:       &repository.Feed{Token: "feed_token", User: "alice", Workspace: "team-a"},
+			method:     http.MethodGet,
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "DELETE feed returns status code 200",
+			feed:       &repository.Feed{Token: "feed_token", User: "alice", Workspace: "default"},
+			method:     http.MethodDelete,
+			statusCode: http.StatusOK,
+			expected:   `null`,
+		},
+		{
+			name:       "DELETE feed without one returns status code 404",
+			method:     http.MethodDelete,
+			statusCode: http.StatusNotFound,
+			expected:   `null`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			if tc.feed != nil {
+				suite.FeedRepo.PopulateData(*tc.feed)
+			}
+			session := util.NewUserSession("alice")
+			suite.SessionRepo.PopulateData(session)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, "/v1/feed", nil)
+			setRequestTokenHeader(t)(req, session.Id)
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_PUTFeed(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.FeedRepo.PopulateData(repository.Feed{Token: "old_token", User: "alice", Workspace: "default"})
+	session := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(session)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/v1/feed", nil)
+	setRequestTokenHeader(t)(req, session.Id)
+
+	suite.Engine.ServeHTTP(rr, req)
+
+	util.AssertHttpStatus(t)(rr, http.StatusCreated)
+	var got routes.FeedOutput
+	json.Unmarshal(rr.Body.Bytes(), &got)
+	util.AssertEqual(t)(got.Result.Path, "/v1/feeds/"+got.Result.Token+".ics")
+	util.AssertEqual(t)(len(suite.FeedRepo.Data), 1)
+	util.AssertEqual(t)(suite.FeedRepo.Data[got.Result.Token].User, "alice")
+}
+
+func Test_GETFeedCalendar(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with the tasks the user may see",
+			path:       "/v1/feeds/feed_token.ics",
+			statusCode: http.StatusOK,
+			expected:   "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//whostodo//whostodo//EN\r\nCALSCALE:GREGORIAN\r\nBEGIN:VTODO\r\nUID:2@whostodo\r\nDTSTAMP:X\r\nSUMMARY:買晚餐\r\nSTATUS:NEEDS-ACTION\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+		},
+		{
+			name:       "returns status code 200 without the extension",
+			path:       "/v1/feeds/feed_token",
+			statusCode: http.StatusOK,
+			expected:   "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//whostodo//whostodo//EN\r\nCALSCALE:GREGORIAN\r\nBEGIN:VTODO\r\nUID:2@whostodo\r\nDTSTAMP:X\r\nSUMMARY:買晚餐\r\nSTATUS:NEEDS-ACTION\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+		},
+		{
+			name:       "returns status code 404 on unknown token",
+			path:       "/v1/feeds/stubbed_token.ics",
+			statusCode: http.StatusNotFound,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "bob"})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", ListId: 1})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐", Creator: "alice"})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 3, Name: "繳帳單", Creator: "bob"})
+			suite.FeedRepo.PopulateData(repository.Feed{Token: "feed_token", User: "alice", Workspace: "default"})
+			suite.SessionRepo.PopulateData(util.NewSession())
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tc.path, nil)
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(dtstampPattern.ReplaceAllString(rr.Body.String(), "DTSTAMP:X"), tc.expected)
+			if tc.statusCode == http.StatusOK {
+				util.AssertEqual(t)(strings.HasPrefix(rr.Header().Get("Content-Type"), "text/calendar"), true)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dannyh79/whostodo/internal/lists"
+	"github.com/dannyh79/whostodo/internal/workspaces"
+	"github.com/gin-gonic/gin"
+)
+
+type ListListItem struct {
+	Id    int    `json:"id"`
+	Name  string `json:"name"`
+	Owner string `json:"owner,omitempty"`
+}
+type ListListsOutput struct {
+	Result []ListListItem `json:"result"`
+}
+
+type PostListOutput struct {
+	Result struct {
+		Name  string `json:"name"`
+		Id    int    `json:"id"`
+		Owner string `json:"owner,omitempty"`
+	} `json:"result"`
+}
+
+type UpdateListOutput struct {
+	Result struct {
+		Name  string `json:"name"`
+		Id    int    `json:"id"`
+		Owner string `json:"owner,omitempty"`
+	} `json:"result"`
+}
+
+type FailedUpdateListOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func listListsHandler(u *lists.ListsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lists := u.ListLists(userFromContext(c))
+		c.JSON(http.StatusOK, toListListsOutput(lists))
+	}
+}
+
+func createListHandler(u *lists.ListsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var payload lists.CreateListInput
+		c.ShouldBind(&payload)
+		list := u.CreateList(userFromContext(c), &payload)
+		c.JSON(http.StatusCreated, toPostListOutput(list))
+	}
+}
+
+func updateListHandler(u *lists.ListsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		var payload lists.UpdateListInput
+		c.ShouldBind(&payload)
+
+		updated, err := u.UpdateList(userFromContext(c), id, &payload)
+		if errors.Is(err, lists.ErrorNotOwner) {
+			c.JSON(http.StatusForbidden, FailedUpdateListOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedUpdateListOutput{})
+			return
+		}
+
+		c.JSON(http.StatusCreated, toUpdateListOutput(updated))
+	}
+}
+
+func deleteListHandler(w *workspaces.Workspace) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		var query lists.DeleteListInput
+		c.ShouldBindQuery(&query)
+
+		err := w.DeleteList(actorFromContext(c), id, &query)
+		if errors.Is(err, lists.ErrorUnknownDeleteMode) {
+			c.JSON(http.StatusBadRequest, nil)
+			return
+		}
+		if errors.Is(err, lists.ErrorNotOwner) {
+			c.JSON(http.StatusForbidden, nil)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, nil)
+			return
+		}
+
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func toListListsOutput(ls []*lists.ListOutput) *ListListsOutput {
+	var result = make([]ListListItem, 0)
+	var output ListListsOutput
+	for _, l := range ls {
+		result = append(result, ListListItem{
+			Id:    l.Id,
+			Name:  l.Name,
+			Owner: l.Owner,
+		})
+	}
+	output.Result = result
+	return &output
+}
+
+func toPostListOutput(l *lists.ListOutput) *PostListOutput {
+	var output PostListOutput
+	output.Result.Id = l.Id
+	output.Result.Name = l.Name
+	output.Result.Owner = l.Owner
+	return &output
+}
+
+func toUpdateListOutput(l *lists.ListOutput) *UpdateListOutput {
+	var output UpdateListOutput
+	output.Result.Id = l.Id
+	output.Result.Name = l.Name
+	output.Result.Owner = l.Owner
+	return &output
+}
@@ -0,0 +1,317 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/rest/v1"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_GETLists(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		data       []repository.ListSchema
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with result",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       []repository.ListSchema{{Id: 1, Name: "家事", Owner: "anonymous"}},
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":0,"name":"Inbox"},{"id":1,"name":"家事","owner":"anonymous"}]}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			for _, row := range tc.data {
+				suite.ListRepo.PopulateData(row)
+			}
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/v1/lists", nil)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_POSTList(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		data       string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 201 with result",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       `{"name":"家事"}`,
+			statusCode: http.StatusCreated,
+			expected:   `{"result":{"name":"家事","id":1,"owner":"anonymous"}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/v1/list", bytes.NewBufferString(tc.data))
+			req.Header.Add("Content-Type", "application/json")
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_PUTList(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		param      int
+		payload    string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 201 with result",
+			authroized: true,
+			session:    util.NewSession(),
+			param:      1,
+			payload:    `{"name":"工作"}`,
+			statusCode: http.StatusCreated,
+			expected:   `{"result":{"name":"工作","id":1,"owner":"anonymous"}}`,
+		},
+		{
+			name:       "returns status code 404 with empty result",
+			authroized: true,
+			session:    util.NewSession(),
+			param:      2,
+			payload:    `{"name":"工作"}`,
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "anonymous"})
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(
+				http.MethodPut,
+				fmt.Sprintf("/v1/list/%d", tc.param),
+				bytes.NewBufferString(tc.payload),
+			)
+			req.Header.Add("Content-Type", "application/json")
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_DELETEList(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		path       string
+		statusCode int
+		remaining  int
+	}{
+		{
+			name:       "moving tasks into the inbox returns status code 200",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/list/1",
+			statusCode: http.StatusOK,
+			remaining:  1,
+		},
+		{
+			name:       "moving tasks into the trash returns status code 200",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/list/1?tasks=cascade",
+			statusCode: http.StatusOK,
+			remaining:  0,
+		},
+		{
+			name:       "with unknown mode returns status code 400",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/list/1?tasks=elsewhere",
+			statusCode: http.StatusBadRequest,
+			remaining:  1,
+		},
+		{
+			name:       "returns status code 404",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/list/2",
+			statusCode: http.StatusNotFound,
+			remaining:  1,
+		},
+		{
+			name:       "without session token returns status code 403",
+			path:       "/v1/list/1",
+			statusCode: http.StatusForbidden,
+			remaining:  1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "anonymous"})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", ListId: 1})
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, tc.path, nil)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(len(suite.TaskRepo.ListAll()), tc.remaining)
+		})
+	}
+}
+
+// Test_DELETESharedList deletes list 1 of alice, shared with bob and carol,
+// holding a task of alice and one of bob. Tasks of the list end up with their
+// creators, and neither the viewer nor the owner keeps those of others.
+func Test_DELETESharedList(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		tasks    map[string][]int
+		trashed  map[string][]int
+		restored map[string][]int
+	}{
+		{
+			name:     "moves tasks into the inbox of their creators",
+			path:     "/v1/list/1",
+			tasks:    map[string][]int{"alice": {1}, "bob": {3, 2}, "carol": nil},
+			trashed:  map[string][]int{"alice": nil, "bob": nil, "carol": nil},
+			restored: map[string][]int{},
+		},
+		{
+			name:     "moves tasks into the trash of their creators",
+			path:     "/v1/list/1?tasks=cascade",
+			tasks:    map[string][]int{"alice": nil, "bob": {3}, "carol": nil},
+			trashed:  map[string][]int{"alice": {1}, "bob": {2}, "carol": nil},
+			restored: map[string][]int{"alice": {1}, "bob": {3, 2}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "alice"})
+			suite.MemberRepo.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "bob", Role: "editor", Accepted: true})
+			suite.MemberRepo.PopulateData(repository.MemberSchema{Id: 2, ListId: 1, User: "carol", Role: "viewer", Accepted: true})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", ListId: 1, Position: "a", Creator: "alice"})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐", ListId: 1, Position: "b", Creator: "bob"})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 3, Name: "繳帳單", Position: "c", Creator: "bob"})
+			for _, user := range []string{"alice", "bob", "carol"} {
+				suite.SessionRepo.PopulateData(util.NewUserSession(user))
+			}
+			serve := func(method string, path string, user string) *httptest.ResponseRecorder {
+				rr := httptest.NewRecorder()
+				req, _ := http.NewRequest(method, path, nil)
+				setRequestTokenHeader(t)(req, user+"_token")
+				suite.Engine.ServeHTTP(rr, req)
+				return rr
+			}
+			ids := func(path string, user string) []int {
+				var got routes.ListTasksOutput
+				json.Unmarshal(serve(http.MethodGet, path, user).Body.Bytes(), &got)
+				var ids []int
+				for _, task := range got.Result {
+					ids = append(ids, task.Id)
+				}
+				return ids
+			}
+
+			util.AssertHttpStatus(t)(serve(http.MethodDelete, tc.path, "alice"), http.StatusOK)
+
+			for user, expected := range tc.tasks {
+				util.AssertEqual(t)(ids("/v1/tasks", user), expected)
+				util.AssertEqual(t)(ids("/v1/trash", user), tc.trashed[user])
+			}
+			util.AssertHttpStatus(t)(serve(http.MethodPost, "/v1/undo", "alice"), http.StatusForbidden)
+			for user, expected := range tc.restored {
+				for _, id := range tc.trashed[user] {
+					util.AssertHttpStatus(t)(serve(http.MethodPost, fmt.Sprintf("/v1/trash/%d/restore", id), user), http.StatusOK)
+				}
+				util.AssertEqual(t)(ids("/v1/tasks", user), expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dannyh79/whostodo/internal/lists"
+	"github.com/gin-gonic/gin"
+)
+
+type MemberResult struct {
+	User     string `json:"user"`
+	Role     string `json:"role"`
+	Accepted bool   `json:"accepted"`
+}
+
+type ListMembersOutput struct {
+	Result []MemberResult `json:"result"`
+}
+
+type MemberOutput struct {
+	Result MemberResult `json:"result"`
+}
+
+type FailedMemberOutput struct {
+	Result struct{} `json:"result"`
+}
+
+type InvitationItem struct {
+	ListId   int    `json:"list_id"`
+	ListName string `json:"list_name"`
+	Owner    string `json:"owner"`
+	Role     string `json:"role"`
+}
+
+type ListInvitationsOutput struct {
+	Result []InvitationItem `json:"result"`
+}
+
+func listMembersHandler(u *lists.ListsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listId, _ := strconv.Atoi(c.Param("id"))
+		members, err := u.ListMembers(userFromContext(c), listId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedMemberOutput{})
+			return
+		}
+
+		var output = ListMembersOutput{Result: make([]MemberResult, 0)}
+		for _, m := range members {
+			output.Result = append(output.Result, toMemberResult(m))
+		}
+		c.JSON(http.StatusOK, output)
+	}
+}
+
+func inviteMemberHandler(u *lists.ListsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listId, _ := strconv.Atoi(c.Param("id"))
+		var payload lists.MemberInput
+		c.ShouldBind(&payload)
+
+		member, err := u.InviteMember(userFromContext(c), listId, c.Param("user"), &payload)
+		if err != nil {
+			c.JSON(memberErrorStatus(err), FailedMemberOutput{})
+			return
+		}
+
+		c.JSON(http.StatusCreated, MemberOutput{Result: toMemberResult(member)})
+	}
+}
+
+func removeMemberHandler(u *lists.ListsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listId, _ := strconv.Atoi(c.Param("id"))
+
+		err := u.RemoveMember(userFromContext(c), listId, c.Param("user"))
+		if err != nil {
+			c.JSON(memberErrorStatus(err), nil)
+			return
+		}
+
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func listInvitationsHandler(u *lists.ListsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var output = ListInvitationsOutput{Result: make([]InvitationItem, 0)}
+		for _, i := range u.ListInvitations(userFromContext(c)) {
+			output.Result = append(output.Result, InvitationItem{
+				ListId:   i.ListId,
+				ListName: i.ListName,
+				Owner:    i.Owner,
+				Role:     i.Role,
+			})
+		}
+		c.JSON(http.StatusOK, output)
+	}
+}
+
+func acceptInvitationHandler(u *lists.ListsUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listId, _ := strconv.Atoi(c.Param("id"))
+		member, err := u.AcceptInvitation(userFromContext(c), listId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedMemberOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, MemberOutput{Result: toMemberResult(member)})
+	}
+}
+
+func memberErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, lists.ErrorInvalidRole), errors.Is(err, lists.ErrorInvalidMember):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, lists.ErrorNotOwner):
+		return http.StatusForbidden
+	default:
+		return http.StatusNotFound
+	}
+}
+
+func toMemberResult(m *lists.MemberOutput) MemberResult {
+	return MemberResult{
+		User:     m.User,
+		Role:     m.Role,
+		Accepted: m.Accepted,
+	}
+}
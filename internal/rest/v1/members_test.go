@@ -0,0 +1,200 @@
+package routes_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func populateMembers(suite *util.MockTestSuite) {
+	suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "alice"})
+	suite.MemberRepo.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "bob", Role: "viewer", Accepted: true})
+	suite.MemberRepo.PopulateData(repository.MemberSchema{Id: 2, ListId: 1, User: "carol", Role: "editor"})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", ListId: 1})
+}
+
+func Test_MemberRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		method     string
+		path       string
+		payload    string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "GET members returns status code 200 with result",
+			authroized: true,
+			session:    util.NewUserSession("bob"),
+			method:     http.MethodGet,
+			path:       "/v1/list/1/members",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"user":"bob","role":"viewer","accepted":true},{"user":"carol","role":"editor","accepted":false}]}`,
+		},
+		{
+			name:       "GET members of a hidden list returns status code 404",
+			authroized: true,
+			session:    util.NewUserSession("dave"),
+			method:     http.MethodGet,
+			path:       "/v1/list/1/members",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "PUT member returns status code 201 with result",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodPut,
+			path:       "/v1/list/1/members/dave",
+			payload:    `{"role":"editor"}`,
+			statusCode: http.StatusCreated,
+			expected:   `{"result":{"user":"dave","role":"editor","accepted":false}}`,
+		},
+		{
+			name:       "PUT member with unknown role returns status code 422",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodPut,
+			path:       "/v1/list/1/members/dave",
+			payload:    `{"role":"owner"}`,
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "PUT member by a non-owner returns status code 403",
+			authroized: true,
+			session:    util.NewUserSession("bob"),
+			method:     http.MethodPut,
+			path:       "/v1/list/1/members/dave",
+			payload:    `{"role":"viewer"}`,
+			statusCode: http.StatusForbidden,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "DELETE member returns status code 200",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodDelete,
+			path:       "/v1/list/1/members/bob",
+			statusCode: http.StatusOK,
+			expected:   `null`,
+		},
+		{
+			name:       "DELETE unknown member returns status code 404",
+			authroized: true,
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodDelete,
+			path:       "/v1/list/1/members/dave",
+			statusCode: http.StatusNotFound,
+			expected:   `null`,
+		},
+		{
+			name:       "GET invitations returns status code 200 with result",
+			authroized: true,
+			session:    util.NewUserSession("carol"),
+			method:     http.MethodGet,
+			path:       "/v1/invitations",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"list_id":1,"list_name":"家事","owner":"alice","role":"editor"}]}`,
+		},
+		{
+			name:       "POST accept returns status code 200 with result",
+			authroized: true,
+			session:    util.NewUserSession("carol"),
+			method:     http.MethodPost,
+			path:       "/v1/invitations/1/accept",
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"user":"carol","role":"editor","accepted":true}}`,
+		},
+		{
+			name:       "POST accept without invitation returns status code 404",
+			authroized: true,
+			session:    util.NewUserSession("bob"),
+			method:     http.MethodPost,
+			path:       "/v1/invitations/1/accept",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "GET task of a hidden list returns status code 404",
+			authroized: true,
+			session:    util.NewUserSession("carol"),
+			method:     http.MethodGet,
+			path:       "/v1/task/1",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "PUT task by a viewer returns status code 403",
+			authroized: true,
+			session:    util.NewUserSession("bob"),
+			method:     http.MethodPut,
+			path:       "/v1/task/1",
+			payload:    `{"name":"洗衣服"}`,
+			statusCode: http.StatusForbidden,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			authroized: false,
+			method:     http.MethodGet,
+			path:       "/v1/invitations",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			populateMembers(suite)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.payload))
+			req.Header.Add("Content-Type", "application/json")
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_RemovedMemberLosesAccess(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	populateMembers(suite)
+	owner := util.NewUserSession("alice")
+	member := util.NewUserSession("bob")
+	suite.SessionRepo.PopulateData(owner)
+	suite.SessionRepo.PopulateData(member)
+
+	get := func() int {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/v1/task/1", nil)
+		setRequestTokenHeader(t)(req, member.Id)
+		suite.Engine.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	util.AssertEqual(t)(get(), http.StatusOK)
+
+	req, _ := http.NewRequest(http.MethodDelete, "/v1/list/1/members/bob", nil)
+	setRequestTokenHeader(t)(req, owner.Id)
+	suite.Engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	util.AssertEqual(t)(get(), http.StatusNotFound)
+}
@@ -0,0 +1,944 @@
+package routes
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/comments"
+	"github.com/dannyh79/whostodo/internal/lists"
+	"github.com/dannyh79/whostodo/internal/sessions"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+// jsonObject is an object of the OpenAPI document.
+type jsonObject = map[string]any
+
+// How requests of an operation are authenticated.
+type authentication int
+
+const (
+	sessionAuth authentication = iota
+	// The session token is only checked for being valid still.
+	optionalSessionAuth
+	adminAuth
+	noAuth
+)
+
+// media stands for bodies other than JSON, in any of the media types; none
+// stands for no body at all.
+type media []string
+
+var noBody = media{}
+
+// parameter is a query parameter.
+type parameter struct {
+	name        string
+	description string
+	schema      jsonObject
+	// Style of objects, such as deepObject.
+	style string
+}
+
+// operation describes a route added by AddRoutes or AddAdminRoutes. Bodies
+// are values of the types bound or rendered as JSON, described by
+// reflection, nil being rendered as null.
+type operation struct {
+	method string
+	// As added to gin.
+	path        string
+	summary     string
+	description string
+	auth        authentication
+	query       []parameter
+	body        any
+	responses   map[int]any
+}
+
+// rejected is rendered by the middlewares for tokens they reject.
+var rejected = struct{}{}
+
+var (
+	descriptionFormatParam = parameter{
+		name:        "format",
+		description: "Format of descriptions; html renders their Markdown.",
+		schema:      enumSchema(FormatMarkdown, FormatHTML),
+	}
+	transferFormatParam = parameter{
+		name:   "format",
+		schema: enumSchema(formatCSV, formatTodoTxt, formatICal),
+	}
+)
+
+var operations = []operation{
+	{
+		method:      http.MethodPost,
+		path:        "/v1/auth",
+		summary:     "Starts a session",
+		description: "Returns 304 without a body if the session of the token sent is valid still. Named users need the password of their account, and are let into the workspaces of their account only; sessions without a user belong to the anonymous user, in the default workspace only.",
+		auth:        optionalSessionAuth,
+		body:        sessions.AuthenticateInput{},
+		responses: map[int]any{
+			http.StatusCreated:             PostAuthSuccessOutput{},
+			http.StatusNotModified:         noBody,
+			http.StatusUnauthorized:        PostAuthSuccessOutput{},
+			http.StatusForbidden:           PostAuthSuccessOutput{},
+			http.StatusUnprocessableEntity: PostAuthSuccessOutput{},
+		},
+	},
+	{
+		method:    http.MethodGet,
+		path:      "/v1/openapi.json",
+		summary:   "Describes the API",
+		auth:      noAuth,
+		responses: map[int]any{http.StatusOK: media{"application/json"}},
+	},
+	{
+		method:      http.MethodGet,
+		path:        "/v1/feeds/:token",
+		summary:     "Serves the task items of a calendar feed",
+		description: "Authenticated by the token of the feed in the path, ending in .ics, instead of a session.",
+		auth:        noAuth,
+		responses: map[int]any{
+			http.StatusOK:                  media{"text/calendar"},
+			http.StatusNotFound:            rejected,
+			http.StatusInternalServerError: rejected,
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/feed",
+		summary: "Shows the calendar feed of the user",
+		responses: map[int]any{
+			http.StatusOK:       FeedOutput{},
+			http.StatusNotFound: FailedFeedOutput{},
+		},
+	},
+	{
+		method:  http.MethodPut,
+		path:    "/v1/feed",
+		summary: "Creates the calendar feed of the user, or replaces its token",
+		responses: map[int]any{
+			http.StatusCreated:  FeedOutput{},
+			http.StatusNotFound: FailedFeedOutput{},
+		},
+	},
+	{
+		method:  http.MethodDelete,
+		path:    "/v1/feed",
+		summary: "Revokes the calendar feed of the user",
+		responses: map[int]any{
+			http.StatusOK:       nil,
+			http.StatusNotFound: nil,
+		},
+	},
+	{
+		method:      http.MethodGet,
+		path:        "/v1/changes",
+		summary:     "Streams changes made in the workspace",
+		description: "Server-sent events: ready once subscribed, then a change event of {actor, method, path} per request that may have changed something.",
+		responses:   map[int]any{http.StatusOK: media{"text/event-stream"}},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/tasks",
+		summary: "Lists task items",
+		query: []parameter{
+			{name: "list", description: "Only lists task items of the list; 0 is the inbox.", schema: jsonObject{"type": "integer"}},
+			{name: "tag", description: "Only lists task items with any of the tags, or all of them by match.", schema: jsonObject{"type": "array", "items": jsonObject{"type": "string"}}},
+			{name: "match", schema: enumSchema(tasks.MatchAny, tasks.MatchAll)},
+			{name: "sort", description: "Sorts by priority instead of the manual order.", schema: enumSchema(tasks.SortByPriority)},
+			{name: "assignee", description: "Only lists task items assigned to the user.", schema: jsonObject{"type": "string"}},
+			descriptionFormatParam,
+		},
+		responses: map[int]any{
+			http.StatusOK:         ListTasksOutput{},
+			http.StatusBadRequest: FailedListTasksOutput{},
+		},
+	},
+	{
+		method:    http.MethodGet,
+		path:      "/v1/tasks/next",
+		summary:   "Lists open task items not blocked by any other",
+		responses: map[int]any{http.StatusOK: ListTasksOutput{}},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/tasks/search",
+		summary: "Searches task items by name and description",
+		query: []parameter{
+			{name: "q", schema: jsonObject{"type": "string"}},
+			descriptionFormatParam,
+		},
+		responses: map[int]any{
+			http.StatusOK:         ListTasksOutput{},
+			http.StatusBadRequest: FailedSearchTasksOutput{},
+		},
+	},
+	{
+		method:    http.MethodGet,
+		path:      "/v1/tasks/assigned",
+		summary:   "Lists task items assigned to the user",
+		responses: map[int]any{http.StatusOK: ListTasksOutput{}},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/tasks/export",
+		summary: "Exports task items as CSV, todo.txt or iCalendar",
+		query:   []parameter{transferFormatParam},
+		responses: map[int]any{
+			http.StatusOK:         media{"text/csv", "text/plain", "text/calendar"},
+			http.StatusBadRequest: nil,
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/v1/tasks/import",
+		summary: "Imports task items from CSV, todo.txt or iCalendar",
+		query: []parameter{
+			transferFormatParam,
+			{name: "columns", description: "Header names columns of the CSV go by, as columns[COLUMN]=HEADER.", schema: jsonObject{"type": "object", "additionalProperties": jsonObject{"type": "string"}}, style: "deepObject"},
+			{name: "dry_run", description: "Only validates the rows.", schema: jsonObject{"type": "boolean"}},
+		},
+		body: media{"text/csv", "text/plain", "text/calendar", "multipart/form-data"},
+		responses: map[int]any{
+			http.StatusOK:                    ImportTasksOutput{},
+			http.StatusBadRequest:            FailedImportTasksOutput{},
+			http.StatusRequestEntityTooLarge: FailedImportTasksOutput{},
+			http.StatusUnprocessableEntity:   ImportTasksOutput{},
+			http.StatusInternalServerError:   FailedImportTasksOutput{},
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/v1/task",
+		summary: "Creates a task item",
+		body:    tasks.CreateTaskInput{},
+		responses: map[int]any{
+			http.StatusCreated:             PostTaskOutput{},
+			http.StatusForbidden:           FailedPostTaskOutput{},
+			http.StatusUnprocessableEntity: FailedPostTaskOutput{},
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/task/:id",
+		summary: "Shows a task item",
+		query:   []parameter{descriptionFormatParam},
+		responses: map[int]any{
+			http.StatusOK:         GetTaskOutput{},
+			http.StatusBadRequest: FailedGetTaskOutput{},
+			http.StatusNotFound:   FailedGetTaskOutput{},
+		},
+	},
+	{
+		method:      http.MethodPut,
+		path:        "/v1/task/:id",
+		summary:     "Updates a task item",
+		description: "Replaces the name and status; other fields are kept when null or missing.",
+		body:        tasks.UpdateTaskInput{},
+		responses: map[int]any{
+			http.StatusCreated:             UpdateTaskOutput{},
+			http.StatusForbidden:           FailedUpdateTaskOutput{},
+			http.StatusNotFound:            FailedUpdateTaskOutput{},
+			http.StatusConflict:            FailedUpdateTaskOutput{},
+			http.StatusUnprocessableEntity: FailedUpdateTaskOutput{},
+		},
+	},
+	{
+		method:  http.MethodDelete,
+		path:    "/v1/task/:id",
+		summary: "Moves a task item into the trash",
+		query: []parameter{
+			{name: "children", description: "What happens to the subtasks; reparent moves them up to the parent.", schema: enumSchema(tasks.Reparent, tasks.Cascade)},
+		},
+		responses: map[int]any{
+			http.StatusOK:         nil,
+			http.StatusBadRequest: nil,
+			http.StatusForbidden:  nil,
+			http.StatusNotFound:   nil,
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/v1/task/:id/move",
+		summary: "Moves a task item in the manual order",
+		body:    tasks.MoveTaskInput{},
+		responses: map[int]any{
+			http.StatusOK:                  MoveTaskOutput{},
+			http.StatusBadRequest:          FailedMoveTaskOutput{},
+			http.StatusForbidden:           FailedMoveTaskOutput{},
+			http.StatusNotFound:            FailedMoveTaskOutput{},
+			http.StatusUnprocessableEntity: FailedMoveTaskOutput{},
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/task/:id/tree",
+		summary: "Shows a task item with its subtasks, nested",
+		responses: map[int]any{
+			http.StatusOK:       TaskTreeOutput{},
+			http.StatusNotFound: FailedTaskTreeOutput{},
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/task/:id/dependencies",
+		summary: "Lists the task items blocking a task item, and those it blocks",
+		responses: map[int]any{
+			http.StatusOK:       DependenciesOutput{},
+			http.StatusNotFound: FailedDependenciesOutput{},
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/v1/task/:id/blockers",
+		summary: "Blocks a task item by another",
+		body:    tasks.BlockerInput{},
+		responses: map[int]any{
+			http.StatusOK:                  BlockerTaskOutput{},
+			http.StatusForbidden:           FailedBlockerTaskOutput{},
+			http.StatusNotFound:            FailedBlockerTaskOutput{},
+			http.StatusUnprocessableEntity: FailedBlockerTaskOutput{},
+		},
+	},
+	{
+		method:  http.MethodDelete,
+		path:    "/v1/task/:id/blockers/:blocker",
+		summary: "Unblocks a task item from another",
+		responses: map[int]any{
+			http.StatusOK:        BlockerTaskOutput{},
+			http.StatusForbidden: FailedBlockerTaskOutput{},
+			http.StatusNotFound:  FailedBlockerTaskOutput{},
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/v1/task/:id/tags",
+		summary: "Tags a task item",
+		body:    tasks.TagsInput{},
+		responses: map[int]any{
+			http.StatusOK:         TagTaskOutput{},
+			http.StatusBadRequest: FailedTagTaskOutput{},
+			http.StatusForbidden:  FailedTagTaskOutput{},
+			http.StatusNotFound:   FailedTagTaskOutput{},
+		},
+	},
+	{
+		method:  http.MethodDelete,
+		path:    "/v1/task/:id/tags/:tag",
+		summary: "Untags a task item",
+		responses: map[int]any{
+			http.StatusOK:        TagTaskOutput{},
+			http.StatusForbidden: FailedTagTaskOutput{},
+			http.StatusNotFound:  FailedTagTaskOutput{},
+		},
+	},
+	{
+		method:  http.MethodPut,
+		path:    "/v1/task/:id/assignee",
+		summary: "Assigns a task item to a user",
+		body:    tasks.AssignTaskInput{},
+		responses: map[int]any{
+			http.StatusCreated:             AssignTaskOutput{},
+			http.StatusForbidden:           FailedAssignTaskOutput{},
+			http.StatusNotFound:            FailedAssignTaskOutput{},
+			http.StatusUnprocessableEntity: FailedAssignTaskOutput{},
+		},
+	},
+	{
+		method:  http.MethodDelete,
+		path:    "/v1/task/:id/assignee",
+		summary: "Unassigns a task item",
+		responses: map[int]any{
+			http.StatusOK:        AssignTaskOutput{},
+			http.StatusForbidden: FailedAssignTaskOutput{},
+			http.StatusNotFound:  FailedAssignTaskOutput{},
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/task/:id/history",
+		summary: "Lists the changes made to a task item",
+		responses: map[int]any{
+			http.StatusOK:       TaskHistoryOutput{},
+			http.StatusNotFound: FailedTaskHistoryOutput{},
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/task/:id/comments",
+		summary: "Lists the comments on a task item",
+		responses: map[int]any{
+			http.StatusOK:       ListCommentsOutput{},
+			http.StatusNotFound: FailedCommentOutput{},
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/v1/task/:id/comments",
+		summary: "Comments on a task item",
+		body:    comments.CommentInput{},
+		responses: map[int]any{
+			http.StatusCreated:             CommentOutput{},
+			http.StatusForbidden:           FailedCommentOutput{},
+			http.StatusNotFound:            FailedCommentOutput{},
+			http.StatusUnprocessableEntity: FailedCommentOutput{},
+		},
+	},
+	{
+		method:  http.MethodPut,
+		path:    "/v1/task/:id/comments/:comment",
+		summary: "Edits a comment of the user",
+		body:    comments.CommentInput{},
+		responses: map[int]any{
+			http.StatusCreated:             CommentOutput{},
+			http.StatusForbidden:           FailedCommentOutput{},
+			http.StatusNotFound:            FailedCommentOutput{},
+			http.StatusUnprocessableEntity: FailedCommentOutput{},
+		},
+	},
+	{
+		method:  http.MethodDelete,
+		path:    "/v1/task/:id/comments/:comment",
+		summary: "Deletes a comment of the user",
+		responses: map[int]any{
+			http.StatusOK:        nil,
+			http.StatusForbidden: nil,
+			http.StatusNotFound:  nil,
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/task/:id/attachments",
+		summary: "Lists the files attached to a task item",
+		responses: map[int]any{
+			http.StatusOK:       ListAttachmentsOutput{},
+			http.StatusNotFound: FailedAttachmentOutput{},
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/v1/task/:id/attachments",
+		summary: "Attaches a file to a task item",
+		body:    media{"multipart/form-data"},
+		responses: map[int]any{
+			http.StatusCreated:               AttachmentOutput{},
+			http.StatusBadRequest:            FailedAttachmentOutput{},
+			http.StatusForbidden:             FailedAttachmentOutput{},
+			http.StatusNotFound:              FailedAttachmentOutput{},
+			http.StatusRequestEntityTooLarge: FailedAttachmentOutput{},
+			http.StatusUnprocessableEntity:   FailedAttachmentOutput{},
+			http.StatusInternalServerError:   FailedAttachmentOutput{},
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/task/:id/attachments/:attachment",
+		summary: "Downloads an attached file",
+		responses: map[int]any{
+			http.StatusOK:                  media{"*/*"},
+			http.StatusForbidden:           FailedAttachmentOutput{},
+			http.StatusNotFound:            FailedAttachmentOutput{},
+			http.StatusInternalServerError: FailedAttachmentOutput{},
+		},
+	},
+	{
+		method:  http.MethodDelete,
+		path:    "/v1/task/:id/attachments/:attachment",
+		summary: "Deletes a file the user attached",
+		responses: map[int]any{
+			http.StatusOK:                  nil,
+			http.StatusForbidden:           nil,
+			http.StatusNotFound:            nil,
+			http.StatusInternalServerError: nil,
+		},
+	},
+	{
+		method:    http.MethodGet,
+		path:      "/v1/tags",
+		summary:   "Lists tags with how many task items have each",
+		responses: map[int]any{http.StatusOK: ListTagsOutput{}},
+	},
+	{
+		method:  http.MethodPut,
+		path:    "/v1/tag/:name",
+		summary: "Renames a tag on every task item",
+		body:    tasks.RenameTagInput{},
+		responses: map[int]any{
+			http.StatusCreated:    RenameTagOutput{},
+			http.StatusBadRequest: FailedRenameTagOutput{},
+			http.StatusForbidden:  FailedRenameTagOutput{},
+			http.StatusNotFound:   FailedRenameTagOutput{},
+		},
+	},
+	{
+		method:    http.MethodGet,
+		path:      "/v1/trash",
+		summary:   "Lists trashed task items",
+		responses: map[int]any{http.StatusOK: ListTrashedTasksOutput{}},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/v1/trash/:id/restore",
+		summary: "Restores a trashed task item",
+		responses: map[int]any{
+			http.StatusOK:        RestoreTaskOutput{},
+			http.StatusForbidden: FailedUpdateTaskOutput{},
+			http.StatusNotFound:  FailedUpdateTaskOutput{},
+		},
+	},
+	{
+		method:  http.MethodDelete,
+		path:    "/v1/trash/:id",
+		summary: "Deletes a trashed task item for good",
+		responses: map[int]any{
+			http.StatusOK:        nil,
+			http.StatusForbidden: nil,
+			http.StatusNotFound:  nil,
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/v1/undo",
+		summary: "Reverts the last operation of the session",
+		responses: map[int]any{
+			http.StatusOK:        UndoOutput{},
+			http.StatusForbidden: FailedUndoOutput{},
+			http.StatusNotFound:  FailedUndoOutput{},
+			http.StatusConflict:  FailedUndoOutput{},
+		},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/v1/redo",
+		summary: "Applies the last operation undone by the session again",
+		responses: map[int]any{
+			http.StatusOK:        UndoOutput{},
+			http.StatusForbidden: FailedUndoOutput{},
+			http.StatusNotFound:  FailedUndoOutput{},
+			http.StatusConflict:  FailedUndoOutput{},
+		},
+	},
+	{
+		method:    http.MethodGet,
+		path:      "/v1/lists",
+		summary:   "Lists the lists of the user, and those shared with the user",
+		responses: map[int]any{http.StatusOK: ListListsOutput{}},
+	},
+	{
+		method:    http.MethodPost,
+		path:      "/v1/list",
+		summary:   "Creates a list",
+		body:      lists.CreateListInput{},
+		responses: map[int]any{http.StatusCreated: PostListOutput{}},
+	},
+	{
+		method:  http.MethodPut,
+		path:    "/v1/list/:id",
+		summary: "Renames a list of the user",
+		body:    lists.UpdateListInput{},
+		responses: map[int]any{
+			http.StatusCreated:   UpdateListOutput{},
+			http.StatusForbidden: FailedUpdateListOutput{},
+			http.StatusNotFound:  FailedUpdateListOutput{},
+		},
+	},
+	{
+		method:  http.MethodDelete,
+		path:    "/v1/list/:id",
+		summary: "Deletes a list of the user",
+		query: []parameter{
+			{name: "tasks", description: "What happens to the task items of the list.", schema: enumSchema(lists.MoveToInbox, lists.Cascade)},
+		},
+		responses: map[int]any{
+			http.StatusOK:         nil,
+			http.StatusBadRequest: nil,
+			http.StatusForbidden:  nil,
+			http.StatusNotFound:   nil,
+		},
+	},
+	{
+		method:  http.MethodGet,
+		path:    "/v1/list/:id/members",
+		summary: "Lists whom a list is shared with",
+		responses: map[int]any{
+			http.StatusOK:       ListMembersOutput{},
+			http.StatusNotFound: FailedMemberOutput{},
+		},
+	},
+	{
+		method:  http.MethodPut,
+		path:    "/v1/list/:id/members/:user",
+		summary: "Invites a user to a list of the user, or changes their role",
+		body:    lists.MemberInput{},
+		responses: map[int]any{
+			http.StatusCreated:             MemberOutput{},
+			http.StatusForbidden:           FailedMemberOutput{},
+			http.StatusNotFound:            FailedMemberOutput{},
+			http.StatusUnprocessableEntity: FailedMemberOutput{},
+		},
+	},
+	{
+		method:  http.MethodDelete,
+		path:    "/v1/list/:id/members/:user",
+		summary: "Stops sharing a list with a user",
+		responses: map[int]any{
+			http.StatusOK:                  nil,
+			http.StatusForbidden:           nil,
+			http.StatusNotFound:            nil,
+			http.StatusUnprocessableEntity: nil,
+		},
+	},
+	{
+		method:    http.MethodGet,
+		path:      "/v1/invitations",
+		summary:   "Lists invitations to lists not accepted yet",
+		responses: map[int]any{http.StatusOK: ListInvitationsOutput{}},
+	},
+	{
+		method:  http.MethodPost,
+		path:    "/v1/invitations/:id/accept",
+		summary: "Accepts the invitation to a list",
+		responses: map[int]any{
+			http.StatusOK:       MemberOutput{},
+			http.StatusNotFound: FailedMemberOutput{},
+		},
+	},
+	{
+		method:      http.MethodGet,
+		path:        "/v1/admin/backup",
+		summary:     "Backs up the server state as an archive",
+		description: "Only added when WHOSTODO_ADMIN_TOKEN is set.",
+		auth:        adminAuth,
+		responses: map[int]any{
+			http.StatusOK:                  media{"application/x-tar"},
+			http.StatusInternalServerError: rejected,
+		},
+	},
+	{
+		method:      http.MethodPost,
+		path:        "/v1/admin/restore",
+		summary:     "Replaces the server state with an archive",
+		description: "Only added when WHOSTODO_ADMIN_TOKEN is set.",
+		auth:        adminAuth,
+		body:        media{"application/x-tar"},
+		responses: map[int]any{
+			http.StatusOK:                    RestoreOutput{},
+			http.StatusBadRequest:            FailedRestoreOutput{},
+			http.StatusRequestEntityTooLarge: FailedRestoreOutput{},
+			http.StatusUnprocessableEntity:   FailedRestoreOutput{},
+			http.StatusInternalServerError:   FailedRestoreOutput{},
+		},
+	},
+	{
+		method:      http.MethodPut,
+		path:        "/v1/admin/accounts/:user",
+		summary:     "Creates the account of a user, or sets its password",
+		description: "Only added when WHOSTODO_ADMIN_TOKEN is set.",
+		auth:        adminAuth,
+		body:        sessions.AccountInput{},
+		responses: map[int]any{
+			http.StatusOK:                  AccountOutput{},
+			http.StatusBadRequest:          FailedAccountOutput{},
+			http.StatusInternalServerError: FailedAccountOutput{},
+		},
+	},
+	{
+		method:      http.MethodDelete,
+		path:        "/v1/admin/accounts/:user",
+		summary:     "Deletes the account of a user, and revokes its feeds",
+		description: "Only added when WHOSTODO_ADMIN_TOKEN is set. Sessions already started go on until they expire.",
+		auth:        adminAuth,
+		responses: map[int]any{
+			http.StatusOK:                  nil,
+			http.StatusNotFound:            nil,
+			http.StatusInternalServerError: nil,
+		},
+	},
+}
+
+func openAPIHandler(document jsonObject) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, document)
+	}
+}
+
+// openAPIDocument describes the operations as an OpenAPI 3.0 document.
+func openAPIDocument() jsonObject {
+	g := &schemaGenerator{schemas: jsonObject{}}
+	paths := jsonObject{}
+	for _, op := range operations {
+		p := openAPIPath(op.path)
+		item, ok := paths[p].(jsonObject)
+		if !ok {
+			item = jsonObject{}
+			paths[p] = item
+		}
+		item[strings.ToLower(op.method)] = g.operation(op)
+	}
+
+	return jsonObject{
+		"openapi": "3.0.3",
+		"info": jsonObject{
+			"title":   "whostodo",
+			"version": "1",
+		},
+		"paths": paths,
+		"components": jsonObject{
+			"schemas": g.schemas,
+			"securitySchemes": jsonObject{
+				"session": jsonObject{"type": "http", "scheme": "bearer", "description": "Token of a session started by POST /v1/auth"},
+				"admin":   jsonObject{"type": "http", "scheme": "bearer", "description": "WHOSTODO_ADMIN_TOKEN"},
+			},
+		},
+	}
+}
+
+// openAPIPath turns parameters of a gin path, such as :id, into {id}.
+func openAPIPath(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Path parameters identifying rows by number; others are strings.
+var idParams = map[string]bool{"id": true, "blocker": true, "comment": true, "attachment": true}
+
+// schemaGenerator describes Go types as schemas, keeping those of named
+// structs in the components.
+type schemaGenerator struct {
+	schemas jsonObject
+}
+
+func (g *schemaGenerator) operation(op operation) jsonObject {
+	o := jsonObject{"summary": op.summary}
+	if op.description != "" {
+		o["description"] = op.description
+	}
+
+	var params []jsonObject
+	for _, segment := range strings.Split(op.path, "/") {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok {
+			continue
+		}
+		schema := jsonObject{"type": "string"}
+		if idParams[name] {
+			schema = jsonObject{"type": "integer"}
+		}
+		params = append(params, jsonObject{"name": name, "in": "path", "required": true, "schema": schema})
+	}
+	for _, q := range op.query {
+		param := jsonObject{"name": q.name, "in": "query", "schema": q.schema}
+		if q.description != "" {
+			param["description"] = q.description
+		}
+		if q.style != "" {
+			param["style"] = q.style
+		}
+		params = append(params, param)
+	}
+	if len(params) > 0 {
+		o["parameters"] = params
+	}
+
+	bodies := make(map[int][]any, len(op.responses)+1)
+	for status, body := range op.responses {
+		bodies[status] = append(bodies[status], body)
+	}
+	switch op.auth {
+	case sessionAuth:
+		o["security"] = []jsonObject{{"session": []string{}}}
+		bodies[http.StatusForbidden] = append(bodies[http.StatusForbidden], rejected)
+	case optionalSessionAuth:
+		o["security"] = []jsonObject{{}, {"session": []string{}}}
+	case adminAuth:
+		o["security"] = []jsonObject{{"admin": []string{}}}
+		bodies[http.StatusForbidden] = append(bodies[http.StatusForbidden], rejected)
+	case noAuth:
+		o["security"] = []jsonObject{}
+	}
+
+	if op.body != nil {
+		o["requestBody"] = jsonObject{"required": true, "content": g.content([]any{op.body}, true)}
+	}
+	responses := jsonObject{}
+	for status, bs := range bodies {
+		response := jsonObject{"description": http.StatusText(status)}
+		if content := g.content(bs, false); len(content) > 0 {
+			response["content"] = content
+		}
+		responses[strconv.Itoa(status)] = response
+	}
+	o["responses"] = responses
+	return o
+}
+
+// content describes the bodies by media type, JSON ones being one of their
+// schemas.
+func (g *schemaGenerator) content(bodies []any, request bool) jsonObject {
+	content := jsonObject{}
+	var schemas []jsonObject
+	for _, body := range bodies {
+		m, ok := body.(media)
+		if !ok {
+			schemas = append(schemas, g.schemaOf(reflect.TypeOf(body), request))
+			continue
+		}
+		for _, mediaType := range m {
+			content[mediaType] = jsonObject{"schema": mediaSchema(mediaType)}
+		}
+	}
+
+	switch {
+	case len(schemas) == 1:
+		content["application/json"] = jsonObject{"schema": schemas[0]}
+	case len(schemas) > 1:
+		content["application/json"] = jsonObject{"schema": jsonObject{"oneOf": schemas}}
+	}
+	return content
+}
+
+func mediaSchema(mediaType string) jsonObject {
+	switch mediaType {
+	case "multipart/form-data":
+		return jsonObject{
+			"type":       "object",
+			"properties": jsonObject{"file": jsonObject{"type": "string", "format": "binary"}},
+			"required":   []string{"file"},
+		}
+	case "application/json":
+		return jsonObject{"type": "object"}
+	case "*/*":
+		return jsonObject{"type": "string", "format": "binary"}
+	default:
+		return jsonObject{"type": "string"}
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf describes values of the type as encoding/json renders them, nil
+// standing for null. Fields of requests are all optional, as missing ones
+// are bound as zero values, while responses have no fields but those
+// described.
+func (g *schemaGenerator) schemaOf(t reflect.Type, request bool) jsonObject {
+	if t == nil {
+		return jsonObject{"nullable": true, "enum": []any{nil}}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(g.schemaOf(t.Elem(), request))
+	case reflect.Struct:
+		if t == timeType {
+			return jsonObject{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return g.structSchema(t, request)
+		}
+		name := schemaName(t)
+		if _, ok := g.schemas[name]; !ok {
+			// Claimed first, as structs such as trees refer to themselves.
+			g.schemas[name] = jsonObject{}
+			g.schemas[name] = g.structSchema(t, request)
+		}
+		return jsonObject{"$ref": "#/components/schemas/" + name}
+	case reflect.Slice, reflect.Array:
+		return jsonObject{"type": "array", "items": g.schemaOf(t.Elem(), request)}
+	case reflect.Map:
+		return jsonObject{"type": "object", "additionalProperties": g.schemaOf(t.Elem(), request)}
+	case reflect.String:
+		return jsonObject{"type": "string"}
+	case reflect.Bool:
+		return jsonObject{"type": "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return jsonObject{"type": "integer", "format": "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return jsonObject{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return jsonObject{"type": "number"}
+	default:
+		return jsonObject{}
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type, request bool) jsonObject {
+	properties := jsonObject{}
+	required := []string{}
+	g.addFields(t, request, properties, &required)
+
+	schema := jsonObject{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	if !request {
+		schema["additionalProperties"] = false
+	}
+	return schema
+}
+
+// addFields adds the fields of the struct as encoding/json renders them,
+// those of embedded structs included.
+func (g *schemaGenerator) addFields(t reflect.Type, request bool, properties jsonObject, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			g.addFields(f.Type, request, properties, required)
+			continue
+		}
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		omitempty := strings.Contains(opts, "omitempty")
+		fieldType := f.Type
+		if omitempty && fieldType.Kind() == reflect.Pointer && !request {
+			// Left out rather than rendered as null.
+			fieldType = fieldType.Elem()
+		}
+		properties[name] = g.schemaOf(fieldType, request)
+		if !omitempty && !request {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// schemaName names schemas of the package by their type, and others by
+// their package too, as in tasks.ImportOutput.
+func schemaName(t reflect.Type) string {
+	if t.PkgPath() == reflect.TypeOf(operation{}).PkgPath() {
+		return t.Name()
+	}
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
+
+func nullable(schema jsonObject) jsonObject {
+	if _, ok := schema["$ref"]; ok {
+		return jsonObject{"allOf": []jsonObject{schema}, "nullable": true}
+	}
+	n := make(jsonObject, len(schema)+1)
+	for k, v := range schema {
+		n[k] = v
+	}
+	n["nullable"] = true
+	return n
+}
+
+func enumSchema(values ...string) jsonObject {
+	return jsonObject{"type": "string", "enum": values}
+}
@@ -0,0 +1,389 @@
+package routes_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+type document = map[string]any
+
+// fetchOpenAPI returns the OpenAPI document served by the suite.
+func fetchOpenAPI(t *testing.T, suite *util.MockTestSuite) document {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/v1/openapi.json", nil)
+	suite.Engine.ServeHTTP(rr, req)
+	util.AssertHttpStatus(t)(rr, http.StatusOK)
+
+	var doc document
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// lookup returns the object at the keys of the document, or nil.
+func lookup(doc document, keys ...string) document {
+	for _, key := range keys {
+		doc, _ = doc[key].(document)
+	}
+	return doc
+}
+
+var ginParam = regexp.MustCompile(`:(\w+)`)
+
+func Test_OpenAPIRoutes(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	doc := fetchOpenAPI(t, suite)
+
+	documented := map[string]bool{}
+	for path, item := range lookup(doc, "paths") {
+		for method := range item.(document) {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+	for _, route := range suite.Engine.Routes() {
+		// GraphQL is served next to the REST routes, not documented along.
+		if !strings.HasPrefix(route.Path, "/v1/") {
+			continue
+		}
+		key := route.Method + " " + ginParam.ReplaceAllString(route.Path, "{$1}")
+		if !documented[key] {
+			t.Errorf("%s is added but not documented", key)
+		}
+		delete(documented, key)
+	}
+	for key := range documented {
+		t.Errorf("%s is documented but not added", key)
+	}
+}
+
+// validate returns where the value does not match the schema, resolving
+// references into the document.
+func validate(doc document, schema document, value any, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return validate(doc, lookup(doc, "components", "schemas", strings.TrimPrefix(ref, "#/components/schemas/")), value, at)
+	}
+	if value == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+		return []string{at + ": is null"}
+	}
+
+	var errs []string
+	for _, s := range asSlice(schema["allOf"]) {
+		errs = append(errs, validate(doc, s.(document), value, at)...)
+	}
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		matched := 0
+		for _, s := range oneOf {
+			if len(validate(doc, s.(document), value, at)) == 0 {
+				matched += 1
+			}
+		}
+		if matched != 1 {
+			errs = append(errs, at+": matches "+strconv.Itoa(matched)+" of oneOf")
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok && !contains(enum, value) {
+		errs = append(errs, at+": is not in enum")
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return append(errs, at+": is not an object")
+		}
+		properties := lookup(schema, "properties")
+		for _, name := range asSlice(schema["required"]) {
+			if _, ok := object[name.(string)]; !ok {
+				errs = append(errs, at+"."+name.(string)+": is missing")
+			}
+		}
+		for name, v := range object {
+			if p, ok := properties[name].(document); ok {
+				errs = append(errs, validate(doc, p, v, at+"."+name)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					errs = append(errs, at+"."+name+": is not documented")
+				}
+			case document:
+				errs = append(errs, validate(doc, additional, v, at+"."+name)...)
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return append(errs, at+": is not an array")
+		}
+		for i, v := range array {
+			errs = append(errs, validate(doc, lookup(schema, "items"), v, at+"["+strconv.Itoa(i)+"]")...)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return append(errs, at+": is not a string")
+		}
+		if _, err := time.Parse(time.RFC3339Nano, s); schema["format"] == "date-time" && err != nil {
+			errs = append(errs, at+": is not a date-time")
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			errs = append(errs, at+": is not an integer")
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			errs = append(errs, at+": is not a number")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			errs = append(errs, at+": is not a boolean")
+		}
+	}
+	return errs
+}
+
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+func contains(values []any, value any) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// multipartBody returns a form carrying the content as its file field, and
+// the content type of the form.
+func multipartBody(filename string, content string) (string, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", filename)
+	part.Write([]byte(content))
+	writer.Close()
+	return body.String(), writer.FormDataContentType()
+}
+
+// Test_OpenAPIResponses sends a request to every documented operation,
+// failing on responses the document does not describe.
+func Test_OpenAPIResponses(t *testing.T) {
+	suite := util.NewTestSuite()
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Creator: "alice"})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "洗碗", Creator: "alice"})
+	suite.FeedRepo.PopulateData(repository.Feed{Token: "feed_token", User: "alice", Workspace: "default"})
+	alice, bob := util.NewUserSession("alice"), util.NewUserSession("bob")
+	suite.SessionRepo.PopulateData(alice)
+	suite.SessionRepo.PopulateData(bob)
+	suite.AccountRepo.PopulateData(util.NewAccount("bob", "密碼"))
+	suite.AccountRepo.PopulateData(util.NewAccount("carol", "密碼"))
+	server := httptest.NewServer(suite.Engine)
+	t.Cleanup(server.Close)
+	doc := fetchOpenAPI(t, suite)
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/v1/admin/backup", nil)
+	setRequestTokenHeader(t)(req, util.AdminToken)
+	suite.Engine.ServeHTTP(rr, req)
+	archive := rr.Body.String()
+	upload, uploadType := multipartBody("receipt.txt", "牛肉麵 120")
+
+	steps := []struct {
+		// As documented, such as GET /v1/task/{id}.
+		operation string
+		path      string
+		token     string
+		body      string
+		// JSON unless set.
+		contentType string
+		status      int
+	}{
+		{operation: "POST /v1/auth", path: "/v1/auth", body: `{"user":"carol","password":"密碼"}`, status: http.StatusCreated},
+		{operation: "POST /v1/auth", path: "/v1/auth", body: `{"user":"carol"}`, status: http.StatusUnauthorized},
+		{operation: "POST /v1/auth", path: "/v1/auth", token: alice.Id, status: http.StatusNotModified},
+		{operation: "POST /v1/auth", path: "/v1/auth", body: `{"workspace":"Team A"}`, status: http.StatusUnprocessableEntity},
+		{operation: "GET /v1/openapi.json", path: "/v1/openapi.json", status: http.StatusOK},
+		{operation: "GET /v1/feeds/{token}", path: "/v1/feeds/feed_token.ics", status: http.StatusOK},
+		{operation: "GET /v1/feeds/{token}", path: "/v1/feeds/unknown_token.ics", status: http.StatusNotFound},
+		{operation: "GET /v1/feed", path: "/v1/feed", token: alice.Id, status: http.StatusOK},
+		{operation: "PUT /v1/feed", path: "/v1/feed", token: alice.Id, status: http.StatusCreated},
+		{operation: "DELETE /v1/feed", path: "/v1/feed", token: alice.Id, status: http.StatusOK},
+		{operation: "GET /v1/feed", path: "/v1/feed", token: alice.Id, status: http.StatusNotFound},
+		{operation: "GET /v1/changes", path: "/v1/changes", token: alice.Id, status: http.StatusOK},
+		{operation: "GET /v1/tasks", path: "/v1/tasks", status: http.StatusForbidden},
+		{operation: "POST /v1/task", path: "/v1/task", token: alice.Id, body: `{"name":"倒垃圾","priority":"high","description":"**週四**","tags":["chores"],"due_at":"2024-01-04T20:00:00Z","recurrence":{"frequency":"weekly","weekdays":["TH"]}}`, status: http.StatusCreated},
+		{operation: "POST /v1/task", path: "/v1/task", token: alice.Id, body: `{"name":"倒垃圾","list_id":9}`, status: http.StatusUnprocessableEntity},
+		{operation: "GET /v1/task/{id}", path: "/v1/task/3?format=html", token: alice.Id, status: http.StatusOK},
+		{operation: "GET /v1/task/{id}", path: "/v1/task/9", token: alice.Id, status: http.StatusNotFound},
+		{operation: "PUT /v1/task/{id}", path: "/v1/task/3", token: alice.Id, body: `{"name":"倒垃圾","status":0,"parent_id":1}`, status: http.StatusCreated},
+		{operation: "POST /v1/undo", path: "/v1/undo", token: alice.Id, status: http.StatusOK},
+		{operation: "POST /v1/redo", path: "/v1/redo", token: alice.Id, status: http.StatusOK},
+		{operation: "POST /v1/redo", path: "/v1/redo", token: alice.Id, status: http.StatusNotFound},
+		{operation: "GET /v1/tasks", path: "/v1/tasks?tag=chores", token: alice.Id, status: http.StatusOK},
+		{operation: "GET /v1/tasks", path: "/v1/tasks?format=pdf", token: alice.Id, status: http.StatusBadRequest},
+		{operation: "GET /v1/tasks/search", path: "/v1/tasks/search?q=" + url.QueryEscape("垃圾"), token: alice.Id, status: http.StatusOK},
+		{operation: "GET /v1/task/{id}/tree", path: "/v1/task/1/tree", token: alice.Id, status: http.StatusOK},
+		{operation: "POST /v1/task/{id}/blockers", path: "/v1/task/2/blockers", token: alice.Id, body: `{"id":1}`, status: http.StatusOK},
+		{operation: "POST /v1/task/{id}/blockers", path: "/v1/task/1/blockers", token: alice.Id, body: `{"id":2}`, status: http.StatusUnprocessableEntity},
+		{operation: "GET /v1/task/{id}/dependencies", path: "/v1/task/2/dependencies", token: alice.Id, status: http.StatusOK},
+		{operation: "GET /v1/tasks/next", path: "/v1/tasks/next", token: alice.Id, status: http.StatusOK},
+		{operation: "DELETE /v1/task/{id}/blockers/{blocker}", path: "/v1/task/2/blockers/1", token: alice.Id, status: http.StatusOK},
+		{operation: "POST /v1/task/{id}/move", path: "/v1/task/1/move", token: alice.Id, body: `{"after":2}`, status: http.StatusOK},
+		{operation: "POST /v1/task/{id}/move", path: "/v1/task/1/move", token: alice.Id, body: `{}`, status: http.StatusBadRequest},
+		{operation: "POST /v1/task/{id}/tags", path: "/v1/task/1/tags", token: alice.Id, body: `{"tags":["errand"]}`, status: http.StatusOK},
+		{operation: "DELETE /v1/task/{id}/tags/{tag}", path: "/v1/task/1/tags/errand", token: alice.Id, status: http.StatusOK},
+		{operation: "GET /v1/tags", path: "/v1/tags", token: alice.Id, status: http.StatusOK},
+		{operation: "PUT /v1/tag/{name}", path: "/v1/tag/chores", token: alice.Id, body: `{"name":"housework"}`, status: http.StatusCreated},
+		{operation: "PUT /v1/task/{id}/assignee", path: "/v1/task/1/assignee", token: alice.Id, body: `{"assignee":"dave"}`, status: http.StatusUnprocessableEntity},
+		{operation: "PUT /v1/task/{id}/assignee", path: "/v1/task/1/assignee", token: alice.Id, body: `{"assignee":"bob"}`, status: http.StatusCreated},
+		{operation: "GET /v1/tasks/assigned", path: "/v1/tasks/assigned", token: bob.Id, status: http.StatusOK},
+		{operation: "GET /v1/task/{id}/history", path: "/v1/task/1/history", token: alice.Id, status: http.StatusOK},
+		{operation: "POST /v1/task/{id}/comments", path: "/v1/task/1/comments", token: alice.Id, body: `{"body":"要加辣"}`, status: http.StatusCreated},
+		{operation: "POST /v1/task/{id}/comments", path: "/v1/task/1/comments", token: alice.Id, body: `{"body":""}`, status: http.StatusUnprocessableEntity},
+		{operation: "PUT /v1/task/{id}/comments/{comment}", path: "/v1/task/1/comments/1", token: alice.Id, body: `{"body":"不要辣"}`, status: http.StatusCreated},
+		{operation: "PUT /v1/task/{id}/comments/{comment}", path: "/v1/task/1/comments/1", token: bob.Id, body: `{"body":"要加辣"}`, status: http.StatusForbidden},
+		{operation: "GET /v1/task/{id}/comments", path: "/v1/task/1/comments", token: alice.Id, status: http.StatusOK},
+		{operation: "DELETE /v1/task/{id}/comments/{comment}", path: "/v1/task/1/comments/1", token: alice.Id, status: http.StatusOK},
+		{operation: "DELETE /v1/task/{id}/assignee", path: "/v1/task/1/assignee", token: alice.Id, status: http.StatusOK},
+		{operation: "POST /v1/task/{id}/attachments", path: "/v1/task/1/attachments", token: alice.Id, body: upload, contentType: uploadType, status: http.StatusCreated},
+		{operation: "POST /v1/task/{id}/attachments", path: "/v1/task/1/attachments", token: alice.Id, body: "牛肉麵", contentType: "text/plain", status: http.StatusBadRequest},
+		{operation: "GET /v1/task/{id}/attachments", path: "/v1/task/1/attachments", token: alice.Id, status: http.StatusOK},
+		{operation: "GET /v1/task/{id}/attachments/{attachment}", path: "/v1/task/1/attachments/1", token: alice.Id, status: http.StatusOK},
+		{operation: "DELETE /v1/task/{id}/attachments/{attachment}", path: "/v1/task/1/attachments/1", token: alice.Id, status: http.StatusOK},
+		{operation: "GET /v1/tasks/export", path: "/v1/tasks/export", token: alice.Id, status: http.StatusOK},
+		{operation: "GET /v1/tasks/export", path: "/v1/tasks/export?format=ics", token: alice.Id, status: http.StatusOK},
+		{operation: "GET /v1/tasks/export", path: "/v1/tasks/export?format=xml", token: alice.Id, status: http.StatusBadRequest},
+		{operation: "POST /v1/tasks/import", path: "/v1/tasks/import?dry_run=true", token: alice.Id, body: "name,priority\n繳電費,high\n", contentType: "text/csv", status: http.StatusOK},
+		{operation: "POST /v1/tasks/import", path: "/v1/tasks/import", token: alice.Id, body: "name,priority\n繳電費,someday\n", contentType: "text/csv", status: http.StatusUnprocessableEntity},
+		{operation: "POST /v1/tasks/import", path: "/v1/tasks/import", token: alice.Id, body: "name\n\"繳電費\n", contentType: "text/csv", status: http.StatusBadRequest},
+		{operation: "DELETE /v1/task/{id}", path: "/v1/task/2", token: alice.Id, status: http.StatusOK},
+		{operation: "DELETE /v1/task/{id}", path: "/v1/task/2", token: alice.Id, status: http.StatusNotFound},
+		{operation: "GET /v1/trash", path: "/v1/trash", token: alice.Id, status: http.StatusOK},
+		{operation: "POST /v1/trash/{id}/restore", path: "/v1/trash/2/restore", token: alice.Id, status: http.StatusOK},
+		{operation: "DELETE /v1/trash/{id}", path: "/v1/trash/2", token: alice.Id, status: http.StatusNotFound},
+		{operation: "POST /v1/list", path: "/v1/list", token: alice.Id, body: `{"name":"家事"}`, status: http.StatusCreated},
+		{operation: "PUT /v1/list/{id}", path: "/v1/list/1", token: alice.Id, body: `{"name":"雜事"}`, status: http.StatusCreated},
+		{operation: "PUT /v1/list/{id}", path: "/v1/list/1", token: bob.Id, body: `{"name":"家事"}`, status: http.StatusNotFound},
+		{operation: "GET /v1/lists", path: "/v1/lists", token: alice.Id, status: http.StatusOK},
+		{operation: "PUT /v1/list/{id}/members/{user}", path: "/v1/list/1/members/bob", token: alice.Id, body: `{"role":"editor"}`, status: http.StatusCreated},
+		{operation: "PUT /v1/list/{id}/members/{user}", path: "/v1/list/1/members/bob", token: alice.Id, body: `{"role":"owner"}`, status: http.StatusUnprocessableEntity},
+		{operation: "GET /v1/invitations", path: "/v1/invitations", token: bob.Id, status: http.StatusOK},
+		{operation: "POST /v1/invitations/{id}/accept", path: "/v1/invitations/1/accept", token: bob.Id, status: http.StatusOK},
+		{operation: "GET /v1/list/{id}/members", path: "/v1/list/1/members", token: bob.Id, status: http.StatusOK},
+		{operation: "DELETE /v1/list/{id}/members/{user}", path: "/v1/list/1/members/bob", token: alice.Id, status: http.StatusOK},
+		{operation: "DELETE /v1/list/{id}", path: "/v1/list/1?tasks=trash", token: alice.Id, status: http.StatusBadRequest},
+		{operation: "DELETE /v1/list/{id}", path: "/v1/list/1", token: alice.Id, status: http.StatusOK},
+		{operation: "PUT /v1/admin/accounts/{user}", path: "/v1/admin/accounts/dave", token: util.AdminToken, body: `{"password":"密碼"}`, status: http.StatusOK},
+		{operation: "PUT /v1/admin/accounts/{user}", path: "/v1/admin/accounts/dave", token: util.AdminToken, body: `{"password":""}`, status: http.StatusBadRequest},
+		{operation: "DELETE /v1/admin/accounts/{user}", path: "/v1/admin/accounts/dave", token: util.AdminToken, status: http.StatusOK},
+		{operation: "DELETE /v1/admin/accounts/{user}", path: "/v1/admin/accounts/dave", token: util.AdminToken, status: http.StatusNotFound},
+		{operation: "GET /v1/admin/backup", path: "/v1/admin/backup", token: util.AdminToken, status: http.StatusOK},
+		{operation: "GET /v1/admin/backup", path: "/v1/admin/backup", token: alice.Id, status: http.StatusForbidden},
+		{operation: "POST /v1/admin/restore", path: "/v1/admin/restore", token: util.AdminToken, body: `{"version":1}`, status: http.StatusBadRequest},
+		{operation: "POST /v1/admin/restore", path: "/v1/admin/restore", token: util.AdminToken, body: archive, status: http.StatusOK},
+		// Trashed by the restore, which brings back the state before.
+		{operation: "DELETE /v1/trash/{id}", path: "/v1/trash/1", token: alice.Id, status: http.StatusNotFound},
+	}
+
+	covered := map[string]bool{}
+	for _, step := range steps {
+		covered[step.operation] = true
+		method, path, _ := strings.Cut(step.operation, " ")
+		operation := lookup(doc, "paths", path, strings.ToLower(method))
+		if operation == nil {
+			t.Errorf("%s is not documented", step.operation)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		req, _ := http.NewRequestWithContext(ctx, method, server.URL+step.path, strings.NewReader(step.body))
+		if step.token != "" {
+			setRequestTokenHeader(t)(req, step.token)
+		}
+		if step.contentType != "" {
+			req.Header.Set("Content-Type", step.contentType)
+		} else if step.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			cancel()
+			t.Fatal(err)
+		}
+		mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+		var body []byte
+		if mediaType != "text/event-stream" {
+			body, _ = io.ReadAll(res.Body)
+		}
+		res.Body.Close()
+		cancel()
+
+		name := step.operation + " " + strconv.Itoa(step.status)
+		if res.StatusCode != step.status {
+			t.Errorf("%s: got status %d", name, res.StatusCode)
+			continue
+		}
+		response := lookup(operation, "responses", strconv.Itoa(res.StatusCode))
+		if response == nil {
+			t.Errorf("%s: is not documented", name)
+			continue
+		}
+		content := lookup(response, "content")
+		if len(content) == 0 {
+			if len(body) > 0 {
+				t.Errorf("%s: is documented without a body, got %s", name, body)
+			}
+			continue
+		}
+		if content[mediaType] == nil && content["*/*"] == nil {
+			t.Errorf("%s: got undocumented media type %s", name, mediaType)
+			continue
+		}
+		if mediaType != "application/json" {
+			continue
+		}
+
+		var value any
+		if err := json.Unmarshal(body, &value); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		for _, err := range validate(doc, lookup(content, mediaType, "schema"), value, "body") {
+			t.Errorf("%s: %s in %s", name, err, body)
+		}
+	}
+
+	for path, item := range lookup(doc, "paths") {
+		for method := range item.(document) {
+			if operation := strings.ToUpper(method) + " " + path; !covered[operation] {
+				t.Errorf("%s is documented but not tested", operation)
+			}
+		}
+	}
+}
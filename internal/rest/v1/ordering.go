@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+type MoveTaskOutput struct {
+	Result TaskResult `json:"result"`
+}
+
+type FailedMoveTaskOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func moveTaskHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		var payload tasks.MoveTaskInput
+		c.ShouldBind(&payload)
+
+		moved, err := u.MoveTask(actorFromContext(c), id, &payload)
+		if errors.Is(err, tasks.ErrorInvalidMove) {
+			c.JSON(http.StatusBadRequest, FailedMoveTaskOutput{})
+			return
+		}
+		if isInvalidTaskInput(err) {
+			c.JSON(http.StatusUnprocessableEntity, FailedMoveTaskOutput{})
+			return
+		}
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, FailedMoveTaskOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedMoveTaskOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, MoveTaskOutput{Result: toTaskResult(moved)})
+	}
+}
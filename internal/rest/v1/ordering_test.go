@@ -0,0 +1,91 @@
+package routes_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	taskentity "github.com/dannyh79/whostodo/internal/tasks/entities"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_POSTTaskMove(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		path       string
+		payload    string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with result",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/2/move",
+			payload:    `{"before":1}`,
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"name":"買晚餐","status":0,"id":2,"priority":"high"}}`,
+		},
+		{
+			name:       "without target returns status code 400",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/2/move",
+			payload:    `{}`,
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "with unknown target returns status code 422",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/2/move",
+			payload:    `{"after":9}`,
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "returns status code 404",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/9/move",
+			payload:    `{"after":1}`,
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			authroized: false,
+			path:       "/v1/task/2/move",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Position: "V"})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐", Position: "k", Priority: taskentity.PriorityHigh})
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.payload))
+			req.Header.Add("Content-Type", "application/json")
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
@@ -1,71 +1,209 @@
 package routes
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dannyh79/whostodo/internal/access"
+	"github.com/dannyh79/whostodo/internal/attachments"
+	"github.com/dannyh79/whostodo/internal/comments"
+	"github.com/dannyh79/whostodo/internal/lists"
 	"github.com/dannyh79/whostodo/internal/sessions"
 	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/dannyh79/whostodo/internal/workspaces"
 	"github.com/gin-gonic/gin"
 )
 
 // Returning format is slightly different per spec
 type ListTaskItem struct {
-	Id     int    `json:"id"`
-	Name   string `json:"name"`
-	Status int    `json:"status"`
+	Id          int               `json:"id"`
+	Name        string            `json:"name"`
+	Status      int               `json:"status"`
+	Priority    string            `json:"priority,omitempty"`
+	Description string            `json:"description,omitempty"`
+	ListId      int               `json:"list_id,omitempty"`
+	ParentId    int               `json:"parent_id,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	BlockedBy   []int             `json:"blocked_by,omitempty"`
+	DueAt       *time.Time        `json:"due_at,omitempty"`
+	Recurrence  *RecurrenceResult `json:"recurrence,omitempty"`
+	Creator     string            `json:"creator,omitempty"`
+	Assignee    string            `json:"assignee,omitempty"`
+	Progress    *ProgressResult   `json:"progress,omitempty"`
 }
 type ListTasksOutput struct {
 	Result []ListTaskItem `json:"result"`
 }
 
+// Single task results list the name first, per spec.
+type TaskResult struct {
+	Name        string            `json:"name"`
+	Status      int               `json:"status"`
+	Id          int               `json:"id"`
+	Priority    string            `json:"priority,omitempty"`
+	Description string            `json:"description,omitempty"`
+	ListId      int               `json:"list_id,omitempty"`
+	ParentId    int               `json:"parent_id,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	BlockedBy   []int             `json:"blocked_by,omitempty"`
+	DueAt       *time.Time        `json:"due_at,omitempty"`
+	Recurrence  *RecurrenceResult `json:"recurrence,omitempty"`
+	Creator     string            `json:"creator,omitempty"`
+	Assignee    string            `json:"assignee,omitempty"`
+	Progress    *ProgressResult   `json:"progress,omitempty"`
+}
+
+type ProgressResult struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+type RecurrenceResult struct {
+	Frequency string     `json:"frequency"`
+	Interval  int        `json:"interval"`
+	Weekdays  []string   `json:"weekdays,omitempty"`
+	Until     *time.Time `json:"until,omitempty"`
+	Count     int        `json:"count,omitempty"`
+}
+
 type PostTaskOutput struct {
-	Result struct {
-		Name   string `json:"name"`
-		Status int    `json:"status"`
-		Id     int    `json:"id"`
-	} `json:"result"`
+	Result TaskResult `json:"result"`
+}
+
+type FailedPostTaskOutput struct {
+	Result struct{} `json:"result"`
 }
 
 type UpdateTaskOutput struct {
-	Result struct {
-		Name   string `json:"name"`
-		Status int    `json:"status"`
-		Id     int    `json:"id"`
-	} `json:"result"`
+	Result TaskResult `json:"result"`
 }
 
 type FailedUpdateTaskOutput struct {
 	Result struct{} `json:"result"`
 }
 
+type ListTrashedTaskItem struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	Status    int       `json:"status"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+type ListTrashedTasksOutput struct {
+	Result []ListTrashedTaskItem `json:"result"`
+}
+
+type RestoreTaskOutput struct {
+	Result TaskResult `json:"result"`
+}
+
+type UndoOutput struct {
+	Result TaskResult `json:"result"`
+}
+
+type FailedUndoOutput struct {
+	Result struct{} `json:"result"`
+}
+
 type PostAuthSuccessOutput struct {
 	Token string `json:"result"`
 }
 
 type PostAuthNotModifiedOutput struct{}
 
+// workspaceKey holds the session's *workspaces.Workspace in the gin context.
+const workspaceKey = "workspace_usecases"
+
 var UnprotectedPaths = map[string]string{
-	"auth": "/auth",
+	"auth":    "/auth",
+	"openapi": "/openapi.json",
 }
 
-func AddRoutes(r *gin.Engine, tasksU *tasks.TasksUsecase, sessionsU *sessions.SessionsUsecase) {
+func AddRoutes(r *gin.Engine, sessionsU *sessions.SessionsUsecase, workspacesU *workspaces.WorkspacesUsecase) {
+	// Calendar apps cannot send an Authorization header, so feeds are read by
+	// the token in their path instead of a session.
+	r.GET("/v1/feeds/:token", feedCalendarHandler(sessionsU, workspacesU))
+
 	v1 := r.Group("/v1")
 
 	v1.Use(sessionMiddleware(sessionsU, UnprotectedPaths))
+	v1.Use(workspaceMiddleware(workspacesU, UnprotectedPaths))
+	v1.Use(changesMiddleware())
 
 	v1.POST(UnprotectedPaths["auth"], authenticateHandler(sessionsU))
+	v1.GET(UnprotectedPaths["openapi"], openAPIHandler(openAPIDocument()))
+
+	v1.GET("/feed", getFeedHandler(sessionsU))
+	v1.PUT("/feed", rotateFeedHandler(sessionsU))
+	v1.DELETE("/feed", revokeFeedHandler(sessionsU))
 
-	v1.GET("/tasks", listTasksHandler(tasksU))
-	v1.POST("/task", createTaskHandler(tasksU))
-	v1.PUT("/task/:id", updateTaskHandler(tasksU))
-	v1.DELETE("/task/:id", deleteTaskHandler(tasksU))
+	v1.GET("/changes", watchChangesHandler)
+
+	v1.GET("/tasks", scoped(tasksOf, listTasksHandler))
+	v1.GET("/tasks/next", scoped(tasksOf, listNextTasksHandler))
+	v1.GET("/tasks/search", scoped(tasksOf, searchTasksHandler))
+	v1.GET("/tasks/assigned", scoped(tasksOf, listAssignedTasksHandler))
+	v1.GET("/tasks/export", scoped(tasksOf, exportTasksHandler))
+	v1.POST("/tasks/import", scoped(tasksOf, importTasksHandler))
+	v1.POST("/task", scoped(tasksOf, createTaskHandler))
+	v1.GET("/task/:id", scoped(tasksOf, getTaskHandler))
+	v1.PUT("/task/:id", scoped(tasksOf, updateTaskHandler))
+	v1.DELETE("/task/:id", scoped(tasksOf, deleteTaskHandler))
+	v1.POST("/task/:id/move", scoped(tasksOf, moveTaskHandler))
+	v1.GET("/task/:id/tree", scoped(tasksOf, taskTreeHandler))
+	v1.GET("/task/:id/dependencies", scoped(tasksOf, dependenciesHandler))
+	v1.POST("/task/:id/blockers", scoped(tasksOf, addBlockerHandler))
+	v1.DELETE("/task/:id/blockers/:blocker", scoped(tasksOf, removeBlockerHandler))
+	v1.POST("/task/:id/tags", scoped(tasksOf, addTagsHandler))
+	v1.DELETE("/task/:id/tags/:tag", scoped(tasksOf, removeTagHandler))
+	v1.PUT("/task/:id/assignee", scoped(tasksOf, assignTaskHandler))
+	v1.DELETE("/task/:id/assignee", scoped(tasksOf, unassignTaskHandler))
+	v1.GET("/task/:id/history", scoped(tasksOf, taskHistoryHandler))
+
+	v1.GET("/task/:id/comments", scoped(commentsOf, listCommentsHandler))
+	v1.POST("/task/:id/comments", scoped(commentsOf, createCommentHandler))
+	v1.PUT("/task/:id/comments/:comment", scoped(commentsOf, updateCommentHandler))
+	v1.DELETE("/task/:id/comments/:comment", scoped(commentsOf, deleteCommentHandler))
+	v1.GET("/task/:id/attachments", scoped(attachmentsOf, listAttachmentsHandler))
+	v1.POST("/task/:id/attachments", scoped(attachmentsOf, uploadAttachmentHandler))
+	v1.GET("/task/:id/attachments/:attachment", scoped(attachmentsOf, downloadAttachmentHandler))
+	v1.DELETE("/task/:id/attachments/:attachment", scoped(attachmentsOf, deleteAttachmentHandler))
+
+	v1.GET("/tags", scoped(tasksOf, listTagsHandler))
+	v1.PUT("/tag/:name", scoped(tasksOf, renameTagHandler))
+
+	v1.GET("/trash", scoped(tasksOf, listTrashedTasksHandler))
+	v1.POST("/trash/:id/restore", scoped(tasksOf, restoreTaskHandler))
+	v1.DELETE("/trash/:id", scoped(tasksOf, purgeTaskHandler))
+
+	v1.POST("/undo", scoped(tasksOf, undoHandler))
+	v1.POST("/redo", scoped(tasksOf, redoHandler))
+
+	v1.GET("/lists", scoped(listsOf, listListsHandler))
+	v1.POST("/list", scoped(listsOf, createListHandler))
+	v1.PUT("/list/:id", scoped(listsOf, updateListHandler))
+	v1.DELETE("/list/:id", scoped(workspaceOf, deleteListHandler))
+	v1.GET("/list/:id/members", scoped(listsOf, listMembersHandler))
+	v1.PUT("/list/:id/members/:user", scoped(listsOf, inviteMemberHandler))
+	v1.DELETE("/list/:id/members/:user", scoped(listsOf, removeMemberHandler))
+	v1.GET("/invitations", scoped(listsOf, listInvitationsHandler))
+	v1.POST("/invitations/:id/accept", scoped(listsOf, acceptInvitationHandler))
 }
 
 func listTasksHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tasks := u.ListTasks()
+		render, ok := descriptionRenderer(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, FailedListTasksOutput{})
+			return
+		}
+
+		var query tasks.ListTasksInput
+		c.ShouldBindQuery(&query)
+		tasks := u.ListTasks(actorFromContext(c), &query)
+		renderDescriptions(render, tasks...)
 		c.JSON(http.StatusOK, toListTasksOutput(tasks))
 	}
 }
@@ -74,7 +212,16 @@ func createTaskHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var payload tasks.CreateTaskInput
 		c.ShouldBind(&payload)
-		task := u.CreateTask(&payload)
+		task, err := u.CreateTask(actorFromContext(c), &payload)
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, FailedPostTaskOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, FailedPostTaskOutput{})
+			return
+		}
+
 		c.JSON(http.StatusCreated, toPostTaskOutput(task))
 	}
 }
@@ -85,7 +232,19 @@ func updateTaskHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
 		var payload tasks.UpdateTaskInput
 		c.ShouldBind(&payload)
 
-		updated, err := u.UpdateTask(id, &payload)
+		updated, err := u.UpdateTask(actorFromContext(c), id, &payload)
+		if isInvalidTaskInput(err) {
+			c.JSON(http.StatusUnprocessableEntity, FailedUpdateTaskOutput{})
+			return
+		}
+		if errors.Is(err, tasks.ErrorBlocked) {
+			c.JSON(http.StatusConflict, FailedUpdateTaskOutput{})
+			return
+		}
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, FailedUpdateTaskOutput{})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusNotFound, FailedUpdateTaskOutput{})
 			return
@@ -98,7 +257,18 @@ func updateTaskHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
 func deleteTaskHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, _ := strconv.Atoi(c.Param("id"))
-		err := u.DeleteTask(id)
+		var query tasks.DeleteTaskInput
+		c.ShouldBindQuery(&query)
+
+		err := u.DeleteTask(actorFromContext(c), id, &query)
+		if errors.Is(err, tasks.ErrorUnknownDeleteMode) {
+			c.JSON(http.StatusBadRequest, nil)
+			return
+		}
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, nil)
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusNotFound, nil)
 			return
@@ -108,6 +278,81 @@ func deleteTaskHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
 	}
 }
 
+func listTrashedTasksHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tasks := u.ListTrashedTasks(actorFromContext(c))
+		c.JSON(http.StatusOK, toListTrashedTasksOutput(tasks))
+	}
+}
+
+func restoreTaskHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		restored, err := u.RestoreTask(actorFromContext(c), id)
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, FailedUpdateTaskOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedUpdateTaskOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, toRestoreTaskOutput(restored))
+	}
+}
+
+func purgeTaskHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		err := u.PurgeTask(actorFromContext(c), id)
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, nil)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, nil)
+			return
+		}
+
+		c.JSON(http.StatusOK, nil)
+	}
+}
+
+func undoHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		task, err := u.Undo(actorFromContext(c))
+		if err != nil {
+			c.JSON(undoErrorStatus(err), FailedUndoOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, toUndoOutput(task))
+	}
+}
+
+func redoHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		task, err := u.Redo(actorFromContext(c))
+		if err != nil {
+			c.JSON(undoErrorStatus(err), FailedUndoOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, toUndoOutput(task))
+	}
+}
+
+func undoErrorStatus(err error) int {
+	if errors.Is(err, tasks.ErrorConflict) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, tasks.ErrorForbidden) {
+		return http.StatusForbidden
+	}
+	return http.StatusNotFound
+}
+
 func authenticateHandler(u *sessions.SessionsUsecase) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := getTokenFromHeader(c)
@@ -116,11 +361,29 @@ func authenticateHandler(u *sessions.SessionsUsecase) gin.HandlerFunc {
 			return
 		}
 
-		token = u.Authenticate()
+		var payload sessions.AuthenticateInput
+		c.ShouldBind(&payload)
+		token, err := u.Authenticate(&payload)
+		if errors.Is(err, sessions.ErrorInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, PostAuthSuccessOutput{})
+			return
+		}
+		if errors.Is(err, sessions.ErrorNotMember) {
+			c.JSON(http.StatusForbidden, PostAuthSuccessOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, PostAuthSuccessOutput{})
+			return
+		}
 		c.JSON(http.StatusCreated, PostAuthSuccessOutput{Token: token})
 	}
 }
 
+// rejectedTokenChallenge is sent along with the 403 of a missing or expired
+// session token.
+const rejectedTokenChallenge = `Bearer error="invalid_token"`
+
 func sessionMiddleware(u *sessions.SessionsUsecase, ignore map[string]string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		for _, path := range ignore {
@@ -131,15 +394,112 @@ func sessionMiddleware(u *sessions.SessionsUsecase, ignore map[string]string) gi
 		}
 
 		token := getTokenFromHeader(c)
-		if !u.Validate(token) {
+		session, ok := u.Session(token)
+		if !ok {
+			// Tells clients to start a new session, unlike the 403s of
+			// handlers refusing this one.
+			c.Header("WWW-Authenticate", rejectedTokenChallenge)
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{})
 			return
 		}
 
+		c.Set(sessions.SessionKey, token)
+		c.Set(sessions.UserKey, session.User)
+		c.Set(sessions.WorkspaceKey, session.Workspace)
+		c.Next()
+	}
+}
+
+// streamingPaths are served without the workspace lock, which they would
+// otherwise hold for as long as they stream.
+var streamingPaths = map[string]bool{
+	"/v1/changes": true,
+}
+
+// workspaceMiddleware resolves the workspace of the session, which every
+// handler but authenticateHandler then serves from, holding its lock for the
+// rest of the request, as the usecases are not safe for concurrent use.
+func workspaceMiddleware(u *workspaces.WorkspacesUsecase, ignore map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, path := range ignore {
+			if c.Request.URL.Path == "/v1"+path {
+				c.Next()
+				return
+			}
+		}
+
+		w, err := u.Find(c.GetString(sessions.WorkspaceKey))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+
+		c.Set(workspaceKey, w)
+		if !streamingPaths[c.FullPath()] {
+			w.Lock()
+			defer w.Unlock()
+		}
 		c.Next()
 	}
 }
 
+// scoped serves the handler with the usecase picked from the session's
+// workspace.
+func scoped[U any](pick func(*workspaces.Workspace) U, handler func(U) gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := c.MustGet(workspaceKey).(*workspaces.Workspace)
+		handler(pick(w))(c)
+	}
+}
+
+func workspaceOf(w *workspaces.Workspace) *workspaces.Workspace {
+	return w
+}
+
+func tasksOf(w *workspaces.Workspace) *tasks.TasksUsecase {
+	return w.Tasks
+}
+
+func listsOf(w *workspaces.Workspace) *lists.ListsUsecase {
+	return w.Lists
+}
+
+func commentsOf(w *workspaces.Workspace) *comments.CommentsUsecase {
+	return w.Comments
+}
+
+func attachmentsOf(w *workspaces.Workspace) *attachments.AttachmentsUsecase {
+	return w.Attachments
+}
+
+// isInvalidTaskInput reports whether the task input refers to something it
+// cannot, as opposed to the task itself not being found.
+func isInvalidTaskInput(err error) bool {
+	return errors.Is(err, tasks.ErrorListNotFound) ||
+		errors.Is(err, tasks.ErrorParentNotFound) ||
+		errors.Is(err, tasks.ErrorCycle) ||
+		errors.Is(err, tasks.ErrorBlockerNotFound) ||
+		errors.Is(err, tasks.ErrorDependencyCycle) ||
+		errors.Is(err, tasks.ErrorInvalidRecurrence) ||
+		errors.Is(err, tasks.ErrorInvalidPriority) ||
+		errors.Is(err, tasks.ErrorMoveTargetNotFound) ||
+		errors.Is(err, tasks.ErrorDescriptionTooLong)
+}
+
+func actorFromContext(c *gin.Context) tasks.Actor {
+	value, _ := c.Get(scopeKey)
+	scope, _ := value.(*access.Scope)
+	return tasks.Actor{
+		SessionId: c.GetString(sessions.SessionKey),
+		User:      userFromContext(c),
+		Scope:     scope,
+	}
+}
+
+func userFromContext(c *gin.Context) string {
+	return c.GetString(sessions.UserKey)
+}
+
 func getTokenFromHeader(c *gin.Context) string {
 	headerValue := c.Request.Header.Get("Authorization")
 	bearerAndToken := strings.Split(headerValue, "Bearer ")
@@ -151,31 +511,100 @@ func getTokenFromHeader(c *gin.Context) string {
 }
 
 func toListTasksOutput(ts []*tasks.TaskOutput) *ListTasksOutput {
-	var result = make([]ListTaskItem, 0)
 	var output ListTasksOutput
+	output.Result = toListTaskItems(ts)
+	return &output
+}
+
+func toListTaskItems(ts []*tasks.TaskOutput) []ListTaskItem {
+	var result = make([]ListTaskItem, 0)
 	for _, t := range ts {
 		result = append(result, ListTaskItem{
-			Id:     t.Id,
-			Name:   t.Name,
-			Status: t.Status,
+			Id:          t.Id,
+			Name:        t.Name,
+			Status:      t.Status,
+			Priority:    t.Priority,
+			Description: t.Description,
+			ListId:      t.ListId,
+			ParentId:    t.ParentId,
+			Tags:        t.Tags,
+			BlockedBy:   t.BlockedBy,
+			DueAt:       t.DueAt,
+			Recurrence:  toRecurrenceResult(t.Recurrence),
+			Creator:     t.Creator,
+			Assignee:    t.Assignee,
+			Progress:    toProgressResult(t.Progress),
 		})
 	}
-	output.Result = result
-	return &output
+	return result
 }
 
 func toPostTaskOutput(t *tasks.TaskOutput) *PostTaskOutput {
-	var output PostTaskOutput
-	output.Result.Id = t.Id
-	output.Result.Name = t.Name
-	output.Result.Status = t.Status
-	return &output
+	return &PostTaskOutput{Result: toTaskResult(t)}
 }
 
 func toUpdateTaskOutput(t *tasks.TaskOutput) *UpdateTaskOutput {
-	var output UpdateTaskOutput
-	output.Result.Id = t.Id
-	output.Result.Name = t.Name
-	output.Result.Status = t.Status
+	return &UpdateTaskOutput{Result: toTaskResult(t)}
+}
+
+func toListTrashedTasksOutput(ts []*tasks.TrashedTaskOutput) *ListTrashedTasksOutput {
+	var result = make([]ListTrashedTaskItem, 0)
+	var output ListTrashedTasksOutput
+	for _, t := range ts {
+		result = append(result, ListTrashedTaskItem{
+			Id:        t.Id,
+			Name:      t.Name,
+			Status:    t.Status,
+			DeletedAt: t.DeletedAt,
+		})
+	}
+	output.Result = result
 	return &output
 }
+
+func toRestoreTaskOutput(t *tasks.TaskOutput) *RestoreTaskOutput {
+	return &RestoreTaskOutput{Result: toTaskResult(t)}
+}
+
+func toUndoOutput(t *tasks.TaskOutput) *UndoOutput {
+	return &UndoOutput{Result: toTaskResult(t)}
+}
+
+func toTaskResult(t *tasks.TaskOutput) TaskResult {
+	return TaskResult{
+		Name:        t.Name,
+		Status:      t.Status,
+		Id:          t.Id,
+		Priority:    t.Priority,
+		Description: t.Description,
+		ListId:      t.ListId,
+		ParentId:    t.ParentId,
+		Tags:        t.Tags,
+		BlockedBy:   t.BlockedBy,
+		DueAt:       t.DueAt,
+		Recurrence:  toRecurrenceResult(t.Recurrence),
+		Creator:     t.Creator,
+		Assignee:    t.Assignee,
+		Progress:    toProgressResult(t.Progress),
+	}
+}
+
+func toProgressResult(p *tasks.ProgressOutput) *ProgressResult {
+	if p == nil {
+		return nil
+	}
+	return &ProgressResult{Done: p.Done, Total: p.Total}
+}
+
+func toRecurrenceResult(r *tasks.RecurrenceOutput) *RecurrenceResult {
+	if r == nil {
+		return nil
+	}
+	return &RecurrenceResult{
+		Frequency: r.Frequency,
+		Interval:  r.Interval,
+		Weekdays:  r.Weekdays,
+		Until:     r.Until,
+		Count:     r.Count,
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/dannyh79/whostodo/internal/repository"
 	"github.com/dannyh79/whostodo/internal/rest/v1"
 	"github.com/dannyh79/whostodo/internal/sessions/entities"
+	"github.com/dannyh79/whostodo/internal/tasks"
 	util "github.com/dannyh79/whostodo/internal/testutil"
 )
 
@@ -21,6 +23,8 @@ func Test_GETTasks(t *testing.T) {
 		name       string
 		authroized bool
 		session    Session
+		query      string
+		lists      []repository.ListSchema
 		data       []repository.TaskSchema
 		statusCode int
 		expected   string
@@ -40,6 +44,31 @@ func Test_GETTasks(t *testing.T) {
 			statusCode: http.StatusOK,
 			expected:   `{"result":[]}`,
 		},
+		{
+			name:       "with list returns status code 200 with tasks of the list",
+			authroized: true,
+			session:    util.NewSession(),
+			query:      "?list=1",
+			lists:      []repository.ListSchema{{Id: 1, Name: "家事", Owner: "anonymous"}},
+			data: []repository.TaskSchema{
+				{Id: 1, Name: "name", Status: 0},
+				{Id: 2, Name: "洗碗", Status: 0, ListId: 1},
+			},
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":2,"name":"洗碗","status":0,"list_id":1}]}`,
+		},
+		{
+			name:       "with tags returns status code 200 with tasks having all of them",
+			authroized: true,
+			session:    util.NewSession(),
+			query:      "?tag=errand&tag=food&match=all",
+			data: []repository.TaskSchema{
+				{Id: 1, Name: "買早餐", Status: 0, Tags: []string{"errand"}},
+				{Id: 2, Name: "買晚餐", Status: 0, Tags: []string{"errand", "food"}},
+			},
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":2,"name":"買晚餐","status":0,"tags":["errand","food"]}]}`,
+		},
 		{
 			name:       "without session token returns status code 403",
 			statusCode: http.StatusForbidden,
@@ -59,13 +88,16 @@ func Test_GETTasks(t *testing.T) {
 			t.Parallel()
 
 			suite := util.NewTestSuite()
+			for _, row := range tc.lists {
+				suite.ListRepo.PopulateData(row)
+			}
 			if data := tc.data; len(data) > 0 {
 				for _, row := range data {
 					suite.TaskRepo.PopulateData(row)
 				}
 			}
 			rr := httptest.NewRecorder()
-			req, _ := http.NewRequest(http.MethodGet, "/v1/tasks", nil)
+			req, _ := http.NewRequest(http.MethodGet, "/v1/tasks"+tc.query, nil)
 			if tc.authroized {
 				suite.SessionRepo.PopulateData(tc.session)
 				setRequestTokenHeader(t)(req, tc.session.Id)
@@ -95,7 +127,31 @@ func Test_POSTTask(t *testing.T) {
 			session:    util.NewSession(),
 			data:       `{"name":"買晚餐"}`,
 			statusCode: http.StatusCreated,
-			expected:   `{"result":{"name":"買晚餐","status":0,"id":1}}`,
+			expected:   `{"result":{"name":"買晚餐","status":0,"id":1,"creator":"anonymous"}}`,
+		},
+		{
+			name:       "with recurrence returns status code 201 with result",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       `{"name":"輪值","due_at":"2024-01-01T09:00:00Z","recurrence":{"frequency":"weekly","weekdays":["MO"]}}`,
+			statusCode: http.StatusCreated,
+			expected:   `{"result":{"name":"輪值","status":0,"id":1,"due_at":"2024-01-01T09:00:00Z","recurrence":{"frequency":"weekly","interval":1,"weekdays":["MO"]},"creator":"anonymous"}}`,
+		},
+		{
+			name:       "with invalid recurrence returns status code 422",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       `{"name":"輪值","recurrence":{"frequency":"hourly"}}`,
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "with unknown list returns status code 422",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       `{"name":"買晚餐","list_id":1}`,
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{}}`,
 		},
 		{
 			name:       "without session token returns status code 403",
@@ -155,6 +211,36 @@ func Test_PUTTask(t *testing.T) {
 			statusCode: http.StatusCreated,
 			expected:   `{"result":{"name":"買晚餐","status":1,"id":1}}`,
 		},
+		{
+			name:       "null due_at removes the due date",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       repository.TaskSchema{Id: 1, Name: "買早餐", DueAt: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+			param:      1,
+			payload:    `{"name":"買晚餐","status":0,"due_at":null}`,
+			statusCode: http.StatusCreated,
+			expected:   `{"result":{"name":"買晚餐","status":0,"id":1}}`,
+		},
+		{
+			name:       "due_at left out keeps the due date",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       repository.TaskSchema{Id: 1, Name: "買早餐", DueAt: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+			param:      1,
+			payload:    `{"name":"買晚餐","status":0}`,
+			statusCode: http.StatusCreated,
+			expected:   `{"result":{"name":"買晚餐","status":0,"id":1,"due_at":"2024-01-05T00:00:00Z"}}`,
+		},
+		{
+			name:       "nesting task under itself returns status code 422",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0},
+			param:      1,
+			payload:    `{"name":"買晚餐","status":1,"parent_id":1}`,
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{}}`,
+		},
 		{
 			name:       "returns status code 404 with empty result",
 			authroized: true,
@@ -207,6 +293,43 @@ func Test_PUTTask(t *testing.T) {
 	}
 }
 
+func Test_RejectedTokenChallenge(t *testing.T) {
+	tests := []struct {
+		name     string
+		session  Session
+		expected string
+	}{
+		{
+			name:     "challenges an expired session for another",
+			session:  util.NewExpiredSession(),
+			expected: `Bearer error="invalid_token"`,
+		},
+		{
+			name:    "does not challenge a session refused by the handler",
+			session: util.NewUserSession("bob"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			populateMembers(suite)
+			suite.SessionRepo.PopulateData(tc.session)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPut, "/v1/task/1", bytes.NewBufferString(`{"name":"洗碗","status":1}`))
+			req.Header.Add("Content-Type", "application/json")
+			setRequestTokenHeader(t)(req, tc.session.Id)
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertHttpStatus(t)(rr, http.StatusForbidden)
+			util.AssertEqual(t)(rr.Header().Get("WWW-Authenticate"), tc.expected)
+		})
+	}
+}
+
 func Test_DELETETask(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -266,6 +389,308 @@ func Test_DELETETask(t *testing.T) {
 	}
 }
 
+func Test_GETTrash(t *testing.T) {
+	deletedAt := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	retention := time.Since(deletedAt) + time.Hour
+
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		data       []repository.TaskSchema
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with trashed tasks",
+			authroized: true,
+			session:    util.NewSession(),
+			data: []repository.TaskSchema{
+				{Id: 1, Name: "買早餐", Status: 0},
+				{Id: 2, Name: "買晚餐", Status: 1, DeletedAt: deletedAt},
+			},
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":2,"name":"買晚餐","status":1,"deleted_at":"2026-10-18T09:00:00Z"}]}`,
+		},
+		{
+			name:       "returns status code 200 with empty result",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       []repository.TaskSchema{{Id: 1, Name: "買早餐", Status: 0}},
+			statusCode: http.StatusOK,
+			expected:   `{"result":[]}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite(tasks.WithTrashRetention(retention))
+			for _, row := range tc.data {
+				suite.TaskRepo.PopulateData(row)
+			}
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/v1/trash", nil)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_POSTTrashRestore(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		data       repository.TaskSchema
+		param      int
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with restored task",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0, DeletedAt: time.Now()},
+			param:      1,
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"name":"買早餐","status":0,"id":1}}`,
+		},
+		{
+			name:       "returns status code 404 when task is not trashed",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0},
+			param:      1,
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(tc.data)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/v1/trash/%d/restore", tc.param), nil)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_DELETETrash(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		data       repository.TaskSchema
+		param      int
+		statusCode int
+	}{
+		{
+			name:       "returns status code 200",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0, DeletedAt: time.Now()},
+			param:      1,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "returns status code 404 when task is not trashed",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0},
+			param:      1,
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "without session token returns status code 403",
+			statusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(tc.data)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/trash/%d", tc.param), nil)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+		})
+	}
+}
+
+func Test_POSTUndo(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		data       repository.TaskSchema
+		payload    string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with reverted task",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0},
+			payload:    `{"name":"買晚餐","status":1}`,
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"name":"買早餐","status":0,"id":1}}`,
+		},
+		{
+			name:       "returns status code 404 with nothing to undo",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0},
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(tc.data)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+			}
+			if tc.payload != "" {
+				req, _ := http.NewRequest(http.MethodPut, "/v1/task/1", bytes.NewBufferString(tc.payload))
+				req.Header.Add("Content-Type", "application/json")
+				setRequestTokenHeader(t)(req, tc.session.Id)
+				suite.Engine.ServeHTTP(httptest.NewRecorder(), req)
+			}
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/v1/undo", nil)
+			if tc.authroized {
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_POSTRedo(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		data       repository.TaskSchema
+		undo       bool
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with reapplied task",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0},
+			undo:       true,
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"name":"買晚餐","status":1,"id":1}}`,
+		},
+		{
+			name:       "returns status code 404 with nothing to redo",
+			authroized: true,
+			session:    util.NewSession(),
+			data:       repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0},
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(tc.data)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				req, _ := http.NewRequest(http.MethodPut, "/v1/task/1", bytes.NewBufferString(`{"name":"買晚餐","status":1}`))
+				req.Header.Add("Content-Type", "application/json")
+				setRequestTokenHeader(t)(req, tc.session.Id)
+				suite.Engine.ServeHTTP(httptest.NewRecorder(), req)
+			}
+			if tc.undo {
+				req, _ := http.NewRequest(http.MethodPost, "/v1/undo", nil)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+				suite.Engine.ServeHTTP(httptest.NewRecorder(), req)
+			}
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/v1/redo", nil)
+			if tc.authroized {
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
 func Test_POSTAuth(t *testing.T) {
 	tests := []struct {
 		name             string
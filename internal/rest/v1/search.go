@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+type FailedSearchTasksOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func searchTasksHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		render, ok := descriptionRenderer(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, FailedSearchTasksOutput{})
+			return
+		}
+
+		var query tasks.SearchTasksInput
+		c.ShouldBindQuery(&query)
+
+		found, err := u.SearchTasks(actorFromContext(c), &query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, FailedSearchTasksOutput{})
+			return
+		}
+
+		renderDescriptions(render, found...)
+		c.JSON(http.StatusOK, toListTasksOutput(found))
+	}
+}
@@ -0,0 +1,75 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_GETTasksSearch(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		query      string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with ranked result",
+			authroized: true,
+			session:    util.NewSession(),
+			query:      "晚餐",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":2,"name":"買晚餐","status":0},{"id":3,"name":"晚餐後洗碗，晚餐前買菜","status":0}]}`,
+		},
+		{
+			name:       "returns status code 200 with empty result",
+			authroized: true,
+			session:    util.NewSession(),
+			query:      "午餐",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[]}`,
+		},
+		{
+			name:       "without query returns status code 400",
+			authroized: true,
+			session:    util.NewSession(),
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			authroized: false,
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐"})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐"})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 3, Name: "晚餐後洗碗，晚餐前買菜"})
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/v1/tasks/search?q="+url.QueryEscape(tc.query), nil)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+type TaskTreeResult struct {
+	TaskResult
+	Children []TaskTreeResult `json:"children"`
+}
+
+type TaskTreeOutput struct {
+	Result TaskTreeResult `json:"result"`
+}
+
+type FailedTaskTreeOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func taskTreeHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		tree, err := u.GetTaskTree(actorFromContext(c), id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedTaskTreeOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, TaskTreeOutput{Result: toTaskTreeResult(tree)})
+	}
+}
+
+func toTaskTreeResult(t *tasks.TaskTreeOutput) TaskTreeResult {
+	result := TaskTreeResult{
+		TaskResult: toTaskResult(&t.TaskOutput),
+		Children:   make([]TaskTreeResult, 0),
+	}
+	for _, child := range t.Children {
+		result.Children = append(result.Children, toTaskTreeResult(child))
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func populateSubtasks(suite *util.MockTestSuite) {
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "搬家"})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "打包", ParentId: 1, Status: 1})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 3, Name: "打包廚房", ParentId: 2})
+}
+
+func Test_GETTaskTree(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		path       string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with nested subtasks",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/1/tree",
+			statusCode: http.StatusOK,
+			expected: `{"result":{"name":"搬家","status":0,"id":1,"progress":{"done":1,"total":2},"children":[` +
+				`{"name":"打包","status":1,"id":2,"parent_id":1,"progress":{"done":0,"total":1},"children":[` +
+				`{"name":"打包廚房","status":0,"id":3,"parent_id":2,"children":[]}]}]}}`,
+		},
+		{
+			name:       "returns status code 404",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/9/tree",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			path:       "/v1/task/1/tree",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			populateSubtasks(suite)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_DELETEParentTask(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		statusCode int
+		remaining  int
+	}{
+		{
+			name:       "moving subtasks up returns status code 200",
+			path:       "/v1/task/2",
+			statusCode: http.StatusOK,
+			remaining:  2,
+		},
+		{
+			name:       "trashing subtasks along returns status code 200",
+			path:       "/v1/task/2?children=cascade",
+			statusCode: http.StatusOK,
+			remaining:  1,
+		},
+		{
+			name:       "with unknown mode returns status code 400",
+			path:       "/v1/task/2?children=orphan",
+			statusCode: http.StatusBadRequest,
+			remaining:  3,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			populateSubtasks(suite)
+			session := util.NewSession()
+			suite.SessionRepo.PopulateData(session)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, tc.path, nil)
+			setRequestTokenHeader(t)(req, session.Id)
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(len(suite.TaskRepo.ListAll()), tc.remaining)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+type TagTaskOutput struct {
+	Result TaskResult `json:"result"`
+}
+
+type FailedTagTaskOutput struct {
+	Result struct{} `json:"result"`
+}
+
+type ListTagItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+type ListTagsOutput struct {
+	Result []ListTagItem `json:"result"`
+}
+
+type RenameTagOutput struct {
+	Result ListTagItem `json:"result"`
+}
+
+type FailedRenameTagOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func addTagsHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		var payload tasks.TagsInput
+		c.ShouldBind(&payload)
+
+		updated, err := u.AddTags(actorFromContext(c), id, &payload)
+		if errors.Is(err, tasks.ErrorInvalidTag) {
+			c.JSON(http.StatusBadRequest, FailedTagTaskOutput{})
+			return
+		}
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, FailedTagTaskOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedTagTaskOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, toTagTaskOutput(updated))
+	}
+}
+
+func removeTagHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+
+		updated, err := u.RemoveTag(actorFromContext(c), id, c.Param("tag"))
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, FailedTagTaskOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedTagTaskOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, toTagTaskOutput(updated))
+	}
+}
+
+func listTagsHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tags := u.ListTags(actorFromContext(c))
+		c.JSON(http.StatusOK, toListTagsOutput(tags))
+	}
+}
+
+func renameTagHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var payload tasks.RenameTagInput
+		c.ShouldBind(&payload)
+
+		renamed, err := u.RenameTag(actorFromContext(c), c.Param("name"), &payload)
+		if errors.Is(err, tasks.ErrorInvalidTag) {
+			c.JSON(http.StatusBadRequest, FailedRenameTagOutput{})
+			return
+		}
+		if errors.Is(err, tasks.ErrorForbidden) {
+			c.JSON(http.StatusForbidden, FailedRenameTagOutput{})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, FailedRenameTagOutput{})
+			return
+		}
+
+		c.JSON(http.StatusCreated, RenameTagOutput{Result: ListTagItem{Name: renamed.Name, Count: renamed.Count}})
+	}
+}
+
+func toTagTaskOutput(t *tasks.TaskOutput) *TagTaskOutput {
+	return &TagTaskOutput{Result: toTaskResult(t)}
+}
+
+func toListTagsOutput(ts []*tasks.TagOutput) *ListTagsOutput {
+	var result = make([]ListTagItem, 0)
+	var output ListTagsOutput
+	for _, t := range ts {
+		result = append(result, ListTagItem{
+			Name:  t.Name,
+			Count: t.Count,
+		})
+	}
+	output.Result = result
+	return &output
+}
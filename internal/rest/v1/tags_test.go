@@ -0,0 +1,248 @@
+package routes_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_POSTTaskTags(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		path       string
+		payload    string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with tagged task",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/1/tags",
+			payload:    `{"tags":["food","errand"]}`,
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"name":"買晚餐","status":0,"id":1,"tags":["errand","food"]}}`,
+		},
+		{
+			name:       "without tags returns status code 400",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/1/tags",
+			payload:    `{"tags":[]}`,
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "returns status code 404",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/2/tags",
+			payload:    `{"tags":["food"]}`,
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			path:       "/v1/task/1/tags",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Tags: []string{"errand"}})
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.payload))
+			req.Header.Add("Content-Type", "application/json")
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_DELETETaskTag(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		path       string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with untagged task",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/1/tags/food",
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"name":"買晚餐","status":0,"id":1,"tags":["errand"]}}`,
+		},
+		{
+			name:       "returns status code 404",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/task/2/tags/food",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			path:       "/v1/task/1/tags/food",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Tags: []string{"errand", "food"}})
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, tc.path, nil)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_GETTags(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 200 with result",
+			authroized: true,
+			session:    util.NewSession(),
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"name":"errand","count":2},{"name":"food","count":1}]}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Tags: []string{"errand"}})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐", Tags: []string{"errand", "food"}})
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/v1/tags", nil)
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_PUTTag(t *testing.T) {
+	tests := []struct {
+		name       string
+		authroized bool
+		session    Session
+		path       string
+		payload    string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "returns status code 201 with renamed tag",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/tag/food",
+			payload:    `{"name":"errand"}`,
+			statusCode: http.StatusCreated,
+			expected:   `{"result":{"name":"errand","count":2}}`,
+		},
+		{
+			name:       "with empty name returns status code 400",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/tag/food",
+			payload:    `{"name":""}`,
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "returns status code 404",
+			authroized: true,
+			session:    util.NewSession(),
+			path:       "/v1/tag/chore",
+			payload:    `{"name":"errand"}`,
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "without session token returns status code 403",
+			path:       "/v1/tag/food",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Tags: []string{"errand"}})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐", Tags: []string{"food"}})
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPut, tc.path, bytes.NewBufferString(tc.payload))
+			req.Header.Add("Content-Type", "application/json")
+			if tc.authroized {
+				suite.SessionRepo.PopulateData(tc.session)
+				setRequestTokenHeader(t)(req, tc.session.Id)
+			}
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+// Import bodies larger than this many bytes are rejected.
+const MaxImportSize = 10 << 20
+
+// Formats tasks are exported and imported in, selected by the format query
+// parameter.
+const (
+	formatCSV     = "csv"
+	formatTodoTxt = "todotxt"
+	formatICal    = "ics"
+)
+
+type ImportTasksOutput struct {
+	Result *tasks.ImportOutput `json:"result"`
+}
+
+type FailedImportTasksOutput struct {
+	Result struct{} `json:"result"`
+}
+
+func exportTasksHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		export := u.ExportCSV
+		switch c.DefaultQuery("format", formatCSV) {
+		case formatCSV:
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Header("Content-Disposition", `attachment; filename="tasks.csv"`)
+		case formatTodoTxt:
+			export = u.ExportTodoTxt
+			c.Header("Content-Type", "text/plain; charset=utf-8")
+			c.Header("Content-Disposition", `attachment; filename="todo.txt"`)
+		case formatICal:
+			export = u.ExportICal
+			c.Header("Content-Type", "text/calendar; charset=utf-8")
+			c.Header("Content-Disposition", `attachment; filename="tasks.ics"`)
+		default:
+			c.JSON(http.StatusBadRequest, nil)
+			return
+		}
+
+		c.Status(http.StatusOK)
+		// Rows stream out as they are written, so failing halfway can only
+		// cut the export short.
+		if err := export(actorFromContext(c), c.Writer); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+func importTasksHandler(u *tasks.TasksUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query tasks.ImportTasksInput
+		c.ShouldBindQuery(&query)
+		query.Columns = c.QueryMap("columns")
+		importTasks := u.ImportCSV
+		switch c.DefaultQuery("format", formatCSV) {
+		case formatCSV:
+		case formatTodoTxt:
+			importTasks = u.ImportTodoTxt
+		case formatICal:
+			importTasks = u.ImportICal
+		default:
+			c.JSON(http.StatusBadRequest, FailedImportTasksOutput{})
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportSize)
+		body := c.Request.Body
+		if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+			header, err := c.FormFile("file")
+			if err != nil {
+				c.JSON(importErrorStatus(fmt.Errorf("%w: %w", tasks.ErrorInvalidCSV, err)), FailedImportTasksOutput{})
+				return
+			}
+			file, err := header.Open()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, FailedImportTasksOutput{})
+				return
+			}
+			defer file.Close()
+			body = file
+		}
+
+		output, err := importTasks(actorFromContext(c), body, &query)
+		if errors.Is(err, tasks.ErrorInvalidRows) {
+			c.JSON(http.StatusUnprocessableEntity, ImportTasksOutput{Result: output})
+			return
+		}
+		if err != nil {
+			c.JSON(importErrorStatus(err), FailedImportTasksOutput{})
+			return
+		}
+
+		c.JSON(http.StatusOK, ImportTasksOutput{Result: output})
+	}
+}
+
+func importErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge), errors.Is(err, tasks.ErrorTooManyRows):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, tasks.ErrorInvalidCSV), errors.Is(err, tasks.ErrorInvalidTodoTxt), errors.Is(err, tasks.ErrorInvalidICal), errors.Is(err, tasks.ErrorUnknownColumn):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,250 @@
+package routes_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+var dtstampPattern = regexp.MustCompile(`DTSTAMP:[0-9]{8}T[0-9]{6}Z`)
+
+func Test_GETTasksExport(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		statusCode  int
+		contentType string
+		filename    string
+		expected    string
+	}{
+		{
+			name:        "returns status code 200 with CSV",
+			path:        "/v1/tasks/export",
+			statusCode:  http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			filename:    "tasks.csv",
+			expected:    "id,name,description,status,priority,list_id,parent_id,tags,due_at,creator,assignee\n1,買晚餐,,0,,0,0,errand,,alice,\n",
+		},
+		{
+			name:        "returns status code 200 with CSV on format csv",
+			path:        "/v1/tasks/export?format=csv",
+			statusCode:  http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			filename:    "tasks.csv",
+			expected:    "id,name,description,status,priority,list_id,parent_id,tags,due_at,creator,assignee\n1,買晚餐,,0,,0,0,errand,,alice,\n",
+		},
+		{
+			name:        "returns status code 200 with todo.txt on format todotxt",
+			path:        "/v1/tasks/export?format=todotxt",
+			statusCode:  http.StatusOK,
+			contentType: "text/plain; charset=utf-8",
+			filename:    "todo.txt",
+			expected:    "買晚餐 @errand id:1\n",
+		},
+		{
+			name:        "returns status code 200 with iCalendar on format ics",
+			path:        "/v1/tasks/export?format=ics",
+			statusCode:  http.StatusOK,
+			contentType: "text/calendar; charset=utf-8",
+			filename:    "tasks.ics",
+			expected:    "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//whostodo//whostodo//EN\r\nCALSCALE:GREGORIAN\r\nBEGIN:VTODO\r\nUID:1@whostodo\r\nDTSTAMP:X\r\nSUMMARY:買晚餐\r\nSTATUS:NEEDS-ACTION\r\nCATEGORIES:errand\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+		},
+		{
+			name:       "returns status code 400 on unknown format",
+			path:       "/v1/tasks/export?format=xml",
+			statusCode: http.StatusBadRequest,
+			expected:   "null",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Tags: []string{"errand"}, Creator: "alice"})
+			session := util.NewUserSession("alice")
+			suite.SessionRepo.PopulateData(session)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tc.path, nil)
+			setRequestTokenHeader(t)(req, session.Id)
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(dtstampPattern.ReplaceAllString(rr.Body.String(), "DTSTAMP:X"), tc.expected)
+			if tc.statusCode == http.StatusOK {
+				util.AssertEqual(t)(rr.Header().Get("Content-Type"), tc.contentType)
+				util.AssertEqual(t)(rr.Header().Get("Content-Disposition"), `attachment; filename="`+tc.filename+`"`)
+			}
+		})
+	}
+}
+
+func Test_POSTTasksImport(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		payload    string
+		statusCode int
+		expected   string
+		tasks      int
+	}{
+		{
+			name:       "returns status code 200 with counts",
+			path:       "/v1/tasks/import",
+			payload:    "id,name,status\n1,買午餐,1\n,洗碗,0\n",
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"dry_run":false,"created":1,"updated":1,"errors":[]}}`,
+			tasks:      2,
+		},
+		{
+			name:       "returns status code 200 with mapped columns",
+			path:       "/v1/tasks/import?columns[name]=Title",
+			payload:    "Title\n洗碗\n",
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"dry_run":false,"created":1,"updated":0,"errors":[]}}`,
+			tasks:      2,
+		},
+		{
+			name:       "returns status code 200 with row errors on dry run",
+			path:       "/v1/tasks/import?dry_run=true",
+			payload:    "name,status\n洗碗,x\n",
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"dry_run":true,"created":0,"updated":0,"errors":[{"row":2,"column":"status","error":"Not a number"}]}}`,
+			tasks:      1,
+		},
+		{
+			name:       "returns status code 422 with row errors",
+			path:       "/v1/tasks/import",
+			payload:    "id,name\n9,洗碗\n",
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{"dry_run":false,"created":0,"updated":0,"errors":[{"row":2,"column":"id","error":"Task not found"}]}}`,
+			tasks:      1,
+		},
+		{
+			name:       "returns status code 200 with counts on format todotxt",
+			path:       "/v1/tasks/import?format=todotxt",
+			payload:    "x 買午餐 id:1\n(A) 洗碗 @home\n",
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"dry_run":false,"created":1,"updated":1,"errors":[]}}`,
+			tasks:      2,
+		},
+		{
+			name:       "returns status code 422 with line errors on format todotxt",
+			path:       "/v1/tasks/import?format=todotxt",
+			payload:    "洗碗 due:tomorrow\n",
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{"dry_run":false,"created":0,"updated":0,"errors":[{"row":1,"column":"due","error":"Not a date"}]}}`,
+			tasks:      1,
+		},
+		{
+			name:       "returns status code 200 with counts on format ics",
+			path:       "/v1/tasks/import?format=ics",
+			payload:    "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:1@whostodo\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\nBEGIN:VTODO\r\nSUMMARY:洗碗\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+			statusCode: http.StatusOK,
+			expected:   `{"result":{"dry_run":false,"created":1,"updated":1,"errors":[]}}`,
+			tasks:      2,
+		},
+		{
+			name:       "returns status code 400 on malformed iCalendar",
+			path:       "/v1/tasks/import?format=ics",
+			payload:    "BEGIN:VTODO\r\nSUMMARY:洗碗\r\nEND:VTODO\r\n",
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{}}`,
+			tasks:      1,
+		},
+		{
+			name:       "returns status code 400 on unknown format",
+			path:       "/v1/tasks/import?format=xml",
+			payload:    "name\n洗碗\n",
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{}}`,
+			tasks:      1,
+		},
+		{
+			name:       "returns status code 400 on unknown mapped column",
+			path:       "/v1/tasks/import?columns[owner]=name",
+			payload:    "name\n洗碗\n",
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{}}`,
+			tasks:      1,
+		},
+		{
+			name:       "returns status code 400 on malformed CSV",
+			path:       "/v1/tasks/import",
+			payload:    "name\n\"洗碗\n",
+			statusCode: http.StatusBadRequest,
+			expected:   `{"result":{}}`,
+			tasks:      1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐"})
+			session := util.NewSession()
+			suite.SessionRepo.PopulateData(session)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.payload))
+			req.Header.Add("Content-Type", "text/csv")
+			setRequestTokenHeader(t)(req, session.Id)
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+			util.AssertEqual(t)(len(suite.TaskRepo.Data), tc.tasks)
+		})
+	}
+}
+
+func Test_POSTTasksImportFile(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	session := util.NewSession()
+	suite.SessionRepo.PopulateData(session)
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "tasks.csv")
+	part.Write([]byte("name\n洗碗\n"))
+	writer.Close()
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/v1/tasks/import", &body)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	setRequestTokenHeader(t)(req, session.Id)
+
+	suite.Engine.ServeHTTP(rr, req)
+
+	util.AssertHttpStatus(t)(rr, http.StatusOK)
+	util.AssertEqual(t)(rr.Body.String(), `{"result":{"dry_run":false,"created":1,"updated":0,"errors":[]}}`)
+}
+
+func Test_POSTTasksImportTooLarge(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	session := util.NewSession()
+	suite.SessionRepo.PopulateData(session)
+	rr := httptest.NewRecorder()
+	payload := "name\n" + strings.Repeat("洗碗洗碗洗碗洗碗\n", 500000)
+	req, _ := http.NewRequest(http.MethodPost, "/v1/tasks/import", strings.NewReader(payload))
+	req.Header.Add("Content-Type", "text/csv")
+	setRequestTokenHeader(t)(req, session.Id)
+
+	suite.Engine.ServeHTTP(rr, req)
+
+	util.AssertHttpStatus(t)(rr, http.StatusRequestEntityTooLarge)
+}
@@ -0,0 +1,252 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/graph"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_WorkspaceRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		session    Session
+		method     string
+		path       string
+		payload    string
+		statusCode int
+		expected   string
+	}{
+		{
+			name:       "GET tasks lists those of the session's workspace",
+			session:    util.NewUserSession("alice"),
+			method:     http.MethodGet,
+			path:       "/v1/tasks",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":1,"name":"洗碗","status":0,"list_id":1}]}`,
+		},
+		{
+			name:       "GET tasks of another workspace returns none",
+			session:    util.NewWorkspaceSession("team-a", "alice"),
+			method:     http.MethodGet,
+			path:       "/v1/tasks",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[]}`,
+		},
+		{
+			name:       "GET task of another workspace returns status code 404",
+			session:    util.NewWorkspaceSession("team-a", "alice"),
+			method:     http.MethodGet,
+			path:       "/v1/task/1",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "PUT task of another workspace returns status code 404",
+			session:    util.NewWorkspaceSession("team-a", "alice"),
+			method:     http.MethodPut,
+			path:       "/v1/task/1",
+			payload:    `{"name":"洗衣服"}`,
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "GET lists of another workspace returns the inbox only",
+			session:    util.NewWorkspaceSession("team-a", "alice"),
+			method:     http.MethodGet,
+			path:       "/v1/lists",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[{"id":0,"name":"Inbox"}]}`,
+		},
+		{
+			name:       "POST task into a list of another workspace returns status code 422",
+			session:    util.NewWorkspaceSession("team-a", "alice"),
+			method:     http.MethodPost,
+			path:       "/v1/task",
+			payload:    `{"name":"洗衣服","list_id":1}`,
+			statusCode: http.StatusUnprocessableEntity,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "GET comments of another workspace returns status code 404",
+			session:    util.NewWorkspaceSession("team-a", "alice"),
+			method:     http.MethodGet,
+			path:       "/v1/task/1/comments",
+			statusCode: http.StatusNotFound,
+			expected:   `{"result":{}}`,
+		},
+		{
+			name:       "GET invitations of another workspace returns none",
+			session:    util.NewWorkspaceSession("team-a", "bob"),
+			method:     http.MethodGet,
+			path:       "/v1/invitations",
+			statusCode: http.StatusOK,
+			expected:   `{"result":[]}`,
+		},
+		{
+			name:       "session of an invalid workspace returns status code 403",
+			session:    util.NewWorkspaceSession("Team A", "alice"),
+			method:     http.MethodGet,
+			path:       "/v1/tasks",
+			statusCode: http.StatusForbidden,
+			expected:   `{}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "alice"})
+			suite.MemberRepo.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "bob", Role: "viewer"})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", ListId: 1})
+			suite.SessionRepo.PopulateData(tc.session)
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.payload))
+			req.Header.Add("Content-Type", "application/json")
+			setRequestTokenHeader(t)(req, tc.session.Id)
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertJsonHeader(t)(rr)
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			util.AssertEqual(t)(rr.Body.String(), tc.expected)
+		})
+	}
+}
+
+func Test_POSTAuthWorkspace(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    string
+		statusCode int
+		workspace  string
+	}{
+		{
+			name:       "returns status code 201 with session in the workspace",
+			payload:    `{"user":"alice","password":"密碼","workspace":"team-a"}`,
+			statusCode: http.StatusCreated,
+			workspace:  "team-a",
+		},
+		{
+			name:       "returns status code 201 with session in the default workspace",
+			payload:    `{"user":"alice","password":"密碼"}`,
+			statusCode: http.StatusCreated,
+			workspace:  "default",
+		},
+		{
+			name:       "returns status code 403 on workspace the user is not a member of",
+			payload:    `{"user":"alice","password":"密碼","workspace":"team-b"}`,
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "returns status code 422 on workspace not set up",
+			payload:    `{"user":"alice","password":"密碼","workspace":"acme"}`,
+			statusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "returns status code 422 on invalid workspace",
+			payload:    `{"user":"alice","password":"密碼","workspace":"../team-a"}`,
+			statusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.AccountRepo.PopulateData(util.NewAccount("alice", "密碼", "default", "team-a"))
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/v1/auth", bytes.NewBufferString(tc.payload))
+			req.Header.Add("Content-Type", "application/json")
+
+			suite.Engine.ServeHTTP(rr, req)
+
+			util.AssertHttpStatus(t)(rr, tc.statusCode)
+			if tc.workspace == "" {
+				util.AssertEqual(t)(rr.Body.String(), `{"result":""}`)
+				util.AssertEqual(t)(len(suite.SessionRepo.Data), 0)
+				return
+			}
+			for _, session := range suite.SessionRepo.Data {
+				util.AssertEqual(t)(session.Workspace, tc.workspace)
+			}
+		})
+	}
+}
+
+func Test_WorkspaceIsolation(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	teamA := util.NewWorkspaceSession("team-a", "alice")
+	teamB := util.NewWorkspaceSession("team-b", "alice")
+	suite.SessionRepo.PopulateData(teamA)
+	suite.SessionRepo.PopulateData(teamB)
+
+	serve := func(session Session, method string, path string, payload string) string {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(payload))
+		req.Header.Add("Content-Type", "application/json")
+		setRequestTokenHeader(t)(req, session.Id)
+		suite.Engine.ServeHTTP(rr, req)
+		return rr.Body.String()
+	}
+
+	util.AssertEqual(t)(serve(teamA, http.MethodPost, "/v1/task", `{"name":"買晚餐"}`), `{"result":{"name":"買晚餐","status":0,"id":1,"creator":"alice"}}`)
+	util.AssertEqual(t)(serve(teamB, http.MethodPost, "/v1/task", `{"name":"洗碗"}`), `{"result":{"name":"洗碗","status":0,"id":1,"creator":"alice"}}`)
+
+	util.AssertEqual(t)(serve(teamA, http.MethodGet, "/v1/tasks", ""), `{"result":[{"id":1,"name":"買晚餐","status":0,"creator":"alice"}]}`)
+	util.AssertEqual(t)(serve(teamB, http.MethodGet, "/v1/tasks", ""), `{"result":[{"id":1,"name":"洗碗","status":0,"creator":"alice"}]}`)
+	util.AssertEqual(t)(len(suite.TaskRepo.Data), 0)
+
+	serve(teamB, http.MethodDelete, "/v1/task/1", "")
+	util.AssertEqual(t)(serve(teamA, http.MethodGet, "/v1/task/1", ""), `{"result":{"name":"買晚餐","status":0,"id":1,"creator":"alice"}}`)
+}
+
+// Run with -race to catch REST and GraphQL requests using the workspace at
+// the same time.
+func Test_WorkspaceConcurrently(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	alice := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(alice)
+
+	serve := func(method string, path string, payload string) int {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(payload))
+		req.Header.Add("Content-Type", "application/json")
+		setRequestTokenHeader(t)(req, alice.Id)
+		suite.Engine.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			util.AssertEqual(t)(serve(http.MethodPost, "/v1/task", fmt.Sprintf(`{"name":"買晚餐 %d"}`, i)), http.StatusCreated)
+			util.AssertEqual(t)(serve(http.MethodGet, "/v1/tasks", ""), http.StatusOK)
+		}()
+		go func() {
+			defer wg.Done()
+			mutation, _ := json.Marshal(graph.Request{Query: fmt.Sprintf(`mutation { createTask(input: {name: "洗碗 %d"}) { id } }`, i)})
+			util.AssertEqual(t)(serve(http.MethodPost, graph.Path, string(mutation)), http.StatusOK)
+			util.AssertEqual(t)(serve(http.MethodPost, graph.Path, `{"query":"{ tasks { name } }"}`), http.StatusOK)
+		}()
+	}
+	wg.Wait()
+
+	util.AssertEqual(t)(len(suite.TaskRepo.Data), 40)
+}
@@ -0,0 +1,30 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/dannyh79/whostodo/internal/sessions"
+	pb "github.com/dannyh79/whostodo/proto/whostodo/v1"
+)
+
+type authService struct {
+	pb.UnimplementedAuthServiceServer
+	sessions *sessions.SessionsUsecase
+}
+
+func (s *authService) Authenticate(ctx context.Context, req *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	token, err := s.sessions.Authenticate(&sessions.AuthenticateInput{
+		User:      req.User,
+		Password:  req.Password,
+		Workspace: req.Workspace,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.AuthenticateResponse{Token: token}, nil
+}
+
+func (s *authService) GetSession(ctx context.Context, req *pb.GetSessionRequest) (*pb.Session, error) {
+	v := viewerOf(ctx)
+	return &pb.Session{User: v.actor.User, Workspace: v.workspace.Id}, nil
+}
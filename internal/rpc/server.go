@@ -0,0 +1,200 @@
+// Package rpc serves the usecases over gRPC, for internal services, on a port
+// of its own next to the REST routes.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dannyh79/whostodo/internal/access"
+	"github.com/dannyh79/whostodo/internal/changes"
+	"github.com/dannyh79/whostodo/internal/sessions"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/dannyh79/whostodo/internal/workspaces"
+	pb "github.com/dannyh79/whostodo/proto/whostodo/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnprotectedMethods are called without a session.
+var UnprotectedMethods = map[string]bool{
+	pb.AuthService_Authenticate_FullMethodName: true,
+}
+
+// changingMethods may change something in the workspace, so watchers are
+// told once they succeed.
+var changingMethods = map[string]bool{
+	pb.TaskService_CreateTask_FullMethodName: true,
+	pb.TaskService_UpdateTask_FullMethodName: true,
+	pb.TaskService_DeleteTask_FullMethodName: true,
+}
+
+var ErrorUnauthenticated = status.Error(codes.Unauthenticated, "Session is missing or expired")
+
+// ErrorListNotVisible ends streams watching a list the viewer may no longer
+// see, such as once removed from its members.
+var ErrorListNotVisible = status.Error(codes.PermissionDenied, "List is not visible")
+
+// viewer is whom a call is served for, in the workspace of their session.
+type viewer struct {
+	actor     tasks.Actor
+	workspace *workspaces.Workspace
+}
+
+type viewerKey struct{}
+
+func viewerOf(ctx context.Context) *viewer {
+	return ctx.Value(viewerKey{}).(*viewer)
+}
+
+// InitServer serves AuthService and TaskService, authenticating calls by the
+// token in their authorization metadata.
+func InitServer(sessionsU *sessions.SessionsUsecase, workspacesU *workspaces.WorkspacesUsecase) *grpc.Server {
+	a := &authenticator{sessions: sessionsU, workspaces: workspacesU}
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(a.unaryInterceptor, lockInterceptor, changesInterceptor),
+		grpc.StreamInterceptor(a.streamInterceptor),
+	)
+	pb.RegisterAuthServiceServer(s, &authService{sessions: sessionsU})
+	pb.RegisterTaskServiceServer(s, &taskService{sessions: sessionsU})
+	return s
+}
+
+// authenticator puts the viewer of the session in the context of calls, as
+// sessionMiddleware and workspaceMiddleware do for the REST routes.
+type authenticator struct {
+	sessions   *sessions.SessionsUsecase
+	workspaces *workspaces.WorkspacesUsecase
+}
+
+func (a *authenticator) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if UnprotectedMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	ctx, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if UnprotectedMethods[info.FullMethod] {
+		return handler(srv, ss)
+	}
+	ctx, err := a.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+func (a *authenticator) authenticate(ctx context.Context) (context.Context, error) {
+	token := tokenFromMetadata(ctx)
+	session, ok := a.sessions.Session(token)
+	if !ok {
+		return nil, ErrorUnauthenticated
+	}
+	w, err := a.workspaces.Find(session.Workspace)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return context.WithValue(ctx, viewerKey{}, &viewer{
+		actor:     tasks.Actor{SessionId: token, User: session.User},
+		workspace: w,
+	}), nil
+}
+
+// authenticatedStream carries the viewer in the context of the stream.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// lockInterceptor holds the lock of the viewer's workspace around calls, as
+// the usecases are not safe for concurrent use. Streams lock it themselves
+// for each response, not for as long as they last.
+func lockInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if UnprotectedMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	w := viewerOf(ctx).workspace
+	w.Lock()
+	defer w.Unlock()
+	return handler(ctx, req)
+}
+
+// changesInterceptor tells watchers of the workspace about calls that may
+// have changed something in it, once they succeeded, if they may see what the
+// call touched, as changesMiddleware does for the REST routes.
+func changesInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !changingMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	v := *viewerOf(ctx)
+	scope := access.InitScope(v.workspace.Lists)
+	v.actor.Scope = scope
+	res, err := handler(context.WithValue(ctx, viewerKey{}, &v), req)
+	if err != nil {
+		return res, err
+	}
+
+	v.workspace.Changes.Publish(changes.Change{
+		Actor:  v.actor.User,
+		Method: http.MethodPost,
+		Path:   info.FullMethod,
+		Scope:  scope,
+	})
+	return res, nil
+}
+
+func tokenFromMetadata(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(value, "Bearer "); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+// toStatus codes an error of the usecases as the status the REST routes
+// respond with would be. Errors not coded otherwise are taken as not found,
+// since repositories have errors of their own for rows not found.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, sessions.ErrorInvalidCredentials):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, tasks.ErrorForbidden), errors.Is(err, sessions.ErrorNotMember):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, tasks.ErrorBlocked):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case isInvalidArgument(err):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.NotFound, err.Error())
+	}
+}
+
+func isInvalidArgument(err error) bool {
+	return errors.Is(err, sessions.ErrorInvalidWorkspace) ||
+		errors.Is(err, tasks.ErrorListNotFound) ||
+		errors.Is(err, tasks.ErrorParentNotFound) ||
+		errors.Is(err, tasks.ErrorCycle) ||
+		errors.Is(err, tasks.ErrorBlockerNotFound) ||
+		errors.Is(err, tasks.ErrorDependencyCycle) ||
+		errors.Is(err, tasks.ErrorInvalidRecurrence) ||
+		errors.Is(err, tasks.ErrorInvalidPriority) ||
+		errors.Is(err, tasks.ErrorDescriptionTooLong) ||
+		errors.Is(err, tasks.ErrorUnknownDeleteMode)
+}
@@ -0,0 +1,130 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+	pb "github.com/dannyh79/whostodo/proto/whostodo/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial connects to the gRPC server of the suite over an in-memory listener.
+func dial(t *testing.T, suite *util.MockTestSuite) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	go suite.RPCServer.Serve(listener)
+	t.Cleanup(suite.RPCServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// withToken authenticates calls made with the returned context by the token.
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// populate stores tasks in a list of alice, one under and blocked by another.
+func populate(suite *util.MockTestSuite) {
+	suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "alice"})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", ListId: 1, Tags: []string{"errand"}, Assignee: "bob"})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "洗碗", ListId: 1, ParentId: 1, BlockedBy: []int{1}})
+}
+
+func Test_AuthService(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	suite.AccountRepo.PopulateData(util.NewAccount("alice", "密碼", "team-a"))
+	client := pb.NewAuthServiceClient(dial(t, suite))
+
+	res, err := client.Authenticate(context.Background(), &pb.AuthenticateRequest{User: "alice", Password: "密碼", Workspace: "team-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := client.GetSession(withToken(res.Token), &pb.GetSessionRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	util.AssertEqual(t)(session.User, "alice")
+	util.AssertEqual(t)(session.Workspace, "team-a")
+}
+
+func Test_AuthServiceErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(client pb.AuthServiceClient) error
+		code     codes.Code
+		expected string
+	}{
+		{
+			name: "with an invalid workspace returns InvalidArgument",
+			call: func(client pb.AuthServiceClient) error {
+				_, err := client.Authenticate(context.Background(), &pb.AuthenticateRequest{Workspace: "不存在的 工作區"})
+				return err
+			},
+			code:     codes.InvalidArgument,
+			expected: "Invalid workspace",
+		},
+		{
+			name: "with a wrong password returns Unauthenticated",
+			call: func(client pb.AuthServiceClient) error {
+				_, err := client.Authenticate(context.Background(), &pb.AuthenticateRequest{User: "alice", Password: "錯的"})
+				return err
+			},
+			code:     codes.Unauthenticated,
+			expected: "Invalid user or password",
+		},
+		{
+			name: "without session token returns Unauthenticated",
+			call: func(client pb.AuthServiceClient) error {
+				_, err := client.GetSession(context.Background(), &pb.GetSessionRequest{})
+				return err
+			},
+			code:     codes.Unauthenticated,
+			expected: "Session is missing or expired",
+		},
+		{
+			name: "with an expired session token returns Unauthenticated",
+			call: func(client pb.AuthServiceClient) error {
+				_, err := client.GetSession(withToken(util.NewExpiredSession().Id), &pb.GetSessionRequest{})
+				return err
+			},
+			code:     codes.Unauthenticated,
+			expected: "Session is missing or expired",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.SessionRepo.PopulateData(util.NewExpiredSession())
+			client := pb.NewAuthServiceClient(dial(t, suite))
+
+			s, _ := status.FromError(tc.call(client))
+
+			util.AssertEqual(t)(s.Code(), tc.code)
+			util.AssertEqual(t)(s.Message(), tc.expected)
+		})
+	}
+}
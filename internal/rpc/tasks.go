@@ -0,0 +1,225 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/access"
+	"github.com/dannyh79/whostodo/internal/sessions"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	pb "github.com/dannyh79/whostodo/proto/whostodo/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type taskService struct {
+	pb.UnimplementedTaskServiceServer
+	// Checks the sessions of streams again on every change.
+	sessions *sessions.SessionsUsecase
+}
+
+func (s *taskService) ListTasks(ctx context.Context, req *pb.ListTasksRequest) (*pb.ListTasksResponse, error) {
+	v := viewerOf(ctx)
+	return &pb.ListTasksResponse{Tasks: v.listTasks(req)}, nil
+}
+
+func (s *taskService) GetTask(ctx context.Context, req *pb.GetTaskRequest) (*pb.Task, error) {
+	v := viewerOf(ctx)
+	task, err := v.workspace.Tasks.GetTask(v.actor, int(req.Id))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toTask(task), nil
+}
+
+func (s *taskService) CreateTask(ctx context.Context, req *pb.CreateTaskRequest) (*pb.Task, error) {
+	v := viewerOf(ctx)
+	task, err := v.workspace.Tasks.CreateTask(v.actor, &tasks.CreateTaskInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Priority:    req.Priority,
+		ListId:      int(req.ListId),
+		ParentId:    int(req.ParentId),
+		Tags:        req.Tags,
+		DueAt:       toTime(req.DueAt),
+		Recurrence:  toRecurrenceInput(req.Recurrence),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toTask(task), nil
+}
+
+func (s *taskService) UpdateTask(ctx context.Context, req *pb.UpdateTaskRequest) (*pb.Task, error) {
+	v := viewerOf(ctx)
+	task, err := v.workspace.Tasks.UpdateTask(v.actor, int(req.Id), &tasks.UpdateTaskInput{
+		Name:        req.Name,
+		Status:      int(req.Status),
+		Description: req.Description,
+		Priority:    req.Priority,
+		ListId:      toInt(req.ListId),
+		ParentId:    toInt(req.ParentId),
+		DueAt:       toTime(req.DueAt),
+		Recurrence:  toRecurrenceInput(req.Recurrence),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toTask(task), nil
+}
+
+func (s *taskService) DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest) (*pb.DeleteTaskResponse, error) {
+	v := viewerOf(ctx)
+	err := v.workspace.Tasks.DeleteTask(v.actor, int(req.Id), &tasks.DeleteTaskInput{Children: req.Children})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteTaskResponse{}, nil
+}
+
+// WatchTasks subscribes before listing the task items the first time, so no
+// change made after the first response is missed. Like watchers of the REST
+// stream, streams falling too far behind miss changes. Streams end once the
+// session expires or is removed, or the viewer may no longer see the list
+// filtered by, checked on every change.
+func (s *taskService) WatchTasks(req *pb.WatchTasksRequest, stream grpc.ServerStreamingServer[pb.WatchTasksResponse]) error {
+	ctx := stream.Context()
+	v := viewerOf(ctx)
+	watched, unsubscribe := v.workspace.Changes.Subscribe(v.actor.User)
+	defer unsubscribe()
+
+	listed, err := s.watched(v, req.Filter)
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(&pb.WatchTasksResponse{Tasks: listed}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case change := <-watched:
+			listed, err := s.watched(v, req.Filter)
+			if err != nil {
+				return err
+			}
+			err = stream.Send(&pb.WatchTasksResponse{
+				Change: &pb.Change{Actor: change.Actor, Method: change.Method, Path: change.Path},
+				Tasks:  listed,
+			})
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// watched checks the viewer may still watch and lists the task items watched,
+// holding the lock of the workspace, which is not held while sending.
+func (s *taskService) watched(v *viewer, filter *pb.ListTasksRequest) ([]*pb.Task, error) {
+	v.workspace.Lock()
+	defer v.workspace.Unlock()
+	if err := s.check(v, filter); err != nil {
+		return nil, err
+	}
+	return v.listTasks(filter), nil
+}
+
+// check fails once the session of the viewer is no longer valid, or the
+// viewer may no longer see the list filtered by.
+func (s *taskService) check(v *viewer, filter *pb.ListTasksRequest) error {
+	if _, ok := s.sessions.Session(v.actor.SessionId); !ok {
+		return ErrorUnauthenticated
+	}
+	if filter != nil && filter.ListId != nil && !access.CanReadList(v.workspace.Lists, v.actor.User, int(*filter.ListId)) {
+		return ErrorListNotVisible
+	}
+	return nil
+}
+
+func (v *viewer) listTasks(req *pb.ListTasksRequest) []*pb.Task {
+	var query tasks.ListTasksInput
+	if req != nil {
+		query = tasks.ListTasksInput{
+			ListId:   toInt(req.ListId),
+			Tags:     req.Tags,
+			Match:    req.Match,
+			Sort:     req.Sort,
+			Assignee: req.Assignee,
+		}
+	}
+	var result []*pb.Task
+	for _, t := range v.workspace.Tasks.ListTasks(v.actor, &query) {
+		result = append(result, toTask(t))
+	}
+	return result
+}
+
+func toTask(t *tasks.TaskOutput) *pb.Task {
+	task := &pb.Task{
+		Id:          int64(t.Id),
+		Name:        t.Name,
+		Description: t.Description,
+		Status:      int32(t.Status),
+		Priority:    t.Priority,
+		ListId:      int64(t.ListId),
+		ParentId:    int64(t.ParentId),
+		Tags:        t.Tags,
+		DueAt:       toTimestamp(t.DueAt),
+		Creator:     t.Creator,
+		Assignee:    t.Assignee,
+	}
+	for _, id := range t.BlockedBy {
+		task.BlockedBy = append(task.BlockedBy, int64(id))
+	}
+	if r := t.Recurrence; r != nil {
+		task.Recurrence = &pb.Recurrence{
+			Frequency: r.Frequency,
+			Interval:  int32(r.Interval),
+			Weekdays:  r.Weekdays,
+			Until:     toTimestamp(r.Until),
+			Count:     int32(r.Count),
+		}
+	}
+	if p := t.Progress; p != nil {
+		task.Progress = &pb.Progress{Done: int32(p.Done), Total: int32(p.Total)}
+	}
+	return task
+}
+
+func toRecurrenceInput(r *pb.Recurrence) *tasks.RecurrenceInput {
+	if r == nil {
+		return nil
+	}
+	return &tasks.RecurrenceInput{
+		Frequency: r.Frequency,
+		Interval:  int(r.Interval),
+		Weekdays:  r.Weekdays,
+		Until:     toTime(r.Until),
+		Count:     int(r.Count),
+	}
+}
+
+func toInt(i *int64) *int {
+	if i == nil {
+		return nil
+	}
+	n := int(*i)
+	return &n
+}
+
+func toTime(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+	time := t.AsTime()
+	return &time
+}
+
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,351 @@
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/sessions"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+	pb "github.com/dannyh79/whostodo/proto/whostodo/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// names lists the names of the task items, in order.
+func names(tasks []*pb.Task) []string {
+	var result []string
+	for _, t := range tasks {
+		result = append(result, t.Name)
+	}
+	return result
+}
+
+func Test_TaskService(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(ctx context.Context, client pb.TaskServiceClient) (any, error)
+		expected any
+	}{
+		{
+			name: "lists tasks",
+			call: func(ctx context.Context, client pb.TaskServiceClient) (any, error) {
+				res, err := client.ListTasks(ctx, &pb.ListTasksRequest{})
+				return names(res.GetTasks()), err
+			},
+			expected: []string{"買晚餐", "洗碗"},
+		},
+		{
+			name: "lists tasks filtered as REST does",
+			call: func(ctx context.Context, client pb.TaskServiceClient) (any, error) {
+				res, err := client.ListTasks(ctx, &pb.ListTasksRequest{Tags: []string{"errand"}})
+				return names(res.GetTasks()), err
+			},
+			expected: []string{"買晚餐"},
+		},
+		{
+			name: "lists tasks in the inbox",
+			call: func(ctx context.Context, client pb.TaskServiceClient) (any, error) {
+				res, err := client.ListTasks(ctx, &pb.ListTasksRequest{ListId: proto.Int64(0)})
+				return names(res.GetTasks()), err
+			},
+			expected: []string(nil),
+		},
+		{
+			name: "gets a task along with its blockers and progress",
+			call: func(ctx context.Context, client pb.TaskServiceClient) (any, error) {
+				res, err := client.GetTask(ctx, &pb.GetTaskRequest{Id: 2})
+				return []any{res.GetName(), res.GetParentId(), res.GetBlockedBy()}, err
+			},
+			expected: []any{"洗碗", int64(1), []int64{1}},
+		},
+		{
+			name: "creates a task",
+			call: func(ctx context.Context, client pb.TaskServiceClient) (any, error) {
+				res, err := client.CreateTask(ctx, &pb.CreateTaskRequest{
+					Name:   "倒垃圾",
+					ListId: 1,
+					Tags:   []string{"Chore"},
+					DueAt:  timestamppb.New(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)),
+				})
+				return []any{res.GetId(), res.GetName(), res.GetListId(), res.GetTags(), res.GetDueAt().AsTime(), res.GetCreator()}, err
+			},
+			expected: []any{int64(3), "倒垃圾", int64(1), []string{"chore"}, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), "alice"},
+		},
+		{
+			name: "updates a task",
+			call: func(ctx context.Context, client pb.TaskServiceClient) (any, error) {
+				res, err := client.UpdateTask(ctx, &pb.UpdateTaskRequest{Id: 1, Name: "買午餐", Status: 1, Priority: proto.String("high")})
+				return []any{res.GetName(), res.GetStatus(), res.GetPriority(), res.GetListId()}, err
+			},
+			expected: []any{"買午餐", int32(1), "high", int64(1)},
+		},
+		{
+			name: "deletes a task",
+			call: func(ctx context.Context, client pb.TaskServiceClient) (any, error) {
+				if _, err := client.DeleteTask(ctx, &pb.DeleteTaskRequest{Id: 1, Children: "cascade"}); err != nil {
+					return nil, err
+				}
+				res, err := client.ListTasks(ctx, &pb.ListTasksRequest{})
+				return names(res.GetTasks()), err
+			},
+			expected: []string(nil),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			populate(suite)
+			alice := util.NewUserSession("alice")
+			suite.SessionRepo.PopulateData(alice)
+			client := pb.NewTaskServiceClient(dial(t, suite))
+
+			got, err := tc.call(withToken(alice.Id), client)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+}
+
+func Test_TaskServiceErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(ctx context.Context, client pb.TaskServiceClient) error
+		code     codes.Code
+		expected string
+	}{
+		{
+			name: "returns NotFound for a task not found",
+			call: func(ctx context.Context, client pb.TaskServiceClient) error {
+				_, err := client.GetTask(ctx, &pb.GetTaskRequest{Id: 9})
+				return err
+			},
+			code:     codes.NotFound,
+			expected: "Task not found",
+		},
+		{
+			name: "returns InvalidArgument for an invalid priority",
+			call: func(ctx context.Context, client pb.TaskServiceClient) error {
+				_, err := client.UpdateTask(ctx, &pb.UpdateTaskRequest{Id: 1, Name: "買午餐", Priority: proto.String("最高")})
+				return err
+			},
+			code:     codes.InvalidArgument,
+			expected: "Invalid priority",
+		},
+		{
+			name: "returns FailedPrecondition for completing a blocked task",
+			call: func(ctx context.Context, client pb.TaskServiceClient) error {
+				_, err := client.UpdateTask(ctx, &pb.UpdateTaskRequest{Id: 2, Name: "洗碗", Status: 1})
+				return err
+			},
+			code:     codes.FailedPrecondition,
+			expected: "Task is blocked by open tasks",
+		},
+		{
+			name: "without session token returns Unauthenticated",
+			call: func(_ context.Context, client pb.TaskServiceClient) error {
+				_, err := client.ListTasks(context.Background(), &pb.ListTasksRequest{})
+				return err
+			},
+			code:     codes.Unauthenticated,
+			expected: "Session is missing or expired",
+		},
+		{
+			name: "without session token does not watch tasks",
+			call: func(_ context.Context, client pb.TaskServiceClient) error {
+				stream, err := client.WatchTasks(context.Background(), &pb.WatchTasksRequest{})
+				if err != nil {
+					return err
+				}
+				_, err = stream.Recv()
+				return err
+			},
+			code:     codes.Unauthenticated,
+			expected: "Session is missing or expired",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			populate(suite)
+			alice := util.NewUserSession("alice")
+			suite.SessionRepo.PopulateData(alice)
+			client := pb.NewTaskServiceClient(dial(t, suite))
+
+			s, _ := status.FromError(tc.call(withToken(alice.Id), client))
+
+			util.AssertEqual(t)(s.Code(), tc.code)
+			util.AssertEqual(t)(s.Message(), tc.expected)
+		})
+	}
+}
+
+func Test_WatchTasks(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	populate(suite)
+	alice := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(alice)
+	client := pb.NewTaskServiceClient(dial(t, suite))
+	ctx, cancel := context.WithTimeout(withToken(alice.Id), 5*time.Second)
+	t.Cleanup(cancel)
+
+	stream, err := client.WatchTasks(ctx, &pb.WatchTasksRequest{Filter: &pb.ListTasksRequest{Tags: []string{"errand"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receive := func() *pb.WatchTasksResponse {
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	first := receive()
+	util.AssertEqual(t)(first.Change == nil, true)
+	util.AssertEqual(t)(names(first.Tasks), []string{"買晚餐"})
+
+	if _, err := client.UpdateTask(ctx, &pb.UpdateTaskRequest{Id: 1, Name: "買午餐"}); err != nil {
+		t.Fatal(err)
+	}
+	changed := receive()
+	util.AssertEqual(t)(changed.Change.Actor, "alice")
+	util.AssertEqual(t)(changed.Change.Method, "POST")
+	util.AssertEqual(t)(changed.Change.Path, pb.TaskService_UpdateTask_FullMethodName)
+	util.AssertEqual(t)(names(changed.Tasks), []string{"買午餐"})
+
+	// Failed calls change nothing, so watchers are not told about them.
+	if _, err := client.UpdateTask(ctx, &pb.UpdateTaskRequest{Id: 9, Name: "買午餐"}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := client.DeleteTask(ctx, &pb.DeleteTaskRequest{Id: 2}); err != nil {
+		t.Fatal(err)
+	}
+	util.AssertEqual(t)(receive().Change.Path, pb.TaskService_DeleteTask_FullMethodName)
+}
+
+func Test_TaskServiceConcurrently(t *testing.T) {
+	t.Parallel()
+
+	suite := util.NewTestSuite()
+	alice := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(alice)
+	client := pb.NewTaskServiceClient(dial(t, suite))
+	ctx, cancel := context.WithTimeout(withToken(alice.Id), 5*time.Second)
+	t.Cleanup(cancel)
+
+	stream, err := client.WatchTasks(ctx, &pb.WatchTasksRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.CreateTask(ctx, &pb.CreateTaskRequest{Name: "買晚餐"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	res, err := client.ListTasks(ctx, &pb.ListTasksRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	util.AssertEqual(t)(len(res.Tasks), 20)
+}
+
+func Test_WatchTasksRevoked(t *testing.T) {
+	// Expires after the first response, before the change.
+	expiring := util.NewUserSession("alice")
+	expiring.CreatedAt = time.Now().Add(-sessions.Timeout + 500*time.Millisecond)
+
+	tests := []struct {
+		name     string
+		session  util.Session
+		wait     time.Duration
+		change   func(t *testing.T, suite *util.MockTestSuite, client pb.TaskServiceClient)
+		code     codes.Code
+		expected string
+	}{
+		{
+			name:    "returns Unauthenticated once the session expires",
+			session: expiring,
+			wait:    600 * time.Millisecond,
+			change: func(t *testing.T, _ *util.MockTestSuite, client pb.TaskServiceClient) {
+				if _, err := client.UpdateTask(withToken("bob_token"), &pb.UpdateTaskRequest{Id: 1, Name: "洗好碗"}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			code:     codes.Unauthenticated,
+			expected: "Session is missing or expired",
+		},
+		{
+			name:    "returns PermissionDenied once removed from the list watched",
+			session: util.NewUserSession("alice"),
+			change: func(t *testing.T, suite *util.MockTestSuite, _ pb.TaskServiceClient) {
+				rr := httptest.NewRecorder()
+				req, _ := http.NewRequest(http.MethodDelete, "/v1/list/1/members/alice", nil)
+				req.Header.Set("Authorization", "Bearer bob_token")
+				suite.Engine.ServeHTTP(rr, req)
+				util.AssertHttpStatus(t)(rr, http.StatusOK)
+			},
+			code:     codes.PermissionDenied,
+			expected: "List is not visible",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			suite := util.NewTestSuite()
+			suite.ListRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "bob"})
+			suite.MemberRepo.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "alice", Role: "editor", Accepted: true})
+			suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", ListId: 1, Creator: "bob"})
+			suite.SessionRepo.PopulateData(tc.session)
+			suite.SessionRepo.PopulateData(util.NewUserSession("bob"))
+			client := pb.NewTaskServiceClient(dial(t, suite))
+			ctx, cancel := context.WithTimeout(withToken(tc.session.Id), 5*time.Second)
+			t.Cleanup(cancel)
+
+			stream, err := client.WatchTasks(ctx, &pb.WatchTasksRequest{Filter: &pb.ListTasksRequest{ListId: proto.Int64(1)}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := stream.Recv(); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(tc.wait)
+			tc.change(t, suite, client)
+			_, err = stream.Recv()
+
+			s, _ := status.FromError(err)
+			util.AssertEqual(t)(s.Code(), tc.code)
+			util.AssertEqual(t)(s.Message(), tc.expected)
+		})
+	}
+}
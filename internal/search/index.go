@@ -0,0 +1,87 @@
+package search
+
+import (
+	"math"
+	"sort"
+)
+
+type Match struct {
+	Id    int
+	Score float64
+}
+
+// Index is an inverted index from tokens to the documents containing them,
+// updated one document at a time.
+type Index struct {
+	postings map[string]map[int]int
+	docs     map[int][]string
+}
+
+func NewIndex() *Index {
+	return &Index{
+		postings: map[string]map[int]int{},
+		docs:     map[int][]string{},
+	}
+}
+
+// Add indexes the document, replacing what was indexed under its id.
+func (x *Index) Add(id int, text string) {
+	x.Remove(id)
+
+	tokens := Tokenize(text)
+	for _, token := range tokens {
+		docs, ok := x.postings[token]
+		if !ok {
+			docs = map[int]int{}
+			x.postings[token] = docs
+		}
+		docs[id] += 1
+	}
+	x.docs[id] = tokens
+}
+
+func (x *Index) Remove(id int) {
+	for _, token := range x.docs[id] {
+		delete(x.postings[token], id)
+		if len(x.postings[token]) == 0 {
+			delete(x.postings, token)
+		}
+	}
+	delete(x.docs, id)
+}
+
+// Search returns documents containing every token of the query, best first.
+// Scores add up TF-IDF weights of the query tokens; ties go to lower ids.
+func (x *Index) Search(query string) []Match {
+	tokens := Tokenize(query)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	scores := map[int]float64{}
+	for i, token := range tokens {
+		docs := x.postings[token]
+		idf := math.Log(1 + float64(len(x.docs))/float64(len(docs)+1))
+		next := map[int]float64{}
+		for id, count := range docs {
+			if _, ok := scores[id]; i > 0 && !ok {
+				continue
+			}
+			tf := float64(count) / float64(len(x.docs[id]))
+			next[id] = scores[id] + tf*idf
+		}
+		scores = next
+	}
+
+	matches := make([]Match, 0, len(scores))
+	for id, score := range scores {
+		matches = append(matches, Match{Id: id, Score: score})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Id < matches[j].Id
+	})
+	return matches
+}
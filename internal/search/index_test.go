@@ -0,0 +1,67 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/search"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func newIndex() *search.Index {
+	x := search.NewIndex()
+	x.Add(1, "買早餐")
+	x.Add(2, "買晚餐")
+	x.Add(3, "晚餐後洗碗，晚餐前買菜")
+	x.Add(4, "Rotate on-call")
+	return x
+}
+
+func matchedIds(matches []search.Match) []int {
+	var ids []int
+	for _, m := range matches {
+		ids = append(ids, m.Id)
+	}
+	return ids
+}
+
+func Test_IndexSearch(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []int
+	}{
+		{name: "ranks documents by relevance", query: "晚餐", expected: []int{2, 3}},
+		{name: "matches every token", query: "買晚餐", expected: []int{2}},
+		{name: "matches single CJK character", query: "買", expected: []int{1, 2, 3}},
+		{name: "matches case-insensitively", query: "ON CALL", expected: []int{4}},
+		{name: "returns nothing for unknown token", query: "午餐", expected: nil},
+		{name: "returns nothing for empty query", query: " ", expected: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			util.AssertEqual(t)(matchedIds(newIndex().Search(tc.query)), tc.expected)
+		})
+	}
+
+	t.Run("reindexes replaced documents", func(t *testing.T) {
+		t.Parallel()
+
+		x := newIndex()
+		x.Add(2, "買午餐")
+
+		util.AssertEqual(t)(matchedIds(x.Search("晚餐")), []int{3})
+		util.AssertEqual(t)(matchedIds(x.Search("午餐")), []int{2})
+	})
+
+	t.Run("forgets removed documents", func(t *testing.T) {
+		t.Parallel()
+
+		x := newIndex()
+		x.Remove(3)
+
+		util.AssertEqual(t)(matchedIds(x.Search("晚餐")), []int{2})
+	})
+}
@@ -0,0 +1,55 @@
+// Package search provides tokenizing and an inverted index for full-text
+// search over short texts such as task names.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize lowercases text and splits it into words on anything but letters
+// and digits. Runs of CJK characters, which are not separated by spaces, are
+// split into single characters followed by overlapping bigrams instead, so a
+// single character finds every word containing it.
+func Tokenize(text string) []string {
+	var tokens []string
+	var word, cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		for _, r := range cjk {
+			tokens = append(tokens, string(r))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
@@ -0,0 +1,30 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/search"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_Tokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{name: "splits words case-insensitively", text: "Rotate On-call", expected: []string{"rotate", "on", "call"}},
+		{name: "splits CJK into characters and bigrams", text: "買晚餐", expected: []string{"買", "晚", "餐", "買晚", "晚餐"}},
+		{name: "keeps lone CJK character", text: "買", expected: []string{"買"}},
+		{name: "splits mixed text", text: "買3顆iPhone充電器", expected: []string{"買", "3", "顆", "iphone", "充", "電", "器", "充電", "電器"}},
+		{name: "returns nothing for punctuation", text: " ,!? ", expected: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			util.AssertEqual(t)(search.Tokenize(tc.text), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrorInvalidUser       = errors.New("Invalid user")
+	ErrorInvalidPassword   = errors.New("Invalid password")
+	ErrorInvalidWorkspaces = errors.New("Invalid workspaces")
+)
+
+// Account is a user sessions can be started as, by the password, in the
+// workspaces the user is a member of. Sessions of AnonymousUser need none,
+// and are only started in DefaultWorkspace.
+type Account struct {
+	User         string
+	PasswordHash []byte
+	// Ordered by id.
+	Workspaces []string
+	CreatedAt  time.Time
+}
+
+func NewAccount(user string, password string) (*Account, error) {
+	user = strings.TrimSpace(user)
+	if user == "" || user == AnonymousUser {
+		return nil, ErrorInvalidUser
+	}
+
+	a := &Account{User: user, Workspaces: []string{DefaultWorkspace}, CreatedAt: time.Now()}
+	if err := a.SetPassword(password); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// SetWorkspaces replaces the workspaces the user is a member of; none makes
+// the user a member of DefaultWorkspace only.
+func (a *Account) SetWorkspaces(ids []string) error {
+	if len(ids) == 0 {
+		ids = []string{DefaultWorkspace}
+	}
+
+	seen := map[string]bool{}
+	var workspaces []string
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if !IsWorkspace(id) {
+			return ErrorInvalidWorkspaces
+		}
+		if !seen[id] {
+			seen[id] = true
+			workspaces = append(workspaces, id)
+		}
+	}
+	sort.Strings(workspaces)
+	a.Workspaces = workspaces
+	return nil
+}
+
+// IsMember reports whether sessions of the user may be started in the
+// workspace.
+func (a *Account) IsMember(workspace string) bool {
+	for _, id := range a.Workspaces {
+		if id == workspace {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPassword replaces the password; bcrypt only reads the first 72 bytes of
+// one, so longer ones are refused rather than cut short.
+func (a *Account) SetPassword(password string) error {
+	if password == "" || len(password) > 72 {
+		return ErrorInvalidPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	a.PasswordHash = hash
+	return nil
+}
+
+func (a *Account) Verify(password string) bool {
+	return bcrypt.CompareHashAndPassword(a.PasswordHash, []byte(password)) == nil
+}
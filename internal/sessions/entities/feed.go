@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// Feed grants reading a user's tasks of a workspace by its token alone, for
+// clients which cannot start sessions, such as calendar apps subscribing to
+// an iCalendar feed. Unlike sessions, feeds do not expire.
+type Feed struct {
+	Token     string
+	User      string
+	Workspace string
+	CreatedAt time.Time
+}
+
+func NewFeed(workspace string, user string) *Feed {
+	return &Feed{
+		Token:     nextId(),
+		User:      user,
+		Workspace: workspace,
+		CreatedAt: time.Now(),
+	}
+}
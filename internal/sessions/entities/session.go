@@ -3,21 +3,49 @@ package entity
 import (
 	"crypto/rand"
 	"fmt"
+	"regexp"
 	"time"
 )
 
+// Sessions started without naming a user belong to AnonymousUser.
+const AnonymousUser = "anonymous"
+
+// Sessions started without naming a workspace belong to DefaultWorkspace.
+const DefaultWorkspace = "default"
+
+// Workspace ids are also used as directory names, hence the narrow alphabet.
+var workspacePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
 type Session struct {
 	Id        string
+	User      string
+	Workspace string
 	CreatedAt time.Time
 }
 
 func NewSession() *Session {
+	return NewUserSession(AnonymousUser)
+}
+
+func NewUserSession(user string) *Session {
+	return NewWorkspaceSession(DefaultWorkspace, user)
+}
+
+func NewWorkspaceSession(workspace string, user string) *Session {
 	return &Session{
 		Id:        nextId(),
+		User:      user,
+		Workspace: workspace,
 		CreatedAt: time.Now(),
 	}
 }
 
+// IsWorkspace reports whether the id is a valid workspace id: lowercase
+// letters, digits and hyphens, up to 63 of them, not starting with a hyphen.
+func IsWorkspace(id string) bool {
+	return workspacePattern.MatchString(id)
+}
+
 // Warning: Not a safe implementation.
 func nextId() string {
 	bytes := make([]byte, 16)
@@ -1,6 +1,7 @@
 package entity_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/dannyh79/whostodo/internal/sessions/entities"
@@ -13,3 +14,55 @@ func Test_NewSession(t *testing.T) {
 
 	util.AssertNotEqual(t)(s1.Id, s2.Id)
 }
+
+func Test_IsWorkspace(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected bool
+	}{
+		{id: "default", expected: true},
+		{id: "team-a", expected: true},
+		{id: "42", expected: true},
+		{id: "", expected: false},
+		{id: "-team", expected: false},
+		{id: "Team", expected: false},
+		{id: "../team", expected: false},
+		{id: strings.Repeat("a", 64), expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			util.AssertEqual(t)(entity.IsWorkspace(tc.id), tc.expected)
+		})
+	}
+}
+
+func Test_NewAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		error    error
+	}{
+		{name: "returns account", user: " alice ", password: "密碼"},
+		{name: "returns error on empty user", user: " ", password: "密碼", error: entity.ErrorInvalidUser},
+		{name: "returns error on anonymous user", user: entity.AnonymousUser, password: "密碼", error: entity.ErrorInvalidUser},
+		{name: "returns error on empty password", user: "alice", error: entity.ErrorInvalidPassword},
+		{name: "returns error on password too long", user: "alice", password: strings.Repeat("密", 25), error: entity.ErrorInvalidPassword},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := entity.NewAccount(tc.user, tc.password)
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			if tc.error == nil {
+				util.AssertEqual(t)(got.User, "alice")
+				util.AssertEqual(t)(got.Verify(tc.password), true)
+				util.AssertEqual(t)(got.Verify("password"), false)
+			}
+		})
+	}
+}
@@ -1,45 +1,325 @@
 package sessions
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/dannyh79/whostodo/internal/repository"
 	"github.com/dannyh79/whostodo/internal/sessions/entities"
 )
 
-const SessionKey = "token"
+const (
+	SessionKey   = "token"
+	UserKey      = "user"
+	WorkspaceKey = "workspace"
+)
+
+var (
+	ErrorInvalidWorkspace   = errors.New("Invalid workspace")
+	ErrorInvalidCredentials = errors.New("Invalid user or password")
+	ErrorNotMember          = errors.New("Not a member of the workspace")
+	ErrorAccountNotFound    = errors.New("Account not found")
+	ErrorFeedNotFound       = errors.New("Feed not found")
+	ErrorFeedsDisabled      = errors.New("Feeds are disabled")
+)
 
 const Timeout = time.Minute
 
 type Session = entity.Session
 
+type Feed = entity.Feed
+
+type Account = entity.Account
+
+type AuthenticateInput struct {
+	// Empty starts a session of entity.AnonymousUser.
+	User string `json:"user"`
+	// Password of the account of User; ignored for entity.AnonymousUser.
+	Password string `json:"password"`
+	// Empty starts a session in entity.DefaultWorkspace.
+	Workspace string `json:"workspace"`
+}
+
+type AccountInput struct {
+	Password string `json:"password"`
+	// Workspaces the user may start sessions in; empty for
+	// entity.DefaultWorkspace only.
+	Workspaces []string `json:"workspaces"`
+}
+
+type AccountOutput struct {
+	User       string    `json:"user"`
+	Workspaces []string  `json:"workspaces"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 type SessionsUsecase struct {
 	repo repository.Repository[Session]
+	// Nil allows sessions of entity.AnonymousUser only.
+	accounts repository.Repository[Account]
+	// Nil disables feeds.
+	feeds repository.Repository[Feed]
+	// Nil allows entity.DefaultWorkspace only.
+	workspaces map[string]bool
 }
 
-func (u *SessionsUsecase) Authenticate() string {
-	s := entity.NewSession()
+type Option func(*SessionsUsecase)
+
+// WithWorkspaces only allows sessions in the given workspaces.
+func WithWorkspaces(ids ...string) Option {
+	return func(u *SessionsUsecase) {
+		u.workspaces = make(map[string]bool)
+		for _, id := range ids {
+			u.workspaces[id] = true
+		}
+	}
+}
+
+// WithAccountRepository allows sessions of the users with accounts in the
+// repository.
+func WithAccountRepository(accounts repository.Repository[Account]) Option {
+	return func(u *SessionsUsecase) {
+		u.accounts = accounts
+	}
+}
+
+// Authenticate starts a session for the user named in the input, in the
+// workspace named in it. Named users must have an account, give its password
+// and be a member of the workspace; sessions of entity.AnonymousUser are open
+// to anyone in entity.DefaultWorkspace, and shared by everyone starting one.
+func (u *SessionsUsecase) Authenticate(i *AuthenticateInput) (string, error) {
+	user := strings.TrimSpace(i.User)
+	if user == "" {
+		user = entity.AnonymousUser
+	}
+	var account *Account
+	if user != entity.AnonymousUser {
+		var ok bool
+		if account, ok = u.verify(user, i.Password); !ok {
+			return "", ErrorInvalidCredentials
+		}
+	}
+	workspace := strings.TrimSpace(i.Workspace)
+	if workspace == "" {
+		workspace = entity.DefaultWorkspace
+	}
+	if !u.allows(workspace) {
+		return "", ErrorInvalidWorkspace
+	}
+	if !isMember(account, workspace) {
+		return "", ErrorNotMember
+	}
+
+	s := entity.NewWorkspaceSession(workspace, user)
 	u.repo.Save(s)
-	return s.Id
+	return s.Id, nil
+}
+
+// isMember reports whether the user of the account may start sessions in the
+// workspace; without an account, that of entity.AnonymousUser, only in
+// entity.DefaultWorkspace.
+func isMember(account *Account, workspace string) bool {
+	if account == nil {
+		return workspace == entity.DefaultWorkspace
+	}
+	return account.IsMember(workspace)
+}
+
+// IsMember reports whether the user may start sessions in the workspace,
+// as Authenticate lets them given the right password.
+func (u *SessionsUsecase) IsMember(workspace string, user string) bool {
+	if user == entity.AnonymousUser {
+		return isMember(nil, workspace)
+	}
+	if u.accounts == nil {
+		return false
+	}
+	account, err := u.accounts.FindBy(user)
+	return err == nil && isMember(account, workspace)
+}
+
+// verify returns the account of the user if the password is its own.
+func (u *SessionsUsecase) verify(user string, password string) (*Account, bool) {
+	if u.accounts == nil {
+		return nil, false
+	}
+	account, err := u.accounts.FindBy(user)
+	if err != nil || !account.Verify(password) {
+		return nil, false
+	}
+	return account, true
+}
+
+// SetAccount creates the account of the user, or sets its password and
+// workspaces if it exists. Workspaces must be allowed. Sessions already
+// started go on until they expire.
+func (u *SessionsUsecase) SetAccount(user string, i *AccountInput) (*AccountOutput, error) {
+	if u.accounts == nil {
+		return nil, ErrorAccountNotFound
+	}
+	for _, id := range i.Workspaces {
+		if !u.allows(strings.TrimSpace(id)) {
+			return nil, ErrorInvalidWorkspace
+		}
+	}
+	account, err := u.accounts.FindBy(strings.TrimSpace(user))
+	if err != nil {
+		created, err := entity.NewAccount(user, i.Password)
+		if err != nil {
+			return nil, err
+		}
+		if err := created.SetWorkspaces(i.Workspaces); err != nil {
+			return nil, err
+		}
+		return toAccountOutput(u.accounts.Save(created)), nil
+	}
+
+	if err := account.SetPassword(i.Password); err != nil {
+		return nil, err
+	}
+	if err := account.SetWorkspaces(i.Workspaces); err != nil {
+		return nil, err
+	}
+	updated, err := u.accounts.Update(account)
+	if err != nil {
+		return nil, err
+	}
+	return toAccountOutput(*updated), nil
+}
+
+// DeleteAccount deletes the account of the user, and revokes its feeds.
+// Sessions already started go on until they expire.
+func (u *SessionsUsecase) DeleteAccount(user string) error {
+	if u.accounts == nil {
+		return ErrorAccountNotFound
+	}
+	account, err := u.accounts.FindBy(strings.TrimSpace(user))
+	if err != nil {
+		return ErrorAccountNotFound
+	}
+	if err := u.accounts.Delete(account); err != nil {
+		return err
+	}
+
+	if u.feeds == nil {
+		return nil
+	}
+	for _, feed := range u.feeds.ListAll() {
+		if feed.User == account.User {
+			if err := u.feeds.Delete(feed); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func toAccountOutput(a Account) *AccountOutput {
+	return &AccountOutput{User: a.User, Workspaces: a.Workspaces, CreatedAt: a.CreatedAt}
 }
 
 func (u *SessionsUsecase) Validate(token any) bool {
+	_, ok := u.Session(token)
+	return ok
+}
+
+// User returns whom a valid session belongs to.
+func (u *SessionsUsecase) User(token any) (string, bool) {
+	session, ok := u.Session(token)
+	if !ok {
+		return "", false
+	}
+
+	return session.User, true
+}
+
+// Session returns a valid session, which tells whom and which workspace it
+// belongs to.
+func (u *SessionsUsecase) Session(token any) (*Session, bool) {
 	if token == nil {
-		return false
+		return nil, false
 	}
 	session, err := u.repo.FindBy(token.(string))
 	if err != nil {
-		return false
+		return nil, false
 	}
-	if isExpiredSession(session) {
-		return false
+	if isExpiredSession(session) || !u.allows(session.Workspace) {
+		return nil, false
 	}
 
-	return true
+	return session, true
 }
 
-func InitSessionsUsecase(repo repository.Repository[Session]) *SessionsUsecase {
-	return &SessionsUsecase{repo}
+func (u *SessionsUsecase) allows(workspace string) bool {
+	if u.workspaces == nil {
+		return workspace == entity.DefaultWorkspace
+	}
+	return entity.IsWorkspace(workspace) && u.workspaces[workspace]
+}
+
+// WithFeedRepository enables feeds, stored in the repository.
+func WithFeedRepository(feeds repository.Repository[Feed]) Option {
+	return func(u *SessionsUsecase) {
+		u.feeds = feeds
+	}
+}
+
+// Feed returns the feed of the user in the workspace.
+func (u *SessionsUsecase) Feed(workspace string, user string) (*Feed, error) {
+	if u.feeds == nil {
+		return nil, ErrorFeedNotFound
+	}
+	for _, feed := range u.feeds.ListAll() {
+		if feed.Workspace == workspace && feed.User == user {
+			return feed, nil
+		}
+	}
+	return nil, ErrorFeedNotFound
+}
+
+// RotateFeed gives the user in the workspace a new feed, revoking the one
+// the user had.
+func (u *SessionsUsecase) RotateFeed(workspace string, user string) (*Feed, error) {
+	if u.feeds == nil {
+		return nil, ErrorFeedsDisabled
+	}
+	if err := u.RevokeFeed(workspace, user); err != nil && !errors.Is(err, ErrorFeedNotFound) {
+		return nil, err
+	}
+
+	feed := u.feeds.Save(entity.NewFeed(workspace, user))
+	return &feed, nil
+}
+
+// RevokeFeed deletes the feed of the user in the workspace, after which its
+// token no longer reads anything.
+func (u *SessionsUsecase) RevokeFeed(workspace string, user string) error {
+	feed, err := u.Feed(workspace, user)
+	if err != nil {
+		return err
+	}
+	return u.feeds.Delete(feed)
+}
+
+// FeedOf returns the feed of the token, as long as its workspace is allowed.
+func (u *SessionsUsecase) FeedOf(token string) (*Feed, bool) {
+	if u.feeds == nil {
+		return nil, false
+	}
+	feed, err := u.feeds.FindBy(token)
+	if err != nil || !u.allows(feed.Workspace) {
+		return nil, false
+	}
+	return feed, true
+}
+
+func InitSessionsUsecase(repo repository.Repository[Session], opts ...Option) *SessionsUsecase {
+	u := &SessionsUsecase{repo: repo}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
 }
 
 func isExpiredSession(s *Session) bool {
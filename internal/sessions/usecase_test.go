@@ -10,15 +10,211 @@ import (
 
 type Session = repository.Session
 
+type Feed = repository.Feed
+
 func Test_Authenticate(t *testing.T) {
 	t.Parallel()
 
 	repo := util.InitMockSessionsRepository()
 	usecase := sessions.InitSessionsUsecase(repo)
 
-	token := usecase.Authenticate()
+	token, err := usecase.Authenticate(&sessions.AuthenticateInput{})
 
+	util.AssertEqual(t)(err, nil)
 	util.AssertEqual(t)(token, repo.Data[token].Id)
+	util.AssertEqual(t)(repo.Data[token].User, "anonymous")
+	util.AssertEqual(t)(repo.Data[token].Workspace, "default")
+}
+
+func Test_AuthenticateUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    sessions.AuthenticateInput
+		expected string
+		error    error
+	}{
+		{
+			name:     "starts session of the account",
+			input:    sessions.AuthenticateInput{User: " alice ", Password: "密碼"},
+			expected: "alice",
+		},
+		{
+			name:  "returns error on a wrong password",
+			input: sessions.AuthenticateInput{User: "alice", Password: "錯的"},
+			error: sessions.ErrorInvalidCredentials,
+		},
+		{
+			name:  "returns error on a user without an account",
+			input: sessions.AuthenticateInput{User: "bob", Password: "密碼"},
+			error: sessions.ErrorInvalidCredentials,
+		},
+		{
+			name:     "starts session of the anonymous user without a password",
+			input:    sessions.AuthenticateInput{User: "anonymous"},
+			expected: "anonymous",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			accounts := util.InitMockAccountRepository()
+			accounts.PopulateData(util.NewAccount("alice", "密碼"))
+			repo := util.InitMockSessionsRepository()
+			usecase := sessions.InitSessionsUsecase(repo, sessions.WithAccountRepository(accounts))
+
+			token, err := usecase.Authenticate(&tc.input)
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			if tc.error != nil {
+				util.AssertEqual(t)(len(repo.Data), 0)
+				return
+			}
+			user, ok := usecase.User(token)
+			util.AssertEqual(t)(ok, true)
+			util.AssertEqual(t)(user, tc.expected)
+		})
+	}
+}
+
+func Test_AuthenticateWithoutAccounts(t *testing.T) {
+	t.Parallel()
+
+	usecase := sessions.InitSessionsUsecase(util.InitMockSessionsRepository())
+
+	_, err := usecase.Authenticate(&sessions.AuthenticateInput{User: "alice", Password: "密碼"})
+
+	util.AssertErrorEqual(t)(err, sessions.ErrorInvalidCredentials)
+}
+
+func Test_SetAccount(t *testing.T) {
+	t.Parallel()
+
+	accounts := util.InitMockAccountRepository()
+	usecase := sessions.InitSessionsUsecase(util.InitMockSessionsRepository(), sessions.WithAccountRepository(accounts))
+
+	created, err := usecase.SetAccount("alice", &sessions.AccountInput{Password: "密碼"})
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(created.User, "alice")
+	_, err = usecase.SetAccount("alice", &sessions.AccountInput{Password: "新密碼"})
+	util.AssertErrorEqual(t)(err, nil)
+
+	_, err = usecase.Authenticate(&sessions.AuthenticateInput{User: "alice", Password: "密碼"})
+	util.AssertErrorEqual(t)(err, sessions.ErrorInvalidCredentials)
+	_, err = usecase.Authenticate(&sessions.AuthenticateInput{User: "alice", Password: "新密碼"})
+	util.AssertErrorEqual(t)(err, nil)
+}
+
+func Test_DeleteAccount(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  string
+		error error
+	}{
+		{name: "deletes account and its feeds", user: "alice"},
+		{name: "deletes account of the user trimmed", user: " alice "},
+		{name: "returns error on account not found", user: "bob", error: sessions.ErrorAccountNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			accounts := util.InitMockAccountRepository()
+			accounts.PopulateData(util.NewAccount("alice", "密碼"))
+			feeds := util.InitMockFeedRepository()
+			feeds.PopulateData(Feed{Token: "feed_token", User: "alice", Workspace: "default"})
+			usecase := sessions.InitSessionsUsecase(util.InitMockSessionsRepository(), sessions.WithAccountRepository(accounts), sessions.WithFeedRepository(feeds))
+
+			err := usecase.DeleteAccount(tc.user)
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			remaining := 0
+			if tc.error != nil {
+				remaining = 1
+			}
+			util.AssertEqual(t)(len(accounts.Data), remaining)
+			util.AssertEqual(t)(len(feeds.Data), remaining)
+		})
+	}
+}
+
+func Test_AuthenticateWorkspace(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []sessions.Option
+		user      string
+		workspace string
+		expected  string
+		error     error
+	}{
+		{
+			name:      "starts session in a workspace of the account",
+			opts:      []sessions.Option{sessions.WithWorkspaces("team-a", "team-b")},
+			user:      "alice",
+			workspace: " team-a ",
+			expected:  "team-a",
+		},
+		{
+			name:     "starts session of anonymous user in the default workspace",
+			expected: "default",
+		},
+		{
+			name:      "returns error on invalid workspace",
+			opts:      []sessions.Option{sessions.WithWorkspaces("team-a", "team-b")},
+			user:      "alice",
+			workspace: "Team A",
+			error:     sessions.ErrorInvalidWorkspace,
+		},
+		{
+			name:      "returns error on workspace not allowed",
+			opts:      []sessions.Option{sessions.WithWorkspaces("team-b")},
+			user:      "alice",
+			workspace: "team-a",
+			error:     sessions.ErrorInvalidWorkspace,
+		},
+		{
+			name:      "returns error on workspace other than the default one without allowed workspaces",
+			user:      "alice",
+			workspace: "team-a",
+			error:     sessions.ErrorInvalidWorkspace,
+		},
+		{
+			name:      "returns error on workspace the account is not a member of",
+			opts:      []sessions.Option{sessions.WithWorkspaces("team-a", "team-b")},
+			user:      "alice",
+			workspace: "team-b",
+			error:     sessions.ErrorNotMember,
+		},
+		{
+			name:      "returns error on anonymous user outside the default workspace",
+			opts:      []sessions.Option{sessions.WithWorkspaces("team-a", "team-b")},
+			workspace: "team-a",
+			error:     sessions.ErrorNotMember,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockSessionsRepository()
+			accounts := util.InitMockAccountRepository()
+			accounts.PopulateData(util.NewAccount("alice", "密碼", "team-a"))
+			usecase := sessions.InitSessionsUsecase(repo, append(tc.opts, sessions.WithAccountRepository(accounts))...)
+			token, err := usecase.Authenticate(&sessions.AuthenticateInput{User: tc.user, Password: "密碼", Workspace: tc.workspace})
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			if tc.error != nil {
+				util.AssertEqual(t)(len(repo.Data), 0)
+				return
+			}
+			session, ok := usecase.Session(token)
+			util.AssertEqual(t)(ok, true)
+			util.AssertEqual(t)(session.Workspace, tc.expected)
+		})
+	}
 }
 
 func Test_Validate(t *testing.T) {
@@ -37,6 +233,11 @@ func Test_Validate(t *testing.T) {
 			data:     util.NewExpiredSession(),
 			expected: false,
 		},
+		{
+			name:     "returns false on invalid workspace",
+			data:     util.NewWorkspaceSession("Team A", "alice"),
+			expected: false,
+		},
 	}
 
 	for _, tc := range tests {
@@ -53,3 +254,84 @@ func Test_Validate(t *testing.T) {
 		})
 	}
 }
+
+func Test_RotateFeed(t *testing.T) {
+	t.Parallel()
+
+	feeds := util.InitMockFeedRepository()
+	usecase := sessions.InitSessionsUsecase(util.InitMockSessionsRepository(), sessions.WithFeedRepository(feeds), sessions.WithWorkspaces("default", "team-a"))
+
+	first, err := usecase.RotateFeed("default", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := usecase.RotateFeed("default", "alice")
+	other, _ := usecase.RotateFeed("team-a", "alice")
+	got, _ := usecase.Feed("default", "alice")
+
+	util.AssertEqual(t)(got, second)
+	util.AssertEqual(t)(len(feeds.Data), 2)
+	_, ok := usecase.FeedOf(first.Token)
+	util.AssertEqual(t)(ok, false)
+	feed, ok := usecase.FeedOf(other.Token)
+	util.AssertEqual(t)(ok, true)
+	util.AssertEqual(t)(feed.Workspace, "team-a")
+}
+
+func Test_RevokeFeed(t *testing.T) {
+	t.Parallel()
+
+	usecase := sessions.InitSessionsUsecase(util.InitMockSessionsRepository(), sessions.WithFeedRepository(util.InitMockFeedRepository()))
+	feed, _ := usecase.RotateFeed("default", "alice")
+
+	util.AssertErrorEqual(t)(usecase.RevokeFeed("default", "alice"), nil)
+	util.AssertErrorEqual(t)(usecase.RevokeFeed("default", "alice"), sessions.ErrorFeedNotFound)
+	_, ok := usecase.FeedOf(feed.Token)
+	util.AssertEqual(t)(ok, false)
+}
+
+func Test_FeedOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []sessions.Option
+		feed     Feed
+		expected bool
+	}{
+		{
+			name:     "returns the feed",
+			opts:     []sessions.Option{sessions.WithWorkspaces("team-a")},
+			feed:     Feed{Token: "feed_token", User: "alice", Workspace: "team-a"},
+			expected: true,
+		},
+		{
+			name:     "returns false on workspace not allowed",
+			opts:     []sessions.Option{sessions.WithWorkspaces("team-b")},
+			feed:     Feed{Token: "feed_token", User: "alice", Workspace: "team-a"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			feeds := util.InitMockFeedRepository()
+			feeds.PopulateData(tc.feed)
+			usecase := sessions.InitSessionsUsecase(util.InitMockSessionsRepository(), append(tc.opts, sessions.WithFeedRepository(feeds))...)
+
+			_, ok := usecase.FeedOf("feed_token")
+
+			util.AssertEqual(t)(ok, tc.expected)
+		})
+	}
+}
+
+func Test_FeedsDisabled(t *testing.T) {
+	t.Parallel()
+
+	usecase := sessions.InitSessionsUsecase(util.InitMockSessionsRepository())
+
+	_, err := usecase.RotateFeed("default", "alice")
+
+	util.AssertErrorEqual(t)(err, sessions.ErrorFeedsDisabled)
+}
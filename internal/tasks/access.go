@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	"github.com/dannyh79/whostodo/internal/access"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+var ErrorForbidden = access.ErrorForbidden
+
+// WithAccessPolicy restricts every read and write to the lists the actor's
+// user has access to. Access is checked on every call, so revoking it takes
+// effect right away.
+func WithAccessPolicy(policy access.Policy) Option {
+	return func(u *TasksUsecase) {
+		u.access = policy
+	}
+}
+
+func (u *TasksUsecase) canRead(a Actor, t *entity.Task) bool {
+	return access.CanReadTask(u.access, a.User, t)
+}
+
+func (u *TasksUsecase) canWrite(a Actor, t *entity.Task) bool {
+	return access.CanWriteTask(u.access, a.User, t)
+}
+
+// checkWritableList reports whether the actor may add tasks to the list, as if
+// it did not exist when the actor may not see it.
+func (u *TasksUsecase) checkWritableList(a Actor, listId int) error {
+	if !access.CanReadList(u.access, a.User, listId) {
+		return ErrorListNotFound
+	}
+	if !access.CanWriteList(u.access, a.User, listId) {
+		return ErrorForbidden
+	}
+	return nil
+}
+
+// find returns the task, as if it did not exist when the actor may not see
+// it.
+func (u *TasksUsecase) find(a Actor, id int) (*entity.Task, error) {
+	return access.FindTask(u.repo, u.access, a.User, id, false)
+}
+
+// findWritable returns the task if the actor may change it.
+func (u *TasksUsecase) findWritable(a Actor, id int) (*entity.Task, error) {
+	return access.FindTask(u.repo, u.access, a.User, id, true)
+}
+
+// findTrashed returns the trashed task if the actor may change it.
+func (u *TasksUsecase) findTrashed(a Actor, id int) (*entity.Task, error) {
+	task, err := u.repo.FindTrashedBy(id)
+	if err != nil {
+		return nil, access.ErrorTaskNotFound
+	}
+	if err := access.CheckTask(u.access, a.User, task, true); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// visible returns the tasks the actor may see, in the same order.
+func (u *TasksUsecase) visible(a Actor, tasks []*entity.Task) []*entity.Task {
+	if u.access == nil {
+		return tasks
+	}
+
+	var output []*entity.Task
+	for _, task := range tasks {
+		if u.canRead(a, task) {
+			output = append(output, task)
+		}
+	}
+	return output
+}
+
+// listAll returns every task outside the trash the actor may see.
+func (u *TasksUsecase) listAll(a Actor) []*entity.Task {
+	return u.visible(a, u.repo.ListAll())
+}
@@ -0,0 +1,265 @@
+package tasks_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/lists"
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+// initSharedUsecase shares list 1, owned by alice, with bob as editor and
+// carol as viewer. Alice and dave have a task each in their inboxes.
+func initSharedUsecase(opts ...tasks.Option) (*tasks.TasksUsecase, *util.MockMemberRepository) {
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", ListId: 1})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐", Creator: "alice"})
+	repo.PopulateData(repository.TaskSchema{Id: 3, Name: "繳帳單", Creator: "dave"})
+	listRepo := util.InitMockListRepository()
+	listRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "alice"})
+	members := util.InitMockMemberRepository()
+	members.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "bob", Role: "editor", Accepted: true})
+	members.PopulateData(repository.MemberSchema{Id: 2, ListId: 1, User: "carol", Role: "viewer", Accepted: true})
+	access := lists.InitListsUsecase(listRepo, members)
+	opts = append([]tasks.Option{tasks.WithListRepository(listRepo), tasks.WithAccessPolicy(access)}, opts...)
+	usecase := tasks.InitTasksUsecase(repo, opts...)
+	return usecase, members
+}
+
+func Test_AccessPolicy_Read(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		expected []int
+		error    error
+	}{
+		{name: "owner sees the shared list and their inbox", user: "alice", expected: []int{1, 2}},
+		{name: "viewer sees the shared list", user: "carol", expected: []int{1}},
+		{name: "stranger sees their inbox only", user: "dave", expected: []int{3}, error: repository.ErrorNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase, _ := initSharedUsecase()
+			a := tasks.Actor{User: tc.user}
+
+			var got []int
+			for _, task := range usecase.ListTasks(a, &tasks.ListTasksInput{}) {
+				got = append(got, task.Id)
+			}
+			util.AssertEqual(t)(got, tc.expected)
+
+			_, err := usecase.GetTask(a, 1)
+			util.AssertErrorEqual(t)(err, tc.error)
+		})
+	}
+}
+
+func Test_AccessPolicy_Write(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  string
+		error error
+	}{
+		{name: "owner changes tasks", user: "alice"},
+		{name: "editor changes tasks", user: "bob"},
+		{name: "viewer cannot change tasks", user: "carol", error: tasks.ErrorForbidden},
+		{name: "stranger cannot find tasks", user: "dave", error: repository.ErrorNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase, _ := initSharedUsecase()
+			a := tasks.Actor{User: tc.user}
+
+			_, err := usecase.UpdateTask(a, 1, &tasks.UpdateTaskInput{Name: "洗衣服"})
+			util.AssertErrorEqual(t)(err, tc.error)
+
+			err = usecase.DeleteTask(a, 1, &tasks.DeleteTaskInput{})
+			util.AssertErrorEqual(t)(err, tc.error)
+		})
+	}
+}
+
+func Test_AccessPolicy_CreateTask(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  string
+		error error
+	}{
+		{name: "editor adds tasks", user: "bob"},
+		{name: "viewer cannot add tasks", user: "carol", error: tasks.ErrorForbidden},
+		{name: "stranger cannot find the list", user: "dave", error: tasks.ErrorListNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase, _ := initSharedUsecase()
+			_, err := usecase.CreateTask(tasks.Actor{User: tc.user}, &tasks.CreateTaskInput{Name: "拖地", ListId: 1})
+
+			util.AssertErrorEqual(t)(err, tc.error)
+		})
+	}
+}
+
+func Test_AccessPolicy_RenameTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		expected map[int][]string
+		error    error
+	}{
+		{name: "editor renames the tag on tasks they may change", user: "bob", expected: map[int][]string{1: {"chores"}, 2: {"housework"}}},
+		{name: "owner renames the tag on every task of theirs", user: "alice", expected: map[int][]string{1: {"chores"}, 2: {"chores"}}},
+		{name: "viewer cannot rename the tag", user: "carol", expected: map[int][]string{1: {"housework"}, 2: {"housework"}}, error: tasks.ErrorForbidden},
+		{name: "stranger cannot find the tag", user: "dave", expected: map[int][]string{1: {"housework"}, 2: {"housework"}}, error: tasks.ErrorTagNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase, _ := initSharedUsecase()
+			for id := range tc.expected {
+				if _, err := usecase.AddTags(tasks.Actor{User: "alice"}, id, &tasks.TagsInput{Tags: []string{"housework"}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := usecase.RenameTag(tasks.Actor{User: tc.user}, "housework", &tasks.RenameTagInput{Name: "chores"})
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			for id, tags := range tc.expected {
+				task, _ := usecase.GetTask(tasks.Actor{User: "alice"}, id)
+				util.AssertEqual(t)(task.Tags, tags)
+			}
+		})
+	}
+}
+
+func Test_AccessPolicy_MoveTask(t *testing.T) {
+	one, two := 1, 2
+
+	tests := []struct {
+		name     string
+		user     string
+		payload  tasks.MoveTaskInput
+		expected []int
+		error    error
+	}{
+		{name: "editor reranks only tasks of the list", user: "bob", payload: tasks.MoveTaskInput{Before: &one}, expected: []int{4, 1}},
+		{name: "viewer cannot move tasks", user: "carol", payload: tasks.MoveTaskInput{Before: &one}, error: tasks.ErrorForbidden},
+		{name: "editor cannot find tasks of other inboxes", user: "bob", payload: tasks.MoveTaskInput{Before: &two}, error: tasks.ErrorMoveTargetNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", ListId: 1})
+			repo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐", Creator: "alice"})
+			repo.PopulateData(repository.TaskSchema{Id: 4, Name: "拖地", ListId: 1})
+			listRepo := util.InitMockListRepository()
+			listRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事", Owner: "alice"})
+			members := util.InitMockMemberRepository()
+			members.PopulateData(repository.MemberSchema{Id: 1, ListId: 1, User: "bob", Role: "editor", Accepted: true})
+			members.PopulateData(repository.MemberSchema{Id: 2, ListId: 1, User: "carol", Role: "viewer", Accepted: true})
+			access := lists.InitListsUsecase(listRepo, members)
+			usecase := tasks.InitTasksUsecase(repo, tasks.WithListRepository(listRepo), tasks.WithAccessPolicy(access))
+
+			_, err := usecase.MoveTask(tasks.Actor{User: tc.user}, 4, &tc.payload)
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			if tc.error == nil {
+				var got []int
+				for _, task := range usecase.ListTasks(tasks.Actor{User: tc.user}, &tasks.ListTasksInput{}) {
+					got = append(got, task.Id)
+				}
+				util.AssertEqual(t)(got, tc.expected)
+			}
+			// Tasks of others are left where they are.
+			util.AssertEqual(t)(repo.Data[2].Position, "")
+		})
+	}
+}
+
+func Test_AccessPolicy_ClearList(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		trash    bool
+		expected map[string][]int
+		error    error
+	}{
+		{name: "moves tasks into the inbox of their creators", user: "alice", expected: map[string][]int{"alice": {2}, "bob": {4}, "carol": nil, "anonymous": {1}}},
+		{name: "moves tasks into the trash of their creators", user: "alice", trash: true, expected: map[string][]int{"alice": {2}, "bob": nil, "carol": nil, "anonymous": nil}},
+		{name: "viewer cannot clear the list", user: "carol", error: tasks.ErrorForbidden, expected: map[string][]int{"alice": {1, 2, 4}, "bob": {1, 4}, "carol": {1, 4}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase, _ := initSharedUsecase()
+			if _, err := usecase.CreateTask(tasks.Actor{User: "bob"}, &tasks.CreateTaskInput{Name: "拖地", ListId: 1}); err != nil {
+				t.Fatal(err)
+			}
+
+			err := usecase.ClearList(tasks.Actor{User: tc.user}, 1, tc.trash)
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			for user, expected := range tc.expected {
+				var got []int
+				for _, task := range usecase.ListTasks(tasks.Actor{User: user}, &tasks.ListTasksInput{}) {
+					got = append(got, task.Id)
+				}
+				util.AssertEqual(t)(got, expected)
+			}
+		})
+	}
+}
+
+func Test_AccessPolicy_RemovedMember(t *testing.T) {
+	t.Parallel()
+
+	usecase, members := initSharedUsecase()
+	a := tasks.Actor{SessionId: "bob_token", User: "bob"}
+
+	if _, err := usecase.UpdateTask(a, 1, &tasks.UpdateTaskInput{Name: "洗衣服"}); err != nil {
+		t.Fatal(err)
+	}
+
+	delete(members.Data, 1)
+
+	_, err := usecase.GetTask(a, 1)
+	util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+	_, err = usecase.Undo(a)
+	util.AssertErrorEqual(t)(err, tasks.ErrorForbidden)
+}
+
+func Test_AccessPolicy_UndoImportAcrossLists(t *testing.T) {
+	t.Parallel()
+
+	usecase, members := initSharedUsecase()
+	a := tasks.Actor{SessionId: "bob_token", User: "bob"}
+
+	if _, err := usecase.ImportCSV(a, strings.NewReader("name,list_id\n拖地,0\n洗衣服,1\n"), &tasks.ImportTasksInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	delete(members.Data, 1)
+
+	_, err := usecase.Undo(a)
+	util.AssertErrorEqual(t)(err, tasks.ErrorForbidden)
+	_, err = usecase.GetTask(a, 4)
+	util.AssertErrorEqual(t)(err, nil)
+}
@@ -0,0 +1,155 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/access"
+	"github.com/dannyh79/whostodo/internal/repository"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+var (
+	ErrorInvalidAssignee   = errors.New("Invalid assignee")
+	ErrorAssigneeNotMember = fmt.Errorf("%w: not a member of the workspace or list", ErrorInvalidAssignee)
+)
+
+type AssignTaskInput struct {
+	Assignee string `json:"assignee"`
+}
+
+type EventOutput struct {
+	Id        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	Actor     string    `json:"actor"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type HistoryRepository interface {
+	repository.Repository[entity.Event]
+	repository.TaskOwned[entity.Event]
+}
+
+// WithHistoryRepository makes the usecase keep a history of assignment
+// changes per task, removed along when the task is purged.
+func WithHistoryRepository(history HistoryRepository) Option {
+	return func(u *TasksUsecase) {
+		u.history = history
+		u.attached = append(u.attached, history)
+	}
+}
+
+// WithMembership restricts assignees to the users isMember reports as members
+// of the workspace, as accounts are kept outside of it.
+func WithMembership(isMember func(user string) bool) Option {
+	return func(u *TasksUsecase) {
+		u.isMember = isMember
+	}
+}
+
+// AssignTask makes the user the one to do the task, replacing any previous
+// assignee. The user must be a member of the workspace who may see the
+// task's list.
+func (u *TasksUsecase) AssignTask(a Actor, id int, i *AssignTaskInput) (*TaskOutput, error) {
+	assignee := strings.TrimSpace(i.Assignee)
+	if assignee == "" {
+		return nil, ErrorInvalidAssignee
+	}
+
+	return u.assign(a, id, assignee)
+}
+
+func (u *TasksUsecase) UnassignTask(a Actor, id int) (*TaskOutput, error) {
+	return u.assign(a, id, "")
+}
+
+// ListAssignedTasks returns tasks assigned to the actor's user, in manual
+// order.
+func (u *TasksUsecase) ListAssignedTasks(a Actor) []*TaskOutput {
+	return u.ListTasks(a, &ListTasksInput{Assignee: a.User})
+}
+
+// GetTaskHistory returns changes made to the task, oldest first.
+func (u *TasksUsecase) GetTaskHistory(a Actor, id int) ([]*EventOutput, error) {
+	if _, err := u.find(a, id); err != nil {
+		return nil, err
+	}
+
+	var output = make([]*EventOutput, 0)
+	if u.history == nil {
+		return output, nil
+	}
+	for _, event := range u.history.ListByTask(id) {
+		output = append(output, toEventOutput(event))
+	}
+
+	return output, nil
+}
+
+// assign changes the assignee, recording the change in the task's history
+// unless the assignee stays the same.
+func (u *TasksUsecase) assign(a Actor, id int, assignee string) (*TaskOutput, error) {
+	task, err := u.findWritable(a, id)
+	if err != nil {
+		return nil, err
+	}
+	if task.Assignee == assignee {
+		return u.present(a, task), nil
+	}
+	if assignee != "" {
+		if err := u.checkAssignee(assignee, task); err != nil {
+			return nil, err
+		}
+	}
+	before := cloneTask(task)
+
+	task.Assignee = assignee
+	output, err := u.save(a, before, task)
+	if err != nil {
+		return nil, err
+	}
+
+	u.emitAssignment(a, before, task)
+	return output, nil
+}
+
+// checkAssignee reports whether the task may be assigned to the user: a
+// member of the workspace who may see the task's list.
+func (u *TasksUsecase) checkAssignee(assignee string, t *entity.Task) error {
+	if u.isMember != nil && !u.isMember(assignee) {
+		return ErrorAssigneeNotMember
+	}
+	if !access.CanReadList(u.access, assignee, t.ListId) {
+		return ErrorAssigneeNotMember
+	}
+	return nil
+}
+
+// emitAssignment records the change of assignee between the snapshots of a
+// task, if any, as assigning, undoing and redoing do alike.
+func (u *TasksUsecase) emitAssignment(a Actor, from *entity.Task, to *entity.Task) {
+	if u.history == nil || from == nil || to == nil || from.Assignee == to.Assignee {
+		return
+	}
+
+	kind := entity.EventAssigned
+	if to.Assignee == "" {
+		kind = entity.EventUnassigned
+	}
+	u.history.Save(entity.NewEvent(to.Id, kind, a.User, from.Assignee, to.Assignee))
+}
+
+func toEventOutput(e *entity.Event) *EventOutput {
+	return &EventOutput{
+		Id:        e.Id,
+		Kind:      e.Kind,
+		Actor:     e.Actor,
+		From:      e.From,
+		To:        e.To,
+		CreatedAt: e.CreatedAt,
+	}
+}
@@ -0,0 +1,216 @@
+package tasks_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_AssignTask(t *testing.T) {
+	tests := []struct {
+		name        string
+		param       int
+		payload     tasks.AssignTaskInput
+		expected    tasks.TaskOutput
+		events      int
+		expectError bool
+		error       error
+	}{
+		{
+			name:     "returns task with new assignee",
+			param:    1,
+			payload:  tasks.AssignTaskInput{Assignee: " bob "},
+			expected: tasks.TaskOutput{Id: 1, Name: "買晚餐", Creator: "alice", Assignee: "bob"},
+			events:   1,
+		},
+		{
+			name:     "records nothing when assignee stays the same",
+			param:    1,
+			payload:  tasks.AssignTaskInput{Assignee: "alice"},
+			expected: tasks.TaskOutput{Id: 1, Name: "買晚餐", Creator: "alice", Assignee: "alice"},
+		},
+		{
+			name:        "returns error without assignee",
+			param:       1,
+			payload:     tasks.AssignTaskInput{Assignee: " "},
+			expectError: true,
+			error:       tasks.ErrorInvalidAssignee,
+		},
+		{
+			name:        "returns error when not found",
+			param:       2,
+			payload:     tasks.AssignTaskInput{Assignee: "bob"},
+			expectError: true,
+			error:       repository.ErrorNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Creator: "alice", Assignee: "alice"})
+			history := util.InitMockEventRepository()
+			usecase := tasks.InitTasksUsecase(repo, tasks.WithHistoryRepository(history))
+			got, err := usecase.AssignTask(tasks.Actor{User: "alice"}, tc.param, &tc.payload)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				if err != nil {
+					t.Error(err)
+				}
+				util.AssertEqual(t)(*got, tc.expected)
+			}
+			util.AssertEqual(t)(len(history.Data), tc.events)
+		})
+	}
+}
+
+func Test_AssignTaskMembership(t *testing.T) {
+	// Erin has no account in the workspace.
+	isMember := func(user string) bool { return user != "erin" }
+
+	tests := []struct {
+		name     string
+		id       int
+		assignee string
+		error    error
+	}{
+		{name: "assigns a member who may see the list", id: 1, assignee: "carol"},
+		{name: "assigns any member in the inbox", id: 2, assignee: "dave"},
+		{name: "returns error on a member who may not see the list", id: 1, assignee: "dave", error: tasks.ErrorAssigneeNotMember},
+		{name: "returns error on a user outside the workspace", id: 2, assignee: "erin", error: tasks.ErrorAssigneeNotMember},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase, _ := initSharedUsecase(tasks.WithMembership(isMember))
+			got, err := usecase.AssignTask(tasks.Actor{User: "alice"}, tc.id, &tasks.AssignTaskInput{Assignee: tc.assignee})
+
+			util.AssertErrorEqual(t)(err, tc.error)
+			if tc.error == nil {
+				util.AssertEqual(t)(got.Assignee, tc.assignee)
+			}
+		})
+	}
+
+	t.Run("reports assignees of CSV rows who may not see the list", func(t *testing.T) {
+		t.Parallel()
+
+		usecase, _ := initSharedUsecase(tasks.WithMembership(isMember))
+		got, err := usecase.ImportCSV(tasks.Actor{User: "alice"}, strings.NewReader("id,assignee\n1,dave\n2,erin\n2,bob\n"), &tasks.ImportTasksInput{DryRun: true})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		util.AssertEqual(t)(got.Errors, []*tasks.ImportErrorOutput{
+			{Row: 2, Column: "assignee", Error: tasks.ErrorAssigneeNotMember.Error()},
+			{Row: 3, Column: "assignee", Error: tasks.ErrorAssigneeNotMember.Error()},
+		})
+	})
+}
+
+func Test_UnassignTask(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Assignee: "bob"})
+	history := util.InitMockEventRepository()
+	usecase := tasks.InitTasksUsecase(repo, tasks.WithHistoryRepository(history))
+	got, err := usecase.UnassignTask(tasks.Actor{User: "alice"}, 1)
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(got.Assignee, "")
+	util.AssertEqual(t)(history.Data[1].Kind, entity.EventUnassigned)
+	util.AssertEqual(t)(history.Data[1].Actor, "alice")
+	util.AssertEqual(t)(history.Data[1].From, "bob")
+}
+
+func Test_ListAssignedTasks(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Assignee: "bob"})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: "買早餐", Assignee: "alice"})
+	repo.PopulateData(repository.TaskSchema{Id: 3, Name: "買午餐"})
+	usecase := tasks.InitTasksUsecase(repo)
+
+	util.AssertEqual(t)(usecase.ListAssignedTasks(tasks.Actor{User: "bob"}), []*tasks.TaskOutput{
+		{Id: 1, Name: "買晚餐", Assignee: "bob"},
+	})
+}
+
+func Test_GetTaskHistory(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐"})
+	history := util.InitMockEventRepository()
+	usecase := tasks.InitTasksUsecase(repo, tasks.WithHistoryRepository(history))
+	usecase.AssignTask(tasks.Actor{User: "alice"}, 1, &tasks.AssignTaskInput{Assignee: "bob"})
+	usecase.AssignTask(tasks.Actor{User: "bob"}, 1, &tasks.AssignTaskInput{Assignee: "carol"})
+
+	got, err := usecase.GetTaskHistory(tasks.Actor{}, 1)
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(len(got), 2)
+	util.AssertEqual(t)(*got[1], tasks.EventOutput{
+		Id:        2,
+		Kind:      entity.EventAssigned,
+		Actor:     "bob",
+		From:      "bob",
+		To:        "carol",
+		CreatedAt: got[1].CreatedAt,
+	})
+
+	usecase.DeleteTask(tasks.Actor{}, 1, &tasks.DeleteTaskInput{})
+	usecase.PurgeTask(tasks.Actor{}, 1)
+	util.AssertEqual(t)(len(history.Data), 0)
+}
+
+func Test_UndoAssignmentRecordsHistory(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐"})
+	history := util.InitMockEventRepository()
+	usecase := tasks.InitTasksUsecase(repo, tasks.WithHistoryRepository(history))
+	alice := tasks.Actor{SessionId: "alice_token", User: "alice"}
+	usecase.AssignTask(alice, 1, &tasks.AssignTaskInput{Assignee: "bob"})
+
+	if _, err := usecase.Undo(alice); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := usecase.Redo(alice); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := usecase.GetTaskHistory(alice, 1)
+
+	var events [][]string
+	for _, e := range got {
+		events = append(events, []string{e.Kind, e.Actor, e.From, e.To})
+	}
+	util.AssertEqual(t)(events, [][]string{
+		{entity.EventAssigned, "alice", "", "bob"},
+		{entity.EventUnassigned, "alice", "bob", ""},
+		{entity.EventAssigned, "alice", "", "bob"},
+	})
+}
+
+func Test_CreateTaskRecordsCreator(t *testing.T) {
+	t.Parallel()
+
+	usecase := tasks.InitTasksUsecase(util.InitMockTaskRepository())
+	got, _ := usecase.CreateTask(tasks.Actor{User: "alice"}, &tasks.CreateTaskInput{Name: "買晚餐"})
+
+	util.AssertEqual(t)(got.Creator, "alice")
+	util.AssertEqual(t)(got.Assignee, "")
+}
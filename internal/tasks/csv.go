@@ -0,0 +1,499 @@
+package tasks
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+// Imports of more rows than this are rejected.
+const MaxImportRows = 5000
+
+// Columns of exported CSV, in order. All but creator are imported.
+var CSVColumns = []string{"id", "name", "description", "status", "priority", "list_id", "parent_id", "tags", "due_at", "creator", "assignee"}
+
+// Tags are joined by this in a single CSV cell.
+const csvTagSeparator = ";"
+
+var (
+	ErrorInvalidCSV    = errors.New("Invalid CSV")
+	ErrorUnknownColumn = errors.New("Unknown column")
+	ErrorTooManyRows   = errors.New("Too many rows")
+	ErrorInvalidRows   = errors.New("Some rows are invalid")
+	ErrorInvalidNumber = errors.New("Not a number")
+	ErrorInvalidDate   = errors.New("Not a date")
+	ErrorNameRequired  = errors.New("Name is required")
+	ErrorDuplicateId   = errors.New("Task is imported more than once")
+)
+
+type ImportTasksInput struct {
+	// Maps columns to the header names they go by in the CSV; unmapped
+	// columns go by their own name.
+	Columns map[string]string
+	// Validates rows without importing any.
+	DryRun bool `form:"dry_run"`
+}
+
+type ImportErrorOutput struct {
+	// Line of the CSV, 1 being the header.
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
+
+type ImportOutput struct {
+	DryRun  bool                 `json:"dry_run"`
+	Created int                  `json:"created"`
+	Updated int                  `json:"updated"`
+	Errors  []*ImportErrorOutput `json:"errors"`
+}
+
+// ExportCSV writes every task outside the trash the actor may see as CSV,
+// a header row of CSVColumns first, in manual order.
+func (u *TasksUsecase) ExportCSV(a Actor, w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(CSVColumns); err != nil {
+		return err
+	}
+
+	for _, task := range u.listAll(a) {
+		if err := out.Write(toCSVRecord(task)); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// ImportCSV creates a task from every row without an id, and updates the
+// task of the id otherwise. Columns missing from the CSV are left as they
+// are; empty cells clear their field. Nothing is imported unless every row
+// is valid, in which case ErrorInvalidRows is returned along with the
+// errors. The whole import is undone in one step.
+func (u *TasksUsecase) ImportCSV(a Actor, r io.Reader, i *ImportTasksInput) (*ImportOutput, error) {
+	rows, err := readCSVRows(r, i.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ImportOutput{DryRun: i.DryRun, Errors: make([]*ImportErrorOutput, 0)}
+	var planned []plannedRow
+	for _, row := range rows {
+		p, errs := u.planRow(a, row)
+		output.Errors = append(output.Errors, errs...)
+		if len(errs) == 0 {
+			planned = append(planned, p)
+		}
+	}
+	output.Errors = append(output.Errors, checkPlannedIds(planned, "id")...)
+	output.Errors = append(output.Errors, checkPlannedParents(u.repo.ListAll(), planned)...)
+
+	return u.finishImport(a, i, output, planned)
+}
+
+// csvRow holds the cells of a CSV line by column, for columns in the CSV.
+type csvRow struct {
+	line  int
+	cells map[string]string
+}
+
+func (r csvRow) cell(column string) (string, bool) {
+	v, ok := r.cells[column]
+	return v, ok
+}
+
+// plannedRow is a validated row: the task before and after importing it,
+// before being nil for tasks to create.
+type plannedRow struct {
+	line   int
+	before *entity.Task
+	after  *entity.Task
+	// Name of a list to create and put the task into; see createProjects.
+	project string
+}
+
+func readCSVRows(r io.Reader, mapping map[string]string) ([]csvRow, error) {
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+
+	header, err := in.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: no header row", ErrorInvalidCSV)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrorInvalidCSV, err)
+	}
+	index, err := columnIndex(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []csvRow
+	for {
+		record, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrorInvalidCSV, err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(rows) == MaxImportRows {
+			return nil, ErrorTooManyRows
+		}
+
+		line, _ := in.FieldPos(0)
+		row := csvRow{line: line, cells: map[string]string{}}
+		for column, i := range index {
+			var v string
+			if i < len(record) {
+				v = unescapeCSVCell(record[i])
+			}
+			row.cells[column] = v
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// columnIndex locates the imported columns in the header, matching header
+// names case-insensitively.
+func columnIndex(header []string, mapping map[string]string) (map[string]int, error) {
+	for column := range mapping {
+		if !isImportedColumn(column) {
+			return nil, fmt.Errorf("%w: %s", ErrorUnknownColumn, column)
+		}
+	}
+
+	positions := map[string]int{}
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheets tend to start UTF-8 files with a byte order mark.
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		positions[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	index := map[string]int{}
+	for _, column := range CSVColumns {
+		if !isImportedColumn(column) {
+			continue
+		}
+		name, mapped := mapping[column]
+		if !mapped {
+			name = column
+		}
+		i, ok := positions[strings.ToLower(strings.TrimSpace(name))]
+		if !ok && mapped {
+			return nil, fmt.Errorf("%w: %s", ErrorUnknownColumn, name)
+		}
+		if ok {
+			index[column] = i
+		}
+	}
+
+	return index, nil
+}
+
+func isImportedColumn(column string) bool {
+	for _, c := range CSVColumns {
+		if c == column && c != "creator" {
+			return true
+		}
+	}
+	return false
+}
+
+// planRow validates the row against the stored tasks, returning the task it
+// would become.
+func (u *TasksUsecase) planRow(a Actor, row csvRow) (plannedRow, []*ImportErrorOutput) {
+	var errs []*ImportErrorOutput
+	fail := func(column string, err error) {
+		errs = append(errs, &ImportErrorOutput{Row: row.line, Column: column, Error: err.Error()})
+	}
+	p := plannedRow{line: row.line, after: &entity.Task{Creator: a.User}}
+
+	if v, ok := row.cell("id"); ok && strings.TrimSpace(v) != "" {
+		id, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			fail("id", ErrorInvalidNumber)
+			return p, errs
+		}
+		task, err := u.findWritable(a, id)
+		if err != nil {
+			fail("id", err)
+			return p, errs
+		}
+		p.before, p.after = cloneTask(task), task
+	}
+	task := p.after
+
+	if v, ok := row.cell("name"); ok {
+		task.Name = v
+	}
+	if strings.TrimSpace(task.Name) == "" {
+		fail("name", ErrorNameRequired)
+	}
+	if v, ok := row.cell("description"); ok {
+		if err := checkDescription(v); err != nil {
+			fail("description", err)
+		}
+		task.Description = v
+	}
+	if v, ok := row.cell("status"); ok {
+		status, err := parseCSVInt(v)
+		if err != nil {
+			fail("status", err)
+		}
+		task.SetStatus(status, time.Now())
+	}
+	if v, ok := row.cell("priority"); ok {
+		priority, err := toPriority(strings.TrimSpace(v))
+		if err != nil {
+			fail("priority", err)
+		}
+		task.Priority = priority
+	}
+	if v, ok := row.cell("list_id"); ok {
+		listId, err := parseCSVInt(v)
+		if err == nil {
+			err = u.checkList(listId)
+		}
+		if err == nil {
+			err = u.checkWritableList(a, listId)
+		}
+		if err != nil {
+			fail("list_id", err)
+		}
+		task.ListId = listId
+	}
+	if v, ok := row.cell("parent_id"); ok {
+		parentId, err := parseCSVInt(v)
+		if err == nil {
+			err = u.checkParent(a, task.Id, parentId)
+		}
+		if err != nil {
+			fail("parent_id", err)
+		}
+		task.ParentId = parentId
+	}
+	if v, ok := row.cell("tags"); ok {
+		task.Tags = entity.NormalizeTags(strings.Split(v, csvTagSeparator))
+	}
+	if v, ok := row.cell("due_at"); ok {
+		dueAt, err := parseCSVTime(v)
+		if err != nil {
+			fail("due_at", err)
+		}
+		task.DueAt = dueAt
+	}
+	if v, ok := row.cell("assignee"); ok {
+		task.Assignee = strings.TrimSpace(v)
+		if task.Assignee != "" && (p.before == nil || p.before.Assignee != task.Assignee) {
+			if err := u.checkAssignee(task.Assignee, task); err != nil {
+				fail("assignee", err)
+			}
+		}
+	}
+	if task.IsDone() && (p.before == nil || !p.before.IsDone()) {
+		if err := u.checkBlockers(task); err != nil {
+			fail("status", err)
+		}
+	}
+
+	return p, errs
+}
+
+// checkPlannedIds catches rows updating a task updated by an earlier row,
+// as every row is planned against the stored task and only the last one would
+// be kept; column names where the id is in the import.
+func checkPlannedIds(planned []plannedRow, column string) []*ImportErrorOutput {
+	seen := map[int]bool{}
+	var errs []*ImportErrorOutput
+	for _, p := range planned {
+		if p.before == nil {
+			continue
+		}
+		if seen[p.after.Id] {
+			errs = append(errs, &ImportErrorOutput{Row: p.line, Column: column, Error: ErrorDuplicateId.Error()})
+		}
+		seen[p.after.Id] = true
+	}
+	return errs
+}
+
+// checkPlannedParents catches subtasks rows would nest under themselves
+// together, which rows checked one by one do not.
+func checkPlannedParents(stored []*entity.Task, planned []plannedRow) []*ImportErrorOutput {
+	parents := map[int]int{}
+	for _, t := range stored {
+		parents[t.Id] = t.ParentId
+	}
+	for _, p := range planned {
+		if p.before != nil {
+			parents[p.after.Id] = p.after.ParentId
+		}
+	}
+
+	var errs []*ImportErrorOutput
+	for _, p := range planned {
+		if p.before == nil {
+			continue
+		}
+		seen := map[int]bool{}
+		for id := parents[p.after.Id]; id != 0 && !seen[id]; id = parents[id] {
+			if id == p.after.Id {
+				errs = append(errs, &ImportErrorOutput{Row: p.line, Column: "parent_id", Error: ErrorCycle.Error()})
+				break
+			}
+			seen[id] = true
+		}
+	}
+	return errs
+}
+
+// finishImport counts the planned rows into the output and applies them,
+// unless any row is invalid or the import is a dry run.
+func (u *TasksUsecase) finishImport(a Actor, i *ImportTasksInput, output *ImportOutput, planned []plannedRow) (*ImportOutput, error) {
+	for _, p := range planned {
+		if p.before == nil {
+			output.Created += 1
+		} else {
+			output.Updated += 1
+		}
+	}
+	if len(output.Errors) > 0 {
+		output.Created, output.Updated = 0, 0
+		if !i.DryRun {
+			return output, ErrorInvalidRows
+		}
+		return output, nil
+	}
+	if i.DryRun {
+		return output, nil
+	}
+
+	if err := u.applyPlanned(a, planned); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// applyPlanned saves the planned rows, recording them as one change. Rows
+// completing recurring tasks spawn their next occurrences, as UpdateTask
+// does.
+func (u *TasksUsecase) applyPlanned(a Actor, planned []plannedRow) error {
+	u.createProjects(a, planned)
+
+	var ops []operation
+	for _, p := range planned {
+		if p.before == nil {
+			p.after.Position = u.nextPosition()
+			if p.after.CreatedAt.IsZero() {
+				p.after.CreatedAt = time.Now()
+			}
+			created := u.repo.Save(p.after)
+			ops = append(ops, operation{kind: createOperation, after: cloneTask(&created)})
+			u.emitAssignment(a, &entity.Task{}, &created)
+			continue
+		}
+
+		updated, updateOps, err := u.update(p.before, p.after)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, updateOps...)
+		u.emitAssignment(a, p.before, updated)
+	}
+
+	u.record(a, ops...)
+	return nil
+}
+
+func toCSVRecord(t *entity.Task) []string {
+	var dueAt string
+	if !t.DueAt.IsZero() {
+		dueAt = t.DueAt.Format(time.RFC3339)
+	}
+	return []string{
+		strconv.Itoa(t.Id),
+		escapeCSVCell(t.Name),
+		escapeCSVCell(t.Description),
+		strconv.Itoa(t.Status),
+		toPriorityOutput(t.Priority),
+		strconv.Itoa(t.ListId),
+		strconv.Itoa(t.ParentId),
+		escapeCSVCell(strings.Join(t.Tags, csvTagSeparator)),
+		dueAt,
+		escapeCSVCell(t.Creator),
+		escapeCSVCell(t.Assignee),
+	}
+}
+
+// escapeCSVCell keeps spreadsheets from evaluating text as a formula by
+// prefixing it with a quote, which unescapeCSVCell strips again.
+func escapeCSVCell(v string) string {
+	if isFormulaLike(v) {
+		return "'" + v
+	}
+	return v
+}
+
+func unescapeCSVCell(v string) string {
+	if strings.HasPrefix(v, "'") && isFormulaLike(v[1:]) {
+		return v[1:]
+	}
+	return v
+}
+
+func isFormulaLike(v string) bool {
+	return v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0]))
+}
+
+func isBlankRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCSVInt parses an integer cell, empty ones being 0.
+func parseCSVInt(v string) (int, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, ErrorInvalidNumber
+	}
+	return n, nil
+}
+
+// parseCSVTime parses an RFC 3339 timestamp or a date, as UTC midnight;
+// empty cells are the zero time.
+func parseCSVTime(v string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, ErrorInvalidDate
+}
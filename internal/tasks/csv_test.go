@@ -0,0 +1,239 @@
+package tasks_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_ExportCSV(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Status: 1, Priority: 3, Tags: []string{"errand", "food"}, DueAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Creator: "alice", Assignee: "bob"})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: "=1+1", Description: "第一行\n第二行", ListId: 1, ParentId: 1})
+	repo.PopulateData(repository.TaskSchema{Id: 3, Name: "洗碗", DeletedAt: time.Now()})
+	usecase := tasks.InitTasksUsecase(repo)
+
+	var got bytes.Buffer
+	err := usecase.ExportCSV(tasks.Actor{}, &got)
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(got.String(), strings.Join([]string{
+		"id,name,description,status,priority,list_id,parent_id,tags,due_at,creator,assignee",
+		"1,買晚餐,,1,high,0,0,errand;food,2024-01-01T09:00:00Z,alice,bob",
+		"2,'=1+1,\"第一行\n第二行\",0,,1,1,,,,",
+		"",
+	}, "\n"))
+}
+
+func Test_ImportCSV(t *testing.T) {
+	importedDue := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		csv         string
+		input       tasks.ImportTasksInput
+		expected    *tasks.ImportOutput
+		tasks       []*tasks.TaskOutput
+		expectError bool
+		error       error
+	}{
+		{
+			name: "creates and updates tasks",
+			csv:  "id,name,status,priority,tags,due_at,assignee\n,洗碗,0,low,家事; Chore,2024-01-02,bob\n1,買晚餐,1,,,,\n",
+			expected: &tasks.ImportOutput{
+				Created: 1,
+				Updated: 1,
+				Errors:  []*tasks.ImportErrorOutput{},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買晚餐", Status: 1, Creator: "alice"},
+				{Id: 2, Name: "洗碗", Priority: "low", Tags: []string{"chore", "家事"}, DueAt: &importedDue, Creator: "alice", Assignee: "bob"},
+			},
+		},
+		{
+			name: "leaves columns missing from the CSV as they are",
+			csv:  "id,status\n1,1\n",
+			expected: &tasks.ImportOutput{
+				Updated: 1,
+				Errors:  []*tasks.ImportErrorOutput{},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Status: 1, Priority: "high", Creator: "alice"},
+			},
+		},
+		{
+			name:  "maps columns to header names",
+			csv:   "\ufeffTitle,Done\n洗碗,1\n",
+			input: tasks.ImportTasksInput{Columns: map[string]string{"name": "title", "status": "Done"}},
+			expected: &tasks.ImportOutput{
+				Created: 1,
+				Errors:  []*tasks.ImportErrorOutput{},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Creator: "alice"},
+				{Id: 2, Name: "洗碗", Status: 1, Creator: "alice"},
+			},
+		},
+		{
+			name:  "reports row errors on dry run",
+			csv:   "id,name,status,priority,list_id,due_at\n9,洗碗,,,,\n,,x,top,7,tomorrow\n,'=SUM(A1),,,,\n",
+			input: tasks.ImportTasksInput{DryRun: true},
+			expected: &tasks.ImportOutput{
+				DryRun: true,
+				Errors: []*tasks.ImportErrorOutput{
+					{Row: 2, Column: "id", Error: "Task not found"},
+					{Row: 3, Column: "name", Error: "Name is required"},
+					{Row: 3, Column: "status", Error: "Not a number"},
+					{Row: 3, Column: "priority", Error: "Invalid priority"},
+					{Row: 3, Column: "list_id", Error: "List not found"},
+					{Row: 3, Column: "due_at", Error: "Not a date"},
+				},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Creator: "alice"},
+			},
+		},
+		{
+			name:  "validates without importing on dry run",
+			csv:   "name\n洗碗\n",
+			input: tasks.ImportTasksInput{DryRun: true},
+			expected: &tasks.ImportOutput{
+				DryRun:  true,
+				Created: 1,
+				Errors:  []*tasks.ImportErrorOutput{},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Creator: "alice"},
+			},
+		},
+		{
+			name: "imports nothing when any row is invalid",
+			csv:  "name,status\n洗碗,0\n拖地,x\n",
+			expected: &tasks.ImportOutput{
+				Errors: []*tasks.ImportErrorOutput{{Row: 3, Column: "status", Error: "Not a number"}},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Creator: "alice"},
+			},
+			expectError: true,
+			error:       tasks.ErrorInvalidRows,
+		},
+		{
+			name: "imports nothing when rows update the same task",
+			csv:  "id,name\n1,買午餐\n1,買晚餐\n",
+			expected: &tasks.ImportOutput{
+				Errors: []*tasks.ImportErrorOutput{{Row: 3, Column: "id", Error: "Task is imported more than once"}},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Creator: "alice"},
+			},
+			expectError: true,
+			error:       tasks.ErrorInvalidRows,
+		},
+		{
+			name:        "returns error on unknown mapped column",
+			csv:         "name\n洗碗\n",
+			input:       tasks.ImportTasksInput{Columns: map[string]string{"creator": "name"}},
+			expectError: true,
+			error:       tasks.ErrorUnknownColumn,
+		},
+		{
+			name:        "returns error on mapped header missing",
+			csv:         "name\n洗碗\n",
+			input:       tasks.ImportTasksInput{Columns: map[string]string{"name": "title"}},
+			expectError: true,
+			error:       tasks.ErrorUnknownColumn,
+		},
+		{
+			name:        "returns error on malformed CSV",
+			csv:         "name\n\"洗碗\n",
+			expectError: true,
+			error:       tasks.ErrorInvalidCSV,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Priority: 3, Position: "a", Creator: "alice"})
+			usecase := tasks.InitTasksUsecase(repo, tasks.WithListRepository(util.InitMockListRepository()))
+			a := tasks.Actor{User: "alice"}
+
+			got, err := usecase.ImportCSV(a, strings.NewReader(tc.csv), &tc.input)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			util.AssertEqual(t)(got, tc.expected)
+			if tc.tasks != nil {
+				util.AssertEqual(t)(usecase.ListTasks(a, &tasks.ListTasksInput{}), tc.tasks)
+			}
+		})
+	}
+}
+
+func Test_ImportCSV_Cycle(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐"})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐"})
+	usecase := tasks.InitTasksUsecase(repo)
+
+	got, err := usecase.ImportCSV(tasks.Actor{}, strings.NewReader("id,parent_id\n1,2\n2,1\n"), &tasks.ImportTasksInput{})
+
+	util.AssertErrorEqual(t)(err, tasks.ErrorInvalidRows)
+	util.AssertEqual(t)(got.Errors, []*tasks.ImportErrorOutput{
+		{Row: 2, Column: "parent_id", Error: "Task cannot be nested under itself"},
+		{Row: 3, Column: "parent_id", Error: "Task cannot be nested under itself"},
+	})
+}
+
+func Test_ImportCSV_Undo(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Position: "a"})
+	usecase := tasks.InitTasksUsecase(repo)
+	a := tasks.Actor{SessionId: "alice"}
+
+	_, err := usecase.ImportCSV(a, strings.NewReader("id,name\n1,買午餐\n,洗碗\n,拖地\n"), &tasks.ImportTasksInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	util.AssertEqual(t)(len(usecase.ListTasks(a, &tasks.ListTasksInput{})), 3)
+
+	if _, err := usecase.Undo(a); err != nil {
+		t.Fatal(err)
+	}
+	util.AssertEqual(t)(usecase.ListTasks(a, &tasks.ListTasksInput{}), []*tasks.TaskOutput{{Id: 1, Name: "買早餐"}})
+}
+
+func Test_ExportImportCSV_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "=HYPERLINK(\"x\")", Description: "-1", Position: "a", Tags: []string{"@home"}})
+	usecase := tasks.InitTasksUsecase(repo)
+	a := tasks.Actor{}
+	before := usecase.ListTasks(a, &tasks.ListTasksInput{})
+
+	var exported bytes.Buffer
+	usecase.ExportCSV(a, &exported)
+	got, err := usecase.ImportCSV(a, &exported, &tasks.ImportTasksInput{})
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(got.Updated, 1)
+	util.AssertEqual(t)(usecase.ListTasks(a, &tasks.ListTasksInput{}), before)
+}
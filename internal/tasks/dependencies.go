@@ -0,0 +1,184 @@
+package tasks
+
+import (
+	"errors"
+	"sort"
+
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+var (
+	ErrorBlockerNotFound   = errors.New("Blocking task not found")
+	ErrorDependencyCycle   = errors.New("Task cannot be blocked by itself")
+	ErrorBlocked           = errors.New("Task is blocked by open tasks")
+	ErrorDependencyMissing = errors.New("Task is not blocked by the task")
+)
+
+type BlockerInput struct {
+	Id int `json:"id"`
+}
+
+type DependenciesOutput struct {
+	Blockers   []*TaskOutput `json:"blockers"`
+	Dependents []*TaskOutput `json:"dependents"`
+}
+
+// AddBlocker declares that the task cannot be done before the blocker is.
+func (u *TasksUsecase) AddBlocker(a Actor, id int, i *BlockerInput) (*TaskOutput, error) {
+	task, err := u.findWritable(a, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.find(a, i.Id); err != nil {
+		return nil, ErrorBlockerNotFound
+	}
+	if u.dependsOn(i.Id, id) {
+		return nil, ErrorDependencyCycle
+	}
+	if task.IsBlockedBy(i.Id) {
+		return u.present(a, task), nil
+	}
+
+	before := cloneTask(task)
+	task.BlockedBy = append(task.BlockedBy, i.Id)
+	sort.Ints(task.BlockedBy)
+
+	return u.save(a, before, task)
+}
+
+func (u *TasksUsecase) RemoveBlocker(a Actor, id int, blockerId int) (*TaskOutput, error) {
+	task, err := u.findWritable(a, id)
+	if err != nil {
+		return nil, err
+	}
+	if !task.IsBlockedBy(blockerId) {
+		return nil, ErrorDependencyMissing
+	}
+
+	before := cloneTask(task)
+	var blockedBy []int
+	for _, b := range task.BlockedBy {
+		if b != blockerId {
+			blockedBy = append(blockedBy, b)
+		}
+	}
+	task.BlockedBy = blockedBy
+
+	return u.save(a, before, task)
+}
+
+// GetDependencies returns the tasks blocking the task and the tasks it blocks.
+func (u *TasksUsecase) GetDependencies(a Actor, id int) (*DependenciesOutput, error) {
+	task, err := u.find(a, id)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &DependenciesOutput{
+		Blockers:   make([]*TaskOutput, 0),
+		Dependents: make([]*TaskOutput, 0),
+	}
+	for _, t := range u.listAll(a) {
+		if task.IsBlockedBy(t.Id) {
+			output.Blockers = append(output.Blockers, toTaskOutput(t))
+		}
+		if t.IsBlockedBy(task.Id) {
+			output.Dependents = append(output.Dependents, toTaskOutput(t))
+		}
+	}
+
+	return output, nil
+}
+
+// ListNextTasks returns open tasks in an order they can be worked on, every
+// task coming after the open tasks blocking it. Among tasks ready at the
+// same time, lower ids come first.
+func (u *TasksUsecase) ListNextTasks(a Actor) []*TaskOutput {
+	var output = make([]*TaskOutput, 0)
+
+	open := map[int]*entity.Task{}
+	for _, t := range u.listAll(a) {
+		if !t.IsDone() {
+			open[t.Id] = t
+		}
+	}
+
+	pending := map[int]int{}
+	dependents := map[int][]int{}
+	for id, t := range open {
+		for _, b := range t.BlockedBy {
+			if _, ok := open[b]; ok {
+				pending[id] += 1
+				dependents[b] = append(dependents[b], id)
+			}
+		}
+	}
+
+	var ready []int
+	for id := range open {
+		if pending[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		id := ready[0]
+		ready = ready[1:]
+		output = append(output, toTaskOutput(open[id]))
+		for _, d := range dependents[id] {
+			pending[d] -= 1
+			if pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	return output
+}
+
+// checkBlockers reports whether a task can be marked as done; trashed
+// blockers no longer count.
+func (u *TasksUsecase) checkBlockers(t *entity.Task) error {
+	for _, b := range t.BlockedBy {
+		blocker, err := u.repo.FindBy(b)
+		if err == nil && !blocker.IsDone() {
+			return ErrorBlocked
+		}
+	}
+	return nil
+}
+
+// dependsOn reports whether the task is blocked by the other task, directly
+// or through other blockers.
+func (u *TasksUsecase) dependsOn(id int, otherId int) bool {
+	return dependsOnBy(id, otherId, func(id int) (*entity.Task, error) {
+		return u.repo.FindBy(id)
+	})
+}
+
+// dependsOnBy is dependsOn looking tasks up by find.
+func dependsOnBy(id int, otherId int, find func(int) (*entity.Task, error)) bool {
+	seen := map[int]bool{}
+	var walk func(int) bool
+	walk = func(id int) bool {
+		if id == otherId {
+			return true
+		}
+		if seen[id] {
+			return false
+		}
+		seen[id] = true
+
+		task, err := find(id)
+		if err != nil {
+			return false
+		}
+		for _, b := range task.BlockedBy {
+			if walk(b) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(id)
+}
@@ -0,0 +1,204 @@
+package tasks_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+// 買菜 blocks 煮飯, which blocks 洗碗.
+func newDependenciesRepo() *util.MockTaskRepository {
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", BlockedBy: []int{2}})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: "煮飯", BlockedBy: []int{3}})
+	repo.PopulateData(repository.TaskSchema{Id: 3, Name: "買菜"})
+	repo.PopulateData(repository.TaskSchema{Id: 4, Name: "倒垃圾"})
+	return repo
+}
+
+func Test_AddBlocker(t *testing.T) {
+	tests := []struct {
+		name        string
+		param       int
+		payload     tasks.BlockerInput
+		expected    tasks.TaskOutput
+		expectError bool
+		error       error
+	}{
+		{
+			name:     "returns blocked task",
+			param:    4,
+			payload:  tasks.BlockerInput{Id: 3},
+			expected: tasks.TaskOutput{Id: 4, Name: "倒垃圾", BlockedBy: []int{3}},
+		},
+		{
+			name:     "returns task already blocked as is",
+			param:    1,
+			payload:  tasks.BlockerInput{Id: 2},
+			expected: tasks.TaskOutput{Id: 1, Name: "洗碗", BlockedBy: []int{2}},
+		},
+		{
+			name:        "returns error blocking task by itself",
+			param:       4,
+			payload:     tasks.BlockerInput{Id: 4},
+			expectError: true,
+			error:       tasks.ErrorDependencyCycle,
+		},
+		{
+			name:        "returns error blocking task by a dependent",
+			param:       3,
+			payload:     tasks.BlockerInput{Id: 1},
+			expectError: true,
+			error:       tasks.ErrorDependencyCycle,
+		},
+		{
+			name:        "returns error blocking task by unknown task",
+			param:       4,
+			payload:     tasks.BlockerInput{Id: 9},
+			expectError: true,
+			error:       tasks.ErrorBlockerNotFound,
+		},
+		{
+			name:        "returns error when not found",
+			param:       9,
+			payload:     tasks.BlockerInput{Id: 3},
+			expectError: true,
+			error:       repository.ErrorNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase := tasks.InitTasksUsecase(newDependenciesRepo())
+			got, err := usecase.AddBlocker(tasks.Actor{}, tc.param, &tc.payload)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				if err != nil {
+					t.Error(err)
+				}
+				util.AssertEqual(t)(*got, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_RemoveBlocker(t *testing.T) {
+	t.Run("returns unblocked task", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := tasks.InitTasksUsecase(newDependenciesRepo())
+		got, err := usecase.RemoveBlocker(tasks.Actor{}, 1, 2)
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(*got, tasks.TaskOutput{Id: 1, Name: "洗碗"})
+	})
+
+	t.Run("returns error when not blocked by the task", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := tasks.InitTasksUsecase(newDependenciesRepo())
+		_, err := usecase.RemoveBlocker(tasks.Actor{}, 1, 3)
+
+		util.AssertErrorEqual(t)(err, tasks.ErrorDependencyMissing)
+	})
+}
+
+func Test_GetDependencies(t *testing.T) {
+	t.Parallel()
+
+	usecase := tasks.InitTasksUsecase(newDependenciesRepo())
+	got, err := usecase.GetDependencies(tasks.Actor{}, 2)
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(*got, tasks.DependenciesOutput{
+		Blockers:   []*tasks.TaskOutput{{Id: 3, Name: "買菜"}},
+		Dependents: []*tasks.TaskOutput{{Id: 1, Name: "洗碗", BlockedBy: []int{2}}},
+	})
+}
+
+func Test_CompleteBlockedTask(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []repository.TaskSchema
+		expectError bool
+		error       error
+	}{
+		{
+			name:        "returns error while a blocker is open",
+			data:        []repository.TaskSchema{{Id: 3, Name: "買菜", Status: 0}},
+			expectError: true,
+			error:       tasks.ErrorBlocked,
+		},
+		{
+			name: "completes the task once blockers are done",
+			data: []repository.TaskSchema{{Id: 3, Name: "買菜", Status: 1}},
+		},
+		{
+			name: "completes the task once blockers are trashed",
+			data: []repository.TaskSchema{{Id: 3, Name: "買菜", DeletedAt: time.Now()}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newDependenciesRepo()
+			for _, row := range tc.data {
+				repo.PopulateData(row)
+			}
+			usecase := tasks.InitTasksUsecase(repo)
+			_, err := usecase.UpdateTask(tasks.Actor{}, 2, &tasks.UpdateTaskInput{Name: "煮飯", Status: 1})
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func Test_ListNextTasks(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []repository.TaskSchema
+		expected []int
+	}{
+		{
+			name:     "returns open tasks after their blockers",
+			expected: []int{3, 2, 1, 4},
+		},
+		{
+			name:     "leaves out done tasks",
+			data:     []repository.TaskSchema{{Id: 3, Name: "買菜", Status: 1}},
+			expected: []int{2, 1, 4},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newDependenciesRepo()
+			for _, row := range tc.data {
+				repo.PopulateData(row)
+			}
+			usecase := tasks.InitTasksUsecase(repo)
+
+			var got []int
+			for _, task := range usecase.ListNextTasks(tasks.Actor{}) {
+				got = append(got, task.Id)
+			}
+
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package entity
+
+import "time"
+
+const (
+	EventAssigned   = "assigned"
+	EventUnassigned = "unassigned"
+)
+
+// Event is an entry of a task's history.
+type Event struct {
+	Id     int
+	TaskId int
+	Kind   string
+	// User who made the change.
+	Actor string
+	// Values before and after the change.
+	From      string
+	To        string
+	CreatedAt time.Time
+}
+
+func NewEvent(taskId int, kind string, actor string, from string, to string) *Event {
+	return &Event{
+		TaskId:    taskId,
+		Kind:      kind,
+		Actor:     actor,
+		From:      from,
+		To:        to,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,163 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+var ErrorInvalidRecurrence = errors.New("Invalid recurrence")
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Recurrence is an RRULE-like schedule: every Interval days, weeks or months,
+// optionally on given Weekdays for weekly ones, until a date or for a Count
+// of occurrences.
+type Recurrence struct {
+	Frequency string
+	Interval  int
+	Weekdays  []time.Weekday
+	Until     time.Time
+	// Occurrences left including the current one; zero means no limit.
+	Count int
+	// Day of month monthly occurrences fall on, clamped to shorter months;
+	// zero takes the day of the first due date.
+	Day int
+}
+
+func NewRecurrence(frequency string, interval int, weekdays []time.Weekday, until time.Time, count int) (*Recurrence, error) {
+	if interval == 0 {
+		interval = 1
+	}
+	r := &Recurrence{
+		Frequency: frequency,
+		Interval:  interval,
+		Weekdays:  weekdays,
+		Until:     until,
+		Count:     count,
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Recurrence) Clone() *Recurrence {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	c.Weekdays = append([]time.Weekday(nil), r.Weekdays...)
+	return &c
+}
+
+// Next returns the recurrence for the occurrence following the one due at
+// the given time along with its due time, or false once the schedule ends.
+func (r *Recurrence) Next(due time.Time) (*Recurrence, time.Time, bool) {
+	if r.Count == 1 {
+		return nil, time.Time{}, false
+	}
+
+	following := r.Clone()
+	var next time.Time
+	switch r.Frequency {
+	case Daily:
+		next = due.AddDate(0, 0, r.Interval)
+	case Weekly:
+		next = r.nextWeekly(due)
+	default:
+		// Clamped dates are not carried over, so Jan 31 is followed by Feb 29,
+		// then Mar 31 rather than Mar 29.
+		if following.Day == 0 {
+			following.Day = due.Day()
+		}
+		next = addMonthsClamped(due, r.Interval, following.Day)
+	}
+	if !r.Until.IsZero() && next.After(r.Until) {
+		return nil, time.Time{}, false
+	}
+
+	if following.Count > 0 {
+		following.Count -= 1
+	}
+	return following, next, true
+}
+
+func (r *Recurrence) validate() error {
+	switch {
+	case r.Frequency != Daily && r.Frequency != Weekly && r.Frequency != Monthly:
+		return ErrorInvalidRecurrence
+	case r.Interval < 0 || r.Count < 0:
+		return ErrorInvalidRecurrence
+	case len(r.Weekdays) > 0 && r.Frequency != Weekly:
+		return ErrorInvalidRecurrence
+	}
+	return nil
+}
+
+// nextWeekly picks the next listed weekday later in the same week, or the
+// first listed weekday Interval weeks later; weeks start on Monday.
+func (r *Recurrence) nextWeekly(due time.Time) time.Time {
+	if len(r.Weekdays) == 0 {
+		return due.AddDate(0, 0, 7*r.Interval)
+	}
+
+	start := weekStart(due)
+	for d := due.AddDate(0, 0, 1); d.Before(start.AddDate(0, 0, 7)); d = d.AddDate(0, 0, 1) {
+		if r.hasWeekday(d.Weekday()) {
+			return d
+		}
+	}
+
+	d := start.AddDate(0, 0, 7*r.Interval)
+	d = time.Date(d.Year(), d.Month(), d.Day(), due.Hour(), due.Minute(), due.Second(), due.Nanosecond(), due.Location())
+	for !r.hasWeekday(d.Weekday()) {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+func (r *Recurrence) hasWeekday(day time.Weekday) bool {
+	for _, w := range r.Weekdays {
+		if w == day {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseWeekday parses two-letter RRULE weekday codes such as "MO".
+func ParseWeekday(code string) (time.Weekday, bool) {
+	for i, c := range weekdayCodes {
+		if strings.EqualFold(c, code) {
+			return time.Weekday(i), true
+		}
+	}
+	return 0, false
+}
+
+func WeekdayCode(day time.Weekday) string {
+	return weekdayCodes[day]
+}
+
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+// addMonthsClamped adds months and moves to the given day of month, falling
+// back to the last day of shorter months.
+func addMonthsClamped(t time.Time, months int, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	if day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,136 @@
+package entity_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/tasks/entities"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_RecurrenceNext(t *testing.T) {
+	// A Wednesday
+	due := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		data      entity.Recurrence
+		from      time.Time
+		expected  time.Time
+		count     int
+		expectEnd bool
+	}{
+		{
+			name:     "returns next day",
+			data:     entity.Recurrence{Frequency: entity.Daily, Interval: 1},
+			expected: time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "returns day after interval",
+			data:     entity.Recurrence{Frequency: entity.Daily, Interval: 3},
+			expected: time.Date(2024, 2, 3, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "returns same weekday after interval",
+			data:     entity.Recurrence{Frequency: entity.Weekly, Interval: 2},
+			expected: time.Date(2024, 2, 14, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "returns next listed weekday in the same week",
+			data:     entity.Recurrence{Frequency: entity.Weekly, Interval: 2, Weekdays: []time.Weekday{time.Monday, time.Friday}},
+			expected: time.Date(2024, 2, 2, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "returns first listed weekday after interval",
+			data:     entity.Recurrence{Frequency: entity.Weekly, Interval: 2, Weekdays: []time.Weekday{time.Monday, time.Tuesday}},
+			expected: time.Date(2024, 2, 12, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "returns last day of shorter month",
+			data:     entity.Recurrence{Frequency: entity.Monthly, Interval: 1},
+			expected: time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "returns the day of month of the first due date after a shorter month",
+			data:     entity.Recurrence{Frequency: entity.Monthly, Interval: 1, Day: 31},
+			from:     time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "counts down occurrences",
+			data:     entity.Recurrence{Frequency: entity.Daily, Interval: 1, Count: 3},
+			expected: time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC),
+			count:    2,
+		},
+		{
+			name:      "ends after the last occurrence",
+			data:      entity.Recurrence{Frequency: entity.Daily, Interval: 1, Count: 1},
+			expectEnd: true,
+		},
+		{
+			name:      "ends after the end date",
+			data:      entity.Recurrence{Frequency: entity.Daily, Interval: 1, Until: due.Add(time.Hour)},
+			expectEnd: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			from := due
+			if !tc.from.IsZero() {
+				from = tc.from
+			}
+			next, got, ok := tc.data.Next(from)
+
+			util.AssertEqual(t)(ok, !tc.expectEnd)
+			if !tc.expectEnd {
+				util.AssertEqual(t)(got, tc.expected)
+				util.AssertEqual(t)(next.Count, tc.count)
+			}
+		})
+	}
+}
+
+func Test_RecurrenceNextMonthly(t *testing.T) {
+	t.Parallel()
+
+	r := &entity.Recurrence{Frequency: entity.Monthly, Interval: 1}
+	due := time.Date(2023, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		r, due, _ = r.Next(due)
+		got = append(got, due.Format(time.DateOnly))
+	}
+
+	util.AssertEqual(t)(got, []string{"2023-02-28", "2023-03-31", "2023-04-30", "2023-05-31"})
+}
+
+func Test_NewRecurrence(t *testing.T) {
+	tests := []struct {
+		name        string
+		frequency   string
+		weekdays    []time.Weekday
+		expectError bool
+	}{
+		{name: "returns recurrence", frequency: entity.Weekly, weekdays: []time.Weekday{time.Monday}},
+		{name: "returns error on unknown frequency", frequency: "hourly", expectError: true},
+		{name: "returns error on weekdays of daily recurrence", frequency: entity.Daily, weekdays: []time.Weekday{time.Monday}, expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := entity.NewRecurrence(tc.frequency, 0, tc.weekdays, time.Time{}, 0)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, entity.ErrorInvalidRecurrence)
+			} else {
+				util.AssertEqual(t)(got.Interval, 1)
+			}
+		})
+	}
+}
@@ -1,9 +1,50 @@
 package entity
 
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	StatusOpen = 0
+	StatusDone = 1
+)
+
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = []string{"none", "low", "medium", "high", "urgent"}
+
 type Task struct {
-	Id     int
-	Name   string
-	Status int
+	Id   int
+	Name string
+	// Markdown source.
+	Description string
+	Status      int
+	Priority    int
+	// Rank string ordering tasks; see package rank.
+	Position  string
+	ListId    int
+	ParentId  int
+	Tags      []string
+	BlockedBy []int
+	// Zero when the task has no due date.
+	DueAt      time.Time
+	Recurrence *Recurrence
+	// Users who created and who is to do the task; empty when unknown or
+	// unassigned.
+	Creator  string
+	Assignee string
+	// Zero when unknown, for tasks stored before these were kept.
+	CreatedAt   time.Time
+	CompletedAt time.Time
+	DeletedAt   time.Time
 }
 
 func NewTask(id int, name string, status int) *Task {
@@ -13,3 +54,102 @@ func NewTask(id int, name string, status int) *Task {
 		Status: status,
 	}
 }
+
+func (t *Task) IsTrashed() bool {
+	return !t.DeletedAt.IsZero()
+}
+
+func (t *Task) IsDone() bool {
+	return t.Status == StatusDone
+}
+
+// SetStatus changes the status, stamping CompletedAt with at when the task
+// gets done and clearing it when the task is reopened.
+func (t *Task) SetStatus(status int, at time.Time) {
+	wasDone := t.IsDone()
+	t.Status = status
+	switch {
+	case t.IsDone() && !wasDone:
+		t.CompletedAt = at
+	case !t.IsDone():
+		t.CompletedAt = time.Time{}
+	}
+}
+
+func (t *Task) HasTag(tag string) bool {
+	for _, tt := range t.Tags {
+		if tt == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTags merges tags into the task's tags, keeping them normalized.
+func (t *Task) AddTags(tags ...string) {
+	t.Tags = NormalizeTags(append(append([]string{}, t.Tags...), tags...))
+}
+
+func (t *Task) RemoveTag(tag string) {
+	tag = NormalizeTag(tag)
+	var tags []string
+	for _, tt := range t.Tags {
+		if tt != tag {
+			tags = append(tags, tt)
+		}
+	}
+	t.Tags = tags
+}
+
+// NormalizeTag trims and lowercases a tag; tags are compared in this form.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// NormalizeTags returns the normalized, sorted and deduplicated tags, or nil
+// when none is left.
+func NormalizeTags(tags []string) []string {
+	seen := map[string]bool{}
+	var normalized []string
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func (t *Task) IsBlockedBy(id int) bool {
+	for _, b := range t.BlockedBy {
+		if b == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Task) IsRecurring() bool {
+	return t.Recurrence != nil
+}
+
+func ParsePriority(name string) (int, bool) {
+	for p, n := range priorityNames {
+		if n == strings.ToLower(name) {
+			return p, true
+		}
+	}
+	return PriorityNone, false
+}
+
+// PriorityName returns the name of the priority, or that of PriorityNone for
+// priorities out of range, such as those of rows restored from elsewhere.
+func PriorityName(priority int) string {
+	if priority < 0 || priority >= len(priorityNames) {
+		return priorityNames[PriorityNone]
+	}
+	return priorityNames[priority]
+}
@@ -0,0 +1,62 @@
+package entity_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/tasks/entities"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_NormalizeTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []string
+		expected []string
+	}{
+		{
+			name:     "returns sorted lowercase tags without duplicates",
+			data:     []string{" Work", "家事", "work", "errand"},
+			expected: []string{"errand", "work", "家事"},
+		},
+		{
+			name:     "returns nil without tags",
+			data:     []string{" ", ""},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := entity.NormalizeTags(tc.data)
+
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+}
+
+func Test_TaskTags(t *testing.T) {
+	task := entity.NewTask(1, "買晚餐", 0)
+
+	task.AddTags("Errand", "家事")
+	task.RemoveTag("家事")
+
+	util.AssertEqual(t)(task.Tags, []string{"errand"})
+	util.AssertEqual(t)(task.HasTag("errand"), true)
+}
+
+func Test_TaskSetStatus(t *testing.T) {
+	task := entity.NewTask(1, "買晚餐", 0)
+	doneAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	task.SetStatus(entity.StatusDone, doneAt)
+	util.AssertEqual(t)(task.CompletedAt, doneAt)
+
+	task.SetStatus(entity.StatusDone, doneAt.Add(time.Hour))
+	util.AssertEqual(t)(task.CompletedAt, doneAt)
+
+	task.SetStatus(entity.StatusOpen, doneAt.Add(time.Hour))
+	util.AssertEqual(t)(task.CompletedAt, time.Time{})
+}
@@ -0,0 +1,524 @@
+package tasks
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+var (
+	ErrorInvalidICal   = errors.New("Invalid iCalendar")
+	ErrorInvalidStatus = errors.New("Invalid status")
+)
+
+const (
+	icalProductId = "-//whostodo//whostodo//EN"
+	// Content lines longer than this many bytes are folded.
+	icalLineLimit  = 75
+	icalDateTime   = "20060102T150405Z"
+	icalDate       = "20060102"
+	icalLocalTime  = "20060102T150405"
+	icalStatusDone = "COMPLETED"
+	icalStatusOpen = "NEEDS-ACTION"
+)
+
+// UIDs of tasks end in @ and this, unless set by WithICalDomain.
+const DefaultICalDomain = "whostodo"
+
+// WithICalDomain sets what UIDs of exported tasks end in after the @, which
+// should name both the server and the workspace: UIDs are to be unique
+// wherever the tasks are imported, and only UIDs in the domain are taken for
+// tasks of the usecase on import.
+func WithICalDomain(domain string) Option {
+	return func(u *TasksUsecase) {
+		u.icalDomain = domain
+	}
+}
+
+// iCalendar priorities by task priority, 1 being the highest; see
+// fromICalPriority for the way back.
+var icalPriorities = map[int]int{
+	entity.PriorityUrgent: 1,
+	entity.PriorityHigh:   3,
+	entity.PriorityMedium: 5,
+	entity.PriorityLow:    9,
+}
+
+// ExportICal writes every task outside the trash the actor may see as a
+// VTODO component of an iCalendar, in manual order. Tasks are identified by
+// a UID of their id in the usecase's domain, which ImportICal matches on.
+func (u *TasksUsecase) ExportICal(a Actor, w io.Writer) error {
+	out := &icalWriter{w: bufio.NewWriter(w)}
+	out.line("BEGIN", "VCALENDAR")
+	out.line("VERSION", "2.0")
+	out.line("PRODID", icalProductId)
+	out.line("CALSCALE", "GREGORIAN")
+
+	stamp := time.Now()
+	for _, task := range u.listAll(a) {
+		writeICalTodo(out, task, u.icalUid(task.Id), stamp)
+	}
+
+	out.line("END", "VCALENDAR")
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+func writeICalTodo(out *icalWriter, t *entity.Task, uid string, stamp time.Time) {
+	out.line("BEGIN", "VTODO")
+	out.line("UID", uid)
+	out.line("DTSTAMP", formatICalTime(stamp))
+	if !t.CreatedAt.IsZero() {
+		out.line("CREATED", formatICalTime(t.CreatedAt))
+	}
+	out.line("SUMMARY", escapeICalText(t.Name))
+	if t.Description != "" {
+		out.line("DESCRIPTION", escapeICalText(t.Description))
+	}
+	if t.IsDone() {
+		out.line("STATUS", icalStatusDone)
+		if !t.CompletedAt.IsZero() {
+			out.line("COMPLETED", formatICalTime(t.CompletedAt))
+		}
+	} else {
+		out.line("STATUS", icalStatusOpen)
+	}
+	if !t.DueAt.IsZero() {
+		due := t.DueAt.UTC()
+		if due.Equal(due.Truncate(24 * time.Hour)) {
+			// Due dates without a time of day are kept as dates.
+			out.line("DUE;VALUE=DATE", due.Format(icalDate))
+		} else {
+			out.line("DUE", formatICalTime(due))
+		}
+	}
+	if priority, ok := icalPriorities[t.Priority]; ok {
+		out.line("PRIORITY", strconv.Itoa(priority))
+	}
+	if len(t.Tags) > 0 {
+		var categories []string
+		for _, tag := range t.Tags {
+			categories = append(categories, escapeICalText(tag))
+		}
+		out.line("CATEGORIES", strings.Join(categories, ","))
+	}
+	out.line("END", "VTODO")
+}
+
+// icalWriter writes content lines, folding long ones, and keeps the first
+// error met.
+type icalWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (w *icalWriter) line(name string, value string) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = w.w.WriteString(foldICalLine(name+":"+value) + "\r\n")
+}
+
+// foldICalLine breaks the line into ones of up to icalLineLimit bytes, each
+// following one starting with a space, without splitting characters.
+func foldICalLine(line string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if width+size > icalLineLimit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+func escapeICalText(v string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(v)
+}
+
+func unescapeICalText(v string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range v {
+		switch {
+		case escaped && (r == 'n' || r == 'N'):
+			b.WriteRune('\n')
+		case escaped:
+			b.WriteRune(r)
+		case r == '\\':
+			escaped = true
+			continue
+		default:
+			b.WriteRune(r)
+		}
+		escaped = false
+	}
+	return b.String()
+}
+
+// splitICalList splits a list value on the commas not escaped.
+func splitICalList(v string) []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(v); i++ {
+		switch v[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, v[start:i])
+			start = i + 1
+		}
+	}
+	return append(values, v[start:])
+}
+
+func formatICalTime(t time.Time) string {
+	return t.UTC().Format(icalDateTime)
+}
+
+// parseICalTime parses a date, a UTC date-time, or a local one in the
+// timezone of the TZID parameter, floating ones taken as UTC.
+func parseICalTime(p icalProperty) (time.Time, error) {
+	if p.params["VALUE"] == "DATE" || len(p.value) == len(icalDate) {
+		if t, err := time.Parse(icalDate, p.value); err == nil {
+			return t, nil
+		}
+		return time.Time{}, ErrorInvalidDate
+	}
+	if t, err := time.Parse(icalDateTime, p.value); err == nil {
+		return t, nil
+	}
+	location := time.UTC
+	if tzid := p.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			location = l
+		}
+	}
+	if t, err := time.ParseInLocation(icalLocalTime, p.value, location); err == nil {
+		return t, nil
+	}
+	return time.Time{}, ErrorInvalidDate
+}
+
+// ImportICal creates a task from every VTODO component of the iCalendar
+// without a UID of the usecase's domain, and updates the task of the UID
+// otherwise; other
+// components are skipped. Properties missing from a component leave their
+// field as it is. As with ImportCSV, nothing is imported unless every
+// component is valid, and the whole import is undone in one step.
+func (u *TasksUsecase) ImportICal(a Actor, r io.Reader, i *ImportTasksInput) (*ImportOutput, error) {
+	todos, err := readICalTodos(r)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ImportOutput{DryRun: i.DryRun, Errors: make([]*ImportErrorOutput, 0)}
+	var planned []plannedRow
+	for _, todo := range todos {
+		p, errs := u.planICalTodo(a, todo)
+		output.Errors = append(output.Errors, errs...)
+		if len(errs) == 0 {
+			planned = append(planned, p)
+		}
+	}
+	output.Errors = append(output.Errors, checkPlannedIds(planned, "uid")...)
+
+	return u.finishImport(a, i, output, planned)
+}
+
+type icalProperty struct {
+	params map[string]string
+	value  string
+}
+
+// icalTodo holds the properties of a VTODO component by name, starting at
+// line.
+type icalTodo struct {
+	line       int
+	properties map[string][]icalProperty
+}
+
+func (t icalTodo) property(name string) (icalProperty, bool) {
+	p, ok := t.properties[name]
+	if !ok {
+		return icalProperty{}, false
+	}
+	return p[0], true
+}
+
+// icalLine is an unfolded content line, starting at line of the input.
+type icalLine struct {
+	line int
+	text string
+}
+
+func readICalTodos(r io.Reader) ([]icalTodo, error) {
+	lines, err := unfoldICalLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var todos []icalTodo
+	var components []string
+	var todo *icalTodo
+	for _, l := range lines {
+		name, p, err := parseICalLine(l.text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrorInvalidICal, l.line, err)
+		}
+
+		switch name {
+		case "BEGIN":
+			if len(components) == 0 && p.value != "VCALENDAR" {
+				return nil, fmt.Errorf("%w: line %d: not in a calendar", ErrorInvalidICal, l.line)
+			}
+			components = append(components, p.value)
+			if p.value == "VTODO" && len(components) == 2 {
+				if len(todos) == MaxImportRows {
+					return nil, ErrorTooManyRows
+				}
+				todo = &icalTodo{line: l.line, properties: map[string][]icalProperty{}}
+			}
+		case "END":
+			if len(components) == 0 || components[len(components)-1] != p.value {
+				return nil, fmt.Errorf("%w: line %d: unexpected END:%s", ErrorInvalidICal, l.line, p.value)
+			}
+			components = components[:len(components)-1]
+			if todo != nil && len(components) == 1 {
+				todos = append(todos, *todo)
+				todo = nil
+			}
+		default:
+			if len(components) == 0 {
+				return nil, fmt.Errorf("%w: line %d: not in a calendar", ErrorInvalidICal, l.line)
+			}
+			// Properties of components within the VTODO, such as alarms,
+			// are not the task's.
+			if todo != nil && len(components) == 2 {
+				todo.properties[name] = append(todo.properties[name], p)
+			}
+		}
+	}
+	if len(components) > 0 {
+		return nil, fmt.Errorf("%w: missing END:%s", ErrorInvalidICal, components[len(components)-1])
+	}
+	if lines == nil {
+		return nil, fmt.Errorf("%w: no calendar", ErrorInvalidICal)
+	}
+
+	return todos, nil
+}
+
+// unfoldICalLines joins folded lines, those starting with a space or a tab,
+// back to the line before.
+func unfoldICalLines(r io.Reader) ([]icalLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, MaxDescriptionSize*2)
+
+	var lines []icalLine
+	for number := 1; scanner.Scan(); number++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if number == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if text == "" {
+			continue
+		}
+		if (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		lines = append(lines, icalLine{line: number, text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrorInvalidICal, err)
+	}
+
+	return lines, nil
+}
+
+// parseICalLine splits a content line into its uppercased name, its
+// parameters and its value.
+func parseICalLine(text string) (string, icalProperty, error) {
+	p := icalProperty{params: map[string]string{}}
+	quoted := false
+	colon := -1
+	for i := 0; i < len(text) && colon < 0; i++ {
+		switch text[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return "", p, errors.New("missing colon")
+	}
+
+	head := strings.Split(text[:colon], ";")
+	name := strings.ToUpper(head[0])
+	if name == "" {
+		return "", p, errors.New("missing name")
+	}
+	for _, param := range head[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	p.value = text[colon+1:]
+	if name == "BEGIN" || name == "END" {
+		p.value = strings.ToUpper(p.value)
+	}
+
+	return name, p, nil
+}
+
+func (u *TasksUsecase) icalUid(id int) string {
+	return strconv.Itoa(id) + "@" + u.icalDomain
+}
+
+// icalTaskId returns the id of the task of a UID the usecase issued; UIDs of
+// other domains are of tasks imported from elsewhere.
+func (u *TasksUsecase) icalTaskId(uid string) (int, bool) {
+	digits, ok := strings.CutSuffix(uid, "@"+u.icalDomain)
+	if !ok || digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return 0, false
+	}
+	id, err := strconv.Atoi(digits)
+	return id, err == nil
+}
+
+// planICalTodo validates the component against the stored tasks, returning
+// the task it would become.
+func (u *TasksUsecase) planICalTodo(a Actor, todo icalTodo) (plannedRow, []*ImportErrorOutput) {
+	var errs []*ImportErrorOutput
+	fail := func(column string, err error) {
+		errs = append(errs, &ImportErrorOutput{Row: todo.line, Column: column, Error: err.Error()})
+	}
+	p := plannedRow{line: todo.line, after: &entity.Task{Creator: a.User}}
+
+	if uid, ok := todo.property("UID"); ok {
+		if id, ok := u.icalTaskId(uid.value); ok {
+			task, err := u.findWritable(a, id)
+			if err != nil {
+				fail("uid", err)
+				return p, errs
+			}
+			p.before, p.after = cloneTask(task), task
+		}
+	}
+	task := p.after
+
+	if v, ok := todo.property("SUMMARY"); ok {
+		task.Name = unescapeICalText(v.value)
+	}
+	if strings.TrimSpace(task.Name) == "" {
+		fail("summary", ErrorNameRequired)
+	}
+	if v, ok := todo.property("DESCRIPTION"); ok {
+		description := unescapeICalText(v.value)
+		if err := checkDescription(description); err != nil {
+			fail("description", err)
+		}
+		task.Description = description
+	}
+
+	status, hasStatus := todo.property("STATUS")
+	completed, hasCompleted := todo.property("COMPLETED")
+	if hasStatus || hasCompleted {
+		done := hasCompleted
+		if hasStatus {
+			switch strings.ToUpper(status.value) {
+			case icalStatusDone:
+				done = true
+			case icalStatusOpen, "IN-PROCESS", "CANCELLED":
+				done = false
+			default:
+				fail("status", ErrorInvalidStatus)
+			}
+		}
+		next := task.Status
+		if done {
+			next = entity.StatusDone
+		} else if task.IsDone() {
+			next = entity.StatusOpen
+		}
+		task.SetStatus(next, time.Now())
+	}
+	if hasCompleted && task.IsDone() {
+		completedAt, err := parseICalTime(completed)
+		if err != nil {
+			fail("completed", err)
+		}
+		task.CompletedAt = completedAt
+	}
+	if task.IsDone() && (p.before == nil || !p.before.IsDone()) {
+		if err := u.checkBlockers(task); err != nil {
+			fail("status", err)
+		}
+	}
+	if v, ok := todo.property("CREATED"); ok {
+		createdAt, err := parseICalTime(v)
+		if err != nil {
+			fail("created", err)
+		}
+		task.CreatedAt = createdAt
+	}
+
+	if v, ok := todo.property("PRIORITY"); ok {
+		priority, err := strconv.Atoi(strings.TrimSpace(v.value))
+		if err != nil || priority < 0 || priority > 9 {
+			fail("priority", ErrorInvalidPriority)
+		}
+		task.Priority = fromICalPriority(priority)
+	}
+	if v, ok := todo.property("DUE"); ok {
+		dueAt, err := parseICalTime(v)
+		if err != nil {
+			fail("due", err)
+		}
+		task.DueAt = dueAt
+	}
+	if properties, ok := todo.properties["CATEGORIES"]; ok {
+		var tags []string
+		for _, v := range properties {
+			for _, category := range splitICalList(v.value) {
+				tags = append(tags, unescapeICalText(category))
+			}
+		}
+		task.Tags = entity.NormalizeTags(tags)
+	}
+
+	return p, errs
+}
+
+// fromICalPriority maps 1 to urgent, 2 to 4 to high, 5 to medium, 6 to 9 to
+// low, and 0, meaning undefined, to none.
+func fromICalPriority(priority int) int {
+	switch {
+	case priority <= 0:
+		return entity.PriorityNone
+	case priority == 1:
+		return entity.PriorityUrgent
+	case priority < 5:
+		return entity.PriorityHigh
+	case priority == 5:
+		return entity.PriorityMedium
+	default:
+		return entity.PriorityLow
+	}
+}
@@ -0,0 +1,254 @@
+package tasks_test
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+var dtstampPattern = regexp.MustCompile(`DTSTAMP:[0-9]{8}T[0-9]{6}Z`)
+
+func Test_ExportICal(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐, 洗碗", Description: "第一行\n第二行", Priority: 3, Tags: []string{"errand", "food"}, DueAt: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), CreatedAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: strings.Repeat("拖地", 20), Status: 1, DueAt: time.Date(2024, 1, 5, 9, 30, 0, 0, time.UTC), CompletedAt: time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)})
+	repo.PopulateData(repository.TaskSchema{Id: 3, Name: "倒垃圾", DeletedAt: time.Now()})
+	usecase := tasks.InitTasksUsecase(repo)
+
+	var got bytes.Buffer
+	err := usecase.ExportICal(tasks.Actor{}, &got)
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(dtstampPattern.ReplaceAllString(got.String(), "DTSTAMP:X"), strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//whostodo//whostodo//EN",
+		"CALSCALE:GREGORIAN",
+		"BEGIN:VTODO",
+		"UID:1@whostodo",
+		"DTSTAMP:X",
+		"CREATED:20240101T090000Z",
+		`SUMMARY:買晚餐\, 洗碗`,
+		`DESCRIPTION:第一行\n第二行`,
+		"STATUS:NEEDS-ACTION",
+		"DUE;VALUE=DATE:20240105",
+		"PRIORITY:3",
+		"CATEGORIES:errand,food",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:2@whostodo",
+		"DTSTAMP:X",
+		"SUMMARY:拖地拖地拖地拖地拖地拖地拖地拖地拖地拖地拖地",
+		" 拖地拖地拖地拖地拖地拖地拖地拖地拖地",
+		"STATUS:COMPLETED",
+		"COMPLETED:20240102T090000Z",
+		"DUE:20240105T093000Z",
+		"END:VTODO",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n"))
+}
+
+func Test_ImportICal(t *testing.T) {
+	dueAt := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	taipei, _ := time.LoadLocation("Asia/Taipei")
+	dueAtTaipei := time.Date(2024, 1, 5, 9, 0, 0, 0, taipei)
+
+	tests := []struct {
+		name        string
+		ical        string
+		input       tasks.ImportTasksInput
+		expected    *tasks.ImportOutput
+		tasks       []*tasks.TaskOutput
+		expectError bool
+		error       error
+	}{
+		{
+			name: "creates and updates tasks",
+			ical: strings.Join([]string{
+				"BEGIN:VCALENDAR",
+				"BEGIN:VTODO",
+				"UID:1@default.example.com",
+				"STATUS:COMPLETED",
+				"END:VTODO",
+				"BEGIN:VTODO",
+				"UID:abc@example.com",
+				`SUMMARY:洗碗\, 拖`,
+				" 地",
+				"DUE;VALUE=DATE:20240105",
+				"PRIORITY:2",
+				`CATEGORIES:Home,a\,b`,
+				"CATEGORIES:chore",
+				"BEGIN:VALARM",
+				"DESCRIPTION:提醒",
+				"END:VALARM",
+				"END:VTODO",
+				"BEGIN:VEVENT",
+				"SUMMARY:開會",
+				"END:VEVENT",
+				"END:VCALENDAR",
+			}, "\r\n"),
+			expected: &tasks.ImportOutput{
+				Created: 1,
+				Updated: 1,
+				Errors:  []*tasks.ImportErrorOutput{},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Status: 1, Priority: "high", Creator: "alice"},
+				{Id: 2, Name: "洗碗, 拖地", Priority: "high", Tags: []string{"a,b", "chore", "home"}, DueAt: &dueAt, Creator: "alice"},
+			},
+		},
+		{
+			name: "reads local times in their timezone",
+			ical: "BEGIN:VCALENDAR\nBEGIN:VTODO\nSUMMARY:洗碗\nDUE;TZID=Asia/Taipei:20240105T090000\nEND:VTODO\nEND:VCALENDAR\n",
+			expected: &tasks.ImportOutput{
+				Created: 1,
+				Errors:  []*tasks.ImportErrorOutput{},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Creator: "alice"},
+				{Id: 2, Name: "洗碗", DueAt: &dueAtTaipei, Creator: "alice"},
+			},
+		},
+		{
+			name: "creates tasks of UIDs issued elsewhere",
+			ical: strings.Join([]string{
+				"BEGIN:VCALENDAR",
+				"BEGIN:VTODO",
+				"UID:1@team-a.example.com",
+				"SUMMARY:開會",
+				"END:VTODO",
+				"BEGIN:VTODO",
+				"UID:1@example.com",
+				"SUMMARY:回信",
+				"END:VTODO",
+				"BEGIN:VTODO",
+				"UID:+1@default.example.com",
+				"SUMMARY:洗碗",
+				"END:VTODO",
+				"END:VCALENDAR",
+			}, "\n"),
+			expected: &tasks.ImportOutput{
+				Created: 3,
+				Errors:  []*tasks.ImportErrorOutput{},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Creator: "alice"},
+				{Id: 2, Name: "開會", Creator: "alice"},
+				{Id: 3, Name: "回信", Creator: "alice"},
+				{Id: 4, Name: "洗碗", Creator: "alice"},
+			},
+		},
+		{
+			name: "reports component errors on dry run",
+			ical: strings.Join([]string{
+				"BEGIN:VCALENDAR",
+				"BEGIN:VTODO",
+				"UID:9@default.example.com",
+				"END:VTODO",
+				"BEGIN:VTODO",
+				"STATUS:DONE",
+				"PRIORITY:high",
+				"DUE:tomorrow",
+				"END:VTODO",
+				"END:VCALENDAR",
+			}, "\n"),
+			input: tasks.ImportTasksInput{DryRun: true},
+			expected: &tasks.ImportOutput{
+				DryRun: true,
+				Errors: []*tasks.ImportErrorOutput{
+					{Row: 2, Column: "uid", Error: "Task not found"},
+					{Row: 5, Column: "summary", Error: "Name is required"},
+					{Row: 5, Column: "status", Error: "Invalid status"},
+					{Row: 5, Column: "priority", Error: "Invalid priority"},
+					{Row: 5, Column: "due", Error: "Not a date"},
+				},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Creator: "alice"},
+			},
+		},
+		{
+			name:        "imports nothing when any component is invalid",
+			ical:        "BEGIN:VCALENDAR\nBEGIN:VTODO\nSUMMARY:洗碗\nEND:VTODO\nBEGIN:VTODO\nEND:VTODO\nEND:VCALENDAR\n",
+			expected:    &tasks.ImportOutput{Errors: []*tasks.ImportErrorOutput{{Row: 5, Column: "summary", Error: "Name is required"}}},
+			tasks:       []*tasks.TaskOutput{{Id: 1, Name: "買早餐", Priority: "high", Creator: "alice"}},
+			expectError: true,
+			error:       tasks.ErrorInvalidRows,
+		},
+		{
+			name:        "returns error on content outside a calendar",
+			ical:        "BEGIN:VTODO\nSUMMARY:洗碗\nEND:VTODO\n",
+			expectError: true,
+			error:       tasks.ErrorInvalidICal,
+		},
+		{
+			name:        "returns error on unterminated calendar",
+			ical:        "BEGIN:VCALENDAR\nBEGIN:VTODO\nSUMMARY:洗碗\n",
+			expectError: true,
+			error:       tasks.ErrorInvalidICal,
+		},
+		{
+			name:        "returns error on a line without colon",
+			ical:        "BEGIN:VCALENDAR\nSUMMARY\nEND:VCALENDAR\n",
+			expectError: true,
+			error:       tasks.ErrorInvalidICal,
+		},
+		{
+			name:        "returns error on empty input",
+			ical:        "",
+			expectError: true,
+			error:       tasks.ErrorInvalidICal,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Priority: 3, Position: "a", Creator: "alice"})
+			usecase := tasks.InitTasksUsecase(repo, tasks.WithICalDomain("default.example.com"))
+			a := tasks.Actor{User: "alice"}
+
+			got, err := usecase.ImportICal(a, strings.NewReader(tc.ical), &tc.input)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			util.AssertEqual(t)(got, tc.expected)
+			if tc.tasks != nil {
+				util.AssertEqual(t)(usecase.ListTasks(a, &tasks.ListTasksInput{}), tc.tasks)
+			}
+		})
+	}
+}
+
+func Test_ExportImportICal_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐; 洗碗", Description: "第一行\n第二行", Priority: 4, Position: "a", Tags: []string{"a,b", "errand"}, DueAt: time.Date(2024, 1, 5, 9, 30, 0, 0, time.UTC), CreatedAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: strings.Repeat("拖地", 40), Status: 1, Position: "b", CompletedAt: time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)})
+	usecase := tasks.InitTasksUsecase(repo)
+	a := tasks.Actor{}
+	before := map[int]repository.TaskSchema{1: repo.Data[1], 2: repo.Data[2]}
+
+	var exported bytes.Buffer
+	usecase.ExportICal(a, &exported)
+	got, err := usecase.ImportICal(a, &exported, &tasks.ImportTasksInput{})
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(got.Updated, 2)
+	util.AssertEqual(t)(repo.Data, before)
+}
@@ -0,0 +1,159 @@
+package tasks
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/dannyh79/whostodo/internal/rank"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+// SortByPriority lists tasks by descending priority, keeping their manual
+// order within a priority.
+const SortByPriority = "priority"
+
+var (
+	ErrorInvalidPriority    = errors.New("Invalid priority")
+	ErrorInvalidMove        = errors.New("Either before or after is required")
+	ErrorMoveTargetNotFound = errors.New("Move target not found")
+)
+
+type MoveTaskInput struct {
+	// Id of the task to move in front of; exclusive with After.
+	Before *int `json:"before"`
+	// Id of the task to move behind; exclusive with Before.
+	After *int `json:"after"`
+}
+
+// MoveTask places the task right before or after another task. Only the
+// moved task gets a new position, unless the tasks of the target's list the
+// actor may change have none to fit in between yet, or the new one would grow
+// past rank.MaxLength.
+func (u *TasksUsecase) MoveTask(a Actor, id int, i *MoveTaskInput) (*TaskOutput, error) {
+	if (i.Before == nil) == (i.After == nil) {
+		return nil, ErrorInvalidMove
+	}
+	targetId := i.Before
+	if i.After != nil {
+		targetId = i.After
+	}
+	if *targetId == id {
+		return nil, ErrorInvalidMove
+	}
+
+	task, err := u.findWritable(a, id)
+	if err != nil {
+		return nil, err
+	}
+	target, err := u.find(a, *targetId)
+	if err != nil {
+		return nil, ErrorMoveTargetNotFound
+	}
+	if !u.canWrite(a, target) {
+		return nil, ErrorForbidden
+	}
+
+	var others []*entity.Task
+	slot := -1
+	for _, t := range u.repo.ListAll() {
+		if t.Id == id || t.ListId != target.ListId || !u.canWrite(a, t) {
+			continue
+		}
+		if t.Id == target.Id {
+			slot = len(others)
+		}
+		others = append(others, t)
+	}
+	if i.After != nil {
+		slot += 1
+	}
+
+	var ops []operation
+	position, err := positionAt(others, slot)
+	if err != nil || len(position) > rank.MaxLength {
+		if ops, err = u.rerank(others); err != nil {
+			return nil, err
+		}
+		if position, err = positionAt(others, slot); err != nil {
+			return nil, err
+		}
+	}
+
+	before := cloneTask(task)
+	task.Position = position
+	updated, err := u.repo.Update(task)
+	if err != nil {
+		return nil, err
+	}
+
+	u.record(a, append(ops, operation{kind: updateOperation, before: before, after: cloneTask(updated)})...)
+	return u.present(a, updated), nil
+}
+
+// nextPosition returns a position after every task.
+func (u *TasksUsecase) nextPosition() string {
+	tasks := u.repo.ListAll()
+	if len(tasks) == 0 {
+		return rank.After("")
+	}
+	return rank.After(tasks[len(tasks)-1].Position)
+}
+
+// rerank gives the tasks fresh positions in their current order.
+func (u *TasksUsecase) rerank(tasks []*entity.Task) ([]operation, error) {
+	var ops []operation
+	var position string
+	for _, t := range tasks {
+		position = rank.After(position)
+		before := cloneTask(t)
+		t.Position = position
+		updated, err := u.repo.Update(t)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, operation{kind: updateOperation, before: before, after: cloneTask(updated)})
+	}
+	return ops, nil
+}
+
+// positionAt returns a position between the tasks around the slot. Tasks
+// without a position, created before ordering existed, cannot be fitted in.
+func positionAt(tasks []*entity.Task, slot int) (string, error) {
+	var prev, next string
+	if slot > 0 {
+		prev = tasks[slot-1].Position
+	}
+	if slot < len(tasks) {
+		next = tasks[slot].Position
+	}
+	if (slot > 0 && prev == "") || (slot < len(tasks) && next == "") {
+		return "", rank.ErrorOutOfOrder
+	}
+	return rank.Between(prev, next)
+}
+
+func toPriority(name string) (int, error) {
+	if name == "" {
+		return entity.PriorityNone, nil
+	}
+	priority, ok := entity.ParsePriority(name)
+	if !ok {
+		return entity.PriorityNone, ErrorInvalidPriority
+	}
+	return priority, nil
+}
+
+// toPriorityOutput leaves out PriorityNone.
+func toPriorityOutput(priority int) string {
+	name := entity.PriorityName(priority)
+	if name == entity.PriorityName(entity.PriorityNone) {
+		return ""
+	}
+	return name
+}
+
+func sortByPriority(tasks []*entity.Task) []*entity.Task {
+	sorted := append([]*entity.Task(nil), tasks...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Priority > sorted[j].Priority })
+	return sorted
+}
@@ -0,0 +1,234 @@
+package tasks_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/rank"
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func newOrderingRepo() *util.MockTaskRepository {
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Position: "F", Priority: entity.PriorityLow})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: "買午餐", Position: "V", Priority: entity.PriorityUrgent})
+	repo.PopulateData(repository.TaskSchema{Id: 3, Name: "買晚餐", Position: "k", Priority: entity.PriorityLow})
+	return repo
+}
+
+func listedIds(ts []*tasks.TaskOutput) []int {
+	var ids []int
+	for _, t := range ts {
+		ids = append(ids, t.Id)
+	}
+	return ids
+}
+
+func Test_MoveTask(t *testing.T) {
+	one, two, three, nine := 1, 2, 3, 9
+
+	tests := []struct {
+		name        string
+		param       int
+		payload     tasks.MoveTaskInput
+		expected    []int
+		expectError bool
+		error       error
+	}{
+		{
+			name:     "moves task before another",
+			param:    3,
+			payload:  tasks.MoveTaskInput{Before: &one},
+			expected: []int{3, 1, 2},
+		},
+		{
+			name:     "moves task after another",
+			param:    1,
+			payload:  tasks.MoveTaskInput{After: &two},
+			expected: []int{2, 1, 3},
+		},
+		{
+			name:     "moves task to the end",
+			param:    1,
+			payload:  tasks.MoveTaskInput{After: &three},
+			expected: []int{2, 3, 1},
+		},
+		{
+			name:        "returns error without a target",
+			param:       1,
+			payload:     tasks.MoveTaskInput{},
+			expectError: true,
+			error:       tasks.ErrorInvalidMove,
+		},
+		{
+			name:        "returns error with both targets",
+			param:       1,
+			payload:     tasks.MoveTaskInput{Before: &two, After: &three},
+			expectError: true,
+			error:       tasks.ErrorInvalidMove,
+		},
+		{
+			name:        "returns error moving task relative to itself",
+			param:       1,
+			payload:     tasks.MoveTaskInput{Before: &one},
+			expectError: true,
+			error:       tasks.ErrorInvalidMove,
+		},
+		{
+			name:        "returns error with unknown target",
+			param:       1,
+			payload:     tasks.MoveTaskInput{Before: &nine},
+			expectError: true,
+			error:       tasks.ErrorMoveTargetNotFound,
+		},
+		{
+			name:        "returns error when not found",
+			param:       9,
+			payload:     tasks.MoveTaskInput{Before: &one},
+			expectError: true,
+			error:       repository.ErrorNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newOrderingRepo()
+			usecase := tasks.InitTasksUsecase(repo)
+			_, err := usecase.MoveTask(tasks.Actor{}, tc.param, &tc.payload)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				if err != nil {
+					t.Error(err)
+				}
+				util.AssertEqual(t)(listedIds(usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{})), tc.expected)
+			}
+		})
+	}
+
+	t.Run("rewrites only the moved task", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newOrderingRepo()
+		usecase := tasks.InitTasksUsecase(repo)
+		usecase.MoveTask(tasks.Actor{}, 3, &tasks.MoveTaskInput{Before: &two})
+
+		util.AssertEqual(t)(repo.Data[1].Position, "F")
+		util.AssertEqual(t)(repo.Data[2].Position, "V")
+		util.AssertEqual(t)(repo.Data[3].Position, "N")
+	})
+
+	t.Run("ranks tasks without positions before moving", func(t *testing.T) {
+		t.Parallel()
+
+		repo := util.InitMockTaskRepository()
+		repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐"})
+		repo.PopulateData(repository.TaskSchema{Id: 2, Name: "買午餐"})
+		repo.PopulateData(repository.TaskSchema{Id: 3, Name: "買晚餐"})
+		usecase := tasks.InitTasksUsecase(repo)
+		_, err := usecase.MoveTask(tasks.Actor{}, 3, &tasks.MoveTaskInput{After: &one})
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(listedIds(usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{})), []int{1, 3, 2})
+	})
+
+	t.Run("reranks tasks once positions grow too long", func(t *testing.T) {
+		t.Parallel()
+
+		repo := util.InitMockTaskRepository()
+		repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Position: "V0000V"})
+		repo.PopulateData(repository.TaskSchema{Id: 2, Name: "買午餐", Position: "V0000V0000000V"})
+		repo.PopulateData(repository.TaskSchema{Id: 3, Name: "買晚餐", Position: "V0000V00001"})
+		usecase := tasks.InitTasksUsecase(repo)
+		_, err := usecase.MoveTask(tasks.Actor{}, 3, &tasks.MoveTaskInput{Before: &two})
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(listedIds(usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{})), []int{1, 3, 2})
+		for _, row := range repo.Data {
+			if len(row.Position) > rank.MaxLength {
+				t.Errorf("position %q of task %d is too long", row.Position, row.Id)
+			}
+		}
+	})
+
+	t.Run("undoes reranking and move together", func(t *testing.T) {
+		t.Parallel()
+
+		alice := tasks.Actor{SessionId: "alice"}
+		repo := util.InitMockTaskRepository()
+		repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐"})
+		repo.PopulateData(repository.TaskSchema{Id: 2, Name: "買午餐"})
+		usecase := tasks.InitTasksUsecase(repo)
+		usecase.MoveTask(alice, 2, &tasks.MoveTaskInput{Before: &one})
+		usecase.Undo(alice)
+
+		util.AssertEqual(t)(repo.Data[1].Position, "")
+		util.AssertEqual(t)(repo.Data[2].Position, "")
+	})
+}
+
+func Test_CreateTaskPosition(t *testing.T) {
+	t.Parallel()
+
+	repo := newOrderingRepo()
+	usecase := tasks.InitTasksUsecase(repo)
+	got, _ := usecase.CreateTask(tasks.Actor{}, &tasks.CreateTaskInput{Name: "買宵夜"})
+
+	util.AssertEqual(t)(listedIds(usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{})), []int{1, 2, 3, got.Id})
+}
+
+func Test_TaskPriority(t *testing.T) {
+	t.Run("lists tasks by priority keeping manual order", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := tasks.InitTasksUsecase(newOrderingRepo())
+		got := usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{Sort: tasks.SortByPriority})
+
+		util.AssertEqual(t)(listedIds(got), []int{2, 1, 3})
+		util.AssertEqual(t)(got[0].Priority, "urgent")
+	})
+
+	t.Run("updates priority", func(t *testing.T) {
+		t.Parallel()
+
+		high := "High"
+		usecase := tasks.InitTasksUsecase(newOrderingRepo())
+		got, err := usecase.UpdateTask(tasks.Actor{}, 1, &tasks.UpdateTaskInput{Name: "買早餐", Priority: &high})
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(got.Priority, "high")
+	})
+
+	t.Run("keeps priority when not given", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := tasks.InitTasksUsecase(newOrderingRepo())
+		got, _ := usecase.UpdateTask(tasks.Actor{}, 1, &tasks.UpdateTaskInput{Name: "買早餐"})
+
+		util.AssertEqual(t)(got.Priority, "low")
+	})
+
+	t.Run("lists priorities out of range as none", func(t *testing.T) {
+		t.Parallel()
+
+		repo := util.InitMockTaskRepository()
+		repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Priority: 9})
+		got := tasks.InitTasksUsecase(repo).ListTasks(tasks.Actor{}, &tasks.ListTasksInput{})
+
+		util.AssertEqual(t)(got[0].Priority, "")
+	})
+
+	t.Run("returns error on unknown priority", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := tasks.InitTasksUsecase(newOrderingRepo())
+		_, err := usecase.CreateTask(tasks.Actor{}, &tasks.CreateTaskInput{Name: "買宵夜", Priority: "asap"})
+
+		util.AssertErrorEqual(t)(err, tasks.ErrorInvalidPriority)
+	})
+}
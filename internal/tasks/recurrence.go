@@ -0,0 +1,109 @@
+package tasks
+
+import (
+	"strings"
+	"time"
+
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+var ErrorInvalidRecurrence = entity.ErrorInvalidRecurrence
+
+type RecurrenceInput struct {
+	// One of daily, weekly or monthly; empty removes the recurrence.
+	Frequency string `json:"frequency"`
+	// Defaults to 1.
+	Interval int `json:"interval"`
+	// Two-letter RRULE codes such as "MO"; weekly recurrences only.
+	Weekdays []string   `json:"weekdays"`
+	Until    *time.Time `json:"until"`
+	// Occurrences left including this one; 0 repeats forever.
+	Count int `json:"count"`
+}
+
+type RecurrenceOutput struct {
+	Frequency string     `json:"frequency"`
+	Interval  int        `json:"interval"`
+	Weekdays  []string   `json:"weekdays,omitempty"`
+	Until     *time.Time `json:"until,omitempty"`
+	Count     int        `json:"count,omitempty"`
+}
+
+// spawnNext creates the next occurrence of the task just completed, which
+// takes the schedule over, unless the recurrence ended. Tasks without a due
+// date recur from the time they are completed.
+func (u *TasksUsecase) spawnNext(completed *entity.Task, recurrence *entity.Recurrence) (operation, bool) {
+	due := completed.DueAt
+	if due.IsZero() {
+		due = time.Now().UTC()
+	}
+	following, next, ok := recurrence.Next(due)
+	if !ok {
+		return operation{}, false
+	}
+
+	spawned := u.repo.Save(&entity.Task{
+		Name:        completed.Name,
+		Description: completed.Description,
+		Priority:    completed.Priority,
+		Position:    u.nextPosition(),
+		ListId:      completed.ListId,
+		ParentId:    completed.ParentId,
+		Tags:        append([]string(nil), completed.Tags...),
+		DueAt:       next,
+		Recurrence:  following,
+		Creator:     completed.Creator,
+		Assignee:    completed.Assignee,
+		CreatedAt:   time.Now(),
+	})
+	return operation{kind: createOperation, after: cloneTask(&spawned)}, true
+}
+
+// toRecurrence returns nil for a missing input or an empty frequency.
+func toRecurrence(i *RecurrenceInput) (*entity.Recurrence, error) {
+	if i == nil || i.Frequency == "" {
+		return nil, nil
+	}
+
+	var weekdays []time.Weekday
+	for _, code := range i.Weekdays {
+		day, ok := entity.ParseWeekday(code)
+		if !ok {
+			return nil, ErrorInvalidRecurrence
+		}
+		weekdays = append(weekdays, day)
+	}
+	var until time.Time
+	if i.Until != nil {
+		until = *i.Until
+	}
+
+	return entity.NewRecurrence(strings.ToLower(i.Frequency), i.Interval, weekdays, until, i.Count)
+}
+
+func toRecurrenceOutput(r *entity.Recurrence) *RecurrenceOutput {
+	if r == nil {
+		return nil
+	}
+
+	output := &RecurrenceOutput{
+		Frequency: r.Frequency,
+		Interval:  r.Interval,
+		Count:     r.Count,
+	}
+	for _, day := range r.Weekdays {
+		output.Weekdays = append(output.Weekdays, entity.WeekdayCode(day))
+	}
+	if !r.Until.IsZero() {
+		until := r.Until
+		output.Until = &until
+	}
+	return output
+}
+
+func toDueAt(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
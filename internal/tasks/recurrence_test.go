@@ -0,0 +1,147 @@
+package tasks_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+var rotationDue = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+func newRecurrenceRepo(r *entity.Recurrence) *util.MockTaskRepository {
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "輪值", ListId: 2, Tags: []string{"ops"}, DueAt: rotationDue, Recurrence: r})
+	return repo
+}
+
+func Test_CompleteRecurringTask(t *testing.T) {
+	weekly := &entity.Recurrence{Frequency: entity.Weekly, Interval: 1}
+
+	t.Run("spawns next occurrence with due date advanced", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newRecurrenceRepo(weekly)
+		usecase := tasks.InitTasksUsecase(repo)
+		got, err := usecase.UpdateTask(tasks.Actor{}, 1, &tasks.UpdateTaskInput{Name: "輪值", Status: entity.StatusDone})
+
+		util.AssertErrorEqual(t)(err, nil)
+		due := rotationDue
+		util.AssertEqual(t)(*got, tasks.TaskOutput{Id: 1, Name: "輪值", Status: entity.StatusDone, ListId: 2, Tags: []string{"ops"}, DueAt: &due})
+
+		next := rotationDue.AddDate(0, 0, 7)
+		util.AssertEqual(t)(usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{})[1], &tasks.TaskOutput{
+			Id:         2,
+			Name:       "輪值",
+			ListId:     2,
+			Tags:       []string{"ops"},
+			DueAt:      &next,
+			Recurrence: &tasks.RecurrenceOutput{Frequency: entity.Weekly, Interval: 1},
+		})
+	})
+
+	t.Run("stops spawning after the last occurrence", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newRecurrenceRepo(&entity.Recurrence{Frequency: entity.Daily, Interval: 1, Count: 1})
+		usecase := tasks.InitTasksUsecase(repo)
+		_, err := usecase.UpdateTask(tasks.Actor{}, 1, &tasks.UpdateTaskInput{Name: "輪值", Status: entity.StatusDone})
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(len(repo.Data), 1)
+	})
+
+	t.Run("does not spawn when renaming a done task", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newRecurrenceRepo(weekly)
+		usecase := tasks.InitTasksUsecase(repo)
+		usecase.UpdateTask(tasks.Actor{}, 1, &tasks.UpdateTaskInput{Name: "輪值", Status: entity.StatusDone})
+		usecase.UpdateTask(tasks.Actor{}, 1, &tasks.UpdateTaskInput{Name: "值班", Status: entity.StatusDone})
+
+		util.AssertEqual(t)(len(repo.Data), 2)
+	})
+
+	t.Run("undoes completion and spawn together", func(t *testing.T) {
+		t.Parallel()
+
+		alice := tasks.Actor{SessionId: "alice"}
+		repo := newRecurrenceRepo(weekly)
+		usecase := tasks.InitTasksUsecase(repo)
+		usecase.UpdateTask(alice, 1, &tasks.UpdateTaskInput{Name: "輪值", Status: entity.StatusDone})
+		_, err := usecase.Undo(alice)
+
+		util.AssertErrorEqual(t)(err, nil)
+		got := usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{})
+		util.AssertEqual(t)(len(got), 1)
+		util.AssertEqual(t)(got[0].Recurrence, &tasks.RecurrenceOutput{Frequency: entity.Weekly, Interval: 1})
+	})
+
+	t.Run("spawns next occurrence when completed by an import", func(t *testing.T) {
+		t.Parallel()
+
+		repo := newRecurrenceRepo(weekly)
+		usecase := tasks.InitTasksUsecase(repo)
+		_, err := usecase.ImportCSV(tasks.Actor{}, strings.NewReader("id,status\n1,1\n"), &tasks.ImportTasksInput{})
+
+		util.AssertErrorEqual(t)(err, nil)
+		next := rotationDue.AddDate(0, 0, 7)
+		got := usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{})
+		util.AssertEqual(t)(len(got), 2)
+		util.AssertEqual(t)(got[0].Recurrence == nil, true)
+		util.AssertEqual(t)(*got[1].DueAt, next)
+		util.AssertEqual(t)(got[1].Recurrence, &tasks.RecurrenceOutput{Frequency: entity.Weekly, Interval: 1})
+	})
+}
+
+func Test_CreateRecurringTask(t *testing.T) {
+	until := rotationDue.AddDate(0, 3, 0)
+
+	tests := []struct {
+		name        string
+		payload     tasks.RecurrenceInput
+		expected    *tasks.RecurrenceOutput
+		expectError bool
+	}{
+		{
+			name:     "returns task with recurrence",
+			payload:  tasks.RecurrenceInput{Frequency: "Weekly", Weekdays: []string{"mo", "TH"}, Until: &until},
+			expected: &tasks.RecurrenceOutput{Frequency: entity.Weekly, Interval: 1, Weekdays: []string{"MO", "TH"}, Until: &until},
+		},
+		{
+			name:     "returns task without recurrence on empty frequency",
+			payload:  tasks.RecurrenceInput{},
+			expected: nil,
+		},
+		{
+			name:        "returns error on unknown weekday",
+			payload:     tasks.RecurrenceInput{Frequency: entity.Weekly, Weekdays: []string{"XX"}},
+			expectError: true,
+		},
+		{
+			name:        "returns error on negative interval",
+			payload:     tasks.RecurrenceInput{Frequency: entity.Daily, Interval: -1},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase := tasks.InitTasksUsecase(util.InitMockTaskRepository())
+			got, err := usecase.CreateTask(tasks.Actor{}, &tasks.CreateTaskInput{Name: "輪值", DueAt: &rotationDue, Recurrence: &tc.payload})
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tasks.ErrorInvalidRecurrence)
+			} else {
+				util.AssertErrorEqual(t)(err, nil)
+				util.AssertEqual(t)(got.Recurrence, tc.expected)
+			}
+		})
+	}
+}
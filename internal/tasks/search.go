@@ -0,0 +1,31 @@
+package tasks
+
+import (
+	"errors"
+
+	"github.com/dannyh79/whostodo/internal/search"
+)
+
+var ErrorEmptyQuery = errors.New("Search query is empty")
+
+type SearchTasksInput struct {
+	Query string `form:"q"`
+}
+
+// SearchTasks returns tasks matching every word of the query, most relevant
+// first.
+func (u *TasksUsecase) SearchTasks(a Actor, i *SearchTasksInput) ([]*TaskOutput, error) {
+	if len(search.Tokenize(i.Query)) == 0 {
+		return nil, ErrorEmptyQuery
+	}
+
+	var output = make([]*TaskOutput, 0)
+	children := childrenIndex(u.listAll(a))
+	for _, task := range u.visible(a, u.repo.Search(i.Query)) {
+		t := toTaskOutput(task)
+		t.Progress = progress(task.Id, children)
+		output = append(output, t)
+	}
+
+	return output, nil
+}
@@ -0,0 +1,34 @@
+package tasks_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_SearchTasks(t *testing.T) {
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐"})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐"})
+	repo.PopulateData(repository.TaskSchema{Id: 3, Name: "煮晚餐", ParentId: 2})
+
+	t.Run("returns matching tasks", func(t *testing.T) {
+		usecase := tasks.InitTasksUsecase(repo)
+		got, err := usecase.SearchTasks(tasks.Actor{}, &tasks.SearchTasksInput{Query: "晚餐"})
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(got, []*tasks.TaskOutput{
+			{Id: 2, Name: "買晚餐", Progress: &tasks.ProgressOutput{Done: 0, Total: 1}},
+			{Id: 3, Name: "煮晚餐", ParentId: 2},
+		})
+	})
+
+	t.Run("returns error on empty query", func(t *testing.T) {
+		usecase := tasks.InitTasksUsecase(repo)
+		_, err := usecase.SearchTasks(tasks.Actor{}, &tasks.SearchTasksInput{Query: "  "})
+
+		util.AssertErrorEqual(t)(err, tasks.ErrorEmptyQuery)
+	})
+}
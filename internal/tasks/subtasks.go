@@ -0,0 +1,180 @@
+package tasks
+
+import (
+	"errors"
+
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+const (
+	// Deleting a task moves its subtasks up to the task's own parent.
+	Reparent = "reparent"
+	// Deleting a task moves its subtasks into the trash along with it.
+	Cascade = "cascade"
+)
+
+var (
+	ErrorParentNotFound    = errors.New("Parent task not found")
+	ErrorCycle             = errors.New("Task cannot be nested under itself")
+	ErrorUnknownDeleteMode = errors.New("Unknown delete mode")
+)
+
+type ProgressOutput struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+type TaskTreeOutput struct {
+	TaskOutput
+	Children []*TaskTreeOutput `json:"children"`
+}
+
+type DeleteTaskInput struct {
+	// Either Reparent or Cascade; empty defaults to Reparent.
+	Children string `form:"children"`
+}
+
+// GetTaskTree returns the task along with its subtasks, nested to any depth.
+func (u *TasksUsecase) GetTaskTree(a Actor, id int) (*TaskTreeOutput, error) {
+	task, err := u.find(a, id)
+	if err != nil {
+		return nil, err
+	}
+
+	children := childrenIndex(u.listAll(a))
+	return toTaskTreeOutput(task, children), nil
+}
+
+// checkParent reports whether the task can be nested under the parent, that
+// is the parent exists, the actor may see it and it is not the task itself or
+// one of its subtasks.
+func (u *TasksUsecase) checkParent(a Actor, id int, parentId int) error {
+	return checkParentBy(id, parentId, func(id int) (*entity.Task, error) {
+		return u.find(a, id)
+	})
+}
+
+// checkParentBy is checkParent looking tasks up by find. Ancestors already
+// nested in a cycle are reported as one too.
+func checkParentBy(id int, parentId int, find func(int) (*entity.Task, error)) error {
+	seen := map[int]bool{}
+	for parentId != 0 {
+		if parentId == id || seen[parentId] {
+			return ErrorCycle
+		}
+		seen[parentId] = true
+		parent, err := find(parentId)
+		if err != nil {
+			return ErrorParentNotFound
+		}
+		parentId = parent.ParentId
+	}
+	return nil
+}
+
+// canDetachChildren reports whether the actor may change every subtask that
+// deleting the task would: its children, or all its descendants on cascade.
+func (u *TasksUsecase) canDetachChildren(a Actor, task *entity.Task, mode string) bool {
+	children := childrenIndex(u.repo.ListAll())
+	var walk func(int) bool
+	walk = func(id int) bool {
+		for _, child := range children[id] {
+			if !u.canWrite(a, child) {
+				return false
+			}
+			if mode == Cascade && !walk(child.Id) {
+				return false
+			}
+		}
+		return true
+	}
+	return walk(task.Id)
+}
+
+// detachChildren trashes or reparents the subtasks of a task being deleted,
+// returning the operations made.
+func (u *TasksUsecase) detachChildren(task *entity.Task, mode string) ([]operation, error) {
+	var ops []operation
+	for _, child := range childrenIndex(u.repo.ListAll())[task.Id] {
+		if mode == Cascade {
+			grandchildren, err := u.detachChildren(child, mode)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, grandchildren...)
+			if err := u.repo.Delete(child); err != nil {
+				return nil, err
+			}
+			ops = append(ops, operation{kind: deleteOperation, before: child})
+			continue
+		}
+
+		before := cloneTask(child)
+		child.ParentId = task.ParentId
+		updated, err := u.repo.Update(child)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, operation{kind: updateOperation, before: before, after: cloneTask(updated)})
+	}
+	return ops, nil
+}
+
+func childrenIndex(tasks []*entity.Task) map[int][]*entity.Task {
+	index := map[int][]*entity.Task{}
+	for _, task := range tasks {
+		if task.ParentId != 0 {
+			index[task.ParentId] = append(index[task.ParentId], task)
+		}
+	}
+	return index
+}
+
+// progress counts the done tasks among every subtask of the task, or returns
+// nil for a task without subtasks. Subtasks nested in a cycle are counted
+// once.
+func progress(id int, children map[int][]*entity.Task) *ProgressOutput {
+	if len(children[id]) == 0 {
+		return nil
+	}
+
+	var p ProgressOutput
+	seen := map[int]bool{id: true}
+	var walk func(int)
+	walk = func(id int) {
+		for _, child := range children[id] {
+			if seen[child.Id] {
+				continue
+			}
+			seen[child.Id] = true
+			p.Total += 1
+			if child.IsDone() {
+				p.Done += 1
+			}
+			walk(child.Id)
+		}
+	}
+	walk(id)
+	return &p
+}
+
+// toTaskTreeOutput nests the subtasks of the task, leaving out those nested
+// in a cycle once seen.
+func toTaskTreeOutput(t *entity.Task, children map[int][]*entity.Task) *TaskTreeOutput {
+	return toTaskTreeOutputOnce(t, children, map[int]bool{})
+}
+
+func toTaskTreeOutputOnce(t *entity.Task, children map[int][]*entity.Task, seen map[int]bool) *TaskTreeOutput {
+	seen[t.Id] = true
+	output := &TaskTreeOutput{
+		TaskOutput: *toTaskOutput(t),
+		Children:   make([]*TaskTreeOutput, 0),
+	}
+	output.Progress = progress(t.Id, children)
+	for _, child := range children[t.Id] {
+		if !seen[child.Id] {
+			output.Children = append(output.Children, toTaskTreeOutputOnce(child, children, seen))
+		}
+	}
+	return output
+}
@@ -0,0 +1,236 @@
+package tasks_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func newSubtasksRepo() *util.MockTaskRepository {
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "搬家"})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: "打包", ParentId: 1, Status: 1})
+	repo.PopulateData(repository.TaskSchema{Id: 3, Name: "打包廚房", ParentId: 2})
+	repo.PopulateData(repository.TaskSchema{Id: 4, Name: "叫車", ParentId: 1})
+	return repo
+}
+
+func Test_NestTask(t *testing.T) {
+	parent, self, descendant, unknown, root := 1, 2, 3, 9, 0
+
+	tests := []struct {
+		name        string
+		param       int
+		payload     tasks.UpdateTaskInput
+		expected    tasks.TaskOutput
+		expectError bool
+		error       error
+	}{
+		{
+			name:     "moves task under another parent",
+			param:    4,
+			payload:  tasks.UpdateTaskInput{Name: "叫車", ParentId: &self},
+			expected: tasks.TaskOutput{Id: 4, Name: "叫車", ParentId: 2},
+		},
+		{
+			name:     "makes task a root task",
+			param:    2,
+			payload:  tasks.UpdateTaskInput{Name: "打包", Status: 1, ParentId: &root},
+			expected: tasks.TaskOutput{Id: 2, Name: "打包", Status: 1, Progress: &tasks.ProgressOutput{Done: 0, Total: 1}},
+		},
+		{
+			name:     "keeps the parent when omitted",
+			param:    3,
+			payload:  tasks.UpdateTaskInput{Name: "打包臥室"},
+			expected: tasks.TaskOutput{Id: 3, Name: "打包臥室", ParentId: 2},
+		},
+		{
+			name:        "returns error nesting task under itself",
+			param:       2,
+			payload:     tasks.UpdateTaskInput{Name: "打包", ParentId: &self},
+			expectError: true,
+			error:       tasks.ErrorCycle,
+		},
+		{
+			name:        "returns error nesting task under its subtask",
+			param:       1,
+			payload:     tasks.UpdateTaskInput{Name: "搬家", ParentId: &descendant},
+			expectError: true,
+			error:       tasks.ErrorCycle,
+		},
+		{
+			name:        "returns error nesting task under unknown parent",
+			param:       4,
+			payload:     tasks.UpdateTaskInput{Name: "叫車", ParentId: &unknown},
+			expectError: true,
+			error:       tasks.ErrorParentNotFound,
+		},
+		{
+			name:     "moves task under its grandparent",
+			param:    3,
+			payload:  tasks.UpdateTaskInput{Name: "打包廚房", ParentId: &parent},
+			expected: tasks.TaskOutput{Id: 3, Name: "打包廚房", ParentId: 1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase := tasks.InitTasksUsecase(newSubtasksRepo())
+			got, err := usecase.UpdateTask(tasks.Actor{}, tc.param, &tc.payload)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				if err != nil {
+					t.Error(err)
+				}
+				util.AssertEqual(t)(*got, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_CreateSubtask(t *testing.T) {
+	t.Run("returns created subtask", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := tasks.InitTasksUsecase(newSubtasksRepo())
+		got, err := usecase.CreateTask(tasks.Actor{}, &tasks.CreateTaskInput{Name: "退租", ParentId: 1})
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(*got, tasks.TaskOutput{Id: 5, Name: "退租", ParentId: 1})
+	})
+
+	t.Run("returns error with unknown parent", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := tasks.InitTasksUsecase(newSubtasksRepo())
+		_, err := usecase.CreateTask(tasks.Actor{}, &tasks.CreateTaskInput{Name: "退租", ParentId: 9})
+
+		util.AssertErrorEqual(t)(err, tasks.ErrorParentNotFound)
+	})
+}
+
+func Test_ListTasksProgress(t *testing.T) {
+	t.Parallel()
+
+	usecase := tasks.InitTasksUsecase(newSubtasksRepo())
+	got := usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{})
+
+	util.AssertEqual(t)(got[0].Progress, &tasks.ProgressOutput{Done: 1, Total: 3})
+	util.AssertEqual(t)(got[1].Progress, &tasks.ProgressOutput{Done: 0, Total: 1})
+	util.AssertEqual(t)(got[2].Progress, (*tasks.ProgressOutput)(nil))
+}
+
+func Test_GetTaskTree(t *testing.T) {
+	t.Run("returns the task with nested subtasks", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := tasks.InitTasksUsecase(newSubtasksRepo())
+		got, err := usecase.GetTaskTree(tasks.Actor{}, 2)
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(*got, tasks.TaskTreeOutput{
+			TaskOutput: tasks.TaskOutput{Id: 2, Name: "打包", Status: 1, ParentId: 1, Progress: &tasks.ProgressOutput{Done: 0, Total: 1}},
+			Children: []*tasks.TaskTreeOutput{
+				{TaskOutput: tasks.TaskOutput{Id: 3, Name: "打包廚房", ParentId: 2}, Children: []*tasks.TaskTreeOutput{}},
+			},
+		})
+	})
+
+	t.Run("returns error when not found", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := tasks.InitTasksUsecase(newSubtasksRepo())
+		_, err := usecase.GetTaskTree(tasks.Actor{}, 9)
+
+		util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+	})
+}
+
+func Test_DeleteParentTask(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     tasks.DeleteTaskInput
+		expected    []*tasks.TaskOutput
+		expectError bool
+		error       error
+	}{
+		{
+			name:    "moves subtasks up to the parent",
+			payload: tasks.DeleteTaskInput{},
+			expected: []*tasks.TaskOutput{
+				{Id: 1, Name: "搬家", Progress: &tasks.ProgressOutput{Done: 0, Total: 2}},
+				{Id: 3, Name: "打包廚房", ParentId: 1},
+				{Id: 4, Name: "叫車", ParentId: 1},
+			},
+		},
+		{
+			name:    "trashes subtasks along",
+			payload: tasks.DeleteTaskInput{Children: tasks.Cascade},
+			expected: []*tasks.TaskOutput{
+				{Id: 1, Name: "搬家", Progress: &tasks.ProgressOutput{Done: 0, Total: 1}},
+				{Id: 4, Name: "叫車", ParentId: 1},
+			},
+		},
+		{
+			name:        "returns error on unknown mode",
+			payload:     tasks.DeleteTaskInput{Children: "orphan"},
+			expectError: true,
+			error:       tasks.ErrorUnknownDeleteMode,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase := tasks.InitTasksUsecase(newSubtasksRepo())
+			err := usecase.DeleteTask(tasks.Actor{}, 2, &tc.payload)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			util.AssertEqual(t)(usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{}), tc.expected)
+		})
+	}
+}
+
+func Test_UndoDeleteParentTask(t *testing.T) {
+	alice := tasks.Actor{SessionId: "alice"}
+
+	for _, mode := range []string{tasks.Reparent, tasks.Cascade} {
+		t.Run("restores subtasks deleted with "+mode, func(t *testing.T) {
+			t.Parallel()
+
+			usecase := tasks.InitTasksUsecase(newSubtasksRepo())
+			expected := usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{})
+			usecase.DeleteTask(alice, 2, &tasks.DeleteTaskInput{Children: mode})
+
+			_, err := usecase.Undo(alice)
+
+			util.AssertErrorEqual(t)(err, nil)
+			util.AssertEqual(t)(usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{}), expected)
+		})
+	}
+}
+
+func Test_RestoreSubtaskOfTrashedParent(t *testing.T) {
+	t.Parallel()
+
+	usecase := tasks.InitTasksUsecase(newSubtasksRepo())
+	usecase.DeleteTask(tasks.Actor{}, 2, &tasks.DeleteTaskInput{Children: tasks.Cascade})
+
+	got, err := usecase.RestoreTask(tasks.Actor{}, 3)
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(*got, tasks.TaskOutput{Id: 3, Name: "打包廚房"})
+}
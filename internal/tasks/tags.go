@@ -0,0 +1,128 @@
+package tasks
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+const (
+	// Lists tasks having any of the given tags.
+	MatchAny = "any"
+	// Lists tasks having all of the given tags.
+	MatchAll = "all"
+)
+
+var (
+	ErrorInvalidTag  = errors.New("Invalid tag")
+	ErrorTagNotFound = errors.New("Tag not found")
+)
+
+type TagOutput struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type TagsInput struct {
+	Tags []string `json:"tags"`
+}
+
+type RenameTagInput struct {
+	Name string `json:"name"`
+}
+
+func (u *TasksUsecase) AddTags(a Actor, id int, i *TagsInput) (*TaskOutput, error) {
+	if len(entity.NormalizeTags(i.Tags)) == 0 {
+		return nil, ErrorInvalidTag
+	}
+
+	return u.updateTags(a, id, func(t *entity.Task) { t.AddTags(i.Tags...) })
+}
+
+func (u *TasksUsecase) RemoveTag(a Actor, id int, tag string) (*TaskOutput, error) {
+	return u.updateTags(a, id, func(t *entity.Task) { t.RemoveTag(tag) })
+}
+
+// ListTags returns every tag in use along with how many tasks carry it,
+// counting only tasks the actor may see.
+func (u *TasksUsecase) ListTags(a Actor) []*TagOutput {
+	var output = make([]*TagOutput, 0)
+
+	tags := u.repo.ListTags()
+	if u.access != nil {
+		tags = countTags(u.listAll(a))
+	}
+	for _, tag := range tags {
+		output = append(output, &TagOutput{Name: tag.Name, Count: tag.Count})
+	}
+
+	return output
+}
+
+// RenameTag renames the tag on the tasks the actor may change, trashed ones
+// included, merging it into an existing tag of the new name. Tasks of others
+// keep the tag as it is.
+func (u *TasksUsecase) RenameTag(a Actor, tag string, i *RenameTagInput) (*TagOutput, error) {
+	to := entity.NormalizeTag(i.Name)
+	if to == "" {
+		return nil, ErrorInvalidTag
+	}
+
+	var found bool
+	var ids []int
+	for _, task := range append(u.repo.ListAll(), u.repo.ListTrashed()...) {
+		if !task.HasTag(entity.NormalizeTag(tag)) || !u.canRead(a, task) {
+			continue
+		}
+		found = true
+		if u.canWrite(a, task) {
+			ids = append(ids, task.Id)
+			a.Scope.AddTask(task)
+		}
+	}
+	if !found {
+		return nil, ErrorTagNotFound
+	}
+	if len(ids) == 0 {
+		return nil, ErrorForbidden
+	}
+	u.repo.RenameTag(tag, to, ids)
+
+	for _, t := range u.ListTags(a) {
+		if t.Name == to {
+			return t, nil
+		}
+	}
+	return &TagOutput{Name: to}, nil
+}
+
+func (u *TasksUsecase) updateTags(a Actor, id int, change func(*entity.Task)) (*TaskOutput, error) {
+	task, err := u.findWritable(a, id)
+	if err != nil {
+		return nil, err
+	}
+	before := cloneTask(task)
+
+	change(task)
+
+	return u.save(a, before, task)
+}
+
+// countTags counts how many of the tasks carry each tag, ordered by name.
+func countTags(tasks []*entity.Task) []repository.TagCount {
+	counts := map[string]int{}
+	for _, task := range tasks {
+		for _, tag := range task.Tags {
+			counts[tag] += 1
+		}
+	}
+
+	var tags []repository.TagCount
+	for name, count := range counts {
+		tags = append(tags, repository.TagCount{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags
+}
@@ -0,0 +1,182 @@
+package tasks_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_AddTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		param       int
+		payload     tasks.TagsInput
+		expected    tasks.TaskOutput
+		expectError bool
+		error       error
+	}{
+		{
+			name:     "returns task with merged tags",
+			param:    1,
+			payload:  tasks.TagsInput{Tags: []string{"Food", "errand"}},
+			expected: tasks.TaskOutput{Id: 1, Name: "買晚餐", Tags: []string{"errand", "food"}},
+		},
+		{
+			name:        "returns error without tags",
+			param:       1,
+			payload:     tasks.TagsInput{Tags: []string{" "}},
+			expectError: true,
+			error:       tasks.ErrorInvalidTag,
+		},
+		{
+			name:        "returns error when not found",
+			param:       2,
+			payload:     tasks.TagsInput{Tags: []string{"food"}},
+			expectError: true,
+			error:       repository.ErrorNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Tags: []string{"errand"}})
+			usecase := tasks.InitTasksUsecase(repo)
+			got, err := usecase.AddTags(tasks.Actor{}, tc.param, &tc.payload)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				if err != nil {
+					t.Error(err)
+				}
+				util.AssertEqual(t)(*got, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_RemoveTag(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Tags: []string{"errand", "food"}})
+	usecase := tasks.InitTasksUsecase(repo)
+	got, err := usecase.RemoveTag(tasks.Actor{}, 1, "Food")
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(*got, tasks.TaskOutput{Id: 1, Name: "買晚餐", Tags: []string{"errand"}})
+}
+
+func Test_ListTags(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Tags: []string{"errand"}})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐", Tags: []string{"errand", "food"}})
+	usecase := tasks.InitTasksUsecase(repo)
+	got := usecase.ListTags(tasks.Actor{})
+
+	util.AssertEqual(t)(got, []*tasks.TagOutput{{Name: "errand", Count: 2}, {Name: "food", Count: 1}})
+}
+
+func Test_RenameTag(t *testing.T) {
+	tests := []struct {
+		name        string
+		param       string
+		payload     tasks.RenameTagInput
+		expected    tasks.TagOutput
+		expectError bool
+		error       error
+	}{
+		{
+			name:     "returns renamed tag",
+			param:    "food",
+			payload:  tasks.RenameTagInput{Name: "Groceries"},
+			expected: tasks.TagOutput{Name: "groceries", Count: 1},
+		},
+		{
+			name:     "returns merged tag",
+			param:    "food",
+			payload:  tasks.RenameTagInput{Name: "errand"},
+			expected: tasks.TagOutput{Name: "errand", Count: 2},
+		},
+		{
+			name:        "returns error on empty name",
+			param:       "food",
+			payload:     tasks.RenameTagInput{Name: ""},
+			expectError: true,
+			error:       tasks.ErrorInvalidTag,
+		},
+		{
+			name:        "returns error when not found",
+			param:       "chore",
+			payload:     tasks.RenameTagInput{Name: "errand"},
+			expectError: true,
+			error:       tasks.ErrorTagNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Tags: []string{"errand"}})
+			repo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐", Tags: []string{"food"}})
+			usecase := tasks.InitTasksUsecase(repo)
+			got, err := usecase.RenameTag(tasks.Actor{}, tc.param, &tc.payload)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				if err != nil {
+					t.Error(err)
+				}
+				util.AssertEqual(t)(*got, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_ListTasksByTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		param    tasks.ListTasksInput
+		expected []int
+	}{
+		{
+			name:     "returns tasks with any of the tags",
+			param:    tasks.ListTasksInput{Tags: []string{"errand", "food"}},
+			expected: []int{1, 2},
+		},
+		{
+			name:     "returns tasks with all of the tags",
+			param:    tasks.ListTasksInput{Tags: []string{"errand", "food"}, Match: tasks.MatchAll},
+			expected: []int{2},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Tags: []string{"errand"}})
+			repo.PopulateData(repository.TaskSchema{Id: 2, Name: "買晚餐", Tags: []string{"errand", "food"}})
+			repo.PopulateData(repository.TaskSchema{Id: 3, Name: "洗碗"})
+			usecase := tasks.InitTasksUsecase(repo)
+
+			var got []int
+			for _, task := range usecase.ListTasks(tasks.Actor{}, &tc.param) {
+				got = append(got, task.Id)
+			}
+
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+}
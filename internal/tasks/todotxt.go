@@ -0,0 +1,409 @@
+package tasks
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/access"
+	listentity "github.com/dannyh79/whostodo/internal/lists/entities"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+var (
+	ErrorInvalidTodoTxt     = errors.New("Invalid todo.txt")
+	ErrorMultipleProjects   = errors.New("Task can be in one project only")
+	ErrorProjectUnavailable = errors.New("Projects are not available")
+)
+
+// todo.txt priorities by task priority; lines of priority D to Z are
+// imported as low.
+var todoTxtPriorities = map[int]string{
+	entity.PriorityUrgent: "A",
+	entity.PriorityHigh:   "B",
+	entity.PriorityMedium: "C",
+	entity.PriorityLow:    "D",
+}
+
+// ExportTodoTxt writes every task outside the trash the actor may see as a
+// todo.txt line, in manual order. Lists become +projects and tags become
+// @contexts; due dates and ids go into due: and id: tags.
+func (u *TasksUsecase) ExportTodoTxt(a Actor, w io.Writer) error {
+	out := bufio.NewWriter(w)
+	projects := map[int]string{}
+	for _, task := range u.listAll(a) {
+		if _, ok := projects[task.ListId]; !ok {
+			projects[task.ListId] = u.projectName(task.ListId)
+		}
+		if _, err := out.WriteString(toTodoTxtLine(task, projects[task.ListId]) + "\n"); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// ImportTodoTxt creates a task from every line without an id: tag, and
+// updates the task of the id otherwise. A line stands for the whole task as
+// far as todo.txt goes, so a missing project moves the task into the inbox
+// and missing contexts clear its tags; descriptions, parents and assignees
+// are left as they are. Projects matching no list the actor may see are
+// created as lists of the actor's. As with ImportCSV, nothing is imported
+// unless every line is valid, and the whole import is undone in one step,
+// save for the lists created.
+func (u *TasksUsecase) ImportTodoTxt(a Actor, r io.Reader, i *ImportTasksInput) (*ImportOutput, error) {
+	lines, err := readTodoTxtLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ImportOutput{DryRun: i.DryRun, Errors: make([]*ImportErrorOutput, 0)}
+	var planned []plannedRow
+	for _, line := range lines {
+		p, errs := u.planTodoTxtLine(a, line)
+		output.Errors = append(output.Errors, errs...)
+		if len(errs) == 0 {
+			planned = append(planned, p)
+		}
+	}
+	output.Errors = append(output.Errors, checkPlannedIds(planned, "id")...)
+
+	return u.finishImport(a, i, output, planned)
+}
+
+// todoTxtLine is a parsed todo.txt line. Fields the line leaves out are
+// zero.
+type todoTxtLine struct {
+	number      int
+	done        bool
+	priority    string
+	completedAt time.Time
+	createdAt   time.Time
+	name        string
+	projects    []string
+	contexts    []string
+	// Values of the key:value tags understood; others are left in the name.
+	tags map[string]string
+}
+
+func readTodoTxtLines(r io.Reader) ([]todoTxtLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, MaxDescriptionSize)
+
+	var lines []todoTxtLine
+	for number := 1; scanner.Scan(); number++ {
+		text := scanner.Text()
+		if number == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if len(lines) == MaxImportRows {
+			return nil, ErrorTooManyRows
+		}
+		lines = append(lines, parseTodoTxtLine(number, text))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrorInvalidTodoTxt, err)
+	}
+
+	return lines, nil
+}
+
+// parseTodoTxtLine reads the completion mark, priority and dates at the start
+// of the line, and the projects, contexts and tags among the rest. Whatever
+// is not understood is kept as the name, less the backslash of words escaped
+// by escapeTodoTxtWord.
+func parseTodoTxtLine(number int, text string) todoTxtLine {
+	line := todoTxtLine{number: number, tags: map[string]string{}}
+	words := strings.Fields(text)
+
+	if len(words) > 0 && words[0] == "x" {
+		line.done = true
+		words = words[1:]
+	}
+	if len(words) > 0 && isTodoTxtPriority(words[0]) {
+		line.priority = words[0][1:2]
+		words = words[1:]
+	}
+	if line.done {
+		if date, ok := parseTodoTxtDate(words); ok {
+			line.completedAt = date
+			words = words[1:]
+		}
+	}
+	if date, ok := parseTodoTxtDate(words); ok {
+		line.createdAt = date
+		words = words[1:]
+	}
+
+	var name []string
+	for _, word := range words {
+		switch {
+		case len(word) > 1 && word[0] == '+':
+			line.projects = append(line.projects, word[1:])
+		case len(word) > 1 && word[0] == '@':
+			line.contexts = append(line.contexts, word[1:])
+		case isTodoTxtTag(word):
+			key, value, _ := strings.Cut(word, ":")
+			line.tags[key] = value
+		default:
+			name = append(name, unescapeTodoTxtWord(word))
+		}
+	}
+	line.name = strings.Join(name, " ")
+
+	return line
+}
+
+// escapeTodoTxtWord prefixes a word of a name with a backslash if it would
+// be read back as something else than a name: a project, context or tag,
+// or, leading the name, a completion mark, priority or date. Words starting
+// with a backslash get one more, so that unescapeTodoTxtWord can strip one.
+func escapeTodoTxtWord(word string, first bool) string {
+	special := strings.HasPrefix(word, `\`) ||
+		(len(word) > 1 && (word[0] == '+' || word[0] == '@')) ||
+		isTodoTxtTag(word)
+	if first {
+		_, err := time.Parse(time.DateOnly, word)
+		special = special || word == "x" || isTodoTxtPriority(word) || err == nil
+	}
+	if special {
+		return `\` + word
+	}
+	return word
+}
+
+func unescapeTodoTxtWord(word string) string {
+	return strings.TrimPrefix(word, `\`)
+}
+
+func isTodoTxtPriority(word string) bool {
+	return len(word) == 3 && word[0] == '(' && word[1] >= 'A' && word[1] <= 'Z' && word[2] == ')'
+}
+
+func isTodoTxtTag(word string) bool {
+	key, value, ok := strings.Cut(word, ":")
+	if !ok || value == "" {
+		return false
+	}
+	return key == "due" || key == "id" || key == "pri"
+}
+
+func parseTodoTxtDate(words []string) (time.Time, bool) {
+	if len(words) == 0 {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(time.DateOnly, words[0])
+	return date, err == nil
+}
+
+// planTodoTxtLine validates the line against the stored tasks, returning the
+// task it would become.
+func (u *TasksUsecase) planTodoTxtLine(a Actor, line todoTxtLine) (plannedRow, []*ImportErrorOutput) {
+	var errs []*ImportErrorOutput
+	fail := func(column string, err error) {
+		errs = append(errs, &ImportErrorOutput{Row: line.number, Column: column, Error: err.Error()})
+	}
+	p := plannedRow{line: line.number, after: &entity.Task{Creator: a.User}}
+
+	if v, ok := line.tags["id"]; ok {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			fail("id", ErrorInvalidNumber)
+			return p, errs
+		}
+		task, err := u.findWritable(a, id)
+		if err != nil {
+			fail("id", err)
+			return p, errs
+		}
+		p.before, p.after = cloneTask(task), task
+	}
+	task := p.after
+
+	task.Name = line.name
+	if task.Name == "" {
+		fail("name", ErrorNameRequired)
+	}
+
+	status := task.Status
+	if line.done {
+		status = entity.StatusDone
+	} else if task.IsDone() {
+		status = entity.StatusOpen
+	}
+	task.SetStatus(status, time.Now())
+	if line.done && !line.completedAt.IsZero() {
+		task.CompletedAt = onDate(task.CompletedAt, line.completedAt)
+	}
+	if !line.createdAt.IsZero() {
+		task.CreatedAt = onDate(task.CreatedAt, line.createdAt)
+	}
+	if task.IsDone() && (p.before == nil || !p.before.IsDone()) {
+		if err := u.checkBlockers(task); err != nil {
+			fail("status", err)
+		}
+	}
+
+	priority := line.priority
+	if v, ok := line.tags["pri"]; ok && priority == "" {
+		priority = v
+	}
+	if priority != "" && !isTodoTxtPriority("("+priority+")") {
+		fail("pri", ErrorInvalidPriority)
+	}
+	task.Priority = fromTodoTxtPriority(priority)
+
+	task.Tags = entity.NormalizeTags(line.contexts)
+
+	dueAt := task.DueAt
+	task.DueAt = time.Time{}
+	if v, ok := line.tags["due"]; ok {
+		date, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			fail("due", ErrorInvalidDate)
+		}
+		task.DueAt = onDate(dueAt, date)
+	}
+
+	task.ListId = listentity.InboxId
+	switch {
+	case len(line.projects) > 1:
+		fail("project", ErrorMultipleProjects)
+	case len(line.projects) == 1:
+		listId, err := u.findProject(a, line.projects[0])
+		if err != nil {
+			fail("project", err)
+		}
+		task.ListId = listId
+		if listId == listentity.InboxId {
+			p.project = line.projects[0]
+		}
+	}
+
+	return p, errs
+}
+
+// onDate returns the time if it falls on the date, as toTodoTxtLine writes
+// it, or else the date. Lines only have room for dates, so times are kept
+// unless the date changed.
+func onDate(t time.Time, date time.Time) time.Time {
+	if !t.IsZero() && !date.IsZero() && t.Format(time.DateOnly) == date.Format(time.DateOnly) {
+		return t
+	}
+	return date
+}
+
+// findProject returns the id of the list the actor may see by the project's
+// name, or InboxId if there is none and the list is to be created.
+func (u *TasksUsecase) findProject(a Actor, project string) (int, error) {
+	if u.lists == nil {
+		return listentity.InboxId, ErrorProjectUnavailable
+	}
+	for _, list := range u.lists.ListAll() {
+		if !access.CanReadList(u.access, a.User, list.Id) || !strings.EqualFold(toTodoTxtProject(list.Name), project) {
+			continue
+		}
+		if !access.CanWriteList(u.access, a.User, list.Id) {
+			return listentity.InboxId, ErrorForbidden
+		}
+		return list.Id, nil
+	}
+	return listentity.InboxId, nil
+}
+
+// createProjects creates a list for every project planned rows are in but
+// which does not exist yet, one per project, and moves the tasks into them.
+func (u *TasksUsecase) createProjects(a Actor, planned []plannedRow) {
+	created := map[string]int{}
+	for _, p := range planned {
+		if p.project == "" {
+			continue
+		}
+		key := strings.ToLower(p.project)
+		if _, ok := created[key]; !ok {
+			list := u.lists.Save(&listentity.List{Name: p.project, Owner: a.User})
+			created[key] = list.Id
+		}
+		p.after.ListId = created[key]
+	}
+}
+
+// projectName returns the list's name as a todo.txt project, or empty for the
+// inbox and lists not found.
+func (u *TasksUsecase) projectName(listId int) string {
+	if u.lists == nil || listId == listentity.InboxId {
+		return ""
+	}
+	list, err := u.lists.FindBy(listId)
+	if err != nil {
+		return ""
+	}
+	return toTodoTxtProject(list.Name)
+}
+
+// toTodoTxtProject joins the words of a list name with dashes, as projects
+// cannot contain spaces.
+func toTodoTxtProject(name string) string {
+	return strings.Join(strings.Fields(name), "-")
+}
+
+func toTodoTxtLine(t *entity.Task, project string) string {
+	var words []string
+	priority := todoTxtPriorities[t.Priority]
+	if t.IsDone() {
+		words = append(words, "x")
+		completedAt := t.CompletedAt
+		if completedAt.IsZero() {
+			// A creation date is only told apart following a completion date.
+			completedAt = t.CreatedAt
+		}
+		if !completedAt.IsZero() {
+			words = append(words, completedAt.Format(time.DateOnly))
+		}
+	} else if priority != "" {
+		words = append(words, "("+priority+")")
+	}
+	if !t.CreatedAt.IsZero() && (!t.IsDone() || !t.CompletedAt.IsZero()) {
+		words = append(words, t.CreatedAt.Format(time.DateOnly))
+	}
+
+	for i, word := range strings.Fields(t.Name) {
+		words = append(words, escapeTodoTxtWord(word, i == 0))
+	}
+	if project != "" {
+		words = append(words, "+"+project)
+	}
+	for _, tag := range t.Tags {
+		if tag = strings.Join(strings.Fields(tag), "-"); tag != "" {
+			words = append(words, "@"+tag)
+		}
+	}
+	if !t.DueAt.IsZero() {
+		words = append(words, "due:"+t.DueAt.Format(time.DateOnly))
+	}
+	if t.IsDone() && priority != "" {
+		// Completed tasks conventionally keep their priority as a tag.
+		words = append(words, "pri:"+priority)
+	}
+	words = append(words, "id:"+strconv.Itoa(t.Id))
+
+	return strings.Join(words, " ")
+}
+
+func fromTodoTxtPriority(priority string) int {
+	if priority == "" {
+		return entity.PriorityNone
+	}
+	for p, letter := range todoTxtPriorities {
+		if letter == priority {
+			return p
+		}
+	}
+	return entity.PriorityLow
+}
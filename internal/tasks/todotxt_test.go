@@ -0,0 +1,307 @@
+package tasks_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_ExportTodoTxt(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Priority: 3, ListId: 1, Tags: []string{"errand", "food"}, DueAt: time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC), CreatedAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: "洗碗", Status: 1, Priority: 4, CreatedAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), CompletedAt: time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)})
+	repo.PopulateData(repository.TaskSchema{Id: 3, Name: "拖地\n掃地", Status: 1})
+	repo.PopulateData(repository.TaskSchema{Id: 4, Name: "倒垃圾", DeletedAt: time.Now()})
+	listRepo := util.InitMockListRepository()
+	listRepo.PopulateData(repository.ListSchema{Id: 1, Name: "Weekly Chores"})
+	usecase := tasks.InitTasksUsecase(repo, tasks.WithListRepository(listRepo))
+
+	var got bytes.Buffer
+	err := usecase.ExportTodoTxt(tasks.Actor{}, &got)
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(got.String(), strings.Join([]string{
+		"(B) 2024-01-01 買晚餐 +Weekly-Chores @errand @food due:2024-01-05 id:1",
+		"x 2024-01-02 2024-01-01 洗碗 pri:A id:2",
+		"x 拖地 掃地 id:3",
+		"",
+	}, "\n"))
+}
+
+func Test_ImportTodoTxt(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dueAt := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		todoTxt     string
+		input       tasks.ImportTasksInput
+		expected    *tasks.ImportOutput
+		tasks       []*tasks.TaskOutput
+		lists       int
+		expectError bool
+		error       error
+	}{
+		{
+			name:    "creates and updates tasks",
+			todoTxt: "(A) 2024-01-01 洗碗 +家事 @home due:2024-01-05\nx 2024-01-02 買早餐 id:1\n",
+			expected: &tasks.ImportOutput{
+				Created: 1,
+				Updated: 1,
+				Errors:  []*tasks.ImportErrorOutput{},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Status: 1, Description: "第一餐", Creator: "alice"},
+				{Id: 2, Name: "洗碗", Priority: "urgent", ListId: 1, Tags: []string{"home"}, DueAt: &dueAt, Creator: "alice"},
+			},
+			lists: 1,
+		},
+		{
+			name:    "creates a list once per project",
+			todoTxt: "洗碗 +Chores\n拖地 +chores\n",
+			expected: &tasks.ImportOutput{
+				Created: 2,
+				Errors:  []*tasks.ImportErrorOutput{},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Description: "第一餐", Creator: "alice"},
+				{Id: 2, Name: "洗碗", ListId: 1, Creator: "alice"},
+				{Id: 3, Name: "拖地", ListId: 1, Creator: "alice"},
+			},
+			lists: 1,
+		},
+		{
+			name:    "keeps unknown tags in the name",
+			todoTxt: "(E) 洗碗 t:2024-01-01 rec:1w\n",
+			expected: &tasks.ImportOutput{
+				Created: 1,
+				Errors:  []*tasks.ImportErrorOutput{},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Description: "第一餐", Creator: "alice"},
+				{Id: 2, Name: "洗碗 t:2024-01-01 rec:1w", Priority: "low", Creator: "alice"},
+			},
+		},
+		{
+			name:    "reports line errors on dry run",
+			todoTxt: "洗碗 id:9\n\n+家事 +公司 due:tomorrow pri:AA\n",
+			input:   tasks.ImportTasksInput{DryRun: true},
+			expected: &tasks.ImportOutput{
+				DryRun: true,
+				Errors: []*tasks.ImportErrorOutput{
+					{Row: 1, Column: "id", Error: "Task not found"},
+					{Row: 3, Column: "name", Error: "Name is required"},
+					{Row: 3, Column: "pri", Error: "Invalid priority"},
+					{Row: 3, Column: "due", Error: "Not a date"},
+					{Row: 3, Column: "project", Error: "Task can be in one project only"},
+				},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Description: "第一餐", Creator: "alice"},
+			},
+		},
+		{
+			name:    "validates without importing on dry run",
+			todoTxt: "洗碗 +家事\n",
+			input:   tasks.ImportTasksInput{DryRun: true},
+			expected: &tasks.ImportOutput{
+				DryRun:  true,
+				Created: 1,
+				Errors:  []*tasks.ImportErrorOutput{},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Description: "第一餐", Creator: "alice"},
+			},
+		},
+		{
+			name:    "imports nothing when any line is invalid",
+			todoTxt: "洗碗 +家事\n拖地 due:tomorrow\n",
+			expected: &tasks.ImportOutput{
+				Errors: []*tasks.ImportErrorOutput{{Row: 2, Column: "due", Error: "Not a date"}},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Description: "第一餐", Creator: "alice"},
+			},
+			expectError: true,
+			error:       tasks.ErrorInvalidRows,
+		},
+		{
+			name:    "imports nothing when lines update the same task",
+			todoTxt: "買午餐 id:1\nx 買晚餐 id:1\n",
+			expected: &tasks.ImportOutput{
+				Errors: []*tasks.ImportErrorOutput{{Row: 2, Column: "id", Error: "Task is imported more than once"}},
+			},
+			tasks: []*tasks.TaskOutput{
+				{Id: 1, Name: "買早餐", Priority: "high", Description: "第一餐", Creator: "alice"},
+			},
+			expectError: true,
+			error:       tasks.ErrorInvalidRows,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Description: "第一餐", Priority: 3, Position: "a", Creator: "alice"})
+			listRepo := util.InitMockListRepository()
+			usecase := tasks.InitTasksUsecase(repo, tasks.WithListRepository(listRepo))
+			a := tasks.Actor{User: "alice"}
+
+			got, err := usecase.ImportTodoTxt(a, strings.NewReader(tc.todoTxt), &tc.input)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			util.AssertEqual(t)(got, tc.expected)
+			util.AssertEqual(t)(usecase.ListTasks(a, &tasks.ListTasksInput{}), tc.tasks)
+			util.AssertEqual(t)(len(listRepo.Data), tc.lists)
+			if tc.name == "creates and updates tasks" {
+				util.AssertEqual(t)(repo.Data[2].CreatedAt, createdAt)
+				util.AssertEqual(t)(repo.Data[1].CompletedAt, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+			}
+		})
+	}
+}
+
+func Test_ImportTodoTxt_Projects(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  string
+		error string
+	}{
+		{name: "puts tasks into a list shared with the user", user: "bob"},
+		{name: "returns error on a list the user may only view", user: "carol", error: "Not allowed to change tasks of the list"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase, _ := initSharedUsecase()
+			a := tasks.Actor{User: tc.user}
+
+			got, _ := usecase.ImportTodoTxt(a, strings.NewReader("拖地 +家事\n"), &tasks.ImportTasksInput{DryRun: true})
+
+			if tc.error == "" {
+				util.AssertEqual(t)(got.Errors, []*tasks.ImportErrorOutput{})
+				return
+			}
+			util.AssertEqual(t)(got.Errors, []*tasks.ImportErrorOutput{{Row: 1, Column: "project", Error: tc.error}})
+		})
+	}
+}
+
+func Test_ExportImportTodoTxt_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Priority: 2, Position: "a", ListId: 1, Tags: []string{"errand"}, DueAt: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	repo.PopulateData(repository.TaskSchema{Id: 2, Name: "洗碗", Status: 1, Priority: 1, Position: "b", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), CompletedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)})
+	listRepo := util.InitMockListRepository()
+	listRepo.PopulateData(repository.ListSchema{Id: 1, Name: "家事"})
+	usecase := tasks.InitTasksUsecase(repo, tasks.WithListRepository(listRepo))
+	a := tasks.Actor{}
+	before := map[int]repository.TaskSchema{1: repo.Data[1], 2: repo.Data[2]}
+
+	var exported bytes.Buffer
+	usecase.ExportTodoTxt(a, &exported)
+	got, err := usecase.ImportTodoTxt(a, &exported, &tasks.ImportTasksInput{})
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(got.Updated, 2)
+	util.AssertEqual(t)(repo.Data, before)
+	util.AssertEqual(t)(len(listRepo.Data), 1)
+}
+
+func Test_ExportImportTodoTxt_RoundTripTimes(t *testing.T) {
+	dueAt := time.Date(2024, 1, 5, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		edit     func(line string) string
+		expected time.Time
+	}{
+		{
+			name:     "keeps the time of an unchanged due date",
+			edit:     func(line string) string { return line },
+			expected: dueAt,
+		},
+		{
+			name:     "takes a changed due date at midnight",
+			edit:     func(line string) string { return strings.Replace(line, "due:2024-01-05", "due:2024-01-06", 1) },
+			expected: time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Status: 1, DueAt: dueAt, CreatedAt: time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC), CompletedAt: time.Date(2024, 1, 2, 18, 15, 0, 0, time.UTC)})
+			usecase := tasks.InitTasksUsecase(repo)
+			a := tasks.Actor{}
+			before := repo.Data[1]
+
+			var exported bytes.Buffer
+			usecase.ExportTodoTxt(a, &exported)
+			_, err := usecase.ImportTodoTxt(a, strings.NewReader(tc.edit(exported.String())), &tasks.ImportTasksInput{})
+
+			util.AssertErrorEqual(t)(err, nil)
+			util.AssertEqual(t)(repo.Data[1].DueAt, tc.expected)
+			util.AssertEqual(t)(repo.Data[1].CreatedAt, before.CreatedAt)
+			util.AssertEqual(t)(repo.Data[1].CompletedAt, before.CompletedAt)
+		})
+	}
+}
+
+func Test_ExportImportTodoTxt_RoundTripNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		task     string
+		status   int
+		expected string
+	}{
+		{name: "keeps a leading x", task: "x 光機", expected: `\x 光機 id:1`},
+		{name: "keeps a leading x of a done task", task: "x 光機", status: 1, expected: `x \x 光機 id:1`},
+		{name: "keeps a leading priority", task: "(A) 計畫", expected: `\(A) 計畫 id:1`},
+		{name: "keeps a leading priority of a done task", task: "(A) 計畫", status: 1, expected: `x \(A) 計畫 id:1`},
+		{name: "keeps a leading date", task: "2024-01-01 回顧", expected: `\2024-01-01 回顧 id:1`},
+		{name: "keeps dates further in", task: "回顧 2024-01-01", expected: "回顧 2024-01-01 id:1"},
+		{name: "keeps projects and contexts", task: "買 +1 杯 給 @bob", expected: `買 \+1 杯 給 \@bob id:1`},
+		{name: "keeps tags", task: "提醒 due:明天 id:7 pri:A", expected: `提醒 \due:明天 \id:7 \pri:A id:1`},
+		{name: "keeps backslashes", task: `\路徑 \\共享`, expected: `\\路徑 \\\共享 id:1`},
+		{name: "keeps words only looking alike", task: "+ @ x due: 備忘", expected: "+ @ x due: 備忘 id:1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(repository.TaskSchema{Id: 1, Name: tc.task, Status: tc.status, Position: "a"})
+			usecase := tasks.InitTasksUsecase(repo, tasks.WithListRepository(util.InitMockListRepository()))
+			a := tasks.Actor{}
+			before := repo.Data[1]
+
+			var exported bytes.Buffer
+			usecase.ExportTodoTxt(a, &exported)
+			util.AssertEqual(t)(exported.String(), tc.expected+"\n")
+			_, err := usecase.ImportTodoTxt(a, &exported, &tasks.ImportTasksInput{})
+
+			util.AssertErrorEqual(t)(err, nil)
+			util.AssertEqual(t)(repo.Data[1], before)
+		})
+	}
+}
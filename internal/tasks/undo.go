@@ -0,0 +1,292 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
+)
+
+// How many operations each session can undo unless overridden by
+// WithUndoDepth.
+const DefaultUndoDepth = 20
+
+var (
+	ErrorNothingToUndo = errors.New("Nothing to undo")
+	ErrorNothingToRedo = errors.New("Nothing to redo")
+	ErrorConflict      = errors.New("Task changed since")
+)
+
+type operationKind int
+
+const (
+	createOperation operationKind = iota
+	updateOperation
+	deleteOperation
+)
+
+// operation snapshots a task around a single change so it can be reverted
+// and reapplied.
+type operation struct {
+	kind   operationKind
+	before *entity.Task
+	after  *entity.Task
+}
+
+// change groups the operations a single usecase call made; they are undone
+// and redone together, the first operation being the one reported back.
+type change []operation
+
+type undoHistory struct {
+	undo []change
+	redo []change
+}
+
+// WithUndoDepth bounds how many operations each session can undo.
+func WithUndoDepth(n int) Option {
+	return func(u *TasksUsecase) {
+		u.undoDepth = n
+	}
+}
+
+// Undo reverts the most recent create, update or delete made by the actor's
+// session, recording assignee changes it reverts in the task's history. An
+// operation whose task was changed since is dropped and ErrorConflict
+// returned, as is ErrorForbidden once the actor lost access to its list.
+// Operations whose tasks would be nested or blocked in a cycle, by changes
+// made to other tasks since, are dropped with ErrorConflict as well.
+func (u *TasksUsecase) Undo(a Actor) (*TaskOutput, error) {
+	h := u.histories[a.SessionId]
+	if h == nil || len(h.undo) == 0 {
+		return nil, ErrorNothingToUndo
+	}
+
+	c := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+
+	if !u.canChange(a, c) {
+		return nil, ErrorForbidden
+	}
+	for _, op := range c {
+		if !u.matches(op, true) {
+			return nil, ErrorConflict
+		}
+	}
+	if err := u.checkWritten(a, c, true); err != nil {
+		return nil, err
+	}
+
+	var task *entity.Task
+	for i := len(c) - 1; i >= 0; i-- {
+		t, err := u.revert(c[i])
+		if err != nil {
+			return nil, err
+		}
+		if c[i].kind == updateOperation {
+			u.emitAssignment(a, c[i].after, c[i].before)
+		}
+		task = t
+	}
+
+	touch(a, c)
+	h.redo = append(h.redo, c)
+	return toTaskOutput(task), nil
+}
+
+// Redo reapplies the most recently undone operation of the actor's session,
+// recording assignee changes as Undo does.
+func (u *TasksUsecase) Redo(a Actor) (*TaskOutput, error) {
+	h := u.histories[a.SessionId]
+	if h == nil || len(h.redo) == 0 {
+		return nil, ErrorNothingToRedo
+	}
+
+	c := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+
+	if !u.canChange(a, c) {
+		return nil, ErrorForbidden
+	}
+	for _, op := range c {
+		if !u.matches(op, false) {
+			return nil, ErrorConflict
+		}
+	}
+	if err := u.checkWritten(a, c, false); err != nil {
+		return nil, err
+	}
+
+	var task *entity.Task
+	for i, op := range c {
+		t, err := u.reapply(op)
+		if err != nil {
+			return nil, err
+		}
+		if op.kind == updateOperation {
+			u.emitAssignment(a, op.before, op.after)
+		}
+		if i == 0 {
+			task = t
+		}
+	}
+
+	touch(a, c)
+	h.undo = append(h.undo, c)
+	return toTaskOutput(task), nil
+}
+
+func (u *TasksUsecase) record(a Actor, c ...operation) {
+	touch(a, c)
+	if a.SessionId == "" || u.undoDepth <= 0 || len(c) == 0 {
+		return
+	}
+
+	h, ok := u.histories[a.SessionId]
+	if !ok {
+		h = &undoHistory{}
+		u.histories[a.SessionId] = h
+	}
+
+	h.undo = append(h.undo, c)
+	if len(h.undo) > u.undoDepth {
+		h.undo = h.undo[len(h.undo)-u.undoDepth:]
+	}
+	h.redo = nil
+}
+
+// touch adds the tasks of the change to the actor's scope, as they were both
+// before and after.
+func touch(a Actor, c change) {
+	for _, op := range c {
+		a.Scope.AddTask(op.before)
+		a.Scope.AddTask(op.after)
+	}
+}
+
+// canChange reports whether the actor may still change every task the change
+// touched, in the lists they were in before and after, reranked and imported
+// ones included.
+func (u *TasksUsecase) canChange(a Actor, c change) bool {
+	for _, op := range c {
+		for _, t := range []*entity.Task{op.before, op.after} {
+			if t != nil && !u.canWrite(a, t) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matches reports whether the task is still as the operation left it, or as
+// it was before the operation when checking for a redo.
+func (u *TasksUsecase) matches(op operation, applied bool) bool {
+	var current *entity.Task
+	var err error
+	switch {
+	case op.kind == createOperation && applied, op.kind == updateOperation && applied:
+		current, err = u.repo.FindBy(op.after.Id)
+		return err == nil && sameTask(current, op.after)
+	case op.kind == createOperation:
+		current, err = u.repo.FindTrashedBy(op.after.Id)
+		return err == nil && sameTask(current, op.after)
+	case op.kind == deleteOperation && applied:
+		current, err = u.repo.FindTrashedBy(op.before.Id)
+	default:
+		current, err = u.repo.FindBy(op.before.Id)
+	}
+	return err == nil && sameTask(current, op.before)
+}
+
+// checkWritten reports whether the tasks the change writes back, as they were
+// before it on undo or after it on redo, can still be nested under their
+// parents and blocked by their blockers, other tasks having changed since.
+// Tasks of the change are looked up as written back.
+func (u *TasksUsecase) checkWritten(a Actor, c change, undo bool) error {
+	written := map[int]*entity.Task{}
+	for _, op := range c {
+		switch {
+		case op.kind == createOperation && undo:
+			written[op.after.Id] = nil
+		case op.kind == deleteOperation && !undo:
+			written[op.before.Id] = nil
+		case undo:
+			written[op.before.Id] = op.before
+		default:
+			written[op.after.Id] = op.after
+		}
+	}
+	lookup := func(find func(int) (*entity.Task, error)) func(int) (*entity.Task, error) {
+		return func(id int) (*entity.Task, error) {
+			if t, ok := written[id]; ok {
+				if t == nil {
+					return nil, repository.ErrorNotFound
+				}
+				return t, nil
+			}
+			return find(id)
+		}
+	}
+	findVisible := lookup(func(id int) (*entity.Task, error) { return u.find(a, id) })
+	findAny := lookup(func(id int) (*entity.Task, error) { return u.repo.FindBy(id) })
+
+	for _, t := range written {
+		if t == nil {
+			continue
+		}
+		if err := checkParentBy(t.Id, t.ParentId, findVisible); err != nil {
+			return fmt.Errorf("%w: %w", ErrorConflict, err)
+		}
+		for _, b := range t.BlockedBy {
+			if dependsOnBy(b, t.Id, findAny) {
+				return fmt.Errorf("%w: %w", ErrorConflict, ErrorDependencyCycle)
+			}
+		}
+	}
+	return nil
+}
+
+func (u *TasksUsecase) revert(op operation) (*entity.Task, error) {
+	switch op.kind {
+	case createOperation:
+		if err := u.repo.Delete(cloneTask(op.after)); err != nil {
+			return nil, err
+		}
+		return op.after, nil
+	case updateOperation:
+		return u.repo.Update(cloneTask(op.before))
+	default:
+		return u.repo.Restore(cloneTask(op.before))
+	}
+}
+
+func (u *TasksUsecase) reapply(op operation) (*entity.Task, error) {
+	switch op.kind {
+	case createOperation:
+		return u.repo.Restore(cloneTask(op.after))
+	case updateOperation:
+		return u.repo.Update(cloneTask(op.after))
+	default:
+		if err := u.repo.Delete(cloneTask(op.before)); err != nil {
+			return nil, err
+		}
+		return op.before, nil
+	}
+}
+
+func cloneTask(t *entity.Task) *entity.Task {
+	c := *t
+	c.Tags = append([]string(nil), t.Tags...)
+	c.BlockedBy = append([]int(nil), t.BlockedBy...)
+	c.Recurrence = t.Recurrence.Clone()
+	return &c
+}
+
+// sameTask compares two snapshots regardless of whether either is trashed.
+func sameTask(a, b *entity.Task) bool {
+	x, y := cloneTask(a), cloneTask(b)
+	x.DeletedAt, y.DeletedAt = time.Time{}, time.Time{}
+	return reflect.DeepEqual(x, y)
+}
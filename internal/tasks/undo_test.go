@@ -0,0 +1,188 @@
+package tasks_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+)
+
+func Test_Undo(t *testing.T) {
+	alice := tasks.Actor{SessionId: "alice"}
+	bob := tasks.Actor{SessionId: "bob"}
+	none, one, two := 0, 1, 2
+
+	tests := []struct {
+		name        string
+		data        []repository.TaskSchema
+		act         func(u *tasks.TasksUsecase)
+		expected    []*tasks.TaskOutput
+		expectError bool
+		error       error
+	}{
+		{
+			name: "reverts a create",
+			act: func(u *tasks.TasksUsecase) {
+				u.CreateTask(alice, &tasks.CreateTaskInput{Name: "買晚餐"})
+			},
+			expected: []*tasks.TaskOutput{},
+		},
+		{
+			name: "reverts an update",
+			data: []repository.TaskSchema{{Id: 1, Name: "買早餐", Status: 0}},
+			act: func(u *tasks.TasksUsecase) {
+				u.UpdateTask(alice, 1, &tasks.UpdateTaskInput{Name: "買晚餐", Status: 1})
+			},
+			expected: []*tasks.TaskOutput{{Id: 1, Name: "買早餐", Status: 0}},
+		},
+		{
+			name: "reverts a delete",
+			data: []repository.TaskSchema{{Id: 1, Name: "買早餐", Status: 0}},
+			act: func(u *tasks.TasksUsecase) {
+				u.DeleteTask(alice, 1, &tasks.DeleteTaskInput{})
+			},
+			expected: []*tasks.TaskOutput{{Id: 1, Name: "買早餐", Status: 0}},
+		},
+		{
+			name: "reverts only the latest operation",
+			data: []repository.TaskSchema{{Id: 1, Name: "買早餐", Status: 0}},
+			act: func(u *tasks.TasksUsecase) {
+				u.UpdateTask(alice, 1, &tasks.UpdateTaskInput{Name: "買午餐", Status: 0})
+				u.UpdateTask(alice, 1, &tasks.UpdateTaskInput{Name: "買晚餐", Status: 0})
+			},
+			expected: []*tasks.TaskOutput{{Id: 1, Name: "買午餐", Status: 0}},
+		},
+		{
+			name: "returns error when another session changed the task since",
+			data: []repository.TaskSchema{{Id: 1, Name: "買早餐", Status: 0}},
+			act: func(u *tasks.TasksUsecase) {
+				u.UpdateTask(alice, 1, &tasks.UpdateTaskInput{Name: "買午餐", Status: 0})
+				u.UpdateTask(bob, 1, &tasks.UpdateTaskInput{Name: "買晚餐", Status: 0})
+			},
+			expected:    []*tasks.TaskOutput{{Id: 1, Name: "買晚餐", Status: 0}},
+			expectError: true,
+			error:       tasks.ErrorConflict,
+		},
+		{
+			name: "returns error when restoring the parent would nest the task in a cycle",
+			data: []repository.TaskSchema{{Id: 1, Name: "買早餐", Status: 0}, {Id: 2, Name: "買晚餐", Status: 0}},
+			act: func(u *tasks.TasksUsecase) {
+				u.UpdateTask(alice, 1, &tasks.UpdateTaskInput{Name: "買早餐", Status: 0, ParentId: &two})
+				u.UpdateTask(alice, 1, &tasks.UpdateTaskInput{Name: "買早餐", Status: 0, ParentId: &none})
+				u.UpdateTask(bob, 2, &tasks.UpdateTaskInput{Name: "買晚餐", Status: 0, ParentId: &one})
+			},
+			expected:    []*tasks.TaskOutput{{Id: 1, Name: "買早餐", Status: 0, Progress: &tasks.ProgressOutput{Total: 1}}, {Id: 2, Name: "買晚餐", Status: 0, ParentId: 1}},
+			expectError: true,
+			error:       tasks.ErrorCycle,
+		},
+		{
+			name: "returns error when restoring a blocker would block the task in a cycle",
+			data: []repository.TaskSchema{{Id: 1, Name: "買早餐", Status: 0}, {Id: 2, Name: "買晚餐", Status: 0}},
+			act: func(u *tasks.TasksUsecase) {
+				u.AddBlocker(alice, 1, &tasks.BlockerInput{Id: 2})
+				u.RemoveBlocker(alice, 1, 2)
+				u.AddBlocker(bob, 2, &tasks.BlockerInput{Id: 1})
+			},
+			expected:    []*tasks.TaskOutput{{Id: 1, Name: "買早餐", Status: 0}, {Id: 2, Name: "買晚餐", Status: 0, BlockedBy: []int{1}}},
+			expectError: true,
+			error:       tasks.ErrorDependencyCycle,
+		},
+		{
+			name: "returns error when the session has nothing to undo",
+			data: []repository.TaskSchema{{Id: 1, Name: "買早餐", Status: 0}},
+			act: func(u *tasks.TasksUsecase) {
+				u.UpdateTask(bob, 1, &tasks.UpdateTaskInput{Name: "買晚餐", Status: 0})
+			},
+			expected:    []*tasks.TaskOutput{{Id: 1, Name: "買晚餐", Status: 0}},
+			expectError: true,
+			error:       tasks.ErrorNothingToUndo,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			for _, row := range tc.data {
+				repo.PopulateData(row)
+			}
+			usecase := tasks.InitTasksUsecase(repo)
+			tc.act(usecase)
+
+			_, err := usecase.Undo(alice)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else if err != nil {
+				t.Error(err)
+			}
+			util.AssertEqual(t)(usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{}), tc.expected)
+		})
+	}
+}
+
+func Test_Redo(t *testing.T) {
+	alice := tasks.Actor{SessionId: "alice"}
+
+	t.Run("reapplies an undone update", func(t *testing.T) {
+		t.Parallel()
+
+		repo := util.InitMockTaskRepository()
+		repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0})
+		usecase := tasks.InitTasksUsecase(repo)
+		usecase.UpdateTask(alice, 1, &tasks.UpdateTaskInput{Name: "買晚餐", Status: 1})
+		usecase.Undo(alice)
+
+		got, err := usecase.Redo(alice)
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(*got, tasks.TaskOutput{Id: 1, Name: "買晚餐", Status: 1})
+	})
+
+	t.Run("reapplies an undone create", func(t *testing.T) {
+		t.Parallel()
+
+		repo := util.InitMockTaskRepository()
+		usecase := tasks.InitTasksUsecase(repo)
+		usecase.CreateTask(alice, &tasks.CreateTaskInput{Name: "買晚餐"})
+		usecase.Undo(alice)
+
+		usecase.Redo(alice)
+
+		util.AssertEqual(t)(usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{}), []*tasks.TaskOutput{{Id: 1, Name: "買晚餐", Status: 0}})
+	})
+
+	t.Run("returns error after a new operation", func(t *testing.T) {
+		t.Parallel()
+
+		repo := util.InitMockTaskRepository()
+		repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0})
+		usecase := tasks.InitTasksUsecase(repo)
+		usecase.UpdateTask(alice, 1, &tasks.UpdateTaskInput{Name: "買午餐", Status: 0})
+		usecase.Undo(alice)
+		usecase.UpdateTask(alice, 1, &tasks.UpdateTaskInput{Name: "買晚餐", Status: 0})
+
+		_, err := usecase.Redo(alice)
+
+		util.AssertErrorEqual(t)(err, tasks.ErrorNothingToRedo)
+	})
+}
+
+func Test_WithUndoDepth(t *testing.T) {
+	t.Parallel()
+
+	alice := tasks.Actor{SessionId: "alice"}
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0})
+	usecase := tasks.InitTasksUsecase(repo, tasks.WithUndoDepth(1))
+	usecase.UpdateTask(alice, 1, &tasks.UpdateTaskInput{Name: "買午餐", Status: 0})
+	usecase.UpdateTask(alice, 1, &tasks.UpdateTaskInput{Name: "買晚餐", Status: 0})
+
+	usecase.Undo(alice)
+	_, err := usecase.Undo(alice)
+
+	util.AssertErrorEqual(t)(err, tasks.ErrorNothingToUndo)
+	util.AssertEqual(t)(usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{}), []*tasks.TaskOutput{{Id: 1, Name: "買午餐", Status: 0}})
+}
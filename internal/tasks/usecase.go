@@ -1,66 +1,328 @@
 package tasks
 
 import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/access"
+	listentity "github.com/dannyh79/whostodo/internal/lists/entities"
 	"github.com/dannyh79/whostodo/internal/repository"
 	entity "github.com/dannyh79/whostodo/internal/tasks/entities"
 )
 
+// Trashed tasks older than this are purged unless overridden by
+// WithTrashRetention.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// Task descriptions longer than this many bytes are rejected.
+const MaxDescriptionSize = 16 * 1024
+
+var (
+	ErrorListNotFound       = errors.New("List not found")
+	ErrorDescriptionTooLong = errors.New("Description too long")
+)
+
+// Actor identifies who calls into the usecase.
+type Actor struct {
+	SessionId string
+	User      string
+	// Collects the tasks the call changes, if set, for telling watchers of
+	// the workspace who may see the change.
+	Scope *access.Scope
+}
+
 type TaskOutput struct {
-	Id     int    `json:"id"`
-	Name   string `json:"name"`
-	Status int    `json:"status"`
+	Id          int               `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Status      int               `json:"status"`
+	Priority    string            `json:"priority,omitempty"`
+	ListId      int               `json:"list_id,omitempty"`
+	ParentId    int               `json:"parent_id,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	BlockedBy   []int             `json:"blocked_by,omitempty"`
+	DueAt       *time.Time        `json:"due_at,omitempty"`
+	Recurrence  *RecurrenceOutput `json:"recurrence,omitempty"`
+	Creator     string            `json:"creator,omitempty"`
+	Assignee    string            `json:"assignee,omitempty"`
+	Progress    *ProgressOutput   `json:"progress,omitempty"`
+}
+
+type TrashedTaskOutput struct {
+	TaskOutput
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type ListTasksInput struct {
+	// Nil lists tasks of every list; listentity.InboxId lists unassigned ones.
+	ListId *int     `form:"list"`
+	Tags   []string `form:"tag"`
+	// Either MatchAny or MatchAll; empty defaults to MatchAny.
+	Match string `form:"match"`
+	// Either SortByPriority or empty for the manual order.
+	Sort string `form:"sort"`
+	// Empty lists tasks regardless of whom they are assigned to.
+	Assignee string `form:"assignee"`
 }
 
 type CreateTaskInput struct {
 	Name string `json:"name"`
+	// Markdown, up to MaxDescriptionSize bytes.
+	Description string           `json:"description"`
+	Priority    string           `json:"priority"`
+	ListId      int              `json:"list_id"`
+	ParentId    int              `json:"parent_id"`
+	Tags        []string         `json:"tags"`
+	DueAt       *time.Time       `json:"due_at"`
+	Recurrence  *RecurrenceInput `json:"recurrence"`
 }
 
 type UpdateTaskInput struct {
-	Name   string `json:"name"`
-	Status int    `json:"status"`
+	Name string `json:"name"`
+	// Nil keeps the current description.
+	Description *string `json:"description"`
+	Status      int     `json:"status"`
+	// Nil keeps the current priority.
+	Priority *string `json:"priority"`
+	// Nil keeps the task in its current list.
+	ListId *int `json:"list_id"`
+	// Nil keeps the task under its current parent; 0 makes it a root task.
+	ParentId *int `json:"parent_id"`
+	// Nil keeps the current due date, unless ClearDueAt.
+	DueAt *time.Time `json:"due_at"`
+	// Removes the due date; set by a due_at of null in JSON.
+	ClearDueAt bool `json:"-"`
+	// Nil keeps the current recurrence; an empty frequency removes it.
+	Recurrence *RecurrenceInput `json:"recurrence"`
 }
 
-type TaskRepository repository.Repository[entity.Task]
+// UnmarshalJSON tells a due_at of null, which removes the due date, from one
+// left out, which keeps it.
+func (i *UpdateTaskInput) UnmarshalJSON(data []byte) error {
+	type input UpdateTaskInput
+	if err := json.Unmarshal(data, (*input)(i)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	dueAt, ok := fields["due_at"]
+	i.ClearDueAt = ok && string(dueAt) == "null"
+	return nil
+}
+
+type TaskRepository interface {
+	repository.Repository[entity.Task]
+	repository.Trash[entity.Task]
+	repository.Tags[entity.Task]
+	repository.Search[entity.Task]
+}
+
+type ListRepository repository.Repository[listentity.List]
+
+// AttachedRepository stores rows attached to tasks, such as comments, which
+// are removed along when their task is purged.
+type AttachedRepository interface {
+	DeleteByTask(taskId int) int
+}
 
 type TasksUsecase struct {
-	repo TaskRepository
+	repo           TaskRepository
+	lists          ListRepository
+	attached       []AttachedRepository
+	history        HistoryRepository
+	access         access.Policy
+	isMember       func(user string) bool
+	trashRetention time.Duration
+	undoDepth      int
+	histories      map[string]*undoHistory
+	icalDomain     string
+}
+
+type Option func(*TasksUsecase)
+
+// WithTrashRetention sets how long trashed tasks are kept; zero or negative
+// values keep them until purged by hand.
+func WithTrashRetention(d time.Duration) Option {
+	return func(u *TasksUsecase) {
+		u.trashRetention = d
+	}
 }
 
-func (u *TasksUsecase) ListTasks() []*TaskOutput {
+// WithListRepository makes the usecase check that tasks are assigned to
+// existing lists.
+func WithListRepository(lists ListRepository) Option {
+	return func(u *TasksUsecase) {
+		u.lists = lists
+	}
+}
+
+// WithAttachedRepository removes rows of the repository along with the task
+// they are attached to when it is purged.
+func WithAttachedRepository(attached AttachedRepository) Option {
+	return func(u *TasksUsecase) {
+		u.attached = append(u.attached, attached)
+	}
+}
+
+func (u *TasksUsecase) ListTasks(a Actor, i *ListTasksInput) []*TaskOutput {
 	var output = make([]*TaskOutput, 0)
 
-	tasks := u.repo.ListAll()
+	all := u.listAll(a)
+	children := childrenIndex(all)
+	tasks := all
+	if len(i.Tags) > 0 {
+		tasks = u.visible(a, u.repo.FindByTags(entity.NormalizeTags(i.Tags), i.Match == MatchAll))
+	}
+	if i.Sort == SortByPriority {
+		tasks = sortByPriority(tasks)
+	}
 	for _, task := range tasks {
-		output = append(output, toTaskOutput(task))
+		if i.ListId != nil && task.ListId != *i.ListId {
+			continue
+		}
+		if i.Assignee != "" && task.Assignee != i.Assignee {
+			continue
+		}
+		t := toTaskOutput(task)
+		t.Progress = progress(task.Id, children)
+		output = append(output, t)
 	}
 
 	return output
 }
 
-func (u *TasksUsecase) CreateTask(i *CreateTaskInput) *TaskOutput {
-	task := u.repo.Save(&entity.Task{Name: i.Name})
-	return toTaskOutput(&task)
+func (u *TasksUsecase) GetTask(a Actor, id int) (*TaskOutput, error) {
+	task, err := u.find(a, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.present(a, task), nil
 }
 
-func (u *TasksUsecase) UpdateTask(id int, i *UpdateTaskInput) (*TaskOutput, error) {
-	task, err := u.repo.FindBy(id)
+func (u *TasksUsecase) CreateTask(a Actor, i *CreateTaskInput) (*TaskOutput, error) {
+	if err := u.checkList(i.ListId); err != nil {
+		return nil, err
+	}
+	if err := u.checkWritableList(a, i.ListId); err != nil {
+		return nil, err
+	}
+	if err := u.checkParent(a, 0, i.ParentId); err != nil {
+		return nil, err
+	}
+	recurrence, err := toRecurrence(i.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	priority, err := toPriority(i.Priority)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkDescription(i.Description); err != nil {
+		return nil, err
+	}
+	var dueAt time.Time
+	if i.DueAt != nil {
+		dueAt = *i.DueAt
+	}
 
-	task.Name = i.Name
-	task.Status = i.Status
+	task := u.repo.Save(&entity.Task{
+		Name:        i.Name,
+		Description: i.Description,
+		Priority:    priority,
+		Position:    u.nextPosition(),
+		ListId:      i.ListId,
+		ParentId:    i.ParentId,
+		Tags:        entity.NormalizeTags(i.Tags),
+		DueAt:       dueAt,
+		Recurrence:  recurrence,
+		Creator:     a.User,
+		CreatedAt:   time.Now(),
+	})
+	u.record(a, operation{kind: createOperation, after: cloneTask(&task)})
+	return toTaskOutput(&task), nil
+}
 
-	updated, err := u.repo.Update(task)
+func (u *TasksUsecase) UpdateTask(a Actor, id int, i *UpdateTaskInput) (*TaskOutput, error) {
+	task, err := u.findWritable(a, id)
 	if err != nil {
 		return nil, err
 	}
+	before := cloneTask(task)
+
+	task.Name = i.Name
+	if i.Description != nil {
+		if err := checkDescription(*i.Description); err != nil {
+			return nil, err
+		}
+		task.Description = *i.Description
+	}
+	task.SetStatus(i.Status, time.Now())
+	if i.Priority != nil {
+		if task.Priority, err = toPriority(*i.Priority); err != nil {
+			return nil, err
+		}
+	}
+	if i.ListId != nil {
+		if err := u.checkList(*i.ListId); err != nil {
+			return nil, err
+		}
+		if err := u.checkWritableList(a, *i.ListId); err != nil {
+			return nil, err
+		}
+		task.ListId = *i.ListId
+	}
+	if i.ParentId != nil {
+		if err := u.checkParent(a, task.Id, *i.ParentId); err != nil {
+			return nil, err
+		}
+		task.ParentId = *i.ParentId
+	}
+	if i.DueAt != nil {
+		task.DueAt = *i.DueAt
+	} else if i.ClearDueAt {
+		task.DueAt = time.Time{}
+	}
+	if i.Recurrence != nil {
+		if task.Recurrence, err = toRecurrence(i.Recurrence); err != nil {
+			return nil, err
+		}
+	}
 
-	return toTaskOutput(updated), nil
+	if task.IsDone() && !before.IsDone() {
+		if err := u.checkBlockers(task); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, ops, err := u.update(before, task)
+	if err != nil {
+		return nil, err
+	}
+	u.record(a, ops...)
+	return u.present(a, updated), nil
 }
 
-func (u *TasksUsecase) DeleteTask(id int) error {
-	task, err := u.repo.FindBy(id)
+// DeleteTask moves the task into the trash, with its subtasks either moved up
+// to its parent or trashed along.
+func (u *TasksUsecase) DeleteTask(a Actor, id int, i *DeleteTaskInput) error {
+	if i.Children != "" && i.Children != Reparent && i.Children != Cascade {
+		return ErrorUnknownDeleteMode
+	}
+
+	task, err := u.findWritable(a, id)
+	if err != nil {
+		return err
+	}
+	if !u.canDetachChildren(a, task, i.Children) {
+		return ErrorForbidden
+	}
+
+	ops, err := u.detachChildren(task, i.Children)
 	if err != nil {
 		return err
 	}
@@ -70,17 +332,229 @@ func (u *TasksUsecase) DeleteTask(id int) error {
 		return err
 	}
 
+	u.record(a, append([]operation{{kind: deleteOperation, before: task}}, ops...)...)
+	u.PurgeExpiredTasks()
 	return nil
 }
 
-func InitTasksUsecase(repo TaskRepository) *TasksUsecase {
-	return &TasksUsecase{repo}
+// ClearList moves every task of the list into the inbox, after every task in
+// their order, and into the trash along when trash is set, for the list to be
+// deleted. Moved tasks are then seen by their creators and assignees only,
+// who may restore them when trashed. The moves are recorded as one change,
+// which cannot be undone once the list is gone.
+func (u *TasksUsecase) ClearList(a Actor, listId int, trash bool) error {
+	if err := u.checkWritableList(a, listId); err != nil {
+		return err
+	}
+
+	var ops []operation
+	for _, task := range u.repo.ListAll() {
+		if task.ListId != listId {
+			continue
+		}
+
+		before := cloneTask(task)
+		task.ListId = listentity.InboxId
+		task.Position = u.nextPosition()
+		updated, err := u.repo.Update(task)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, operation{kind: updateOperation, before: before, after: cloneTask(updated)})
+
+		if trash {
+			if err := u.repo.Delete(updated); err != nil {
+				return err
+			}
+			ops = append(ops, operation{kind: deleteOperation, before: cloneTask(updated)})
+		}
+	}
+
+	u.record(a, ops...)
+	return nil
+}
+
+func (u *TasksUsecase) ListTrashedTasks(a Actor) []*TrashedTaskOutput {
+	var output = make([]*TrashedTaskOutput, 0)
+
+	u.PurgeExpiredTasks()
+	for _, task := range u.visible(a, u.repo.ListTrashed()) {
+		output = append(output, toTrashedTaskOutput(task))
+	}
+
+	return output
+}
+
+// RestoreTask takes the task out of the trash, back into its list or into the
+// inbox if the list was deleted meanwhile. A task whose parent is gone
+// becomes a root task.
+func (u *TasksUsecase) RestoreTask(a Actor, id int) (*TaskOutput, error) {
+	task, err := u.findTrashed(a, id)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := u.repo.Restore(task)
+	if err != nil {
+		return nil, err
+	}
+	a.Scope.AddTask(restored)
+
+	listGone := u.checkList(restored.ListId) != nil
+	parentGone := u.checkParent(a, restored.Id, restored.ParentId) != nil
+	if listGone || parentGone {
+		if listGone {
+			restored.ListId = listentity.InboxId
+		}
+		if parentGone {
+			restored.ParentId = 0
+		}
+		if restored, err = u.repo.Update(restored); err != nil {
+			return nil, err
+		}
+		a.Scope.AddTask(restored)
+	}
+
+	return u.present(a, restored), nil
+}
+
+// PurgeTask permanently removes a trashed task.
+func (u *TasksUsecase) PurgeTask(a Actor, id int) error {
+	task, err := u.findTrashed(a, id)
+	if err != nil {
+		return err
+	}
+
+	a.Scope.AddTask(task)
+	return u.purge(task)
+}
+
+// PurgeExpiredTasks permanently removes tasks trashed longer than the
+// retention ago and returns how many were removed. It runs on every delete
+// and trash listing, so expired tasks never show up in the trash.
+func (u *TasksUsecase) PurgeExpiredTasks() int {
+	if u.trashRetention <= 0 {
+		return 0
+	}
+
+	var purged int
+	expiredAt := time.Now().Add(-u.trashRetention)
+	for _, task := range u.repo.ListTrashed() {
+		if task.DeletedAt.After(expiredAt) {
+			continue
+		}
+		if err := u.purge(task); err == nil {
+			purged += 1
+		}
+	}
+
+	return purged
+}
+
+// purge permanently removes the task with whatever is attached to it.
+func (u *TasksUsecase) purge(task *entity.Task) error {
+	if err := u.repo.Purge(task); err != nil {
+		return err
+	}
+	for _, attached := range u.attached {
+		attached.DeleteByTask(task.Id)
+	}
+	return nil
+}
+
+func (u *TasksUsecase) checkList(id int) error {
+	if u.lists == nil || id == listentity.InboxId {
+		return nil
+	}
+	if _, err := u.lists.FindBy(id); err != nil {
+		return ErrorListNotFound
+	}
+	return nil
+}
+
+func checkDescription(description string) error {
+	if len(description) > MaxDescriptionSize {
+		return ErrorDescriptionTooLong
+	}
+	return nil
+}
+
+// save updates the task and records the change for undo.
+func (u *TasksUsecase) save(a Actor, before *entity.Task, task *entity.Task) (*TaskOutput, error) {
+	updated, err := u.repo.Update(task)
+	if err != nil {
+		return nil, err
+	}
+
+	u.record(a, operation{kind: updateOperation, before: before, after: cloneTask(updated)})
+	return u.present(a, updated), nil
+}
+
+// update saves the task without recording it, returning the operations to
+// record. Completing a recurring task spawns its next occurrence along, so
+// both are undone in one step.
+func (u *TasksUsecase) update(before *entity.Task, task *entity.Task) (*entity.Task, []operation, error) {
+	var recurrence *entity.Recurrence
+	if task.IsDone() && !before.IsDone() && task.IsRecurring() {
+		recurrence, task.Recurrence = task.Recurrence, nil
+	}
+	updated, err := u.repo.Update(task)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ops := []operation{{kind: updateOperation, before: before, after: cloneTask(updated)}}
+	if recurrence != nil {
+		if spawned, ok := u.spawnNext(updated, recurrence); ok {
+			ops = append(ops, spawned)
+		}
+	}
+	return updated, ops, nil
+}
+
+// present converts the task into output, including the progress of the
+// subtasks the actor may see.
+func (u *TasksUsecase) present(a Actor, t *entity.Task) *TaskOutput {
+	output := toTaskOutput(t)
+	output.Progress = progress(t.Id, childrenIndex(u.listAll(a)))
+	return output
+}
+
+func InitTasksUsecase(repo TaskRepository, opts ...Option) *TasksUsecase {
+	u := &TasksUsecase{
+		repo:           repo,
+		trashRetention: DefaultTrashRetention,
+		undoDepth:      DefaultUndoDepth,
+		histories:      map[string]*undoHistory{},
+		icalDomain:     DefaultICalDomain,
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
 }
 
 func toTaskOutput(t *entity.Task) *TaskOutput {
 	return &TaskOutput{
-		Id:     t.Id,
-		Name:   t.Name,
-		Status: t.Status,
+		Id:          t.Id,
+		Name:        t.Name,
+		Description: t.Description,
+		Status:      t.Status,
+		Priority:    toPriorityOutput(t.Priority),
+		ListId:      t.ListId,
+		ParentId:    t.ParentId,
+		Tags:        t.Tags,
+		BlockedBy:   t.BlockedBy,
+		DueAt:       toDueAt(t.DueAt),
+		Recurrence:  toRecurrenceOutput(t.Recurrence),
+		Creator:     t.Creator,
+		Assignee:    t.Assignee,
+	}
+}
+
+func toTrashedTaskOutput(t *entity.Task) *TrashedTaskOutput {
+	return &TrashedTaskOutput{
+		TaskOutput: *toTaskOutput(t),
+		DeletedAt:  t.DeletedAt,
 	}
 }
@@ -1,7 +1,9 @@
 package tasks_test
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dannyh79/whostodo/internal/repository"
 	"github.com/dannyh79/whostodo/internal/tasks"
@@ -36,7 +38,7 @@ func Test_ListTasks(t *testing.T) {
 				}
 			}
 			usecase := tasks.InitTasksUsecase(repo)
-			got := usecase.ListTasks()
+			got := usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{})
 
 			util.AssertEqual(t)(got, tc.expected)
 		})
@@ -62,7 +64,7 @@ func Test_CreateTask(t *testing.T) {
 
 			repo := util.InitMockTaskRepository()
 			usecase := tasks.InitTasksUsecase(repo)
-			got := usecase.CreateTask(&tc.data)
+			got, _ := usecase.CreateTask(tasks.Actor{}, &tc.data)
 
 			util.AssertEqual(t)(*got, tc.expected)
 		})
@@ -93,7 +95,7 @@ func Test_UpdateTask(t *testing.T) {
 			param:       2,
 			payload:     tasks.UpdateTaskInput{Name: "買晚餐", Status: 1},
 			expectError: true,
-			error:       util.MockNotFoundError,
+			error:       repository.ErrorNotFound,
 		},
 	}
 
@@ -104,7 +106,7 @@ func Test_UpdateTask(t *testing.T) {
 			repo := util.InitMockTaskRepository()
 			repo.PopulateData(tc.data)
 			usecase := tasks.InitTasksUsecase(repo)
-			got, err := usecase.UpdateTask(tc.param, &tc.payload)
+			got, err := usecase.UpdateTask(tasks.Actor{}, tc.param, &tc.payload)
 
 			if tc.expectError {
 				util.AssertErrorEqual(t)(err, tc.error)
@@ -137,7 +139,7 @@ func Test_DeteleTask(t *testing.T) {
 			data:        repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0},
 			param:       2,
 			expectError: true,
-			error:       util.MockNotFoundError,
+			error:       repository.ErrorNotFound,
 		},
 	}
 
@@ -148,7 +150,7 @@ func Test_DeteleTask(t *testing.T) {
 			repo := util.InitMockTaskRepository()
 			repo.PopulateData(tc.data)
 			usecase := tasks.InitTasksUsecase(repo)
-			err := usecase.DeleteTask(tc.param)
+			err := usecase.DeleteTask(tasks.Actor{}, tc.param, &tasks.DeleteTaskInput{})
 
 			if tc.expectError {
 				util.AssertErrorEqual(t)(err, tc.error)
@@ -160,3 +162,306 @@ func Test_DeteleTask(t *testing.T) {
 		})
 	}
 }
+
+func Test_ListTrashedTasks(t *testing.T) {
+	deletedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		data      []repository.TaskSchema
+		retention time.Duration
+		expected  []*tasks.TrashedTaskOutput
+	}{
+		{
+			name: "returns trashed tasks",
+			data: []repository.TaskSchema{
+				{Id: 1, Name: "買早餐", Status: 0},
+				{Id: 2, Name: "買晚餐", Status: 0, DeletedAt: deletedAt},
+			},
+			retention: tasks.DefaultTrashRetention,
+			expected: []*tasks.TrashedTaskOutput{
+				{TaskOutput: tasks.TaskOutput{Id: 2, Name: "買晚餐", Status: 0}, DeletedAt: deletedAt},
+			},
+		},
+		{
+			name:      "purges tasks trashed beyond retention",
+			data:      []repository.TaskSchema{{Id: 1, Name: "買晚餐", Status: 0, DeletedAt: deletedAt}},
+			retention: time.Minute,
+			expected:  []*tasks.TrashedTaskOutput{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			for _, row := range tc.data {
+				repo.PopulateData(row)
+			}
+			usecase := tasks.InitTasksUsecase(repo, tasks.WithTrashRetention(tc.retention))
+			got := usecase.ListTrashedTasks(tasks.Actor{})
+
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+}
+
+func Test_RestoreTask(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        repository.TaskSchema
+		param       int
+		expected    tasks.TaskOutput
+		expectError bool
+		error       error
+	}{
+		{
+			name:     "returns restored task",
+			data:     repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0, DeletedAt: time.Now()},
+			param:    1,
+			expected: tasks.TaskOutput{Id: 1, Name: "買早餐", Status: 0},
+		},
+		{
+			name:        "returns error when task is not trashed",
+			data:        repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0},
+			param:       1,
+			expectError: true,
+			error:       repository.ErrorNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(tc.data)
+			usecase := tasks.InitTasksUsecase(repo)
+			got, err := usecase.RestoreTask(tasks.Actor{}, tc.param)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				if err != nil {
+					t.Error(err)
+				}
+				util.AssertEqual(t)(*got, tc.expected)
+				util.AssertEqual(t)(len(usecase.ListTasks(tasks.Actor{}, &tasks.ListTasksInput{})), 1)
+			}
+		})
+	}
+}
+
+func Test_PurgeTask(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        repository.TaskSchema
+		param       int
+		expectError bool
+		error       error
+	}{
+		{
+			name:  "purges the task",
+			data:  repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0, DeletedAt: time.Now()},
+			param: 1,
+		},
+		{
+			name:        "returns error when task is not trashed",
+			data:        repository.TaskSchema{Id: 1, Name: "買早餐", Status: 0},
+			param:       1,
+			expectError: true,
+			error:       repository.ErrorNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(tc.data)
+			usecase := tasks.InitTasksUsecase(repo)
+			err := usecase.PurgeTask(tasks.Actor{}, tc.param)
+
+			if tc.expectError {
+				util.AssertErrorEqual(t)(err, tc.error)
+			} else {
+				if err != nil {
+					t.Error(err)
+				}
+				util.AssertEqual(t)(len(repo.Data), 0)
+			}
+		})
+	}
+}
+
+func Test_PurgeTaskWithAttached(t *testing.T) {
+	t.Parallel()
+
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買早餐", DeletedAt: time.Now()})
+	comments := util.InitMockCommentRepository()
+	comments.PopulateData(repository.CommentSchema{Id: 1, TaskId: 1, Author: "alice", Body: "買了"})
+	comments.PopulateData(repository.CommentSchema{Id: 2, TaskId: 2, Author: "alice", Body: "還沒"})
+	usecase := tasks.InitTasksUsecase(repo, tasks.WithAttachedRepository(comments))
+
+	err := usecase.PurgeTask(tasks.Actor{}, 1)
+
+	util.AssertErrorEqual(t)(err, nil)
+	util.AssertEqual(t)(len(comments.Data), 1)
+	util.AssertEqual(t)(comments.Data[2].TaskId, 2)
+}
+
+func Test_ListTasksByList(t *testing.T) {
+	inbox, chores := 0, 1
+
+	tests := []struct {
+		name     string
+		param    tasks.ListTasksInput
+		expected []*tasks.TaskOutput
+	}{
+		{
+			name:  "returns tasks of every list",
+			param: tasks.ListTasksInput{},
+			expected: []*tasks.TaskOutput{
+				{Id: 1, Name: "買晚餐", Status: 0},
+				{Id: 2, Name: "洗碗", Status: 0, ListId: 1},
+			},
+		},
+		{
+			name:     "returns tasks of the list",
+			param:    tasks.ListTasksInput{ListId: &chores},
+			expected: []*tasks.TaskOutput{{Id: 2, Name: "洗碗", Status: 0, ListId: 1}},
+		},
+		{
+			name:     "returns tasks of the inbox",
+			param:    tasks.ListTasksInput{ListId: &inbox},
+			expected: []*tasks.TaskOutput{{Id: 1, Name: "買晚餐", Status: 0}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := util.InitMockTaskRepository()
+			repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Status: 0})
+			repo.PopulateData(repository.TaskSchema{Id: 2, Name: "洗碗", Status: 0, ListId: 1})
+			usecase := tasks.InitTasksUsecase(repo)
+			got := usecase.ListTasks(tasks.Actor{}, &tc.param)
+
+			util.AssertEqual(t)(got, tc.expected)
+		})
+	}
+}
+
+func Test_AssignTaskToList(t *testing.T) {
+	chores, unknown := 1, 2
+
+	t.Run("creates task in the list", func(t *testing.T) {
+		t.Parallel()
+
+		lists := util.InitMockListRepository()
+		lists.PopulateData(repository.ListSchema{Id: 1, Name: "家事"})
+		usecase := tasks.InitTasksUsecase(util.InitMockTaskRepository(), tasks.WithListRepository(lists))
+
+		got, err := usecase.CreateTask(tasks.Actor{}, &tasks.CreateTaskInput{Name: "洗碗", ListId: chores})
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(*got, tasks.TaskOutput{Id: 1, Name: "洗碗", ListId: 1})
+	})
+
+	t.Run("returns error creating task in unknown list", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := tasks.InitTasksUsecase(util.InitMockTaskRepository(), tasks.WithListRepository(util.InitMockListRepository()))
+
+		_, err := usecase.CreateTask(tasks.Actor{}, &tasks.CreateTaskInput{Name: "洗碗", ListId: unknown})
+
+		util.AssertErrorEqual(t)(err, tasks.ErrorListNotFound)
+	})
+
+	t.Run("moves task into the list", func(t *testing.T) {
+		t.Parallel()
+
+		lists := util.InitMockListRepository()
+		lists.PopulateData(repository.ListSchema{Id: 1, Name: "家事"})
+		repo := util.InitMockTaskRepository()
+		repo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗"})
+		usecase := tasks.InitTasksUsecase(repo, tasks.WithListRepository(lists))
+
+		got, err := usecase.UpdateTask(tasks.Actor{}, 1, &tasks.UpdateTaskInput{Name: "洗碗", ListId: &chores})
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(*got, tasks.TaskOutput{Id: 1, Name: "洗碗", ListId: 1})
+	})
+
+	t.Run("restores task of a deleted list into the inbox", func(t *testing.T) {
+		t.Parallel()
+
+		repo := util.InitMockTaskRepository()
+		repo.PopulateData(repository.TaskSchema{Id: 1, Name: "洗碗", ListId: 1, DeletedAt: time.Now()})
+		usecase := tasks.InitTasksUsecase(repo, tasks.WithListRepository(util.InitMockListRepository()))
+
+		got, err := usecase.RestoreTask(tasks.Actor{}, 1)
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(*got, tasks.TaskOutput{Id: 1, Name: "洗碗"})
+	})
+}
+
+func Test_GetTask(t *testing.T) {
+	repo := util.InitMockTaskRepository()
+	repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Description: "- 牛奶\n- 雞蛋"})
+
+	t.Run("returns task", func(t *testing.T) {
+		usecase := tasks.InitTasksUsecase(repo)
+		got, err := usecase.GetTask(tasks.Actor{}, 1)
+
+		util.AssertErrorEqual(t)(err, nil)
+		util.AssertEqual(t)(*got, tasks.TaskOutput{Id: 1, Name: "買晚餐", Description: "- 牛奶\n- 雞蛋"})
+	})
+
+	t.Run("returns error when not found", func(t *testing.T) {
+		usecase := tasks.InitTasksUsecase(repo)
+		_, err := usecase.GetTask(tasks.Actor{}, 9)
+
+		util.AssertErrorEqual(t)(err, repository.ErrorNotFound)
+	})
+}
+
+func Test_TaskDescription(t *testing.T) {
+	tooLong := strings.Repeat("餐", tasks.MaxDescriptionSize/3+1)
+
+	t.Run("keeps description when not given", func(t *testing.T) {
+		t.Parallel()
+
+		repo := util.InitMockTaskRepository()
+		repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Description: "**快**"})
+		usecase := tasks.InitTasksUsecase(repo)
+		got, _ := usecase.UpdateTask(tasks.Actor{}, 1, &tasks.UpdateTaskInput{Name: "買宵夜"})
+
+		util.AssertEqual(t)(got.Description, "**快**")
+	})
+
+	t.Run("returns error creating task with too long description", func(t *testing.T) {
+		t.Parallel()
+
+		usecase := tasks.InitTasksUsecase(util.InitMockTaskRepository())
+		_, err := usecase.CreateTask(tasks.Actor{}, &tasks.CreateTaskInput{Name: "買晚餐", Description: tooLong})
+
+		util.AssertErrorEqual(t)(err, tasks.ErrorDescriptionTooLong)
+	})
+
+	t.Run("returns error updating task with too long description", func(t *testing.T) {
+		t.Parallel()
+
+		repo := util.InitMockTaskRepository()
+		repo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐"})
+		usecase := tasks.InitTasksUsecase(repo)
+		_, err := usecase.UpdateTask(tasks.Actor{}, 1, &tasks.UpdateTaskInput{Name: "買晚餐", Description: &tooLong})
+
+		util.AssertErrorEqual(t)(err, tasks.ErrorDescriptionTooLong)
+	})
+}
@@ -1,11 +1,21 @@
 package testutil_test
 
 import (
+	"bytes"
 	"errors"
+	"io"
+	"sort"
 	"time"
 
+	attachmententity "github.com/dannyh79/whostodo/internal/attachments/entities"
+	"github.com/dannyh79/whostodo/internal/blob"
+	commententity "github.com/dannyh79/whostodo/internal/comments/entities"
+	listentity "github.com/dannyh79/whostodo/internal/lists/entities"
 	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/search"
+	sessionentity "github.com/dannyh79/whostodo/internal/sessions/entities"
 	"github.com/dannyh79/whostodo/internal/tasks/entities"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type TaskSchema = repository.TaskSchema
@@ -20,44 +30,798 @@ var MockNotFoundError = errors.New("not found")
 
 func (r *MockTaskRepository) FindBy(id any) (*Task, error) {
 	row, ok := r.Data[id.(int)]
-	if !ok {
+	if !ok || !row.DeletedAt.IsZero() {
 		return nil, MockNotFoundError
 	}
-	return &Task{Id: row.Id, Name: row.Name, Status: row.Status}, nil
+	return toMockTask(row), nil
 }
 
 func (r *MockTaskRepository) Update(t *Task) (*Task, error) {
-	r.Data[t.Id] = TaskSchema{Id: t.Id, Name: t.Name, Status: t.Status}
-	return &Task{Id: t.Id, Name: t.Name, Status: t.Status}, nil
+	r.Data[t.Id] = toMockTaskSchema(t)
+	return toMockTask(r.Data[t.Id]), nil
 }
 
 func (r *MockTaskRepository) Save(t *Task) Task {
 	t.Id = len(r.Data) + 1
+	r.Data[t.Id] = toMockTaskSchema(t)
 	return *t
 }
 
 func (r *MockTaskRepository) Delete(t *Task) error {
+	row, ok := r.Data[t.Id]
+	if !ok {
+		return nil
+	}
+	row.DeletedAt = time.Now()
+	r.Data[t.Id] = row
 	return nil
 }
 
 func (r *MockTaskRepository) ListAll() []*Task {
 	var tasks []*entity.Task
-	for _, row := range r.Data {
-		tasks = append(tasks, entity.NewTask(row.Id, row.Name, row.Status))
+	for _, row := range r.sortedRows() {
+		if row.DeletedAt.IsZero() {
+			tasks = append(tasks, toMockTask(row))
+		}
+	}
+	return tasks
+}
+
+func (r *MockTaskRepository) ListTrashed() []*Task {
+	var tasks []*entity.Task
+	for _, row := range r.sortedRows() {
+		if !row.DeletedAt.IsZero() {
+			tasks = append(tasks, toMockTask(row))
+		}
+	}
+	return tasks
+}
+
+func (r *MockTaskRepository) FindTrashedBy(id any) (*Task, error) {
+	row, ok := r.Data[id.(int)]
+	if !ok || row.DeletedAt.IsZero() {
+		return nil, MockNotFoundError
+	}
+	return toMockTask(row), nil
+}
+
+func (r *MockTaskRepository) Restore(t *Task) (*Task, error) {
+	row := r.Data[t.Id]
+	row.DeletedAt = time.Time{}
+	r.Data[t.Id] = row
+	return toMockTask(row), nil
+}
+
+func (r *MockTaskRepository) Purge(t *Task) error {
+	delete(r.Data, t.Id)
+	return nil
+}
+
+func (r *MockTaskRepository) ListTags() []repository.TagCount {
+	counts := map[string]int{}
+	for _, task := range r.ListAll() {
+		for _, tag := range task.Tags {
+			counts[tag] += 1
+		}
+	}
+	tags := []repository.TagCount{}
+	for name, count := range counts {
+		tags = append(tags, repository.TagCount{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags
+}
+
+func (r *MockTaskRepository) FindByTags(tags []string, matchAll bool) []*Task {
+	var tasks []*Task
+	for _, task := range r.ListAll() {
+		matched := 0
+		for _, tag := range tags {
+			if task.HasTag(tag) {
+				matched += 1
+			}
+		}
+		if (matchAll && matched == len(tags)) || (!matchAll && matched > 0) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+func (r *MockTaskRepository) RenameTag(from string, to string, ids []int) int {
+	var renamed int
+	for _, id := range ids {
+		row, ok := r.Data[id]
+		if !ok {
+			continue
+		}
+		task := toMockTask(row)
+		if !task.HasTag(from) {
+			continue
+		}
+		task.RemoveTag(from)
+		task.AddTags(to)
+		r.Data[id] = toMockTaskSchema(task)
+		renamed += 1
+	}
+	return renamed
+}
+
+func (r *MockTaskRepository) Search(query string) []*Task {
+	index := search.NewIndex()
+	for _, task := range r.ListAll() {
+		index.Add(task.Id, task.Name+"\n"+task.Description)
+	}
+	var tasks []*Task
+	for _, match := range index.Search(query) {
+		tasks = append(tasks, toMockTask(r.Data[match.Id]))
 	}
 	return tasks
 }
 
+func (r *MockTaskRepository) sortedRows() []TaskSchema {
+	rows := make([]TaskSchema, 0, len(r.Data))
+	for _, row := range r.Data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Position != rows[j].Position {
+			return rows[i].Position < rows[j].Position
+		}
+		return rows[i].Id < rows[j].Id
+	})
+	return rows
+}
+
 func (r *MockTaskRepository) PopulateData(row TaskSchema) {
 	r.Data[row.Id] = row
 }
 
+func toMockTask(row TaskSchema) *Task {
+	return &Task{
+		Id:          row.Id,
+		Name:        row.Name,
+		Description: row.Description,
+		Status:      row.Status,
+		Priority:    row.Priority,
+		Position:    row.Position,
+		ListId:      row.ListId,
+		ParentId:    row.ParentId,
+		Tags:        row.Tags,
+		BlockedBy:   row.BlockedBy,
+		DueAt:       row.DueAt,
+		Recurrence:  row.Recurrence,
+		Creator:     row.Creator,
+		Assignee:    row.Assignee,
+		CreatedAt:   row.CreatedAt,
+		CompletedAt: row.CompletedAt,
+		DeletedAt:   row.DeletedAt,
+	}
+}
+
+func toMockTaskSchema(t *Task) TaskSchema {
+	return TaskSchema{
+		Id:          t.Id,
+		Name:        t.Name,
+		Description: t.Description,
+		Status:      t.Status,
+		Priority:    t.Priority,
+		Position:    t.Position,
+		ListId:      t.ListId,
+		ParentId:    t.ParentId,
+		Tags:        t.Tags,
+		BlockedBy:   t.BlockedBy,
+		DueAt:       t.DueAt,
+		Recurrence:  t.Recurrence,
+		Creator:     t.Creator,
+		Assignee:    t.Assignee,
+		CreatedAt:   t.CreatedAt,
+		CompletedAt: t.CompletedAt,
+		DeletedAt:   t.DeletedAt,
+	}
+}
+
+type ListSchema = repository.ListSchema
+
+type List = listentity.List
+
+type MockListRepository struct {
+	Data map[int]ListSchema
+}
+
+func (r *MockListRepository) FindBy(id any) (*List, error) {
+	row, ok := r.Data[id.(int)]
+	if !ok {
+		return nil, MockNotFoundError
+	}
+	return toMockList(row), nil
+}
+
+func (r *MockListRepository) Update(l *List) (*List, error) {
+	r.Data[l.Id] = toMockListSchema(l)
+	return toMockList(r.Data[l.Id]), nil
+}
+
+func (r *MockListRepository) Save(l *List) List {
+	l.Id = len(r.Data) + 1
+	r.Data[l.Id] = toMockListSchema(l)
+	return *l
+}
+
+func (r *MockListRepository) Delete(l *List) error {
+	delete(r.Data, l.Id)
+	return nil
+}
+
+func (r *MockListRepository) ListAll() []*List {
+	var lists []*List
+	for _, row := range r.Data {
+		lists = append(lists, toMockList(row))
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Id < lists[j].Id })
+	return lists
+}
+
+func (r *MockListRepository) PopulateData(row ListSchema) {
+	r.Data[row.Id] = row
+}
+
+func (r *MockListRepository) Dump() []ListSchema {
+	rows := make([]ListSchema, 0, len(r.Data))
+	for _, row := range r.Data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+func (r *MockListRepository) Load(rows []ListSchema) {
+	r.Data = make(map[int]ListSchema, len(rows))
+	for _, row := range rows {
+		r.Data[row.Id] = row
+	}
+}
+
+func InitMockListRepository() *MockListRepository {
+	return &MockListRepository{
+		Data: make(map[int]ListSchema),
+	}
+}
+
+func toMockList(row ListSchema) *List {
+	return &List{Id: row.Id, Name: row.Name, Owner: row.Owner}
+}
+
+func toMockListSchema(l *List) ListSchema {
+	return ListSchema{Id: l.Id, Name: l.Name, Owner: l.Owner}
+}
+
+type MemberSchema = repository.MemberSchema
+
+type Member = listentity.Member
+
+type MockMemberRepository struct {
+	Data map[int]MemberSchema
+}
+
+func (r *MockMemberRepository) FindBy(id any) (*Member, error) {
+	row, ok := r.Data[id.(int)]
+	if !ok {
+		return nil, MockNotFoundError
+	}
+	return toMockMember(row), nil
+}
+
+func (r *MockMemberRepository) Update(m *Member) (*Member, error) {
+	r.Data[m.Id] = toMockMemberSchema(m)
+	return toMockMember(r.Data[m.Id]), nil
+}
+
+func (r *MockMemberRepository) Save(m *Member) Member {
+	m.Id = len(r.Data) + 1
+	r.Data[m.Id] = toMockMemberSchema(m)
+	return *m
+}
+
+func (r *MockMemberRepository) Delete(m *Member) error {
+	delete(r.Data, m.Id)
+	return nil
+}
+
+func (r *MockMemberRepository) ListAll() []*Member {
+	var members []*Member
+	for _, row := range r.Data {
+		members = append(members, toMockMember(row))
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Id < members[j].Id })
+	return members
+}
+
+func (r *MockMemberRepository) FindMember(listId int, user string) (*Member, error) {
+	for _, m := range r.ListAll() {
+		if m.ListId == listId && m.User == user {
+			return m, nil
+		}
+	}
+	return nil, MockNotFoundError
+}
+
+func (r *MockMemberRepository) ListByList(listId int) []*Member {
+	var members []*Member
+	for _, m := range r.ListAll() {
+		if m.ListId == listId {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+func (r *MockMemberRepository) ListByUser(user string) []*Member {
+	var members []*Member
+	for _, m := range r.ListAll() {
+		if m.User == user {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+func (r *MockMemberRepository) DeleteByList(listId int) int {
+	var deleted int
+	for id, row := range r.Data {
+		if row.ListId == listId {
+			delete(r.Data, id)
+			deleted += 1
+		}
+	}
+	return deleted
+}
+
+func (r *MockMemberRepository) PopulateData(row MemberSchema) {
+	r.Data[row.Id] = row
+}
+
+func (r *MockMemberRepository) Dump() []MemberSchema {
+	rows := make([]MemberSchema, 0, len(r.Data))
+	for _, row := range r.Data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+func (r *MockMemberRepository) Load(rows []MemberSchema) {
+	r.Data = make(map[int]MemberSchema, len(rows))
+	for _, row := range rows {
+		r.Data[row.Id] = row
+	}
+}
+
+func InitMockMemberRepository() *MockMemberRepository {
+	return &MockMemberRepository{
+		Data: make(map[int]MemberSchema),
+	}
+}
+
+func toMockMember(row MemberSchema) *Member {
+	return &Member{
+		Id:        row.Id,
+		ListId:    row.ListId,
+		User:      row.User,
+		Role:      row.Role,
+		Accepted:  row.Accepted,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+func toMockMemberSchema(m *Member) MemberSchema {
+	return MemberSchema{
+		Id:        m.Id,
+		ListId:    m.ListId,
+		User:      m.User,
+		Role:      m.Role,
+		Accepted:  m.Accepted,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+type CommentSchema = repository.CommentSchema
+
+type Comment = commententity.Comment
+
+type MockCommentRepository struct {
+	Data map[int]CommentSchema
+}
+
+func (r *MockCommentRepository) FindBy(id any) (*Comment, error) {
+	row, ok := r.Data[id.(int)]
+	if !ok {
+		return nil, MockNotFoundError
+	}
+	return toMockComment(row), nil
+}
+
+func (r *MockCommentRepository) Update(c *Comment) (*Comment, error) {
+	r.Data[c.Id] = toMockCommentSchema(c)
+	return toMockComment(r.Data[c.Id]), nil
+}
+
+func (r *MockCommentRepository) Save(c *Comment) Comment {
+	c.Id = len(r.Data) + 1
+	r.Data[c.Id] = toMockCommentSchema(c)
+	return *c
+}
+
+func (r *MockCommentRepository) Delete(c *Comment) error {
+	delete(r.Data, c.Id)
+	return nil
+}
+
+func (r *MockCommentRepository) ListAll() []*Comment {
+	var comments []*Comment
+	for _, row := range r.Data {
+		comments = append(comments, toMockComment(row))
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].Id < comments[j].Id })
+	return comments
+}
+
+func (r *MockCommentRepository) ListByTask(taskId int) []*Comment {
+	var comments []*Comment
+	for _, c := range r.ListAll() {
+		if c.TaskId == taskId {
+			comments = append(comments, c)
+		}
+	}
+	return comments
+}
+
+func (r *MockCommentRepository) DeleteByTask(taskId int) int {
+	var deleted int
+	for id, row := range r.Data {
+		if row.TaskId == taskId {
+			delete(r.Data, id)
+			deleted += 1
+		}
+	}
+	return deleted
+}
+
+func (r *MockCommentRepository) PopulateData(row CommentSchema) {
+	r.Data[row.Id] = row
+}
+
+func (r *MockCommentRepository) Dump() []CommentSchema {
+	rows := make([]CommentSchema, 0, len(r.Data))
+	for _, row := range r.Data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+func (r *MockCommentRepository) Load(rows []CommentSchema) {
+	r.Data = make(map[int]CommentSchema, len(rows))
+	for _, row := range rows {
+		r.Data[row.Id] = row
+	}
+}
+
+func InitMockCommentRepository() *MockCommentRepository {
+	return &MockCommentRepository{
+		Data: make(map[int]CommentSchema),
+	}
+}
+
+func toMockComment(row CommentSchema) *Comment {
+	return &Comment{
+		Id:        row.Id,
+		TaskId:    row.TaskId,
+		Author:    row.Author,
+		Body:      row.Body,
+		CreatedAt: row.CreatedAt,
+		EditedAt:  row.EditedAt,
+	}
+}
+
+func toMockCommentSchema(c *Comment) CommentSchema {
+	return CommentSchema{
+		Id:        c.Id,
+		TaskId:    c.TaskId,
+		Author:    c.Author,
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt,
+		EditedAt:  c.EditedAt,
+	}
+}
+
+type AttachmentSchema = repository.AttachmentSchema
+
+type Attachment = attachmententity.Attachment
+
+type MockAttachmentRepository struct {
+	Data map[int]AttachmentSchema
+}
+
+func (r *MockAttachmentRepository) FindBy(id any) (*Attachment, error) {
+	row, ok := r.Data[id.(int)]
+	if !ok {
+		return nil, MockNotFoundError
+	}
+	return toMockAttachment(row), nil
+}
+
+func (r *MockAttachmentRepository) Update(a *Attachment) (*Attachment, error) {
+	r.Data[a.Id] = toMockAttachmentSchema(a)
+	return toMockAttachment(r.Data[a.Id]), nil
+}
+
+func (r *MockAttachmentRepository) Save(a *Attachment) Attachment {
+	a.Id = len(r.Data) + 1
+	r.Data[a.Id] = toMockAttachmentSchema(a)
+	return *a
+}
+
+func (r *MockAttachmentRepository) Delete(a *Attachment) error {
+	delete(r.Data, a.Id)
+	return nil
+}
+
+func (r *MockAttachmentRepository) ListAll() []*Attachment {
+	var attachments []*Attachment
+	for _, row := range r.Data {
+		attachments = append(attachments, toMockAttachment(row))
+	}
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].Id < attachments[j].Id })
+	return attachments
+}
+
+func (r *MockAttachmentRepository) ListByTask(taskId int) []*Attachment {
+	var attachments []*Attachment
+	for _, a := range r.ListAll() {
+		if a.TaskId == taskId {
+			attachments = append(attachments, a)
+		}
+	}
+	return attachments
+}
+
+func (r *MockAttachmentRepository) DeleteByTask(taskId int) int {
+	var deleted int
+	for id, row := range r.Data {
+		if row.TaskId == taskId {
+			delete(r.Data, id)
+			deleted += 1
+		}
+	}
+	return deleted
+}
+
+func (r *MockAttachmentRepository) PopulateData(row AttachmentSchema) {
+	r.Data[row.Id] = row
+}
+
+func (r *MockAttachmentRepository) Dump() []AttachmentSchema {
+	rows := make([]AttachmentSchema, 0, len(r.Data))
+	for _, row := range r.Data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+func (r *MockAttachmentRepository) Load(rows []AttachmentSchema) {
+	r.Data = make(map[int]AttachmentSchema, len(rows))
+	for _, row := range rows {
+		r.Data[row.Id] = row
+	}
+}
+
+func InitMockAttachmentRepository() *MockAttachmentRepository {
+	return &MockAttachmentRepository{
+		Data: make(map[int]AttachmentSchema),
+	}
+}
+
+func toMockAttachment(row AttachmentSchema) *Attachment {
+	return &Attachment{
+		Id:          row.Id,
+		TaskId:      row.TaskId,
+		Name:        row.Name,
+		ContentType: row.ContentType,
+		Size:        row.Size,
+		BlobKey:     row.BlobKey,
+		Uploader:    row.Uploader,
+		CreatedAt:   row.CreatedAt,
+	}
+}
+
+func toMockAttachmentSchema(a *Attachment) AttachmentSchema {
+	return AttachmentSchema{
+		Id:          a.Id,
+		TaskId:      a.TaskId,
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		BlobKey:     a.BlobKey,
+		Uploader:    a.Uploader,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+type MockBlobStore struct {
+	Data map[string][]byte
+}
+
+func (s *MockBlobStore) Put(key string, content io.Reader) (int64, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return 0, err
+	}
+	s.Data[key] = data
+	return int64(len(data)), nil
+}
+
+func (s *MockBlobStore) Get(key string) (io.ReadCloser, error) {
+	data, ok := s.Data[key]
+	if !ok {
+		return nil, blob.ErrorNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *MockBlobStore) Delete(key string) error {
+	if _, ok := s.Data[key]; !ok {
+		return blob.ErrorNotFound
+	}
+	delete(s.Data, key)
+	return nil
+}
+
+func (s *MockBlobStore) PopulateData(key string, data []byte) {
+	s.Data[key] = data
+}
+
+func InitMockBlobStore() *MockBlobStore {
+	return &MockBlobStore{
+		Data: make(map[string][]byte),
+	}
+}
+
+type EventSchema = repository.EventSchema
+
+type Event = entity.Event
+
+type MockEventRepository struct {
+	Data map[int]EventSchema
+}
+
+func (r *MockEventRepository) FindBy(id any) (*Event, error) {
+	row, ok := r.Data[id.(int)]
+	if !ok {
+		return nil, MockNotFoundError
+	}
+	return toMockEvent(row), nil
+}
+
+func (r *MockEventRepository) Update(e *Event) (*Event, error) {
+	r.Data[e.Id] = toMockEventSchema(e)
+	return toMockEvent(r.Data[e.Id]), nil
+}
+
+func (r *MockEventRepository) Save(e *Event) Event {
+	e.Id = len(r.Data) + 1
+	r.Data[e.Id] = toMockEventSchema(e)
+	return *e
+}
+
+func (r *MockEventRepository) Delete(e *Event) error {
+	delete(r.Data, e.Id)
+	return nil
+}
+
+func (r *MockEventRepository) ListAll() []*Event {
+	var events []*Event
+	for _, row := range r.Data {
+		events = append(events, toMockEvent(row))
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Id < events[j].Id })
+	return events
+}
+
+func (r *MockEventRepository) ListByTask(taskId int) []*Event {
+	var events []*Event
+	for _, e := range r.ListAll() {
+		if e.TaskId == taskId {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func (r *MockEventRepository) DeleteByTask(taskId int) int {
+	var deleted int
+	for id, row := range r.Data {
+		if row.TaskId == taskId {
+			delete(r.Data, id)
+			deleted += 1
+		}
+	}
+	return deleted
+}
+
+func (r *MockEventRepository) PopulateData(row EventSchema) {
+	r.Data[row.Id] = row
+}
+
+func (r *MockEventRepository) Dump() []EventSchema {
+	rows := make([]EventSchema, 0, len(r.Data))
+	for _, row := range r.Data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+func (r *MockEventRepository) Load(rows []EventSchema) {
+	r.Data = make(map[int]EventSchema, len(rows))
+	for _, row := range rows {
+		r.Data[row.Id] = row
+	}
+}
+
+func InitMockEventRepository() *MockEventRepository {
+	return &MockEventRepository{
+		Data: make(map[int]EventSchema),
+	}
+}
+
+func toMockEvent(row EventSchema) *Event {
+	return &Event{
+		Id:        row.Id,
+		TaskId:    row.TaskId,
+		Kind:      row.Kind,
+		Actor:     row.Actor,
+		From:      row.From,
+		To:        row.To,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+func toMockEventSchema(e *Event) EventSchema {
+	return EventSchema{
+		Id:        e.Id,
+		TaskId:    e.TaskId,
+		Kind:      e.Kind,
+		Actor:     e.Actor,
+		From:      e.From,
+		To:        e.To,
+		CreatedAt: e.CreatedAt,
+	}
+}
+
 type Session = repository.Session
 
 type MockSessionsRepository struct {
 	Data map[string]Session
 }
 
+func (r *MockTaskRepository) Dump() []TaskSchema {
+	rows := make([]TaskSchema, 0, len(r.Data))
+	for _, row := range r.Data {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+func (r *MockTaskRepository) Load(rows []TaskSchema) {
+	r.Data = make(map[int]TaskSchema, len(rows))
+	for _, row := range rows {
+		r.Data[row.Id] = row
+	}
+}
+
 func InitMockTaskRepository() *MockTaskRepository {
 	return &MockTaskRepository{
 		Data: make(map[int]repository.TaskSchema),
@@ -74,7 +838,7 @@ func (r *MockSessionsRepository) FindBy(id any) (*Session, error) {
 		return nil, MockNotFoundError
 	}
 
-	return &Session{Id: row.Id, CreatedAt: row.CreatedAt}, nil
+	return &Session{Id: row.Id, User: row.User, Workspace: row.Workspace, CreatedAt: row.CreatedAt}, nil
 }
 
 func (r *MockSessionsRepository) Update(s *Session) (*Session, error) {
@@ -98,21 +862,196 @@ func (r *MockSessionsRepository) PopulateData(row Session) {
 	r.Data[row.Id] = row
 }
 
+func (r *MockSessionsRepository) Dump() []repository.SessionSchema {
+	rows := make([]repository.SessionSchema, 0, len(r.Data))
+	for _, row := range r.Data {
+		rows = append(rows, repository.SessionSchema{Id: row.Id, User: row.User, Workspace: row.Workspace, CreatedAt: row.CreatedAt})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Id < rows[j].Id })
+	return rows
+}
+
+func (r *MockSessionsRepository) Load(rows []repository.SessionSchema) {
+	r.Data = make(map[string]Session, len(rows))
+	for _, row := range rows {
+		r.Data[row.Id] = Session{Id: row.Id, User: row.User, Workspace: row.Workspace, CreatedAt: row.CreatedAt}
+	}
+}
+
 func InitMockSessionsRepository() *MockSessionsRepository {
 	return &MockSessionsRepository{
 		Data: make(map[string]Session),
 	}
 }
 
+type Feed = repository.Feed
+
+type MockFeedRepository struct {
+	Data map[string]Feed
+}
+
+func (r *MockFeedRepository) ListAll() []*Feed {
+	var feeds []*Feed
+	for _, row := range r.Data {
+		feed := row
+		feeds = append(feeds, &feed)
+	}
+	return feeds
+}
+
+func (r *MockFeedRepository) Save(f *Feed) Feed {
+	r.Data[f.Token] = *f
+	return *f
+}
+
+func (r *MockFeedRepository) FindBy(token any) (*Feed, error) {
+	row, ok := r.Data[token.(string)]
+	if !ok {
+		return nil, MockNotFoundError
+	}
+	return &row, nil
+}
+
+func (r *MockFeedRepository) Update(f *Feed) (*Feed, error) {
+	panic("not implemented")
+}
+
+func (r *MockFeedRepository) Delete(f *Feed) error {
+	if _, ok := r.Data[f.Token]; !ok {
+		return MockNotFoundError
+	}
+	delete(r.Data, f.Token)
+	return nil
+}
+
+func (r *MockFeedRepository) PopulateData(row Feed) {
+	r.Data[row.Token] = row
+}
+
+func (r *MockFeedRepository) Dump() []repository.FeedSchema {
+	rows := make([]repository.FeedSchema, 0, len(r.Data))
+	for _, row := range r.Data {
+		rows = append(rows, repository.FeedSchema{Token: row.Token, User: row.User, Workspace: row.Workspace, CreatedAt: row.CreatedAt})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Token < rows[j].Token })
+	return rows
+}
+
+func (r *MockFeedRepository) Load(rows []repository.FeedSchema) {
+	r.Data = make(map[string]Feed, len(rows))
+	for _, row := range rows {
+		r.Data[row.Token] = Feed{Token: row.Token, User: row.User, Workspace: row.Workspace, CreatedAt: row.CreatedAt}
+	}
+}
+
+func InitMockFeedRepository() *MockFeedRepository {
+	return &MockFeedRepository{
+		Data: make(map[string]Feed),
+	}
+}
+
+type Account = repository.Account
+
+type MockAccountRepository struct {
+	Data map[string]Account
+}
+
+func (r *MockAccountRepository) ListAll() []*Account {
+	var accounts []*Account
+	for _, row := range r.Data {
+		account := row
+		accounts = append(accounts, &account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].User < accounts[j].User })
+	return accounts
+}
+
+func (r *MockAccountRepository) Save(a *Account) Account {
+	r.Data[a.User] = *a
+	return *a
+}
+
+func (r *MockAccountRepository) FindBy(user any) (*Account, error) {
+	row, ok := r.Data[user.(string)]
+	if !ok {
+		return nil, MockNotFoundError
+	}
+	return &row, nil
+}
+
+func (r *MockAccountRepository) Update(a *Account) (*Account, error) {
+	if _, ok := r.Data[a.User]; !ok {
+		return nil, MockNotFoundError
+	}
+	r.Data[a.User] = *a
+	return a, nil
+}
+
+func (r *MockAccountRepository) Delete(a *Account) error {
+	if _, ok := r.Data[a.User]; !ok {
+		return MockNotFoundError
+	}
+	delete(r.Data, a.User)
+	return nil
+}
+
+func (r *MockAccountRepository) PopulateData(row Account) {
+	r.Data[row.User] = row
+}
+
+func (r *MockAccountRepository) Dump() []repository.AccountSchema {
+	rows := make([]repository.AccountSchema, 0, len(r.Data))
+	for _, row := range r.Data {
+		rows = append(rows, repository.AccountSchema{User: row.User, PasswordHash: row.PasswordHash, Workspaces: row.Workspaces, CreatedAt: row.CreatedAt})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].User < rows[j].User })
+	return rows
+}
+
+func (r *MockAccountRepository) Load(rows []repository.AccountSchema) {
+	r.Data = make(map[string]Account, len(rows))
+	for _, row := range rows {
+		r.Data[row.User] = Account{User: row.User, PasswordHash: row.PasswordHash, Workspaces: row.Workspaces, CreatedAt: row.CreatedAt}
+	}
+}
+
+func InitMockAccountRepository() *MockAccountRepository {
+	return &MockAccountRepository{
+		Data: make(map[string]Account),
+	}
+}
+
+// NewAccount returns an account of the user with the password, hashed at the
+// lowest cost to keep tests fast, and a member of the workspaces, or of the
+// default one if none are given.
+func NewAccount(user string, password string, workspaces ...string) Account {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		panic(err)
+	}
+	if len(workspaces) == 0 {
+		workspaces = []string{sessionentity.DefaultWorkspace}
+	}
+	return Account{User: user, PasswordHash: hash, Workspaces: workspaces, CreatedAt: time.Now()}
+}
+
 func NewSession() Session {
-	return newStubSession("stubbed_token", time.Now())
+	return newStubSession("stubbed_token", sessionentity.DefaultWorkspace, sessionentity.AnonymousUser, time.Now())
+}
+
+func NewUserSession(user string) Session {
+	return newStubSession(user+"_token", sessionentity.DefaultWorkspace, user, time.Now())
+}
+
+func NewWorkspaceSession(workspace string, user string) Session {
+	return newStubSession(workspace+"_"+user+"_token", workspace, user, time.Now())
 }
 
 func NewExpiredSession() Session {
 	oneMinuteAgo := time.Now().Add(-(time.Minute + time.Second))
-	return newStubSession("stubbed_token", oneMinuteAgo)
+	return newStubSession("stubbed_token", sessionentity.DefaultWorkspace, sessionentity.AnonymousUser, oneMinuteAgo)
 }
 
-func newStubSession(id string, createdAt time.Time) Session {
-	return Session{Id: id, CreatedAt: createdAt}
+func newStubSession(id string, workspace string, user string, createdAt time.Time) Session {
+	return Session{Id: id, User: user, Workspace: workspace, CreatedAt: createdAt}
 }
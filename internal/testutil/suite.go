@@ -1,37 +1,123 @@
 package testutil_test
 
 import (
+	"github.com/dannyh79/whostodo/internal/attachments"
+	"github.com/dannyh79/whostodo/internal/backup"
+	"github.com/dannyh79/whostodo/internal/comments"
+	"github.com/dannyh79/whostodo/internal/graph"
+	"github.com/dannyh79/whostodo/internal/lists"
 	"github.com/dannyh79/whostodo/internal/repository"
 	"github.com/dannyh79/whostodo/internal/rest/v1"
+	"github.com/dannyh79/whostodo/internal/rpc"
 	"github.com/dannyh79/whostodo/internal/sessions"
+	sessionentity "github.com/dannyh79/whostodo/internal/sessions/entities"
 	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/dannyh79/whostodo/internal/workspaces"
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 )
 
+// AdminToken authenticates the admin routes of the suite.
+const AdminToken = "admin_token"
+
+// Workspaces are those the suite is set up with.
+var Workspaces = []string{sessionentity.DefaultWorkspace, "team-a", "team-b"}
+
+// MockTestSuite serves the routes and services from mock repositories. The
+// repositories exposed are those of the default workspace; other workspaces
+// get fresh ones.
 type MockTestSuite struct {
-	Engine      *gin.Engine
-	TaskRepo    *MockTaskRepository
-	SessionRepo *MockSessionsRepository
+	Engine         *gin.Engine
+	RPCServer      *grpc.Server
+	TaskRepo       *MockTaskRepository
+	SessionRepo    *MockSessionsRepository
+	AccountRepo    *MockAccountRepository
+	FeedRepo       *MockFeedRepository
+	ListRepo       *MockListRepository
+	CommentRepo    *MockCommentRepository
+	AttachmentRepo *MockAttachmentRepository
+	EventRepo      *MockEventRepository
+	MemberRepo     *MockMemberRepository
+	BlobStore      *MockBlobStore
 }
 
-func NewTestSuite() *MockTestSuite {
+func NewTestSuite(opts ...tasks.Option) *MockTestSuite {
 	gin.SetMode(gin.TestMode)
 	engine := gin.Default()
 
-	taskRepo := &MockTaskRepository{
-		Data: make(map[int]repository.TaskSchema),
-	}
 	sessionRepo := &MockSessionsRepository{
 		Data: make(map[string]Session),
 	}
-	tasksUsecase := tasks.InitTasksUsecase(taskRepo)
-	sessionsUsecase := sessions.InitSessionsUsecase(sessionRepo)
-
-	routes.AddRoutes(engine, tasksUsecase, sessionsUsecase)
-
-	return &MockTestSuite{
+	suite := &MockTestSuite{
 		Engine:      engine,
-		TaskRepo:    taskRepo,
 		SessionRepo: sessionRepo,
+		AccountRepo: InitMockAccountRepository(),
+		FeedRepo:    InitMockFeedRepository(),
+	}
+	sessionsUsecase := sessions.InitSessionsUsecase(
+		sessionRepo,
+		sessions.WithAccountRepository(suite.AccountRepo),
+		sessions.WithFeedRepository(suite.FeedRepo),
+		sessions.WithWorkspaces(Workspaces...),
+	)
+	membership := func(id string) []tasks.Option {
+		return append([]tasks.Option{
+			tasks.WithMembership(func(user string) bool { return sessionsUsecase.IsMember(id, user) }),
+		}, opts...)
+	}
+	defaultWorkspace := suite.newWorkspace(sessionentity.DefaultWorkspace, membership(sessionentity.DefaultWorkspace)...)
+	workspacesUsecase := workspaces.InitWorkspacesUsecase(func(id string) (*workspaces.Workspace, error) {
+		if id == sessionentity.DefaultWorkspace {
+			return defaultWorkspace, nil
+		}
+		return (&MockTestSuite{}).newWorkspace(id, membership(id)...), nil
+	}, Workspaces...)
+
+	routes.AddRoutes(engine, sessionsUsecase, workspacesUsecase)
+	routes.AddAdminRoutes(engine, sessionsUsecase, backup.InitBackupUsecase(sessionRepo, suite.AccountRepo, suite.FeedRepo, workspacesUsecase), AdminToken)
+	graph.AddRoutes(engine, sessionsUsecase, workspacesUsecase)
+	suite.RPCServer = rpc.InitServer(sessionsUsecase, workspacesUsecase)
+
+	return suite
+}
+
+// newWorkspace builds a workspace on mock repositories, keeping them in the
+// suite.
+func (s *MockTestSuite) newWorkspace(id string, opts ...tasks.Option) *workspaces.Workspace {
+	s.TaskRepo = &MockTaskRepository{
+		Data: make(map[int]repository.TaskSchema),
+	}
+	s.ListRepo = InitMockListRepository()
+	s.CommentRepo = InitMockCommentRepository()
+	s.AttachmentRepo = InitMockAttachmentRepository()
+	s.BlobStore = InitMockBlobStore()
+	s.EventRepo = InitMockEventRepository()
+	s.MemberRepo = InitMockMemberRepository()
+
+	listsUsecase := lists.InitListsUsecase(s.ListRepo, s.MemberRepo)
+	attachmentsUsecase := attachments.InitAttachmentsUsecase(s.AttachmentRepo, s.TaskRepo, s.BlobStore, attachments.WithAccessPolicy(listsUsecase))
+	opts = append([]tasks.Option{
+		tasks.WithListRepository(s.ListRepo),
+		tasks.WithAttachedRepository(s.CommentRepo),
+		tasks.WithAttachedRepository(attachmentsUsecase),
+		tasks.WithHistoryRepository(s.EventRepo),
+		tasks.WithAccessPolicy(listsUsecase),
+	}, opts...)
+
+	return &workspaces.Workspace{
+		Id:          id,
+		Tasks:       tasks.InitTasksUsecase(s.TaskRepo, opts...),
+		Lists:       listsUsecase,
+		Comments:    comments.InitCommentsUsecase(s.CommentRepo, s.TaskRepo, comments.WithAccessPolicy(listsUsecase)),
+		Attachments: attachmentsUsecase,
+		Rows: &workspaces.Rows{
+			Tasks:       s.TaskRepo,
+			Lists:       s.ListRepo,
+			Members:     s.MemberRepo,
+			Comments:    s.CommentRepo,
+			Attachments: s.AttachmentRepo,
+			Events:      s.EventRepo,
+			Files:       s.BlobStore,
+		},
 	}
 }
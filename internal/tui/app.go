@@ -0,0 +1,202 @@
+// Package tui implements a full-screen terminal UI for the tasks of a
+// session, kept up to date as they change.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dannyh79/whostodo/client"
+)
+
+var (
+	ErrorNotTerminal = errors.New("Not a terminal")
+	ErrorUnsupported = errors.New("Terminal UI not supported on this platform")
+)
+
+// Size of terminals that do not tell theirs.
+const (
+	defaultCols = 80
+	defaultRows = 24
+)
+
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+)
+
+// Terminal is what the UI reads keys from and draws on.
+type Terminal struct {
+	In  io.Reader
+	Out io.Writer
+	// Returns the width and height in cells.
+	Size func() (cols int, rows int)
+	// Receives whenever the size changes; nil if never told.
+	Resized <-chan struct{}
+}
+
+type App struct {
+	client  *client.Client
+	model   model
+	watched <-chan client.Change
+}
+
+// Run shows the UI until quit, or until the input of the terminal ends.
+func (a *App) Run(ctx context.Context, term *Terminal) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	a.watch(ctx)
+	if err := a.refresh(ctx); err != nil {
+		return err
+	}
+	keys := make(chan key)
+	go readKeys(ctx, term.In, keys)
+
+	io.WriteString(term.Out, enterScreen)
+	defer io.WriteString(term.Out, leaveScreen)
+	for {
+		io.WriteString(term.Out, a.model.render(term.Size()))
+
+		select {
+		case k, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			act := a.model.press(k)
+			if act.kind == quitAction {
+				return nil
+			}
+			a.perform(ctx, act)
+		case _, ok := <-a.watched:
+			if !ok {
+				a.watched = nil
+				a.model.live = false
+				a.model.message = "live updates stopped; r reconnects"
+				continue
+			}
+			a.drainChanges()
+			if err := a.refresh(ctx); err != nil {
+				a.model.message = "error: " + err.Error()
+			}
+		case <-term.Resized:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// perform carries out the action through the API, reporting how it went on
+// the status line.
+func (a *App) perform(ctx context.Context, act action) {
+	var message string
+	var selected int
+	var err error
+	switch act.kind {
+	case noAction:
+		return
+	case refreshAction:
+		if a.watched == nil {
+			a.watch(ctx)
+		}
+		message = "refreshed"
+	case toggleAction:
+		message, err = a.toggle(ctx, act.id)
+	case renameAction:
+		message, err = a.rename(ctx, act.id, act.name)
+	case addAction:
+		var created int
+		created, err = a.add(ctx, act.name)
+		message, selected = "added: "+act.name, created
+	}
+	if err != nil {
+		a.model.message = "error: " + err.Error()
+		return
+	}
+
+	if err := a.refresh(ctx); err != nil {
+		a.model.message = "error: " + err.Error()
+		return
+	}
+	if selected != 0 {
+		a.model.selectTask(selected)
+	}
+	a.model.message = message
+}
+
+// toggle marks the task done, or open again if done. The task is read
+// first, as updates replace its name.
+func (a *App) toggle(ctx context.Context, id int) (string, error) {
+	t, err := a.client.GetTask(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	status, message := client.StatusDone, "done: "
+	if t.IsDone() {
+		status, message = client.StatusOpen, "reopened: "
+	}
+	if _, err := a.client.UpdateTask(ctx, id, &client.UpdateTaskInput{Name: t.Name, Status: status}); err != nil {
+		return "", err
+	}
+	return message + t.Name, nil
+}
+
+func (a *App) rename(ctx context.Context, id int, name string) (string, error) {
+	t, err := a.client.GetTask(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if _, err := a.client.UpdateTask(ctx, id, &client.UpdateTaskInput{Name: name, Status: t.Status}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("renamed: %s to %s", t.Name, name), nil
+}
+
+func (a *App) add(ctx context.Context, name string) (int, error) {
+	created, err := a.client.CreateTask(ctx, &client.CreateTaskInput{Name: name})
+	if err != nil {
+		return 0, err
+	}
+	return created.Id, nil
+}
+
+func (a *App) refresh(ctx context.Context) error {
+	listed, err := a.client.ListTasks(ctx, nil)
+	if err != nil {
+		return err
+	}
+	a.model.setTasks(listed)
+	return nil
+}
+
+// watch subscribes to changes, going without live updates if it cannot.
+func (a *App) watch(ctx context.Context) {
+	watched, err := a.client.WatchChanges(ctx)
+	if err != nil {
+		a.model.message = "no live updates: " + err.Error()
+		return
+	}
+	a.watched = watched
+	a.model.live = true
+}
+
+// drainChanges skips changes already waiting, as a single refresh covers
+// them all.
+func (a *App) drainChanges() {
+	for {
+		select {
+		case _, ok := <-a.watched:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func InitApp(c *client.Client) *App {
+	return &App{client: c}
+}
@@ -0,0 +1,216 @@
+package tui_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dannyh79/whostodo/client"
+	"github.com/dannyh79/whostodo/internal/repository"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+	"github.com/dannyh79/whostodo/internal/tui"
+)
+
+var escapes = regexp.MustCompile("\x1b\\[[0-9;?]*[A-Za-z]")
+
+// screen keeps what the UI drew, for tests to wait on.
+type screen struct {
+	mu  sync.Mutex
+	out bytes.Buffer
+}
+
+func (s *screen) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.out.Write(p)
+}
+
+// frame returns the last screen drawn, without escape sequences.
+func (s *screen) frame() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	frames := strings.Split(s.out.String(), "\x1b[H\x1b[2J")
+	return escapes.ReplaceAllString(frames[len(frames)-1], "")
+}
+
+type session struct {
+	t      *testing.T
+	client *client.Client
+	keys   io.Writer
+	screen *screen
+	done   chan error
+}
+
+// start runs the UI on a terminal of 60 by 8 cells, against a server of the
+// suite as a session of alice.
+func start(t *testing.T, suite *util.MockTestSuite) *session {
+	t.Helper()
+	server := httptest.NewServer(suite.Engine)
+	t.Cleanup(server.Close)
+	alice := util.NewUserSession("alice")
+	suite.SessionRepo.PopulateData(alice)
+	c := client.InitClient(server.URL, alice.Id)
+
+	in, keys := io.Pipe()
+	s := &session{t: t, client: c, keys: keys, screen: &screen{}, done: make(chan error, 1)}
+	term := &tui.Terminal{In: in, Out: s.screen, Size: func() (int, int) { return 60, 8 }}
+	go func() {
+		s.done <- tui.InitApp(c).Run(context.Background(), term)
+	}()
+	t.Cleanup(func() { keys.Close() })
+	return s
+}
+
+func (s *session) press(keys string) {
+	s.keys.Write([]byte(keys))
+}
+
+// waitFor waits until the screen shows the text.
+func (s *session) waitFor(text string) string {
+	s.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if frame := s.screen.frame(); strings.Contains(frame, text) {
+			return frame
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.t.Fatalf("screen does not show %q:\n%s", text, s.screen.frame())
+	return ""
+}
+
+func (s *session) task(id int) string {
+	s.t.Helper()
+	got, err := s.client.GetTask(context.Background(), id)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return got.Name
+}
+
+func newSuite() *util.MockTestSuite {
+	suite := util.NewTestSuite()
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 1, Name: "買晚餐", Tags: []string{"errand"}, Creator: "alice"})
+	suite.TaskRepo.PopulateData(repository.TaskSchema{Id: 2, Name: "洗碗", Creator: "alice"})
+	return suite
+}
+
+func Test_Screen(t *testing.T) {
+	t.Parallel()
+
+	s := start(t, newSuite())
+
+	frame := s.waitFor("洗碗")
+
+	util.AssertEqual(t)(frame, strings.Join([]string{
+		"whostodo  2 tasks  live",
+		"[ ]    1  買晚餐 #errand" + strings.Repeat(" ", 36),
+		"[ ]    2  洗碗",
+		"", "", "", "",
+		"j/k move  space toggle  e edit  a add  / filter  r refresh  ",
+	}, "\r\n"))
+}
+
+func Test_Toggle(t *testing.T) {
+	t.Parallel()
+
+	s := start(t, newSuite())
+	s.waitFor("洗碗")
+
+	s.press("j ")
+	s.waitFor("done: 洗碗")
+	got, _ := s.client.GetTask(context.Background(), 2)
+	util.AssertEqual(t)(got.Status, 1)
+	s.waitFor("[x]    2  洗碗")
+
+	s.press("x")
+	s.waitFor("reopened: 洗碗")
+	got, _ = s.client.GetTask(context.Background(), 2)
+	util.AssertEqual(t)(got.Status, 0)
+}
+
+func Test_Rename(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string
+		status   string
+		expected string
+	}{
+		{name: "renames the task inline", keys: "e\x15買午餐\r", status: "renamed: 買晚餐 to 買午餐", expected: "買午餐"},
+		{name: "edits the current name", keys: "\r\x7f\x7f早餐\r", status: "renamed: 買晚餐 to 買早餐", expected: "買早餐"},
+		{name: "keeps the name on escape", keys: "e\x15買午餐\x1b", status: "j/k move", expected: "買晚餐"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := start(t, newSuite())
+			s.waitFor("洗碗")
+
+			s.press(tc.keys)
+			s.waitFor(tc.status)
+
+			util.AssertEqual(t)(s.task(1), tc.expected)
+		})
+	}
+}
+
+func Test_Filter(t *testing.T) {
+	t.Parallel()
+
+	s := start(t, newSuite())
+	s.waitFor("洗碗")
+
+	s.press("/ERR")
+	frame := s.waitFor(`1 matching "ERR"`)
+	util.AssertEqual(t)(strings.Contains(frame, "洗碗"), false)
+
+	s.press("\r\x1b")
+	s.waitFor("洗碗")
+}
+
+func Test_Add(t *testing.T) {
+	t.Parallel()
+
+	s := start(t, newSuite())
+	s.waitFor("洗碗")
+
+	s.press("a倒垃圾\r")
+
+	s.waitFor("added: 倒垃圾")
+	util.AssertEqual(t)(s.task(3), "倒垃圾")
+}
+
+func Test_LiveUpdates(t *testing.T) {
+	t.Parallel()
+
+	s := start(t, newSuite())
+	s.waitFor("live")
+
+	s.client.CreateTask(context.Background(), &client.CreateTaskInput{Name: "倒垃圾"})
+
+	s.waitFor("倒垃圾")
+}
+
+func Test_Quit(t *testing.T) {
+	t.Parallel()
+
+	s := start(t, newSuite())
+	s.waitFor("洗碗")
+
+	s.press("q")
+
+	select {
+	case err := <-s.done:
+		util.AssertErrorEqual(t)(err, nil)
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not quit")
+	}
+}
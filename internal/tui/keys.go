@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"unicode/utf8"
+)
+
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyUp
+	keyDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyInterrupt
+	keyClearLine
+	// Sequences and control characters not bound to anything.
+	keyUnknown
+)
+
+// key is a key pressed; r is only set for keyRune.
+type key struct {
+	code keyCode
+	r    rune
+}
+
+// readKeys sends the keys read from the terminal until it fails, as it does
+// on EOF, or ctx is done, then closes the channel.
+func readKeys(ctx context.Context, in io.Reader, keys chan<- key) {
+	defer close(keys)
+	r := bufio.NewReader(in)
+	for {
+		k, err := readKey(r)
+		if err != nil {
+			return
+		}
+		select {
+		case keys <- k:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func readKey(r *bufio.Reader) (key, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return key{}, err
+	}
+
+	switch c {
+	case '\r', '\n':
+		return key{code: keyEnter}, nil
+	case 0x7f, 0x08:
+		return key{code: keyBackspace}, nil
+	case 0x03, 0x04:
+		return key{code: keyInterrupt}, nil
+	case 0x15:
+		return key{code: keyClearLine}, nil
+	case 0x1b:
+		// Terminals write escape sequences at once, so a lone escape is the
+		// escape key itself.
+		if r.Buffered() == 0 {
+			return key{code: keyEscape}, nil
+		}
+		return readSequence(r)
+	}
+	if c < 0x20 || c == utf8.RuneError {
+		return key{code: keyUnknown}, nil
+	}
+	return key{code: keyRune, r: c}, nil
+}
+
+// readSequence reads the rest of an escape sequence, such as ESC [ A for the
+// up arrow.
+func readSequence(r *bufio.Reader) (key, error) {
+	introducer, err := r.ReadByte()
+	if err != nil {
+		return key{}, err
+	}
+	if introducer != '[' && introducer != 'O' {
+		return key{code: keyUnknown}, nil
+	}
+
+	var params []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return key{}, err
+		}
+		if b < 0x40 || b > 0x7e {
+			params = append(params, b)
+			continue
+		}
+
+		switch {
+		case b == 'A':
+			return key{code: keyUp}, nil
+		case b == 'B':
+			return key{code: keyDown}, nil
+		case b == 'H', b == '~' && (string(params) == "1" || string(params) == "7"):
+			return key{code: keyHome}, nil
+		case b == 'F', b == '~' && (string(params) == "4" || string(params) == "8"):
+			return key{code: keyEnd}, nil
+		}
+		return key{code: keyUnknown}, nil
+	}
+}
@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/dannyh79/whostodo/client"
+)
+
+type mode int
+
+const (
+	browsing mode = iota
+	// Typing a new name for the selected task.
+	renaming
+	// Typing the name of a task to add.
+	adding
+	// Typing text to filter tasks by.
+	filtering
+)
+
+type actionKind int
+
+const (
+	noAction actionKind = iota
+	quitAction
+	refreshAction
+	toggleAction
+	renameAction
+	addAction
+)
+
+// action is what a key asks the app to do through the API.
+type action struct {
+	kind actionKind
+	id   int
+	name string
+}
+
+// model is the state of the screen, changed by keys alone; the app carries
+// out the actions they return and hands back the tasks fetched.
+type model struct {
+	tasks []client.Task
+	// Tasks matching the filter, as indexes into tasks.
+	shown  []int
+	cursor int
+	// Index of the first shown task on screen.
+	offset int
+	filter string
+	mode   mode
+	input  []rune
+	// Id of the task being renamed, kept apart from the cursor as live
+	// updates may move it.
+	renamed int
+	// Message for the status line, replaced by the next one.
+	message string
+	live    bool
+}
+
+// setTasks replaces the tasks, keeping the same task selected if still
+// shown.
+func (m *model) setTasks(tasks []client.Task) {
+	selected, ok := m.selected()
+	m.tasks = tasks
+	m.applyFilter()
+	if ok {
+		m.selectTask(selected.Id)
+	}
+}
+
+// selectTask moves the cursor onto the task, if shown.
+func (m *model) selectTask(id int) {
+	for i, index := range m.shown {
+		if m.tasks[index].Id == id {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+func (m *model) applyFilter() {
+	m.shown = m.shown[:0]
+	for i, t := range m.tasks {
+		if matches(t, m.filterText()) {
+			m.shown = append(m.shown, i)
+		}
+	}
+	m.cursor = min(m.cursor, max(len(m.shown)-1, 0))
+}
+
+// filterText is what is typed while filtering, and the filter otherwise.
+func (m *model) filterText() string {
+	if m.mode == filtering {
+		return string(m.input)
+	}
+	return m.filter
+}
+
+// matches reports whether the name or a tag of the task contains the text,
+// ignoring case.
+func matches(t client.Task, text string) bool {
+	text = strings.ToLower(text)
+	if strings.Contains(strings.ToLower(t.Name), text) {
+		return true
+	}
+	for _, tag := range t.Tags {
+		if strings.Contains(strings.ToLower(tag), text) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *model) selected() (client.Task, bool) {
+	if len(m.shown) == 0 {
+		return client.Task{}, false
+	}
+	return m.tasks[m.shown[m.cursor]], true
+}
+
+// press handles the key, returning what the app is to do.
+func (m *model) press(k key) action {
+	if k.code == keyInterrupt {
+		return action{kind: quitAction}
+	}
+	if m.mode != browsing {
+		return m.typeKey(k)
+	}
+
+	m.message = ""
+	switch {
+	case k.code == keyUp, k.r == 'k':
+		m.cursor = max(m.cursor-1, 0)
+	case k.code == keyDown, k.r == 'j':
+		m.cursor = min(m.cursor+1, max(len(m.shown)-1, 0))
+	case k.code == keyHome, k.r == 'g':
+		m.cursor = 0
+	case k.code == keyEnd, k.r == 'G':
+		m.cursor = max(len(m.shown)-1, 0)
+	case k.r == ' ', k.r == 'x':
+		if t, ok := m.selected(); ok {
+			return action{kind: toggleAction, id: t.Id}
+		}
+	case k.code == keyEnter, k.r == 'e':
+		if t, ok := m.selected(); ok {
+			m.mode, m.input, m.renamed = renaming, []rune(t.Name), t.Id
+		}
+	case k.r == 'a':
+		m.mode, m.input = adding, nil
+	case k.r == '/':
+		m.mode, m.input = filtering, []rune(m.filter)
+	case k.code == keyEscape:
+		m.filter = ""
+		m.applyFilter()
+	case k.r == 'r':
+		return action{kind: refreshAction}
+	case k.r == 'q':
+		return action{kind: quitAction}
+	}
+	return action{}
+}
+
+// typeKey handles the key while typing into the input line.
+func (m *model) typeKey(k key) action {
+	switch k.code {
+	case keyRune:
+		m.input = append(m.input, k.r)
+	case keyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case keyClearLine:
+		m.input = nil
+	case keyEscape:
+		m.mode = browsing
+	case keyEnter:
+		return m.submit()
+	}
+	m.applyFilter()
+	return action{}
+}
+
+func (m *model) submit() action {
+	input := strings.TrimSpace(string(m.input))
+	current := m.mode
+	m.mode, m.input = browsing, nil
+
+	switch current {
+	case filtering:
+		m.filter = input
+		m.applyFilter()
+	case renaming:
+		if input != "" {
+			return action{kind: renameAction, id: m.renamed, name: input}
+		}
+	case adding:
+		if input != "" {
+			return action{kind: addAction, name: input}
+		}
+	}
+	return action{}
+}
+
+// scroll moves the offset so the cursor is within the rows on screen.
+func (m *model) scroll(rows int) {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if rows > 0 && m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+	m.offset = max(min(m.offset, len(m.shown)-rows), 0)
+}
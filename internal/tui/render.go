@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	reverse     = "\x1b[7m"
+	dim         = "\x1b[2m"
+	reset       = "\x1b[0m"
+)
+
+const help = "j/k move  space toggle  e edit  a add  / filter  r refresh  q quit"
+
+// render draws the whole screen, of the width and height in cells.
+func (m *model) render(cols int, rows int) string {
+	var b strings.Builder
+	b.WriteString(clearScreen)
+
+	title := fmt.Sprintf("whostodo  %d tasks", len(m.tasks))
+	if f := m.filterText(); f != "" || m.mode == filtering {
+		title += fmt.Sprintf(", %d matching %q", len(m.shown), f)
+	}
+	if m.live {
+		title += "  live"
+	}
+	b.WriteString(fit(title, cols) + "\r\n")
+
+	// Leaves a row for the title and one for the status line.
+	listRows := max(rows-2, 1)
+	m.scroll(listRows)
+	for row := 0; row < listRows; row++ {
+		i := m.offset + row
+		if i < len(m.shown) {
+			b.WriteString(m.renderTask(i, cols))
+		}
+		b.WriteString("\r\n")
+	}
+
+	switch m.mode {
+	case renaming:
+		b.WriteString(fit("name: "+string(m.input)+"_", cols))
+	case adding:
+		b.WriteString(fit("add: "+string(m.input)+"_", cols))
+	case filtering:
+		b.WriteString(fit("/"+string(m.input)+"_", cols))
+	case browsing:
+		if m.message != "" {
+			b.WriteString(fit(m.message, cols))
+		} else {
+			b.WriteString(dim + fit(help, cols) + reset)
+		}
+	}
+	return b.String()
+}
+
+// renderTask draws the shown task of the index, selected in reverse video.
+func (m *model) renderTask(i int, cols int) string {
+	t := m.tasks[m.shown[i]]
+	box := "[ ]"
+	if t.IsDone() {
+		box = "[x]"
+	}
+	line := fmt.Sprintf("%s %4d  %s", box, t.Id, t.Name)
+	for _, tag := range t.Tags {
+		line += " #" + tag
+	}
+	if t.Priority != "" {
+		line += " !" + t.Priority
+	}
+	if t.DueAt != nil {
+		line += " due " + t.DueAt.Format("2006-01-02")
+	}
+
+	line = fit(line, cols)
+	if i == m.cursor {
+		return reverse + line + strings.Repeat(" ", max(cols-cells(line), 0)) + reset
+	}
+	return line
+}
+
+// fit cuts the text to the cells, wide characters such as CJK ones taking
+// two cells each.
+func fit(text string, cols int) string {
+	used := 0
+	for i, r := range text {
+		used += cellsOf(r)
+		if used > cols {
+			return text[:i]
+		}
+	}
+	return text
+}
+
+func cells(text string) int {
+	n := 0
+	for _, r := range text {
+		n += cellsOf(r)
+	}
+	return n
+}
+
+func cellsOf(r rune) int {
+	if r == utf8.RuneError {
+		return 1
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
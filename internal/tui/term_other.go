@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package tui
+
+import "os"
+
+// OpenTerminal fails, as raw mode is only implemented for Unix terminals.
+func OpenTerminal(in *os.File, out *os.File) (term *Terminal, restore func(), err error) {
+	return nil, nil, ErrorUnsupported
+}
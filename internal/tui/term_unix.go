@@ -0,0 +1,62 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package tui
+
+import (
+	"os"
+	"os/signal"
+
+	"golang.org/x/sys/unix"
+)
+
+// OpenTerminal puts the terminal of in into raw mode, so keys are read as
+// pressed and not echoed, until restore is called.
+func OpenTerminal(in *os.File, out *os.File) (term *Terminal, restore func(), err error) {
+	fd := int(in.Fd())
+	saved, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, nil, ErrorNotTerminal
+	}
+
+	raw := *saved
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Oflag &^= unix.OPOST
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	signals := make(chan os.Signal, 1)
+	resized := make(chan struct{}, 1)
+	signal.Notify(signals, unix.SIGWINCH)
+	go func() {
+		for range signals {
+			select {
+			case resized <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	term = &Terminal{
+		In:  in,
+		Out: out,
+		Size: func() (int, int) {
+			size, err := unix.IoctlGetWinsize(int(out.Fd()), unix.TIOCGWINSZ)
+			if err != nil || size.Col == 0 || size.Row == 0 {
+				return defaultCols, defaultRows
+			}
+			return int(size.Col), int(size.Row)
+		},
+		Resized: resized,
+	}
+	return term, func() {
+		signal.Stop(signals)
+		close(signals)
+		unix.IoctlSetTermios(fd, ioctlSetTermios, saved)
+	}, nil
+}
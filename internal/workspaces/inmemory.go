@@ -0,0 +1,48 @@
+package workspaces
+
+import (
+	"github.com/dannyh79/whostodo/internal/attachments"
+	"github.com/dannyh79/whostodo/internal/blob"
+	"github.com/dannyh79/whostodo/internal/comments"
+	"github.com/dannyh79/whostodo/internal/lists"
+	"github.com/dannyh79/whostodo/internal/repository"
+	"github.com/dannyh79/whostodo/internal/tasks"
+)
+
+// InitInMemoryWorkspace builds a workspace on in-memory repositories, keeping
+// attached files in the store.
+func InitInMemoryWorkspace(id string, store blob.Store, opts ...tasks.Option) *Workspace {
+	taskRepo := repository.InitInMemoryTaskRepository()
+	listRepo := repository.InitInMemoryListRepository()
+	commentRepo := repository.InitInMemoryCommentRepository()
+	attachmentRepo := repository.InitInMemoryAttachmentRepository()
+	eventRepo := repository.InitInMemoryEventRepository()
+	memberRepo := repository.InitInMemoryMemberRepository()
+
+	listsUsecase := lists.InitListsUsecase(listRepo, memberRepo)
+	attachmentsUsecase := attachments.InitAttachmentsUsecase(attachmentRepo, taskRepo, store, attachments.WithAccessPolicy(listsUsecase))
+	opts = append([]tasks.Option{
+		tasks.WithListRepository(listRepo),
+		tasks.WithAttachedRepository(commentRepo),
+		tasks.WithAttachedRepository(attachmentsUsecase),
+		tasks.WithHistoryRepository(eventRepo),
+		tasks.WithAccessPolicy(listsUsecase),
+	}, opts...)
+
+	return &Workspace{
+		Id:          id,
+		Tasks:       tasks.InitTasksUsecase(taskRepo, opts...),
+		Lists:       listsUsecase,
+		Comments:    comments.InitCommentsUsecase(commentRepo, taskRepo, comments.WithAccessPolicy(listsUsecase)),
+		Attachments: attachmentsUsecase,
+		Rows: &Rows{
+			Tasks:       taskRepo,
+			Lists:       listRepo,
+			Members:     memberRepo,
+			Comments:    commentRepo,
+			Attachments: attachmentRepo,
+			Events:      eventRepo,
+			Files:       store,
+		},
+	}
+}
@@ -0,0 +1,170 @@
+package workspaces
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/dannyh79/whostodo/internal/attachments"
+	"github.com/dannyh79/whostodo/internal/blob"
+	"github.com/dannyh79/whostodo/internal/changes"
+	"github.com/dannyh79/whostodo/internal/comments"
+	"github.com/dannyh79/whostodo/internal/lists"
+	"github.com/dannyh79/whostodo/internal/repository"
+	sessionentity "github.com/dannyh79/whostodo/internal/sessions/entities"
+	"github.com/dannyh79/whostodo/internal/tasks"
+)
+
+var (
+	ErrorInvalidWorkspace  = errors.New("Invalid workspace")
+	ErrorWorkspaceNotFound = errors.New("Workspace not found")
+)
+
+// Workspace holds the usecases of a tenant. Every workspace is backed by
+// repositories of its own, so tasks, lists, comments and attachments of one
+// are never seen from another.
+type Workspace struct {
+	// Held around calls into the usecases by callers making them
+	// concurrently, as the usecases are not safe for concurrent use.
+	sync.Mutex
+	Id          string
+	Tasks       *tasks.TasksUsecase
+	Lists       *lists.ListsUsecase
+	Comments    *comments.CommentsUsecase
+	Attachments *attachments.AttachmentsUsecase
+	// Nil for workspaces whose repositories cannot be backed up.
+	Rows *Rows
+	// Tells watchers of the workspace about changes made in it.
+	Changes *changes.Broadcaster
+}
+
+// DeleteList deletes the list as the actor's user, moving its tasks out
+// through the tasks usecase first, so that the moves are recorded and added to
+// the actor's scope as any other change of tasks.
+func (w *Workspace) DeleteList(a tasks.Actor, id int, i *lists.DeleteListInput) error {
+	if err := w.Lists.CheckDeleteList(a.User, id, i); err != nil {
+		return err
+	}
+	if err := w.Tasks.ClearList(a, id, i.Tasks == lists.Cascade); err != nil {
+		return err
+	}
+	return w.Lists.DeleteList(a.User, id, i)
+}
+
+// Rows are the repositories behind the usecases of a workspace, for backing
+// it up and restoring it as a whole.
+type Rows struct {
+	Tasks       repository.Snapshot[repository.TaskSchema]
+	Lists       repository.Snapshot[repository.ListSchema]
+	Members     repository.Snapshot[repository.MemberSchema]
+	Comments    repository.Snapshot[repository.CommentSchema]
+	Attachments repository.Snapshot[repository.AttachmentSchema]
+	Events      repository.Snapshot[repository.EventSchema]
+	// Holds the contents of attached files, by their blob keys.
+	Files blob.Store
+}
+
+// Factory builds the workspace of the id, with nothing in it yet.
+type Factory func(id string) (*Workspace, error)
+
+// WorkspacesUsecase hands out the workspaces it was set up with by id,
+// building each on first use.
+type WorkspacesUsecase struct {
+	factory    Factory
+	ids        map[string]bool
+	mu         sync.Mutex
+	workspaces map[string]*Workspace
+}
+
+// Find returns the workspace of the id, building it if it was not used yet.
+// Ids the usecase was not set up with are not found, so that workspaces are
+// never made up by whoever names one.
+func (u *WorkspacesUsecase) Find(id string) (*Workspace, error) {
+	if err := u.check(id); err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if w, ok := u.workspaces[id]; ok {
+		return w, nil
+	}
+
+	w, err := u.factory(id)
+	if err != nil {
+		return nil, err
+	}
+	// Changes are not stored anywhere, so are the same whatever the factory.
+	w.Changes = changes.InitBroadcaster()
+	u.workspaces[id] = w
+	return w, nil
+}
+
+// Build builds an empty workspace of the id without handing it out, to be
+// filled and then swapped in by Replace.
+func (u *WorkspacesUsecase) Build(id string) (*Workspace, error) {
+	if err := u.check(id); err != nil {
+		return nil, err
+	}
+	return u.factory(id)
+}
+
+// Replace hands out the built workspaces in place of those of their ids, all
+// at once, and returns the workspaces replaced. Watchers of a replaced
+// workspace keep watching the one replacing it.
+func (u *WorkspacesUsecase) Replace(built []*Workspace) []*Workspace {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var replaced []*Workspace
+	for _, w := range built {
+		if old, ok := u.workspaces[w.Id]; ok {
+			w.Changes = old.Changes
+			replaced = append(replaced, old)
+		} else {
+			w.Changes = changes.InitBroadcaster()
+		}
+		u.workspaces[w.Id] = w
+	}
+	return replaced
+}
+
+// List returns the workspaces built so far, ordered by id.
+func (u *WorkspacesUsecase) List() []*Workspace {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	list := make([]*Workspace, 0, len(u.workspaces))
+	for _, w := range u.workspaces {
+		list = append(list, w)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+	return list
+}
+
+func (u *WorkspacesUsecase) check(id string) error {
+	if !sessionentity.IsWorkspace(id) {
+		return ErrorInvalidWorkspace
+	}
+	if !u.ids[id] {
+		return ErrorWorkspaceNotFound
+	}
+	return nil
+}
+
+// InitWorkspacesUsecase sets up the workspaces of the ids, built by the
+// factory; sessionentity.DefaultWorkspace only when none are given.
+func InitWorkspacesUsecase(factory Factory, ids ...string) *WorkspacesUsecase {
+	if len(ids) == 0 {
+		ids = []string{sessionentity.DefaultWorkspace}
+	}
+	u := &WorkspacesUsecase{
+		factory:    factory,
+		ids:        make(map[string]bool),
+		workspaces: make(map[string]*Workspace),
+	}
+	for _, id := range ids {
+		u.ids[id] = true
+	}
+	return u
+}
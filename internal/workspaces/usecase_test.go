@@ -0,0 +1,154 @@
+package workspaces_test
+
+import (
+	"testing"
+
+	"github.com/dannyh79/whostodo/internal/lists"
+	"github.com/dannyh79/whostodo/internal/tasks"
+	util "github.com/dannyh79/whostodo/internal/testutil"
+	"github.com/dannyh79/whostodo/internal/workspaces"
+)
+
+func initWorkspacesUsecase() *workspaces.WorkspacesUsecase {
+	return workspaces.InitWorkspacesUsecase(func(id string) (*workspaces.Workspace, error) {
+		return workspaces.InitInMemoryWorkspace(id, util.InitMockBlobStore()), nil
+	}, "team-a", "team-b")
+}
+
+func Test_Find(t *testing.T) {
+	t.Parallel()
+
+	usecase := initWorkspacesUsecase()
+	a, err := usecase.Find("team-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := usecase.Find("team-a")
+	b, _ := usecase.Find("team-b")
+
+	util.AssertEqual(t)(a.Id, "team-a")
+	util.AssertEqual(t)(again == a, true)
+	util.AssertEqual(t)(b == a, false)
+}
+
+func Test_FindDefault(t *testing.T) {
+	t.Parallel()
+
+	usecase := workspaces.InitWorkspacesUsecase(func(id string) (*workspaces.Workspace, error) {
+		return workspaces.InitInMemoryWorkspace(id, util.InitMockBlobStore()), nil
+	})
+
+	w, err := usecase.Find("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = usecase.Find("team-a")
+
+	util.AssertEqual(t)(w.Id, "default")
+	util.AssertErrorEqual(t)(err, workspaces.ErrorWorkspaceNotFound)
+}
+
+func Test_FindError(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		factory workspaces.Factory
+		error   error
+	}{
+		{
+			name:  "returns error on invalid id",
+			id:    "../team-a",
+			error: workspaces.ErrorInvalidWorkspace,
+		},
+		{
+			name:  "returns error on workspace not set up",
+			id:    "team-c",
+			error: workspaces.ErrorWorkspaceNotFound,
+		},
+		{
+			name: "returns error of the factory",
+			id:   "team-a",
+			factory: func(string) (*workspaces.Workspace, error) {
+				return nil, util.MockNotFoundError
+			},
+			error: util.MockNotFoundError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			usecase := initWorkspacesUsecase()
+			if tc.factory != nil {
+				usecase = workspaces.InitWorkspacesUsecase(tc.factory, "team-a")
+			}
+			_, err := usecase.Find(tc.id)
+
+			util.AssertErrorEqual(t)(err, tc.error)
+		})
+	}
+}
+
+func Test_Isolation(t *testing.T) {
+	t.Parallel()
+
+	usecase := initWorkspacesUsecase()
+	a, _ := usecase.Find("team-a")
+	b, _ := usecase.Find("team-b")
+	alice := tasks.Actor{User: "alice"}
+
+	list := a.Lists.CreateList("alice", &lists.CreateListInput{Name: "家事"})
+	created, err := a.Tasks.CreateTask(alice, &tasks.CreateTaskInput{Name: "洗碗", ListId: list.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	util.AssertEqual(t)(len(a.Tasks.ListTasks(alice, &tasks.ListTasksInput{})), 1)
+	util.AssertEqual(t)(len(b.Tasks.ListTasks(alice, &tasks.ListTasksInput{})), 0)
+	util.AssertEqual(t)(len(b.Lists.ListLists("alice")), 1)
+	_, err = b.Tasks.GetTask(alice, created.Id)
+	if err == nil {
+		t.Error("task of another workspace found")
+	}
+	_, err = b.Tasks.CreateTask(alice, &tasks.CreateTaskInput{Name: "洗碗", ListId: list.Id})
+	util.AssertErrorEqual(t)(err, tasks.ErrorListNotFound)
+}
+
+func Test_List(t *testing.T) {
+	t.Parallel()
+
+	usecase := initWorkspacesUsecase()
+	usecase.Find("team-b")
+	usecase.Find("team-a")
+
+	var got []string
+	for _, w := range usecase.List() {
+		got = append(got, w.Id)
+	}
+
+	util.AssertEqual(t)(got, []string{"team-a", "team-b"})
+}
+
+func Test_Replace(t *testing.T) {
+	t.Parallel()
+
+	usecase := initWorkspacesUsecase()
+	old, _ := usecase.Find("team-a")
+	built, err := usecase.Build("team-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unchanged, _ := usecase.Find("team-a")
+	fresh, _ := usecase.Build("team-b")
+
+	replaced := usecase.Replace([]*workspaces.Workspace{built, fresh})
+
+	got, _ := usecase.Find("team-a")
+	util.AssertEqual(t)(unchanged == old, true)
+	util.AssertEqual(t)(got == built, true)
+	util.AssertEqual(t)(got.Changes == old.Changes, true)
+	util.AssertEqual(t)(len(replaced), 1)
+	util.AssertEqual(t)(replaced[0] == old, true)
+	util.AssertEqual(t)(fresh.Changes != nil, true)
+}
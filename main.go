@@ -1,21 +1,103 @@
 package main
 
 import (
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dannyh79/whostodo/internal/backup"
+	"github.com/dannyh79/whostodo/internal/blob"
+	"github.com/dannyh79/whostodo/internal/cli"
+	"github.com/dannyh79/whostodo/internal/graph"
 	"github.com/dannyh79/whostodo/internal/repository"
 	"github.com/dannyh79/whostodo/internal/rest/v1"
+	"github.com/dannyh79/whostodo/internal/rpc"
 	"github.com/dannyh79/whostodo/internal/sessions"
+	sessionentity "github.com/dannyh79/whostodo/internal/sessions/entities"
 	"github.com/dannyh79/whostodo/internal/tasks"
+	"github.com/dannyh79/whostodo/internal/workspaces"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	taskRepo := repository.InitInMemoryTaskRepository()
-	tasksUsecase := tasks.InitTasksUsecase(taskRepo)
+	if cli.IsCommand(os.Args[1:]) {
+		os.Exit(cli.Run(&cli.Env{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}, os.Args[1:]))
+	}
+
+	var taskOpts []tasks.Option
+	if v := os.Getenv("WHOSTODO_TRASH_RETENTION"); v != "" {
+		retention, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("invalid WHOSTODO_TRASH_RETENTION %q: %v", v, err)
+		}
+		taskOpts = append(taskOpts, tasks.WithTrashRetention(retention))
+	}
+
+	attachmentDir := os.Getenv("WHOSTODO_ATTACHMENT_DIR")
+	if attachmentDir == "" {
+		attachmentDir = filepath.Join(os.TempDir(), "whostodo-attachments")
+	}
+	if _, err := blob.InitLocalStore(attachmentDir); err != nil {
+		log.Fatalf("invalid WHOSTODO_ATTACHMENT_DIR %q: %v", attachmentDir, err)
+	}
+
+	accountRepo := repository.InitInMemoryAccountRepository()
+	feedRepo := repository.InitInMemoryFeedRepository()
+	workspaceIds := []string{sessionentity.DefaultWorkspace}
+	if v := os.Getenv("WHOSTODO_WORKSPACES"); v != "" {
+		workspaceIds = strings.Split(v, ",")
+		for i, id := range workspaceIds {
+			workspaceIds[i] = strings.TrimSpace(id)
+			if !sessionentity.IsWorkspace(workspaceIds[i]) {
+				log.Fatalf("invalid workspace %q in WHOSTODO_WORKSPACES", workspaceIds[i])
+			}
+		}
+	}
+	instance := os.Getenv("WHOSTODO_INSTANCE")
+	if instance == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Fatalf("cannot name the instance, set WHOSTODO_INSTANCE: %v", err)
+		}
+		instance = hostname
+	}
+	sessionOpts := []sessions.Option{
+		sessions.WithAccountRepository(accountRepo),
+		sessions.WithFeedRepository(feedRepo),
+		sessions.WithWorkspaces(workspaceIds...),
+	}
+
 	sessionRepo := repository.InitInMemorySessionRepository()
-	sessionsUsecase := sessions.InitSessionsUsecase(sessionRepo)
+	sessionsUsecase := sessions.InitSessionsUsecase(sessionRepo, sessionOpts...)
+	workspacesUsecase := workspaces.InitWorkspacesUsecase(func(id string) (*workspaces.Workspace, error) {
+		store, err := blob.InitLocalStore(filepath.Join(attachmentDir, id))
+		if err != nil {
+			return nil, err
+		}
+		opts := append([]tasks.Option{
+			tasks.WithICalDomain(id + "." + instance),
+			tasks.WithMembership(func(user string) bool { return sessionsUsecase.IsMember(id, user) }),
+		}, taskOpts...)
+		return workspaces.InitInMemoryWorkspace(id, store, opts...), nil
+	}, workspaceIds...)
+
+	rpcAddr := os.Getenv("WHOSTODO_GRPC_ADDR")
+	if rpcAddr == "" {
+		rpcAddr = ":9090"
+	}
+	listener, err := net.Listen("tcp", rpcAddr)
+	if err != nil {
+		log.Fatalf("invalid WHOSTODO_GRPC_ADDR %q: %v", rpcAddr, err)
+	}
+	go rpc.InitServer(sessionsUsecase, workspacesUsecase).Serve(listener)
 
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.Default()
-	routes.AddRoutes(engine, tasksUsecase, sessionsUsecase)
+	routes.AddRoutes(engine, sessionsUsecase, workspacesUsecase)
+	routes.AddAdminRoutes(engine, sessionsUsecase, backup.InitBackupUsecase(sessionRepo, accountRepo, feedRepo, workspacesUsecase), os.Getenv("WHOSTODO_ADMIN_TOKEN"))
+	graph.AddRoutes(engine, sessionsUsecase, workspacesUsecase)
 	engine.Run()
 }